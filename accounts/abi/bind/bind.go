@@ -14,7 +14,7 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package bind generates Ethereum contract Go bindings.
+// Package bind generates Ethereum contract Go, TypeScript and Python bindings.
 //
 // Detailed usage document and tutorial available on the go-ethereum Wiki page:
 // https://github.com/shudolab/core-geth/wiki/Native-DApps:-Go-bindings-to-Ethereum-contracts
@@ -22,6 +22,7 @@ package bind
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/format"
 	"regexp"
@@ -38,6 +39,8 @@ type Lang int
 
 const (
 	LangGo Lang = iota
+	LangTypeScript
+	LangPython
 )
 
 func isKeyWord(arg string) bool {
@@ -77,11 +80,26 @@ func isKeyWord(arg string) bool {
 	return true
 }
 
-// Bind generates a Go wrapper around a contract ABI. This wrapper isn't meant
-// to be used as is in client code, but rather as an intermediate struct which
-// enforces compile time type safety and naming convention as opposed to having to
-// manually maintain hard coded strings that break on runtime.
+// Bind generates a Go, TypeScript or Python wrapper around a contract ABI. This
+// wrapper isn't meant to be used as is in client code, but rather as an
+// intermediate struct which enforces compile time type safety and naming
+// convention as opposed to having to manually maintain hard coded strings that
+// break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]string, pkg string, lang Lang, libs map[string]string, aliases map[string]string) (string, error) {
+	return bindContracts(types, abis, bytecodes, fsigs, pkg, lang, libs, aliases, false)
+}
+
+// BindWithIndexer generates the same wrappers as Bind, and additionally a typed
+// event indexer built on Indexer for every contract with events. Indexers are
+// only supported for Go bindings.
+func BindWithIndexer(types []string, abis []string, bytecodes []string, fsigs []map[string]string, pkg string, lang Lang, libs map[string]string, aliases map[string]string) (string, error) {
+	if lang != LangGo {
+		return "", errors.New("event indexers are only supported for Go bindings")
+	}
+	return bindContracts(types, abis, bytecodes, fsigs, pkg, lang, libs, aliases, true)
+}
+
+func bindContracts(types []string, abis []string, bytecodes []string, fsigs []map[string]string, pkg string, lang Lang, libs map[string]string, aliases map[string]string, indexer bool) (string, error) {
 	var (
 		// contracts is the map of each individual contract requested binding
 		contracts = make(map[string]*tmplContract)
@@ -276,6 +294,7 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 		Contracts: contracts,
 		Libraries: libs,
 		Structs:   structs,
+		Indexer:   indexer,
 	}
 	buffer := new(bytes.Buffer)
 
@@ -283,6 +302,10 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 		"bindtype":      bindType[lang],
 		"bindtopictype": bindTopicType[lang],
 		"namedtype":     namedType[lang],
+		"decode":        decodeValue[lang],
+		"decodetopic":   decodeTopic[lang],
+		"encode":        encodeValue[lang],
+		"ident":         identifier[lang],
 		"capitalise":    capitalise,
 		"decapitalise":  decapitalise,
 	}
@@ -305,7 +328,9 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language types.
 var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo: bindTypeGo,
+	LangGo:         bindTypeGo,
+	LangTypeScript: bindTypeTS,
+	LangPython:     bindTypePython,
 }
 
 // bindBasicTypeGo converts basic solidity types(except array, slice and tuple) to Go ones.
@@ -351,7 +376,9 @@ func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 // bindTopicType is a set of type binders that convert Solidity types to some
 // supported programming language topic types.
 var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo: bindTopicTypeGo,
+	LangGo:         bindTopicTypeGo,
+	LangTypeScript: bindTopicTypeTS,
+	LangPython:     bindTopicTypePython,
 }
 
 // bindTopicTypeGo converts a Solidity topic type to a Go one. It is almost the same
@@ -374,7 +401,9 @@ func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 // bindStructType is a set of type binders that convert Solidity tuple types to some supported
 // programming language struct definition.
 var bindStructType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo: bindStructTypeGo,
+	LangGo:         bindStructTypeGo,
+	LangTypeScript: bindStructTypeTS,
+	LangPython:     bindStructTypePython,
 }
 
 // bindStructTypeGo converts a Solidity tuple type to a Go one and records the mapping
@@ -423,10 +452,307 @@ func bindStructTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 	}
 }
 
+// bindBasicTypeTS converts basic solidity types(except array, slice and tuple) to
+// TypeScript ones, matching the values returned by the ethers ABI coder.
+func bindBasicTypeTS(kind abi.Type) string {
+	switch kind.T {
+	case abi.IntTy, abi.UintTy:
+		return "bigint"
+	case abi.BoolTy:
+		return "boolean"
+	default:
+		// address, string, bytes and function types are hex or plain strings
+		return "string"
+	}
+}
+
+// bindTypeTS converts solidity types to TypeScript ones.
+func bindTypeTS(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[kind.TupleRawName+kind.String()].Name
+	case abi.ArrayTy, abi.SliceTy:
+		return bindTypeTS(*kind.Elem, structs) + "[]"
+	default:
+		return bindBasicTypeTS(kind)
+	}
+}
+
+// bindTopicTypeTS converts a Solidity topic type to a TypeScript one. Hashed
+// topics are represented by their hex encoded hash.
+func bindTopicTypeTS(kind abi.Type, structs map[string]*tmplStruct) string {
+	if isHashedTopic(kind) {
+		return "string"
+	}
+	return bindTypeTS(kind, structs)
+}
+
+// bindStructTypeTS converts a Solidity tuple type to a TypeScript interface and
+// records the mapping in the given map. Fields keep their Solidity names, so
+// values of the interface can be passed to the ABI encoder as is.
+func bindStructTypeTS(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		id := kind.TupleRawName + kind.String()
+		if s, exist := structs[id]; exist {
+			return s.Name
+		}
+		var fields []*tmplField
+		for i, elem := range kind.TupleElems {
+			name := kind.TupleRawNames[i]
+			if name == "" {
+				name = fmt.Sprintf("field%d", i)
+			}
+			fields = append(fields, &tmplField{Type: bindStructTypeTS(*elem, structs), Name: name, SolKind: *elem})
+		}
+		name := kind.TupleRawName
+		if name == "" {
+			name = fmt.Sprintf("Struct%d", len(structs))
+		}
+		name = capitalise(name)
+
+		structs[id] = &tmplStruct{
+			Name:   name,
+			Fields: fields,
+		}
+		return name
+	case abi.ArrayTy, abi.SliceTy:
+		return bindStructTypeTS(*kind.Elem, structs) + "[]"
+	default:
+		return bindBasicTypeTS(kind)
+	}
+}
+
+// decodeValueTS converts a value returned by the ethers ABI coder to the bound
+// TypeScript type. Only structs need converting, all other values are already
+// represented by their bound type.
+func decodeValueTS(kind abi.Type, structs map[string]*tmplStruct, expr string) string {
+	return decodeTS(kind, structs, expr, 0)
+}
+
+func decodeTS(kind abi.Type, structs map[string]*tmplStruct, expr string, depth int) string {
+	switch {
+	case kind.T == abi.TupleTy:
+		return fmt.Sprintf("decode%s(%s)", structs[kind.TupleRawName+kind.String()].Name, expr)
+	case (kind.T == abi.ArrayTy || kind.T == abi.SliceTy) && hasStruct(kind):
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("Array.from(%s, (%s: any) => %s)", expr, v, decodeTS(*kind.Elem, structs, v, depth+1))
+	default:
+		return expr
+	}
+}
+
+// decodeTopicTS converts an indexed event field decoded by ethers to the bound
+// TypeScript topic type.
+func decodeTopicTS(kind abi.Type, structs map[string]*tmplStruct, expr string) string {
+	if isHashedTopic(kind) {
+		return expr + ".hash"
+	}
+	return decodeValueTS(kind, structs, expr)
+}
+
+// identifierTS makes a camel-case identifier which starts with a lower case
+// character and doesn't clash with reserved words or binding members.
+func identifierTS(name string) string {
+	name = decapitalise(name)
+	switch name {
+	case "break", "case", "catch", "class", "const", "continue", "debugger", "default",
+		"delete", "do", "else", "enum", "export", "extends", "false", "finally", "for",
+		"function", "if", "implements", "import", "in", "instanceof", "interface", "let",
+		"new", "null", "package", "private", "protected", "public", "return", "static",
+		"super", "switch", "this", "throw", "true", "try", "typeof", "var", "void",
+		"while", "with", "yield", "await", "constructor", "contract", "overrides":
+		return name + "_"
+	}
+	return name
+}
+
+// bindBasicTypePython converts basic solidity types(except array, slice and
+// tuple) to Python ones, matching the values returned by the web3.py ABI coder.
+func bindBasicTypePython(kind abi.Type) string {
+	switch kind.T {
+	case abi.IntTy, abi.UintTy:
+		return "int"
+	case abi.BoolTy:
+		return "bool"
+	case abi.AddressTy, abi.StringTy:
+		return "str"
+	default:
+		// bytes, fixed bytes and function types
+		return "bytes"
+	}
+}
+
+// bindTypePython converts solidity types to Python ones.
+func bindTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[kind.TupleRawName+kind.String()].Name
+	case abi.ArrayTy, abi.SliceTy:
+		return "List[" + bindTypePython(*kind.Elem, structs) + "]"
+	default:
+		return bindBasicTypePython(kind)
+	}
+}
+
+// bindTopicTypePython converts a Solidity topic type to a Python one. Hashed
+// topics are represented by their raw hash.
+func bindTopicTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	if isHashedTopic(kind) {
+		return "bytes"
+	}
+	return bindTypePython(kind, structs)
+}
+
+// bindStructTypePython converts a Solidity tuple type to a Python dataclass and
+// records the mapping in the given map.
+func bindStructTypePython(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		id := kind.TupleRawName + kind.String()
+		if s, exist := structs[id]; exist {
+			return s.Name
+		}
+		var (
+			names  = make(map[string]bool)
+			fields []*tmplField
+		)
+		for i, elem := range kind.TupleElems {
+			name := identifierPython(kind.TupleRawNames[i])
+			if name == "" {
+				name = fmt.Sprintf("field%d", i)
+			}
+			name = abi.ResolveNameConflict(name, func(s string) bool { return names[s] })
+			names[name] = true
+			fields = append(fields, &tmplField{Type: bindStructTypePython(*elem, structs), Name: name, SolKind: *elem})
+		}
+		name := kind.TupleRawName
+		if name == "" {
+			name = fmt.Sprintf("Struct%d", len(structs))
+		}
+		name = capitalise(name)
+
+		structs[id] = &tmplStruct{
+			Name:   name,
+			Fields: fields,
+		}
+		return name
+	case abi.ArrayTy, abi.SliceTy:
+		return "List[" + bindStructTypePython(*kind.Elem, structs) + "]"
+	default:
+		return bindBasicTypePython(kind)
+	}
+}
+
+// decodeValuePython converts a value returned by the web3.py ABI coder to the
+// bound Python type. Structs and arrays are returned as tuples, all other values
+// are already represented by their bound type.
+func decodeValuePython(kind abi.Type, structs map[string]*tmplStruct, expr string) string {
+	return decodePython(kind, structs, expr, 0)
+}
+
+func decodePython(kind abi.Type, structs map[string]*tmplStruct, expr string, depth int) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return fmt.Sprintf("%s.from_abi(%s)", structs[kind.TupleRawName+kind.String()].Name, expr)
+	case abi.ArrayTy, abi.SliceTy:
+		if elem := kind.Elem.T; elem != abi.TupleTy && elem != abi.ArrayTy && elem != abi.SliceTy {
+			return fmt.Sprintf("list(%s)", expr)
+		}
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("[%s for %s in %s]", decodePython(*kind.Elem, structs, v, depth+1), v, expr)
+	default:
+		return expr
+	}
+}
+
+// decodeTopicPython converts an indexed event field decoded by web3.py to the
+// bound Python topic type.
+func decodeTopicPython(kind abi.Type, structs map[string]*tmplStruct, expr string) string {
+	if isHashedTopic(kind) {
+		return "bytes(" + expr + ")"
+	}
+	return decodeValuePython(kind, structs, expr)
+}
+
+// encodeValuePython converts a bound Python value to one accepted by the
+// web3.py ABI coder, which expects structs as tuples.
+func encodeValuePython(kind abi.Type, expr string) string {
+	return encodePython(kind, expr, 0)
+}
+
+func encodePython(kind abi.Type, expr string, depth int) string {
+	switch {
+	case kind.T == abi.TupleTy:
+		return expr + ".to_abi()"
+	case (kind.T == abi.ArrayTy || kind.T == abi.SliceTy) && hasStruct(kind):
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("[%s for %s in %s]", encodePython(*kind.Elem, v, depth+1), v, expr)
+	default:
+		return expr
+	}
+}
+
+var (
+	snakeBoundary   = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	snakeAcronymEnd = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+)
+
+// identifierPython makes a snake-case identifier which doesn't clash with
+// reserved words or binding members.
+func identifierPython(name string) string {
+	name = abi.ToCamelCase(name)
+	name = snakeAcronymEnd.ReplaceAllString(name, "${1}_${2}")
+	name = strings.ToLower(snakeBoundary.ReplaceAllString(name, "${1}_${2}"))
+	switch name {
+	case "and", "as", "assert", "async", "await", "break", "class", "continue", "def",
+		"del", "elif", "else", "except", "finally", "for", "from", "global",
+		"if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass",
+		"raise", "return", "try", "while", "with", "yield", "self", "cls",
+		"contract", "w3", "deploy", "tx", "block_identifier":
+		return name + "_"
+	}
+	return name
+}
+
 // namedType is a set of functions that transform language specific types to
 // named versions that may be used inside method names.
 var namedType = map[Lang]func(string, abi.Type) string{
-	LangGo: func(string, abi.Type) string { panic("this shouldn't be needed") },
+	LangGo:         func(string, abi.Type) string { panic("this shouldn't be needed") },
+	LangTypeScript: func(string, abi.Type) string { panic("this shouldn't be needed") },
+	LangPython:     func(string, abi.Type) string { panic("this shouldn't be needed") },
+}
+
+// decodeValue is a set of functions that produce an expression converting a
+// decoded ABI value held in expr to the bound language type.
+var decodeValue = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct, expr string) string{
+	LangGo:         func(abi.Type, map[string]*tmplStruct, string) string { panic("this shouldn't be needed") },
+	LangTypeScript: decodeValueTS,
+	LangPython:     decodeValuePython,
+}
+
+// decodeTopic is a set of functions that produce an expression converting a
+// decoded indexed event field held in expr to the bound language topic type.
+var decodeTopic = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct, expr string) string{
+	LangGo:         func(abi.Type, map[string]*tmplStruct, string) string { panic("this shouldn't be needed") },
+	LangTypeScript: decodeTopicTS,
+	LangPython:     decodeTopicPython,
+}
+
+// encodeValue is a set of functions that produce an expression converting a
+// bound language value held in expr to one accepted by the ABI encoder.
+var encodeValue = map[Lang]func(kind abi.Type, expr string) string{
+	LangGo:         func(abi.Type, string) string { panic("this shouldn't be needed") },
+	LangTypeScript: func(kind abi.Type, expr string) string { return expr },
+	LangPython:     encodeValuePython,
+}
+
+// identifier is a set of functions that convert argument and field names to
+// valid identifiers of the target language.
+var identifier = map[Lang]func(string) string{
+	LangGo:         func(string) string { panic("this shouldn't be needed") },
+	LangTypeScript: identifierTS,
+	LangPython:     identifierPython,
 }
 
 // alias returns an alias of the given string based on the aliasing rules
@@ -441,7 +767,9 @@ func alias(aliases map[string]string, n string) string {
 // methodNormalizer is a name transformer that modifies Solidity method names to
 // conform to target language naming conventions.
 var methodNormalizer = map[Lang]func(string) string{
-	LangGo: abi.ToCamelCase,
+	LangGo:         abi.ToCamelCase,
+	LangTypeScript: identifierTS,
+	LangPython:     identifierPython,
 }
 
 // capitalise makes a camel-case string which starts with an upper case character.
@@ -480,6 +808,17 @@ func structured(args abi.Arguments) bool {
 	return true
 }
 
+// isHashedTopic reports whether an indexed event field of the given type is
+// stored as the keccak256 hash of its encoding instead of the value itself.
+func isHashedTopic(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	default:
+		return false
+	}
+}
+
 // hasStruct returns an indicator whether the given type is struct, struct slice
 // or struct array.
 func hasStruct(t abi.Type) bool {
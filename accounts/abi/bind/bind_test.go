@@ -17,6 +17,7 @@
 package bind
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
@@ -28,6 +29,11 @@ import (
 	"github.com/shudolab/core-geth/common"
 )
 
+// To regenerate the TypeScript and Python golden bindings, run
+//
+//	go test -run TestForeignBindings -write-bindings
+var writeBindingsFlag = flag.Bool("write-bindings", false, "Overwrite golden bindings in testdata/")
+
 var bindTests = []struct {
 	name     string
 	contract string
@@ -2150,3 +2156,136 @@ func TestGolangBindings(t *testing.T) {
 		t.Fatalf("failed to run binding test: %v\n%s", err, out)
 	}
 }
+
+// Tests that TypeScript and Python bindings match the golden files in testdata.
+func TestForeignBindings(t *testing.T) {
+	langs := map[Lang]string{LangTypeScript: ".ts", LangPython: ".py"}
+	for _, name := range []string{"registry", "nested"} {
+		abi, err := os.ReadFile(filepath.Join("testdata", name+".abi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kind := capitalise(name)
+		for lang, ext := range langs {
+			code, err := Bind([]string{kind}, []string{string(abi)}, []string{"0x6080"}, nil, "", lang, nil, nil)
+			if err != nil {
+				t.Fatalf("%s%s: failed to generate binding: %v", name, ext, err)
+			}
+			file := filepath.Join("testdata", name+ext)
+			if *writeBindingsFlag {
+				if err := os.WriteFile(file, []byte(code), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != string(want) {
+				t.Errorf("%s: binding mismatch, run with -write-bindings to update\n%s", file, code)
+			}
+		}
+	}
+}
+
+// Tests that the TypeScript golden bindings type check against ethers. The test
+// is skipped unless tsc is installed and ethers can be resolved from testdata.
+func TestTypeScriptBindingsTypeCheck(t *testing.T) {
+	tsc, err := exec.LookPath("tsc")
+	if err != nil {
+		t.Skip("tsc not found for testing")
+	}
+	resolver := exec.Command("node", "-e", `require.resolve("ethers")`)
+	resolver.Dir = "testdata"
+	if err := resolver.Run(); err != nil {
+		t.Skip("ethers not found for testing")
+	}
+	cmd := exec.Command(tsc, "--noEmit", "--strict", "--skipLibCheck",
+		"--target", "es2020", "--module", "commonjs", "--moduleResolution", "node",
+		"registry.ts", "nested.ts")
+	cmd.Dir = "testdata"
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to type check bindings: %v\n%s", err, out)
+	}
+}
+
+// Tests that the Python golden bindings type check against web3. The test is
+// skipped unless mypy is installed and web3 can be imported.
+func TestPythonBindingsTypeCheck(t *testing.T) {
+	mypy, err := exec.LookPath("mypy")
+	if err != nil {
+		t.Skip("mypy not found for testing")
+	}
+	if err := exec.Command("python3", "-c", "import eth_utils, hexbytes, web3").Run(); err != nil {
+		t.Skip("web3 not found for testing")
+	}
+	cmd := exec.Command(mypy, "--no-incremental", "--cache-dir", t.TempDir(), "registry.py", "nested.py")
+	cmd.Dir = "testdata"
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to type check bindings: %v\n%s", err, out)
+	}
+}
+
+// Tests that overloaded methods and events get distinct names in foreign
+// bindings, and that aliases rename them.
+func TestForeignBindingsOverloads(t *testing.T) {
+	const abi = `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"}],"outputs":[]},
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"data","type":"bytes"}],"outputs":[]}
+	]`
+	tests := []struct {
+		lang    Lang
+		aliases map[string]string
+		want    []string
+	}{
+		{LangTypeScript, nil, []string{"async transfer(to: string,", "async transfer0(to: string, data: string,", `getFunction("transfer(address,bytes)")`}},
+		{LangTypeScript, map[string]string{"transfer0": "transferWithData"}, []string{"async transferWithData(to: string, data: string,"}},
+		{LangPython, nil, []string{"def transfer(self, to: str,", "def transfer0(self, to: str, data: bytes,", `get_function_by_signature("transfer(address,bytes)")`}},
+		{LangPython, map[string]string{"transfer0": "transferWithData"}, []string{"def transfer_with_data(self, to: str, data: bytes,"}},
+	}
+	for i, test := range tests {
+		code, err := Bind([]string{"Token"}, []string{abi}, []string{""}, nil, "", test.lang, nil, test.aliases)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(code, want) {
+				t.Errorf("test %d: binding doesn't contain %q\n%s", i, want, code)
+			}
+		}
+	}
+}
+
+// Tests that event indexers are generated only on request, and only for Go.
+func TestBindWithIndexer(t *testing.T) {
+	abi, err := os.ReadFile(filepath.Join("testdata", "registry.abi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := func(lang Lang) ([]string, []string, []string, []map[string]string, string, Lang, map[string]string, map[string]string) {
+		return []string{"Registry"}, []string{string(abi)}, []string{""}, nil, "bindtest", lang, nil, nil
+	}
+	code, err := Bind(args(LangGo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(code, "Indexer") {
+		t.Error("indexer generated without request")
+	}
+	code, err = BindWithIndexer(args(LangGo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func NewRegistryIndexer(address common.Address, backend bind.IndexerBackend, store bind.IndexerStore, handlers RegistryIndexerHandlers, config bind.IndexerConfig) (*bind.Indexer, error)",
+		"OnRegistered0 func(event *RegistryRegistered0) error",
+		"event, err := h.filterer.ParseTagged(log)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("indexer binding doesn't contain %q", want)
+		}
+	}
+	if _, err := BindWithIndexer(args(LangTypeScript)); err == nil {
+		t.Error("expected error for TypeScript indexer")
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	ethereum "github.com/shudolab/core-geth"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/types"
+)
+
+const (
+	defaultIndexerChunkSize  = 2000
+	defaultIndexerReorgDepth = 64
+	indexerHistorySize       = 128
+)
+
+// IndexerCheckpoint identifies the last block whose logs an Indexer has fully
+// processed.
+type IndexerCheckpoint struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// IndexerStore persists the progress of an Indexer.
+type IndexerStore interface {
+	// LoadCheckpoint returns the stored checkpoint, or nil if nothing has been
+	// indexed yet.
+	LoadCheckpoint() (*IndexerCheckpoint, error)
+
+	// SaveCheckpoint stores the checkpoint after the logs up to and including
+	// its block have been handled.
+	SaveCheckpoint(checkpoint IndexerCheckpoint) error
+}
+
+// MemoryIndexerStore is an IndexerStore which keeps the checkpoint in memory.
+type MemoryIndexerStore struct {
+	mu         sync.Mutex
+	checkpoint *IndexerCheckpoint
+}
+
+// LoadCheckpoint implements IndexerStore.
+func (s *MemoryIndexerStore) LoadCheckpoint() (*IndexerCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checkpoint == nil {
+		return nil, nil
+	}
+	cp := *s.checkpoint
+	return &cp, nil
+}
+
+// SaveCheckpoint implements IndexerStore.
+func (s *MemoryIndexerStore) SaveCheckpoint(checkpoint IndexerCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoint = &checkpoint
+	return nil
+}
+
+// LogHandler processes the logs delivered by an Indexer.
+type LogHandler interface {
+	// HandleLog is called for each log of the indexed contract, in chain order.
+	HandleLog(log types.Log) error
+
+	// Rewind is called when the blocks after number are no longer part of the
+	// canonical chain. All state derived from logs of later blocks must be
+	// discarded, the logs of the new canonical blocks are delivered afterwards.
+	Rewind(number uint64) error
+}
+
+// IndexerBackend is the chain access needed by an Indexer.
+type IndexerBackend interface {
+	ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// IndexerConfig contains the settings of an Indexer.
+type IndexerConfig struct {
+	Start      uint64 // First block to index if the store holds no checkpoint
+	ChunkSize  uint64 // Number of blocks per FilterLogs query (default 2000)
+	ReorgDepth uint64 // Number of blocks rewound if the fork point of a reorg is unknown (default 64)
+}
+
+// Indexer feeds the logs of a contract to a LogHandler. It backfills the logs
+// since the last checkpoint with FilterLogs in chunks, then follows the chain
+// through SubscribeFilterLogs and SubscribeNewHead. Reorgs are detected from
+// removed logs and by checking the hash of the checkpoint block, and are handled
+// by rewinding to the newest processed block which is still canonical.
+//
+// Logs are delivered at least once: logs handled after the last saved
+// checkpoint are delivered again when the indexer restarts.
+type Indexer struct {
+	address common.Address
+	backend IndexerBackend
+	store   IndexerStore
+	handler LogHandler
+	config  IndexerConfig
+
+	checkpoint *IndexerCheckpoint
+	history    []IndexerCheckpoint // recently processed blocks, oldest first
+}
+
+// NewIndexer creates an indexer for the logs emitted by the contract at address.
+func NewIndexer(address common.Address, backend IndexerBackend, store IndexerStore, handler LogHandler, config IndexerConfig) *Indexer {
+	if config.ChunkSize == 0 {
+		config.ChunkSize = defaultIndexerChunkSize
+	}
+	if config.ReorgDepth == 0 {
+		config.ReorgDepth = defaultIndexerReorgDepth
+	}
+	return &Indexer{
+		address: address,
+		backend: backend,
+		store:   store,
+		handler: handler,
+		config:  config,
+	}
+}
+
+// Run indexes the contract logs until the context is canceled or an error
+// occurs. It returns nil when the context is canceled.
+func (ix *Indexer) Run(ctx context.Context) error {
+	cp, err := ix.store.LoadCheckpoint()
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	ix.checkpoint, ix.history = cp, nil
+	if cp != nil {
+		ix.history = append(ix.history, *cp)
+	}
+	// Subscribe before backfilling, so no logs are missed in between. New heads
+	// trigger syncs, removed logs signal reorgs which may not change the height
+	// of the chain.
+	logs := make(chan types.Log, 128)
+	logSub, err := ix.backend.SubscribeFilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{ix.address}}, logs)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to logs: %w", err)
+	}
+	defer logSub.Unsubscribe()
+
+	heads := make(chan *types.Header, 16)
+	headSub, err := ix.backend.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to heads: %w", err)
+	}
+	defer headSub.Unsubscribe()
+
+	if err := ix.sync(ctx); err != nil {
+		return ix.exitErr(ctx, err)
+	}
+	for {
+		var removed bool
+		select {
+		case log := <-logs:
+			removed = log.Removed
+		case <-heads:
+		case err := <-logSub.Err():
+			return err
+		case err := <-headSub.Err():
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+		// Coalesce the notifications which are already queued, the logs
+		// themselves are retrieved by the sync.
+	drain:
+		for {
+			select {
+			case log := <-logs:
+				removed = removed || log.Removed
+			case <-heads:
+			default:
+				break drain
+			}
+		}
+		if removed {
+			if err := ix.rewindToCanonical(ctx); err != nil {
+				return ix.exitErr(ctx, err)
+			}
+		}
+		if err := ix.sync(ctx); err != nil {
+			return ix.exitErr(ctx, err)
+		}
+	}
+}
+
+// exitErr suppresses errors caused by canceling the context.
+func (ix *Indexer) exitErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// sync processes the logs from the checkpoint up to the current head.
+func (ix *Indexer) sync(ctx context.Context) error {
+	for {
+		head, err := ix.backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return err
+		}
+		from := ix.config.Start
+		if ix.checkpoint != nil {
+			from = ix.checkpoint.Number + 1
+		}
+		if from > head.Number.Uint64() {
+			// The chain may have been reorged to a lower height.
+			if ix.checkpoint != nil && ix.checkpoint.Number > head.Number.Uint64() {
+				if err := ix.rewindToCanonical(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+			return nil
+		}
+		to := min(from+ix.config.ChunkSize-1, head.Number.Uint64())
+		if err := ix.processRange(ctx, from, to); err != nil {
+			return err
+		}
+	}
+}
+
+// processRange handles the logs of the blocks from..to and advances the
+// checkpoint to block to.
+func (ix *Indexer) processRange(ctx context.Context, from, to uint64) error {
+	last, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil // chain shrunk since the head was retrieved, retried by the caller
+	}
+	if err != nil {
+		return err
+	}
+	logs, err := ix.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{ix.address},
+	})
+	if err != nil {
+		return err
+	}
+	// The logs are consistent if the last block of the range didn't change while
+	// they were retrieved, and the range extends the checkpoint.
+	switch ok, err := ix.isCanonical(ctx, IndexerCheckpoint{to, last.Hash()}); {
+	case err != nil:
+		return err
+	case !ok:
+		return nil // retried by the caller
+	}
+	if ix.checkpoint != nil {
+		switch ok, err := ix.isCanonical(ctx, *ix.checkpoint); {
+		case err != nil:
+			return err
+		case !ok:
+			return ix.rewindToCanonical(ctx)
+		}
+	}
+	for _, log := range logs {
+		if log.Removed || log.BlockHash == (common.Hash{}) {
+			continue
+		}
+		if err := ix.handler.HandleLog(log); err != nil {
+			return err
+		}
+		ix.remember(IndexerCheckpoint{log.BlockNumber, log.BlockHash})
+	}
+	return ix.commit(IndexerCheckpoint{to, last.Hash()})
+}
+
+// rewindToCanonical rewinds the checkpoint to the newest recently processed
+// block which is still canonical. If there is no such block, the checkpoint is
+// moved back by the configured reorg depth.
+func (ix *Indexer) rewindToCanonical(ctx context.Context) error {
+	if ix.checkpoint == nil {
+		return nil
+	}
+	for i := len(ix.history) - 1; i >= 0; i-- {
+		ok, err := ix.isCanonical(ctx, ix.history[i])
+		if err != nil {
+			return err
+		}
+		if ok {
+			return ix.rewind(ix.history[i])
+		}
+	}
+	// No known block is canonical anymore, fall back to the reorg depth.
+	number := ix.config.Start
+	if oldest := ix.history[0].Number; oldest > ix.config.Start+ix.config.ReorgDepth {
+		number = oldest - ix.config.ReorgDepth
+	}
+	if number == 0 {
+		// Forget the checkpoint entirely so indexing restarts at the start block.
+		if err := ix.handler.Rewind(0); err != nil {
+			return err
+		}
+		ix.checkpoint, ix.history = nil, nil
+		return nil
+	}
+	header, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number-1))
+	if err != nil {
+		return err
+	}
+	return ix.rewind(IndexerCheckpoint{number - 1, header.Hash()})
+}
+
+// rewind resets the checkpoint to the given canonical block.
+func (ix *Indexer) rewind(cp IndexerCheckpoint) error {
+	if ix.checkpoint != nil && *ix.checkpoint == cp {
+		return nil
+	}
+	if err := ix.handler.Rewind(cp.Number); err != nil {
+		return err
+	}
+	for len(ix.history) > 0 && ix.history[len(ix.history)-1].Number >= cp.Number {
+		ix.history = ix.history[:len(ix.history)-1]
+	}
+	return ix.commit(cp)
+}
+
+// commit saves the checkpoint.
+func (ix *Indexer) commit(cp IndexerCheckpoint) error {
+	if err := ix.store.SaveCheckpoint(cp); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	ix.checkpoint = &cp
+	ix.remember(cp)
+	return nil
+}
+
+// remember adds a processed block to the history used to find the fork point
+// of reorgs.
+func (ix *Indexer) remember(cp IndexerCheckpoint) {
+	if n := len(ix.history); n > 0 && ix.history[n-1] == cp {
+		return
+	}
+	ix.history = append(ix.history, cp)
+	if len(ix.history) > indexerHistorySize {
+		ix.history = ix.history[len(ix.history)-indexerHistorySize:]
+	}
+}
+
+// isCanonical reports whether the given block is part of the canonical chain.
+func (ix *Indexer) isCanonical(ctx context.Context, cp IndexerCheckpoint) (bool, error) {
+	header, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(cp.Number))
+	if errors.Is(err, ethereum.NotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return header.Hash() == cp.Hash, nil
+}
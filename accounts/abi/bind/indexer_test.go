@@ -0,0 +1,215 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth"
+	"github.com/shudolab/core-geth/accounts/abi"
+	"github.com/shudolab/core-geth/accounts/abi/bind"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/ethclient/simulated"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+)
+
+// storedEvent is the signature of the log emitted by the emitter contract.
+var storedEvent = crypto.Keccak256Hash([]byte("Stored(uint256)"))
+
+// emitterCode returns the deployment code of a contract which emits a Stored
+// event carrying the first 32 bytes of the calldata on every call.
+func emitterCode() []byte {
+	runtime := "600035600052" + // mstore(0, calldataload(0))
+		"7f" + hex.EncodeToString(storedEvent[:]) + // push32 topic
+		"60206000a1" + // log1(0, 32, topic)
+		"00"
+	code, _ := hex.DecodeString(runtime)
+	init, _ := hex.DecodeString("602d600c600039602d6000f3") // codecopy(0, 12, 45), return(0, 45)
+	return append(init, code...)
+}
+
+// recordingHandler is a LogHandler which tracks the indexed values.
+type recordingHandler struct {
+	mu     sync.Mutex
+	values []uint64
+	blocks []uint64
+}
+
+func (h *recordingHandler) HandleLog(log types.Log) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.values = append(h.values, new(big.Int).SetBytes(log.Data).Uint64())
+	h.blocks = append(h.blocks, log.BlockNumber)
+	return nil
+}
+
+func (h *recordingHandler) Rewind(number uint64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for len(h.blocks) > 0 && h.blocks[len(h.blocks)-1] > number {
+		h.values = h.values[:len(h.values)-1]
+		h.blocks = h.blocks[:len(h.blocks)-1]
+	}
+	return nil
+}
+
+func (h *recordingHandler) indexed() []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]uint64{}, h.values...)
+}
+
+type indexerTest struct {
+	t        *testing.T
+	sim      *simulated.Backend
+	client   simulated.Client
+	opts     *bind.TransactOpts
+	contract *bind.BoundContract
+	address  common.Address
+}
+
+func newIndexerTest(t *testing.T) *indexerTest {
+	key, _ := crypto.GenerateKey()
+	opts, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	sim := simulated.NewBackend(genesisT.GenesisAlloc{opts.From: {Balance: big.NewInt(1e18)}})
+	t.Cleanup(func() { sim.Close() })
+
+	address, _, contract, err := bind.DeployContract(opts, abi.ABI{}, emitterCode(), sim.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	return &indexerTest{t: t, sim: sim, client: sim.Client(), opts: opts, contract: contract, address: address}
+}
+
+// emit sends transactions emitting the given values, without committing them.
+func (it *indexerTest) emit(values ...uint64) {
+	for _, v := range values {
+		opts := *it.opts
+		opts.GasLimit = 50000
+		if _, err := it.contract.RawTransact(&opts, common.BigToHash(new(big.Int).SetUint64(v)).Bytes()); err != nil {
+			it.t.Fatal(err)
+		}
+	}
+}
+
+// canonical returns the values emitted on the canonical chain.
+func (it *indexerTest) canonical() []uint64 {
+	logs, err := it.client.FilterLogs(context.Background(), ethereum.FilterQuery{Addresses: []common.Address{it.address}})
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	values := []uint64{}
+	for _, log := range logs {
+		values = append(values, new(big.Int).SetBytes(log.Data).Uint64())
+	}
+	return values
+}
+
+// waitIndexed waits until the handler and store reflect the canonical chain.
+func (it *indexerTest) waitIndexed(handler *recordingHandler, store bind.IndexerStore) {
+	it.t.Helper()
+
+	var (
+		want []uint64
+		have []uint64
+	)
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		head, err := it.client.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			it.t.Fatal(err)
+		}
+		want, have = it.canonical(), handler.indexed()
+		cp, _ := store.LoadCheckpoint()
+		if cp != nil && cp.Hash == head.Hash() && reflect.DeepEqual(have, want) {
+			return
+		}
+	}
+	it.t.Fatalf("indexer didn't catch up: have %v, want %v", have, want)
+}
+
+func TestIndexer(t *testing.T) {
+	it := newIndexerTest(t)
+
+	// Emit some events before the indexer starts, so they are backfilled.
+	it.emit(1, 2)
+	it.sim.Commit()
+	it.emit(3)
+	it.sim.Commit()
+	for i := 0; i < 5; i++ {
+		it.sim.Commit()
+	}
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		handler     = new(recordingHandler)
+		store       = new(bind.MemoryIndexerStore)
+		indexer     = bind.NewIndexer(it.address, it.client, store, handler, bind.IndexerConfig{ChunkSize: 2})
+		done        = make(chan error, 1)
+	)
+	go func() { done <- indexer.Run(ctx) }()
+	it.waitIndexed(handler, store)
+
+	// Follow new events.
+	it.emit(4)
+	fork, _ := it.client.HeaderByNumber(context.Background(), nil)
+	it.sim.Commit()
+	it.emit(5)
+	it.sim.Commit()
+	it.waitIndexed(handler, store)
+	if have := handler.indexed(); !reflect.DeepEqual(have, []uint64{1, 2, 3, 4, 5}) {
+		t.Fatalf("wrong values indexed: %v", have)
+	}
+
+	// Replace the blocks of the last two events with a longer side chain.
+	if err := it.sim.Fork(fork.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	it.sim.Commit()
+	it.emit(6)
+	it.sim.Commit()
+	it.sim.Commit()
+	it.waitIndexed(handler, store)
+	if have := handler.indexed(); !reflect.DeepEqual(have, []uint64{1, 2, 3, 6}) {
+		t.Fatalf("wrong values indexed after reorg: %v", have)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("indexer failed: %v", err)
+	}
+
+	// Restarting from the stored checkpoint must only deliver new events.
+	it.emit(7)
+	it.sim.Commit()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	indexer = bind.NewIndexer(it.address, it.client, store, handler, bind.IndexerConfig{ChunkSize: 2})
+	go func() { done <- indexer.Run(ctx) }()
+	it.waitIndexed(handler, store)
+}
@@ -24,6 +24,7 @@ type tmplData struct {
 	Contracts map[string]*tmplContract // List of contracts to generate into this file
 	Libraries map[string]string        // Map the bytecode's link pattern to the library name
 	Structs   map[string]*tmplStruct   // Contract struct type definitions
+	Indexer   bool                     // Whether to generate typed event indexers
 }
 
 // tmplContract contains the data needed to generate an individual contract binding.
@@ -75,7 +76,9 @@ type tmplStruct struct {
 // tmplSource is language to template mapping containing all the supported
 // programming languages the package can generate to.
 var tmplSource = map[Lang]string{
-	LangGo: tmplSourceGo,
+	LangGo:         tmplSourceGo,
+	LangTypeScript: tmplSourceTS,
+	LangPython:     tmplSourcePython,
 }
 
 // tmplSourceGo is the Go source template that the generated Go contract binding
@@ -567,5 +570,377 @@ var (
 		}
 
  	{{end}}
+
+	{{if and $.Indexer .Events}}
+		// {{.Type}}IndexerHandlers contains the callbacks invoked by a {{.Type}} event
+		// indexer. Events without a callback are skipped.
+		type {{.Type}}IndexerHandlers struct {
+			{{- range .Events}}
+			// On{{.Normalized.Name}} is called for every {{.Original.RawName}} event, in chain order.
+			On{{.Normalized.Name}} func(event *{{$contract.Type}}{{.Normalized.Name}}) error
+			{{end}}
+			// OnRewind is called when the blocks after number are reorged out. All
+			// state derived from events of later blocks must be discarded.
+			OnRewind func(number uint64) error
+		}
+
+		// New{{.Type}}Indexer creates an event indexer for the {{.Type}} contract deployed
+		// at address. The indexer backfills past events, follows new ones and handles
+		// reorgs, persisting its progress in store.
+		func New{{.Type}}Indexer(address common.Address, backend bind.IndexerBackend, store bind.IndexerStore, handlers {{.Type}}IndexerHandlers, config bind.IndexerConfig) (*bind.Indexer, error) {
+			filterer, err := New{{.Type}}Filterer(address, backend)
+			if err != nil {
+				return nil, err
+			}
+			handler := &{{decapitalise .Type}}LogHandler{filterer: filterer, handlers: handlers}
+			return bind.NewIndexer(address, backend, store, handler, config), nil
+		}
+
+		// {{decapitalise .Type}}LogHandler dispatches the logs of the {{.Type}} contract to
+		// the typed event callbacks.
+		type {{decapitalise .Type}}LogHandler struct {
+			filterer *{{.Type}}Filterer
+			handlers {{.Type}}IndexerHandlers
+		}
+
+		// HandleLog implements bind.LogHandler.
+		func (h *{{decapitalise .Type}}LogHandler) HandleLog(log types.Log) error {
+			if len(log.Topics) == 0 {
+				return nil
+			}
+			switch log.Topics[0] {
+			{{- range .Events}}
+			case common.HexToHash("0x{{printf "%x" .Original.ID}}"):
+				if h.handlers.On{{.Normalized.Name}} == nil {
+					return nil
+				}
+				event, err := h.filterer.Parse{{.Normalized.Name}}(log)
+				if err != nil {
+					return err
+				}
+				return h.handlers.On{{.Normalized.Name}}(event)
+			{{- end}}
+			}
+			return nil
+		}
+
+		// Rewind implements bind.LogHandler.
+		func (h *{{decapitalise .Type}}LogHandler) Rewind(number uint64) error {
+			if h.handlers.OnRewind == nil {
+				return nil
+			}
+			return h.handlers.OnRewind(number)
+		}
+	{{end}}
 {{end}}
 `
+
+// tmplSourceTS is the TypeScript source template that the generated TypeScript
+// contract binding is based on. The binding wraps an ethers v6 Contract.
+const tmplSourceTS = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+import {
+  Contract,
+  ContractFactory,
+  type ContractRunner,
+  type ContractTransactionResponse,
+  type Log,
+  type Overrides,
+} from "ethers";{{$structs := .Structs}}
+{{- range $structs}}
+
+// {{.Name}} is an auto generated low-level TypeScript binding around an user-defined struct.
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}: {{.Type}};
+{{- end}}
+}
+
+function decode{{.Name}}(value: any): {{.Name}} {
+  return {
+{{- range $i, $field := .Fields}}
+    {{$field.Name}}: {{decode $field.SolKind $structs (printf "value[%d]" $i)}},
+{{- end}}
+  };
+}
+{{- end}}
+{{- range $contract := .Contracts}}
+
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+export const {{.Type}}ABI = "{{.InputABI}}";
+{{- if .InputBin}}
+
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+export const {{.Type}}Bin = "0x{{.InputBin}}";
+{{- end}}
+{{- range .Calls}}{{if .Structured}}
+
+// {{$contract.Type}}{{capitalise .Normalized.Name}}Output is the named return of the {{.Original.RawName}} method.
+export interface {{$contract.Type}}{{capitalise .Normalized.Name}}Output {
+{{- range .Normalized.Outputs}}
+  {{ident .Name}}: {{bindtype .Type $structs}};
+{{- end}}
+}
+{{- end}}{{end}}
+{{- range .Events}}
+
+// {{$contract.Type}}{{capitalise .Normalized.Name}} represents a {{.Original.RawName}} event raised by the {{$contract.Type}} contract.
+export interface {{$contract.Type}}{{capitalise .Normalized.Name}} {
+{{- range .Normalized.Inputs}}
+  {{ident .Name}}: {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}};
+{{- end}}
+  raw: Log;
+}
+{{- end}}
+
+// {{.Type}} is an auto generated TypeScript binding around an Ethereum contract.
+export class {{.Type}} {
+  readonly contract: Contract;
+
+  constructor(address: string, runner?: ContractRunner | null) {
+    this.contract = new Contract(address, {{.Type}}ABI, runner);
+  }
+{{- if .InputBin}}
+
+  // deploy deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
+  static async deploy(runner: ContractRunner{{range $i, $in := .Constructor.Inputs}}, {{ident (or $in.Name (printf "arg%d" $i))}}: {{bindtype $in.Type $structs}}{{end}}, overrides: Overrides = {}): Promise<{{.Type}}> {
+    const factory = new ContractFactory({{.Type}}ABI, {{.Type}}Bin, runner);
+    const contract = await factory.deploy({{range $i, $in := .Constructor.Inputs}}{{ident (or $in.Name (printf "arg%d" $i))}}, {{end}}overrides);
+    await contract.waitForDeployment();
+    return new {{.Type}}(await contract.getAddress(), runner);
+  }
+{{- end}}
+{{- range .Calls}}
+
+  // {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.ID}}.
+  //
+  // Solidity: {{.Original.String}}
+  async {{.Normalized.Name}}({{range .Normalized.Inputs}}{{ident .Name}}: {{bindtype .Type $structs}}, {{end}}overrides: Overrides = {}): Promise<
+    {{- if .Structured}}{{$contract.Type}}{{capitalise .Normalized.Name}}Output
+    {{- else if eq (len .Normalized.Outputs) 0}}void
+    {{- else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type $structs}}
+    {{- else}}[{{range $i, $out := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype $out.Type $structs}}{{end}}]{{end}}> {
+    {{if .Normalized.Outputs}}const result = {{end}}await this.contract.getFunction("{{.Original.Sig}}").staticCallResult({{range .Normalized.Inputs}}{{encode .Type (ident .Name)}}, {{end}}overrides);
+    {{- if .Structured}}
+    return {
+    {{- range $i, $out := .Normalized.Outputs}}
+      {{ident $out.Name}}: {{decode $out.Type $structs (printf "result[%d]" $i)}},
+    {{- end}}
+    };
+    {{- else if eq (len .Normalized.Outputs) 1}}
+    return {{decode (index .Normalized.Outputs 0).Type $structs "result[0]"}};
+    {{- else if .Normalized.Outputs}}
+    return [{{range $i, $out := .Normalized.Outputs}}{{if $i}}, {{end}}{{decode $out.Type $structs (printf "result[%d]" $i)}}{{end}}];
+    {{- end}}
+  }
+{{- end}}
+{{- range .Transacts}}
+
+  // {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.ID}}.
+  //
+  // Solidity: {{.Original.String}}
+  async {{.Normalized.Name}}({{range .Normalized.Inputs}}{{ident .Name}}: {{bindtype .Type $structs}}, {{end}}overrides: Overrides = {}): Promise<ContractTransactionResponse> {
+    return this.contract.getFunction("{{.Original.Sig}}").send({{range .Normalized.Inputs}}{{encode .Type (ident .Name)}}, {{end}}overrides);
+  }
+{{- end}}
+{{- range .Events}}
+
+  // parse{{capitalise .Normalized.Name}} decodes a {{.Original.RawName}} event from a log, returning null if the log holds a different event.
+  //
+  // Solidity: {{.Original.String}}
+  parse{{capitalise .Normalized.Name}}(log: Log): {{$contract.Type}}{{capitalise .Normalized.Name}} | null {
+    const fragment = this.contract.interface.getEvent("{{.Original.Sig}}")!;
+    if (log.topics[0] !== fragment.topicHash) {
+      return null;
+    }
+    const args = this.contract.interface.decodeEventLog(fragment, log.data, log.topics);
+    return {
+    {{- range $i, $in := .Normalized.Inputs}}
+      {{ident $in.Name}}: {{if $in.Indexed}}{{decodetopic $in.Type $structs (printf "args[%d]" $i)}}{{else}}{{decode $in.Type $structs (printf "args[%d]" $i)}}{{end}},
+    {{- end}}
+      raw: log,
+    };
+  }
+
+  // filter{{capitalise .Normalized.Name}} retrieves the {{.Original.RawName}} events raised in the given block range.
+  //
+  // Solidity: {{.Original.String}}
+  async filter{{capitalise .Normalized.Name}}(fromBlock?: number | string, toBlock?: number | string): Promise<{{$contract.Type}}{{capitalise .Normalized.Name}}[]> {
+    const logs = await this.contract.queryFilter("{{.Original.Sig}}", fromBlock, toBlock);
+    return logs.map((log) => this.parse{{capitalise .Normalized.Name}}(log)!);
+  }
+
+  // watch{{capitalise .Normalized.Name}} subscribes to {{.Original.RawName}} events, returning a function to cancel the subscription.
+  //
+  // Solidity: {{.Original.String}}
+  async watch{{capitalise .Normalized.Name}}(callback: (event: {{$contract.Type}}{{capitalise .Normalized.Name}}) => void): Promise<() => Promise<void>> {
+    const listener = (...args: any[]) => {
+      const event = this.parse{{capitalise .Normalized.Name}}(args[args.length - 1].log);
+      if (event !== null) {
+        callback(event);
+      }
+    };
+    await this.contract.on("{{.Original.Sig}}", listener);
+    return async () => {
+      await this.contract.off("{{.Original.Sig}}", listener);
+    };
+  }
+{{- end}}
+}
+{{- end}}
+`
+
+// tmplSourcePython is the Python source template that the generated Python
+// contract binding is based on. The binding wraps a web3.py Contract.
+const tmplSourcePython = `# Code generated - DO NOT EDIT.
+# This file is a generated binding and any manual changes will be lost.
+
+from __future__ import annotations
+
+import json
+from dataclasses import dataclass
+from typing import Any, List, Optional, Tuple
+
+from eth_utils import event_abi_to_log_topic
+from hexbytes import HexBytes
+from web3 import Web3
+from web3.contract import Contract
+from web3.types import BlockIdentifier, EventData, LogReceipt, TxParams{{$structs := .Structs}}
+{{- range $structs}}
+
+
+@dataclass
+class {{.Name}}:
+    """{{.Name}} is an auto generated low-level Python binding around an user-defined struct."""
+{{range .Fields}}
+    {{.Name}}: {{.Type}}
+{{- end}}
+
+    @classmethod
+    def from_abi(cls, value: Any) -> {{.Name}}:
+        return cls(
+{{- range $i, $field := .Fields}}
+            {{$field.Name}}={{decode $field.SolKind $structs (printf "value[%d]" $i)}},
+{{- end}}
+        )
+
+    def to_abi(self) -> Tuple[Any, ...]:
+        return (
+{{- range .Fields}}
+            {{encode .SolKind (printf "self.%s" .Name)}},
+{{- end}}
+        )
+{{- end}}
+{{- range $contract := .Contracts}}
+{{- range .Calls}}{{if .Structured}}
+
+
+@dataclass
+class {{$contract.Type}}{{capitalise .Normalized.Name}}Output:
+    """{{$contract.Type}}{{capitalise .Normalized.Name}}Output is the named return of the {{.Original.RawName}} method."""
+{{range .Normalized.Outputs}}
+    {{ident .Name}}: {{bindtype .Type $structs}}
+{{- end}}
+{{- end}}{{end}}
+{{- range .Events}}
+
+
+@dataclass
+class {{$contract.Type}}{{capitalise .Normalized.Name}}:
+    """{{$contract.Type}}{{capitalise .Normalized.Name}} represents a {{.Original.RawName}} event raised by the {{$contract.Type}} contract."""
+{{range .Normalized.Inputs}}
+    {{ident .Name}}: {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}}
+{{- end}}
+    raw: EventData
+{{- end}}
+
+
+class {{.Type}}:
+    """{{.Type}} is an auto generated Python binding around an Ethereum contract."""
+
+    ABI: List[Any] = json.loads("{{.InputABI}}")
+{{- if .InputBin}}
+    BIN = "0x{{.InputBin}}"
+{{- end}}
+
+    def __init__(self, w3: Web3, address: str) -> None:
+        self.w3 = w3
+        self.contract: Contract = w3.eth.contract(address=Web3.to_checksum_address(address), abi=self.ABI)
+{{- if .InputBin}}
+
+    @classmethod
+    def deploy(cls, w3: Web3{{range $i, $in := .Constructor.Inputs}}, {{ident (or $in.Name (printf "arg%d" $i))}}: {{bindtype $in.Type $structs}}{{end}}, tx: Optional[TxParams] = None) -> {{.Type}}:
+        """Deploys a new Ethereum contract and waits for it to be mined."""
+        factory = w3.eth.contract(abi=cls.ABI, bytecode=cls.BIN)
+        tx_hash = factory.constructor({{range $i, $in := .Constructor.Inputs}}{{if $i}}, {{end}}{{encode $in.Type (ident (or $in.Name (printf "arg%d" $i)))}}{{end}}).transact(tx or {})
+        receipt = w3.eth.wait_for_transaction_receipt(tx_hash)
+        return cls(w3, receipt["contractAddress"])
+{{- end}}
+{{- range .Calls}}
+
+    def {{.Normalized.Name}}(self{{range .Normalized.Inputs}}, {{ident .Name}}: {{bindtype .Type $structs}}{{end}}, block_identifier: BlockIdentifier = "latest") -> {{if .Structured}}{{$contract.Type}}{{capitalise .Normalized.Name}}Output
+        {{- else if eq (len .Normalized.Outputs) 0}}None
+        {{- else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type $structs}}
+        {{- else}}Tuple[{{range $i, $out := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype $out.Type $structs}}{{end}}]{{end}}:
+        """Free data retrieval call binding the contract method 0x{{printf "%x" .Original.ID}}.
+
+        Solidity: {{.Original.String}}
+        """
+        {{if .Normalized.Outputs}}result = {{end}}self.contract.get_function_by_signature("{{.Original.Sig}}")({{range $i, $in := .Normalized.Inputs}}{{if $i}}, {{end}}{{encode $in.Type (ident $in.Name)}}{{end}}).call(block_identifier=block_identifier)
+        {{- if .Structured}}
+        return {{$contract.Type}}{{capitalise .Normalized.Name}}Output(
+        {{- range $i, $out := .Normalized.Outputs}}
+            {{ident $out.Name}}={{decode $out.Type $structs (printf "result[%d]" $i)}},
+        {{- end}}
+        )
+        {{- else if eq (len .Normalized.Outputs) 1}}
+        return {{decode (index .Normalized.Outputs 0).Type $structs "result"}}
+        {{- else if .Normalized.Outputs}}
+        return ({{range $i, $out := .Normalized.Outputs}}{{if $i}}, {{end}}{{decode $out.Type $structs (printf "result[%d]" $i)}}{{end}})
+        {{- end}}
+{{- end}}
+{{- range .Transacts}}
+
+    def {{.Normalized.Name}}(self{{range .Normalized.Inputs}}, {{ident .Name}}: {{bindtype .Type $structs}}{{end}}, tx: Optional[TxParams] = None) -> HexBytes:
+        """Paid mutator transaction binding the contract method 0x{{printf "%x" .Original.ID}}.
+
+        Solidity: {{.Original.String}}
+        """
+        return self.contract.get_function_by_signature("{{.Original.Sig}}")({{range $i, $in := .Normalized.Inputs}}{{if $i}}, {{end}}{{encode $in.Type (ident $in.Name)}}{{end}}).transact(tx or {})
+{{- end}}
+{{- range $event := .Events}}
+
+    def parse_{{.Normalized.Name}}(self, log: LogReceipt) -> Optional[{{$contract.Type}}{{capitalise .Normalized.Name}}]:
+        """Decodes a {{.Original.RawName}} event from a log, returning None if the log holds a different event.
+
+        Solidity: {{.Original.String}}
+        """
+        if not log["topics"] or bytes(log["topics"][0]) != bytes.fromhex("{{printf "%x" .Original.ID}}"):
+            return None
+        fragment = next(e for e in self.ABI if e["type"] == "event" and event_abi_to_log_topic(e) == bytes(log["topics"][0]))
+        event = self.w3.eth.contract(abi=[fragment]).events[fragment["name"]]().process_log(log)
+        args = event["args"]
+        return {{$contract.Type}}{{capitalise .Normalized.Name}}(
+        {{- range $i, $in := .Normalized.Inputs}}
+            {{ident $in.Name}}={{if $in.Indexed}}{{decodetopic $in.Type $structs (printf "args[%q]" (index $event.Original.Inputs $i).Name)}}{{else}}{{decode $in.Type $structs (printf "args[%q]" (index $event.Original.Inputs $i).Name)}}{{end}},
+        {{- end}}
+            raw=event,
+        )
+
+    def filter_{{.Normalized.Name}}(self, from_block: BlockIdentifier = "earliest", to_block: BlockIdentifier = "latest") -> List[{{$contract.Type}}{{capitalise .Normalized.Name}}]:
+        """Retrieves the {{.Original.RawName}} events raised in the given block range.
+
+        Solidity: {{.Original.String}}
+        """
+        logs = self.w3.eth.get_logs({
+            "address": self.contract.address,
+            "topics": [HexBytes("0x{{printf "%x" .Original.ID}}")],
+            "fromBlock": from_block,
+            "toBlock": to_block,
+        })
+        events = [self.parse_{{.Normalized.Name}}(log) for log in logs]
+        return [event for event in events if event is not None]
+{{- end}}
+{{- end}}
+`
@@ -0,0 +1,127 @@
+# Code generated - DO NOT EDIT.
+# This file is a generated binding and any manual changes will be lost.
+
+from __future__ import annotations
+
+import json
+from dataclasses import dataclass
+from typing import Any, List, Optional, Tuple
+
+from eth_utils import event_abi_to_log_topic
+from hexbytes import HexBytes
+from web3 import Web3
+from web3.contract import Contract
+from web3.types import BlockIdentifier, EventData, LogReceipt, TxParams
+
+
+@dataclass
+class Struct1:
+    """Struct1 is an auto generated low-level Python binding around an user-defined struct."""
+
+    pool: str
+    fee: int
+    path: List[Struct0]
+
+    @classmethod
+    def from_abi(cls, value: Any) -> Struct1:
+        return cls(
+            pool=value[0],
+            fee=value[1],
+            path=[Struct0.from_abi(v0) for v0 in value[2]],
+        )
+
+    def to_abi(self) -> Tuple[Any, ...]:
+        return (
+            self.pool,
+            self.fee,
+            [v0.to_abi() for v0 in self.path],
+        )
+
+
+@dataclass
+class Struct0:
+    """Struct0 is an auto generated low-level Python binding around an user-defined struct."""
+
+    token: str
+    weight: int
+
+    @classmethod
+    def from_abi(cls, value: Any) -> Struct0:
+        return cls(
+            token=value[0],
+            weight=value[1],
+        )
+
+    def to_abi(self) -> Tuple[Any, ...]:
+        return (
+            self.token,
+            self.weight,
+        )
+
+
+@dataclass
+class NestedRouted:
+    """NestedRouted represents a Routed event raised by the Nested contract."""
+
+    sender: str
+    hop: bytes
+    path: List[Struct0]
+    raw: EventData
+
+
+class Nested:
+    """Nested is an auto generated Python binding around an Ethereum contract."""
+
+    ABI: List[Any] = json.loads("[{\"type\":\"function\",\"name\":\"route\",\"inputs\":[{\"name\":\"hops\",\"type\":\"tuple[2]\",\"components\":[{\"name\":\"pool\",\"type\":\"address\"},{\"name\":\"fee\",\"type\":\"int24\"},{\"name\":\"path\",\"type\":\"tuple[]\",\"components\":[{\"name\":\"token\",\"type\":\"address\"},{\"name\":\"weight\",\"type\":\"uint8\"}]}]},{\"name\":\"default\",\"type\":\"bytes\"}],\"outputs\":[{\"name\":\"amounts\",\"type\":\"uint256[][]\"},{\"name\":\"\",\"type\":\"tuple\",\"components\":[{\"name\":\"token\",\"type\":\"address\"},{\"name\":\"weight\",\"type\":\"uint8\"}]}],\"stateMutability\":\"view\"},{\"type\":\"event\",\"name\":\"Routed\",\"inputs\":[{\"name\":\"sender\",\"type\":\"address\",\"indexed\":true},{\"name\":\"hop\",\"type\":\"tuple\",\"indexed\":true,\"components\":[{\"name\":\"token\",\"type\":\"address\"},{\"name\":\"weight\",\"type\":\"uint8\"}]},{\"name\":\"path\",\"type\":\"tuple[]\",\"indexed\":false,\"components\":[{\"name\":\"token\",\"type\":\"address\"},{\"name\":\"weight\",\"type\":\"uint8\"}]}],\"anonymous\":false}]")
+    BIN = "0x6080"
+
+    def __init__(self, w3: Web3, address: str) -> None:
+        self.w3 = w3
+        self.contract: Contract = w3.eth.contract(address=Web3.to_checksum_address(address), abi=self.ABI)
+
+    @classmethod
+    def deploy(cls, w3: Web3, tx: Optional[TxParams] = None) -> Nested:
+        """Deploys a new Ethereum contract and waits for it to be mined."""
+        factory = w3.eth.contract(abi=cls.ABI, bytecode=cls.BIN)
+        tx_hash = factory.constructor().transact(tx or {})
+        receipt = w3.eth.wait_for_transaction_receipt(tx_hash)
+        return cls(w3, receipt["contractAddress"])
+
+    def route(self, hops: List[Struct1], arg1: bytes, block_identifier: BlockIdentifier = "latest") -> Tuple[List[List[int]], Struct0]:
+        """Free data retrieval call binding the contract method 0xa5024fd7.
+
+        Solidity: function route((address,int24,(address,uint8)[])[2] hops, bytes default) view returns(uint256[][] amounts, (address,uint8))
+        """
+        result = self.contract.get_function_by_signature("route((address,int24,(address,uint8)[])[2],bytes)")([v0.to_abi() for v0 in hops], arg1).call(block_identifier=block_identifier)
+        return ([list(v0) for v0 in result[0]], Struct0.from_abi(result[1]))
+
+    def parse_routed(self, log: LogReceipt) -> Optional[NestedRouted]:
+        """Decodes a Routed event from a log, returning None if the log holds a different event.
+
+        Solidity: event Routed(address indexed sender, (address,uint8) indexed hop, (address,uint8)[] path)
+        """
+        if not log["topics"] or bytes(log["topics"][0]) != bytes.fromhex("2ce49f2e5b71e75dfccb69a076b975941c50da04d0ef7292166547346073f238"):
+            return None
+        fragment = next(e for e in self.ABI if e["type"] == "event" and event_abi_to_log_topic(e) == bytes(log["topics"][0]))
+        event = self.w3.eth.contract(abi=[fragment]).events[fragment["name"]]().process_log(log)
+        args = event["args"]
+        return NestedRouted(
+            sender=args["sender"],
+            hop=bytes(args["hop"]),
+            path=[Struct0.from_abi(v0) for v0 in args["path"]],
+            raw=event,
+        )
+
+    def filter_routed(self, from_block: BlockIdentifier = "earliest", to_block: BlockIdentifier = "latest") -> List[NestedRouted]:
+        """Retrieves the Routed events raised in the given block range.
+
+        Solidity: event Routed(address indexed sender, (address,uint8) indexed hop, (address,uint8)[] path)
+        """
+        logs = self.w3.eth.get_logs({
+            "address": self.contract.address,
+            "topics": [HexBytes("0x2ce49f2e5b71e75dfccb69a076b975941c50da04d0ef7292166547346073f238")],
+            "fromBlock": from_block,
+            "toBlock": to_block,
+        })
+        events = [self.parse_routed(log) for log in logs]
+        return [event for event in events if event is not None]
@@ -66,6 +66,12 @@ type DerivationPath []uint32
 // paths (which will get appended to the default root path) must not have prefixes
 // in front of the first element. Whitespace is ignored.
 func ParseDerivationPath(path string) (DerivationPath, error) {
+	return parseDerivationPath(path, DefaultRootDerivationPath)
+}
+
+// parseDerivationPath converts a derivation path string to its binary form,
+// appending relative paths to the given root.
+func parseDerivationPath(path string, root DerivationPath) (DerivationPath, error) {
 	var result DerivationPath
 
 	// Handle absolute or relative paths
@@ -81,7 +87,7 @@ func ParseDerivationPath(path string) (DerivationPath, error) {
 		components = components[1:]
 
 	default:
-		result = append(result, DefaultRootDerivationPath...)
+		result = append(result, root...)
 	}
 	// All remaining components are relative, append one by one
 	if len(components) == 0 {
@@ -16,6 +16,12 @@
 
 package accounts
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // https://github.com/satoshilabs/slips/blob/master/slip-0044.md
 const BIP0044CoinTypeTestnet uint32 = 0x1       // 1
 const BIP0044CoinTypeEther uint32 = 0x3c        // 60
@@ -28,13 +34,131 @@ var BIP0044CoinType uint32
 
 // SetCoinTypeConfiguration sets the global coin type configuration to the given value.
 func SetCoinTypeConfiguration(coinType uint32) {
+	paths := NewHDPathConfig(coinType)
 	BIP0044CoinType = coinType
-	DefaultRootDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + coinType, 0x80000000 + 0, 0}
-	DefaultBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + coinType, 0x80000000 + 0, 0, 0}
-	LegacyLedgerBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + coinType, 0x80000000 + 0, 0}
+	DefaultRootDerivationPath = paths.RootPath()
+	DefaultBaseDerivationPath = paths.BasePath()
+	LegacyLedgerBaseDerivationPath = paths.LegacyLedgerBasePath()
 }
 
 // init configures the global coin type and root derivation path for Ethereum mainnet.
 func init() {
 	SetCoinTypeConfiguration(BIP0044CoinTypeEther)
 }
+
+// HDPathConfig describes the BIP-44 derivation paths used for a single network.
+// Unlike the package level defaults, a config can be handed to individual wallet
+// backends, so that wallets deriving for different coin types can coexist in a
+// single process (e.g. clef serving both ETH and ETC).
+type HDPathConfig struct {
+	CoinType uint32 `json:"coinType"`
+}
+
+// NewHDPathConfig creates a derivation configuration for the given SLIP-0044 coin type.
+func NewHDPathConfig(coinType uint32) HDPathConfig {
+	return HDPathConfig{CoinType: coinType}
+}
+
+// DefaultHDPathConfig returns the derivation configuration matching the process
+// wide coin type set by SetCoinTypeConfiguration.
+func DefaultHDPathConfig() HDPathConfig {
+	return NewHDPathConfig(BIP0044CoinType)
+}
+
+// hdNetworkCoinTypes maps network names to the coin types their accounts are derived with.
+var hdNetworkCoinTypes = map[string]uint32{
+	"mainnet": BIP0044CoinTypeEther,
+	"classic": BIP0044CoinTypeEtherClassic,
+	"mordor":  BIP0044CoinTypeTestnet,
+	"goerli":  BIP0044CoinTypeTestnet,
+	"sepolia": BIP0044CoinTypeTestnet,
+	"holesky": BIP0044CoinTypeTestnet,
+	"testnet": BIP0044CoinTypeTestnet,
+}
+
+// hdChainCoinTypes maps the chain IDs of known networks to the coin types their
+// accounts are derived with.
+var hdChainCoinTypes = map[uint64]uint32{
+	1:        BIP0044CoinTypeEther,
+	61:       BIP0044CoinTypeEtherClassic,
+	63:       BIP0044CoinTypeTestnet, // mordor
+	5:        BIP0044CoinTypeTestnet, // goerli
+	11155111: BIP0044CoinTypeTestnet, // sepolia
+	17000:    BIP0044CoinTypeTestnet, // holesky
+}
+
+// HDPathConfigForChainID returns the derivation configuration of the network
+// with the given chain ID, defaulting to the Ether coin type for unknown chains.
+func HDPathConfigForChainID(chainID uint64) HDPathConfig {
+	if coinType, ok := hdChainCoinTypes[chainID]; ok {
+		return NewHDPathConfig(coinType)
+	}
+	return NewHDPathConfig(BIP0044CoinTypeEther)
+}
+
+// HDPathConfigForNetwork returns the derivation configuration of a network,
+// identified either by its name (e.g. "classic") or by a numeric coin type.
+func HDPathConfigForNetwork(network string) (HDPathConfig, error) {
+	if coinType, ok := hdNetworkCoinTypes[strings.ToLower(strings.TrimSpace(network))]; ok {
+		return NewHDPathConfig(coinType), nil
+	}
+	coinType, err := strconv.ParseUint(network, 0, 31)
+	if err != nil {
+		return HDPathConfig{}, fmt.Errorf("unknown HD derivation network %q", network)
+	}
+	return NewHDPathConfig(uint32(coinType)), nil
+}
+
+// RootPath is the root path to which custom derivation endpoints are appended,
+// i.e. m/44'/<coin>'/0'/0.
+func (c HDPathConfig) RootPath() DerivationPath {
+	return DerivationPath{0x80000000 + 44, 0x80000000 + c.CoinType, 0x80000000 + 0, 0}
+}
+
+// BasePath is the base path from which custom derivation endpoints are
+// incremented, i.e. m/44'/<coin>'/0'/0/0.
+func (c HDPathConfig) BasePath() DerivationPath {
+	return DerivationPath{0x80000000 + 44, 0x80000000 + c.CoinType, 0x80000000 + 0, 0, 0}
+}
+
+// LegacyLedgerBasePath is the legacy Ledger base path, i.e. m/44'/<coin>'/0'/0.
+func (c HDPathConfig) LegacyLedgerBasePath() DerivationPath {
+	return DerivationPath{0x80000000 + 44, 0x80000000 + c.CoinType, 0x80000000 + 0, 0}
+}
+
+// ParseDerivationPath converts a user specified derivation path string to the
+// internal binary representation, resolving relative paths against the root
+// path of the configuration.
+func (c HDPathConfig) ParseDerivationPath(path string) (DerivationPath, error) {
+	return parseDerivationPath(path, c.RootPath())
+}
+
+// HDPathConfigurer is implemented by wallets which derive accounts for a
+// specific network rather than the process wide default.
+type HDPathConfigurer interface {
+	// HDPathConfig returns the derivation configuration of the wallet.
+	HDPathConfig() HDPathConfig
+}
+
+// WalletHDPathConfig returns the derivation configuration of a wallet, falling
+// back to the process wide default for wallets not carrying their own.
+func WalletHDPathConfig(wallet Wallet) HDPathConfig {
+	if w, ok := wallet.(HDPathConfigurer); ok {
+		return w.HDPathConfig()
+	}
+	return DefaultHDPathConfig()
+}
+
+// ParseWalletDerivationPath converts a user specified derivation path for the
+// given wallet. Relative paths are resolved against the root path of network,
+// if specified, or the wallet's own derivation configuration otherwise.
+func ParseWalletDerivationPath(wallet Wallet, path string, network *string) (DerivationPath, error) {
+	paths := WalletHDPathConfig(wallet)
+	if network != nil && *network != "" {
+		var err error
+		if paths, err = HDPathConfigForNetwork(*network); err != nil {
+			return nil, err
+		}
+	}
+	return paths.ParseDerivationPath(path)
+}
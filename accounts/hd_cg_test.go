@@ -132,6 +132,49 @@ func TestHdPathIteration_CG(t *testing.T) {
 	t.Run("TestHdPathIteration_Testnet", testHdPathIteration(BIP0044CoinTypeTestnet))
 }
 
+// Tests that derivation configurations of different networks can be used side
+// by side, independently of the process wide default.
+func TestHDPathConfig_CG(t *testing.T) {
+	SetCoinTypeConfiguration(BIP0044CoinTypeEther)
+
+	etc, err := HDPathConfigForNetwork("classic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eth, err := HDPathConfigForNetwork("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		paths HDPathConfig
+		want  DerivationPath
+	}{
+		{etc, DerivationPath{0x80000000 + 44, 0x80000000 + BIP0044CoinTypeEtherClassic, 0x80000000 + 0, 0, 7}},
+		{eth, DerivationPath{0x80000000 + 44, 0x80000000 + BIP0044CoinTypeEther, 0x80000000 + 0, 0, 7}},
+	} {
+		if path, err := tt.paths.ParseDerivationPath("7"); err != nil || !reflect.DeepEqual(path, tt.want) {
+			t.Errorf("coin type %d: parse mismatch: have %v (%v), want %v", tt.paths.CoinType, path, err, tt.want)
+		}
+		if base := tt.paths.BasePath(); !reflect.DeepEqual(base, append(tt.paths.RootPath(), 0)) {
+			t.Errorf("coin type %d: base path %v not below root %v", tt.paths.CoinType, base, tt.paths.RootPath())
+		}
+	}
+	if !reflect.DeepEqual(DefaultBaseDerivationPath, eth.BasePath()) {
+		t.Errorf("global default changed: have %v, want %v", DefaultBaseDerivationPath, eth.BasePath())
+	}
+	if paths, err := HDPathConfigForNetwork("1337"); err != nil || paths.CoinType != 1337 {
+		t.Errorf("numeric coin type: have %v (%v), want 1337", paths.CoinType, err)
+	}
+	if _, err := HDPathConfigForNetwork("nonexistent"); err == nil {
+		t.Error("expected error for unknown network")
+	}
+	for chainID, want := range map[uint64]uint32{1: BIP0044CoinTypeEther, 61: BIP0044CoinTypeEtherClassic, 63: BIP0044CoinTypeTestnet, 1337: BIP0044CoinTypeEther} {
+		if paths := HDPathConfigForChainID(chainID); paths.CoinType != want {
+			t.Errorf("chain %d: coin type mismatch: have %d, want %d", chainID, paths.CoinType, want)
+		}
+	}
+}
+
 func mustStr(i uint32) string {
 	return fmt.Sprintf("%d", i)
 }
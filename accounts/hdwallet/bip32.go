@@ -0,0 +1,114 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/common/math"
+	"github.com/shudolab/core-geth/crypto"
+)
+
+// errInvalidChildKey is returned in the astronomically unlikely case that a
+// derivation step yields an invalid secp256k1 scalar. BIP-32 mandates skipping
+// to the next index, which is left to the caller.
+var errInvalidChildKey = errors.New("invalid derived key, use the next index")
+
+// masterKeySalt is the HMAC key used to derive a BIP-32 master node from a seed.
+var masterKeySalt = []byte("Bitcoin seed")
+
+// extendedKey is a private BIP-32 extended key: a secp256k1 private key along
+// with the chain code used to derive its children.
+type extendedKey struct {
+	key   []byte // 32 byte private key scalar
+	chain []byte // 32 byte chain code
+}
+
+// newMasterKey derives the BIP-32 master node from a (BIP-39) seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	return &extendedKey{key: sum[:32], chain: sum[32:]}, nil
+}
+
+// child derives the private child key at the given index. Indices with the
+// highest bit set are derived hardened.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= 0x80000000 {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, crypto.CompressPubkey(&priv.PublicKey)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	il.Add(il, new(big.Int).SetBytes(k.key))
+	il.Mod(il, n)
+	if il.Sign() == 0 {
+		return nil, errInvalidChildKey
+	}
+	return &extendedKey{key: math.PaddedBigBytes(il, 32), chain: sum[32:]}, nil
+}
+
+// derive walks the given derivation path from this node and returns the private
+// key of the final node.
+func (k *extendedKey) derive(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	node := k
+	for _, index := range path {
+		var err error
+		if node, err = node.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(node.key)
+}
+
+// zero overwrites the key material in memory.
+func (k *extendedKey) zero() {
+	for i := range k.key {
+		k.key[i] = 0
+	}
+	for i := range k.chain {
+		k.chain[i] = 0
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hdwallet implements a software hierarchical deterministic wallet
+// backed by an encrypted BIP-39 mnemonic.
+//
+// Each wallet is stored in its own file, encrypting the mnemonic the same way
+// the keystore encrypts private keys (Web3 Secret Storage v3). Accounts are
+// derived according to BIP-32/BIP-44, using the coin type the wallet was created
+// for, so the same mnemonic yields reproducible accounts across nodes.
+package hdwallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/keystore"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/event"
+	"github.com/shudolab/core-geth/log"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Scheme is the protocol scheme prefixing HD wallet URLs.
+const Scheme = "hd"
+
+// version is the version of the wallet file format.
+const version = 1
+
+// mnemonicEntropyBits is the entropy of newly generated mnemonics (24 words).
+const mnemonicEntropyBits = 256
+
+// BackendType is the reflect type of an HD wallet backend.
+var BackendType = reflect.TypeOf(&Backend{})
+
+var (
+	// ErrInvalidMnemonic is returned if an imported mnemonic fails the BIP-39 checksum.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrWalletExists is returned when importing a mnemonic that is already present.
+	ErrWalletExists = errors.New("wallet already exists")
+)
+
+// walletJSON is the on-disk representation of an HD wallet. The derived accounts
+// are public and stored in plain text so they can be listed without unlocking.
+type walletJSON struct {
+	Version  int                                        `json:"version"`
+	ID       string                                     `json:"id"`
+	CoinType uint32                                     `json:"coinType"`
+	Address  common.Address                             `json:"address"` // First account on the base path, identifies the seed
+	Crypto   keystore.CryptoJSON                        `json:"crypto"`
+	Accounts map[common.Address]accounts.DerivationPath `json:"accounts,omitempty"`
+}
+
+// secretJSON is the plain text encrypted into the crypto section of a wallet file.
+type secretJSON struct {
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase,omitempty"` // Optional BIP-39 seed passphrase
+}
+
+// Backend is an accounts.Backend managing the HD wallets stored in a directory.
+type Backend struct {
+	dir     string                // Directory containing the wallet files
+	scryptN int                   // Scrypt parameters to encrypt new wallets with
+	scryptP int                   //
+	paths   accounts.HDPathConfig // Derivation paths of newly created wallets
+
+	wallets     []accounts.Wallet       // Wallets currently tracked, sorted by URL
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+
+	lock sync.RWMutex
+}
+
+// NewBackend creates an HD wallet backend for the given directory, loading all
+// wallets stored therein. New wallets derive accounts of the network described
+// by paths; existing ones keep the coin type they were created with.
+func NewBackend(dir string, scryptN, scryptP int, paths accounts.HDPathConfig) *Backend {
+	b := &Backend{dir: dir, scryptN: scryptN, scryptP: scryptP, paths: paths}
+
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Failed to read HD wallet directory", "dir", dir, "err", err)
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, fi.Name())
+		w, err := loadWallet(b, path)
+		if err != nil {
+			log.Warn("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		b.wallets = append(b.wallets, w)
+	}
+	sort.Sort(accounts.WalletsByURL(b.wallets))
+	return b
+}
+
+// Wallets implements accounts.Backend, returning all the HD wallets stored in
+// the backend directory.
+func (b *Backend) Wallets() []accounts.Wallet {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(b.wallets))
+	copy(cpy, b.wallets)
+	return cpy
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of HD wallets.
+func (b *Backend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.updateScope.Track(b.updateFeed.Subscribe(sink))
+}
+
+// NewWallet generates a new mnemonic, stores it encrypted with passphrase and
+// returns the wallet along with the mnemonic, which the user must back up.
+func (b *Backend) NewWallet(passphrase string) (*Wallet, string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return nil, "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, "", err
+	}
+	w, err := b.ImportMnemonic(mnemonic, "", passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, mnemonic, nil
+}
+
+// ImportMnemonic stores an existing mnemonic, with an optional BIP-39 seed
+// passphrase, as a new wallet encrypted with passphrase.
+func (b *Backend) ImportMnemonic(mnemonic, seedPassphrase, passphrase string) (*Wallet, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	master, err := newMasterKey(bip39.NewSeed(mnemonic, seedPassphrase))
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	key, err := master.derive(b.paths.BasePath())
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, have := range b.wallets {
+		if w := have.(*Wallet); w.address == address && w.paths == b.paths {
+			return nil, fmt.Errorf("%w: %v", ErrWalletExists, w.URL())
+		}
+	}
+	secret, err := json.Marshal(&secretJSON{Mnemonic: mnemonic, Passphrase: seedPassphrase})
+	if err != nil {
+		return nil, err
+	}
+	cryptoStruct, err := keystore.EncryptDataV3(secret, []byte(passphrase), b.scryptN, b.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New().String()
+	w := &Wallet{
+		backend: b,
+		url:     accounts.URL{Scheme: Scheme, Path: filepath.Join(b.dir, id+".json")},
+		id:      id,
+		paths:   b.paths,
+		address: address,
+		crypto:  cryptoStruct,
+		derived: make(map[common.Address]accounts.DerivationPath),
+	}
+	// Track the first account by default, it's the one most users expect
+	w.pin(address, b.paths.BasePath())
+	if err := w.store(); err != nil {
+		return nil, err
+	}
+	b.wallets = append(b.wallets, w)
+	sort.Sort(accounts.WalletsByURL(b.wallets))
+
+	b.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletArrived})
+	return w, nil
+}
+
+// Close terminates all event subscriptions of the backend.
+func (b *Backend) Close() {
+	b.updateScope.Close()
+}
+
+// loadWallet reads an HD wallet from its file.
+func loadWallet(b *Backend, path string) (*Wallet, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var enc walletJSON
+	if err := json.Unmarshal(blob, &enc); err != nil {
+		return nil, err
+	}
+	if enc.Version != version {
+		return nil, fmt.Errorf("unsupported version %d", enc.Version)
+	}
+	w := &Wallet{
+		backend: b,
+		url:     accounts.URL{Scheme: Scheme, Path: path},
+		id:      enc.ID,
+		paths:   accounts.NewHDPathConfig(enc.CoinType),
+		address: enc.Address,
+		crypto:  enc.Crypto,
+		derived: make(map[common.Address]accounts.DerivationPath),
+	}
+	// Sort the pinned accounts by path to have a stable listing order
+	addrs := make([]common.Address, 0, len(enc.Accounts))
+	for addr := range enc.Accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return enc.Accounts[addrs[i]].String() < enc.Accounts[addrs[j]].String()
+	})
+	for _, addr := range addrs {
+		w.pin(addr, enc.Accounts[addr])
+	}
+	return w, nil
+}
+
+// writeWalletFile atomically replaces the content of a wallet file.
+func writeWalletFile(file string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), file)
+}
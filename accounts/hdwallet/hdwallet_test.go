@@ -0,0 +1,157 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/keystore"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+// Tests BIP-32 derivation against the official test vector 1.
+func TestBIP32Vector(t *testing.T) {
+	master, err := newMasterKey(common.FromHex("000102030405060708090a0b0c0d0e0f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := master.derive(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if have := common.Bytes2Hex(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("%s: key mismatch: have %s, want %s", tt.path, have, tt.key)
+		}
+	}
+}
+
+func TestImportMnemonic(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBackend(dir, keystore.LightScryptN, keystore.LightScryptP, accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEther))
+
+	w, err := b.ImportMnemonic(testMnemonic, "", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	if accs := w.Accounts(); len(accs) != 1 || accs[0].Address != first {
+		t.Fatalf("unexpected accounts: %v", accs)
+	}
+	if _, err := b.ImportMnemonic(testMnemonic, "", "password"); !errors.Is(err, ErrWalletExists) {
+		t.Fatalf("expected %v, got %v", ErrWalletExists, err)
+	}
+	if _, err := b.ImportMnemonic("test test test", "", "password"); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected %v, got %v", ErrInvalidMnemonic, err)
+	}
+	// Derivation requires the wallet to be unlocked
+	second, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	if _, err := w.Derive(second, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("expected %v, got %v", accounts.ErrWalletClosed, err)
+	}
+	if err := w.Open("wrong"); err != keystore.ErrDecrypt {
+		t.Fatalf("expected %v, got %v", keystore.ErrDecrypt, err)
+	}
+	if err := w.Open("password"); err != nil {
+		t.Fatal(err)
+	}
+	acc, err := w.Derive(second, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"); acc.Address != want {
+		t.Fatalf("derived address mismatch: have %v, want %v", acc.Address, want)
+	}
+	// Sign with both the unlocked wallet and a passphrase, and check the sender
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := w.SignTx(acc, tx, big.NewInt(61))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, _ := types.Sender(types.LatestSignerForChainID(big.NewInt(61)), signed); from != acc.Address {
+		t.Errorf("sender mismatch: have %v, want %v", from, acc.Address)
+	}
+	w.Close()
+	if _, err := w.SignTx(acc, tx, big.NewInt(61)); err != keystore.ErrLocked {
+		t.Fatalf("expected %v, got %v", keystore.ErrLocked, err)
+	}
+	if _, err := w.SignTxWithPassphrase(acc, "password", tx, big.NewInt(61)); err != nil {
+		t.Fatal(err)
+	}
+	// The pinned accounts must survive a reload of the backend
+	reloaded := NewBackend(dir, keystore.LightScryptN, keystore.LightScryptP, accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEther))
+	wallets := reloaded.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("expected 1 wallet, got %d", len(wallets))
+	}
+	if accs := wallets[0].Accounts(); len(accs) != 2 || accs[1].Address != acc.Address {
+		t.Fatalf("unexpected accounts after reload: %v", accs)
+	}
+	mnemonic, _, err := wallets[0].(*Wallet).Mnemonic("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mnemonic != testMnemonic {
+		t.Errorf("exported mnemonic mismatch: have %q, want %q", mnemonic, testMnemonic)
+	}
+}
+
+// Tests that wallets of different coin types derive different accounts from the
+// same mnemonic and can live in the same backend directory.
+func TestCoinTypes(t *testing.T) {
+	dir := t.TempDir()
+	eth := NewBackend(dir, keystore.LightScryptN, keystore.LightScryptP, accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEther))
+	etc := NewBackend(dir, keystore.LightScryptN, keystore.LightScryptP, accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEtherClassic))
+
+	ethWallet, err := eth.ImportMnemonic(testMnemonic, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etcWallet, err := etc.ImportMnemonic(testMnemonic, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ethWallet.HDPathConfig().CoinType != 60 || etcWallet.HDPathConfig().CoinType != 61 {
+		t.Fatalf("wrong coin types: %d, %d", ethWallet.HDPathConfig().CoinType, etcWallet.HDPathConfig().CoinType)
+	}
+	if bytes.Equal(ethWallet.Accounts()[0].Address[:], etcWallet.Accounts()[0].Address[:]) {
+		t.Fatal("same account derived for different coin types")
+	}
+	if wallets := NewBackend(dir, keystore.LightScryptN, keystore.LightScryptP, accounts.DefaultHDPathConfig()).Wallets(); len(wallets) != 2 {
+		t.Fatalf("expected 2 wallets, got %d", len(wallets))
+	}
+}
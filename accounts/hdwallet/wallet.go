@@ -0,0 +1,384 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"sync"
+
+	"github.com/shudolab/core-geth"
+	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/keystore"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/log"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// selfDeriveLimit caps the number of consecutive accounts discovered on a base
+// path during self-derivation, protecting against misbehaving chain readers.
+const selfDeriveLimit = 1000
+
+// Wallet is a software HD wallet deriving accounts from an encrypted mnemonic.
+type Wallet struct {
+	backend *Backend              // Backend the wallet is stored in
+	url     accounts.URL          // Textual URL uniquely identifying this wallet
+	id      string                // Unique identifier of the wallet file
+	paths   accounts.HDPathConfig // Derivation paths of the network the wallet was created for
+	address common.Address        // First account on the base path, identifies the seed
+	crypto  keystore.CryptoJSON   // Encrypted mnemonic
+
+	accounts []accounts.Account                         // Derived accounts pinned in the wallet
+	derived  map[common.Address]accounts.DerivationPath // Known derivation paths for signing operations
+	master   *extendedKey                               // Master node of the seed, nil while locked
+
+	deriveBases []accounts.DerivationPath // Base paths for account auto-discovery
+	deriveChain ethereum.ChainStateReader // Blockchain state reader to discover used accounts with
+
+	lock sync.RWMutex
+}
+
+// URL implements accounts.Wallet, returning the URL of the wallet file.
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+// HDPathConfig implements accounts.HDPathConfigurer, returning the derivation
+// paths of the network the wallet was created for.
+func (w *Wallet) HDPathConfig() accounts.HDPathConfig {
+	return w.paths
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet
+// is decrypted or not.
+func (w *Wallet) Status() (string, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.master != nil {
+		return "Unlocked", nil
+	}
+	return "Locked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// given passphrase and keeping it in memory until the wallet is closed.
+func (w *Wallet) Open(passphrase string) error {
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return err
+	}
+	w.lock.Lock()
+	if w.master != nil {
+		w.lock.Unlock()
+		master.zero()
+		return accounts.ErrWalletAlreadyOpen
+	}
+	w.master = master
+	bases, chain := w.deriveBases, w.deriveChain
+	w.lock.Unlock()
+
+	w.backend.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	if chain != nil {
+		go w.selfDerive(bases, chain)
+	}
+	return nil
+}
+
+// Close implements accounts.Wallet, dropping the decrypted seed from memory.
+func (w *Wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.master != nil {
+		w.master.zero()
+		w.master = nil
+	}
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the wallet.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not pinned into this wallet instance.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	_, exists := w.derived[account.Address]
+	return exists && (account.URL == (accounts.URL{}) || account.URL == w.url)
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted in the wallet file.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	key, err := w.master.derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	account := accounts.Account{Address: address, URL: w.url}
+	if !pin {
+		return account, nil
+	}
+	if _, ok := w.derived[address]; !ok {
+		w.pin(address, path)
+		if err := w.store(); err != nil {
+			return accounts.Account{}, err
+		}
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, setting the base paths from which the
+// wallet discovers accounts with a transaction history or balance. Discovery
+// runs once the wallet is open.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	w.lock.Lock()
+	w.deriveBases = make([]accounts.DerivationPath, len(bases))
+	for i, base := range bases {
+		w.deriveBases[i] = make(accounts.DerivationPath, len(base))
+		copy(w.deriveBases[i][:], base[:])
+	}
+	w.deriveChain = chain
+	bases, open := w.deriveBases, w.master != nil
+	w.lock.Unlock()
+
+	if open && chain != nil {
+		go w.selfDerive(bases, chain)
+	}
+}
+
+// selfDerive pins all accounts on the given bases that were used on chain, up
+// to and including the first unused one.
+func (w *Wallet) selfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	ctx := context.Background()
+	for _, base := range bases {
+		next := accounts.DefaultIterator(base)
+		for i := 0; i < selfDeriveLimit; i++ {
+			path := next()
+			account, err := w.Derive(path, false)
+			if err != nil {
+				log.Debug("HD wallet self-derivation aborted", "url", w.url, "err", err)
+				return
+			}
+			balance, err := chain.BalanceAt(ctx, account.Address, nil)
+			if err != nil {
+				log.Warn("HD wallet balance retrieval failed", "url", w.url, "err", err)
+				return
+			}
+			nonce, err := chain.NonceAt(ctx, account.Address, nil)
+			if err != nil {
+				log.Warn("HD wallet nonce retrieval failed", "url", w.url, "err", err)
+				return
+			}
+			if _, err := w.Derive(path, true); err != nil {
+				log.Warn("HD wallet account pinning failed", "url", w.url, "err", err)
+				return
+			}
+			if balance.Sign() == 0 && nonce == 0 {
+				break
+			}
+			log.Info("HD wallet discovered used account", "address", account.Address, "path", path, "balance", balance, "nonce", nonce)
+		}
+	}
+}
+
+// SignData implements accounts.Wallet, signing keccak256(data) with the given
+// account of an opened wallet.
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, signing keccak256(data)
+// after decrypting the seed with the given passphrase.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, crypto.Keccak256(data))
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text with
+// the given account of an opened wallet.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, signing the hash of the
+// given text after decrypting the seed with the given passphrase.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.signHashWithPassphrase(account, passphrase, accounts.TextHash(text))
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with the
+// given account of an opened wallet.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.key(account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, signing the given transaction
+// after decrypting the seed with the given passphrase.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.keyWithPassphrase(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+}
+
+// Mnemonic decrypts and returns the mnemonic and optional BIP-39 seed passphrase
+// of the wallet, for backups or transfer to other wallets.
+func (w *Wallet) Mnemonic(passphrase string) (string, string, error) {
+	secret, err := w.decryptSecret(passphrase)
+	if err != nil {
+		return "", "", err
+	}
+	return secret.Mnemonic, secret.Passphrase, nil
+}
+
+// signHash signs the given hash with the given account of an opened wallet.
+func (w *Wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.key(account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// signHashWithPassphrase signs the given hash after decrypting the seed.
+func (w *Wallet) signHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	key, err := w.keyWithPassphrase(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// key derives the private key of a pinned account from the decrypted seed.
+func (w *Wallet) key(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.master == nil {
+		return nil, keystore.ErrLocked
+	}
+	return w.master.derive(w.derived[account.Address])
+}
+
+// keyWithPassphrase derives the private key of a pinned account, decrypting the
+// seed with the given passphrase for the duration of the call.
+func (w *Wallet) keyWithPassphrase(account accounts.Account, passphrase string) (*ecdsa.PrivateKey, error) {
+	if !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+
+	w.lock.RLock()
+	path := w.derived[account.Address]
+	w.lock.RUnlock()
+
+	return master.derive(path)
+}
+
+// decryptSecret decrypts the mnemonic of the wallet.
+func (w *Wallet) decryptSecret(passphrase string) (*secretJSON, error) {
+	plain, err := keystore.DecryptDataV3(w.crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(secretJSON)
+	if err := json.Unmarshal(plain, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// decrypt decrypts the mnemonic of the wallet and derives the master node.
+func (w *Wallet) decrypt(passphrase string) (*extendedKey, error) {
+	secret, err := w.decryptSecret(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return newMasterKey(bip39.NewSeed(secret.Mnemonic, secret.Passphrase))
+}
+
+// pin adds an account to the list of tracked accounts.
+//
+// The method assumes that the state lock is held!
+func (w *Wallet) pin(address common.Address, path accounts.DerivationPath) {
+	w.derived[address] = path
+	w.accounts = append(w.accounts, accounts.Account{Address: address, URL: w.url})
+}
+
+// store persists the wallet into its file.
+//
+// The method assumes that the state lock is held!
+func (w *Wallet) store() error {
+	blob, err := json.Marshal(&walletJSON{
+		Version:  version,
+		ID:       w.id,
+		CoinType: w.paths.CoinType,
+		Address:  w.address,
+		Crypto:   w.crypto,
+		Accounts: w.derived,
+	})
+	if err != nil {
+		return err
+	}
+	return writeWalletFile(w.url.Path, blob)
+}
+
+// zeroKey zeroes a private key in memory.
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -69,7 +69,8 @@ type smartcardPairing struct {
 
 // Hub is a accounts.Backend that can find and handle generic PC/SC hardware wallets.
 type Hub struct {
-	scheme string // Protocol scheme prefixing account and wallet URLs.
+	scheme string                // Protocol scheme prefixing account and wallet URLs.
+	paths  accounts.HDPathConfig // Derivation paths of the network the hub serves
 
 	context  *pcsc.Client
 	datadir  string
@@ -151,14 +152,22 @@ func (hub *Hub) setPairing(wallet *Wallet, pairing *smartcardPairing) error {
 	return hub.writePairings()
 }
 
-// NewHub creates a new hardware wallet manager for smartcards.
+// NewHub creates a new hardware wallet manager for smartcards, deriving with the
+// process wide default coin type.
 func NewHub(daemonPath string, scheme string, datadir string) (*Hub, error) {
+	return NewHubWithPaths(daemonPath, scheme, datadir, accounts.DefaultHDPathConfig())
+}
+
+// NewHubWithPaths creates a new hardware wallet manager for smartcards, deriving
+// accounts of the network described by paths.
+func NewHubWithPaths(daemonPath string, scheme string, datadir string, paths accounts.HDPathConfig) (*Hub, error) {
 	context, err := pcsc.EstablishContext(daemonPath, pcsc.ScopeSystem)
 	if err != nil {
 		return nil, err
 	}
 	hub := &Hub{
 		scheme:  scheme,
+		paths:   paths,
 		context: context,
 		datadir: datadir,
 		wallets: make(map[string]*Wallet),
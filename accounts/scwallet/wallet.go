@@ -294,6 +294,12 @@ func (w *Wallet) URL() accounts.URL {
 	}
 }
 
+// HDPathConfig implements accounts.HDPathConfigurer, returning the derivation
+// paths of the network the wallet was discovered for.
+func (w *Wallet) HDPathConfig() accounts.HDPathConfig {
+	return w.Hub.paths
+}
+
 // Status returns a textual status to aid the user in the current state of the
 // wallet. It also returns an error indicating any failure the wallet might have
 // encountered.
@@ -785,7 +791,7 @@ func (w *Wallet) findAccountPath(account accounts.Account) (accounts.DerivationP
 		return nil, fmt.Errorf("URL %s is not for this wallet", account.URL)
 	}
 
-	return accounts.ParseDerivationPath(path)
+	return w.Hub.paths.ParseDerivationPath(path)
 }
 
 // Session represents a secured communication session with the wallet.
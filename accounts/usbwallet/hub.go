@@ -51,6 +51,7 @@ type Hub struct {
 	usageID    uint16                  // USB usage page identifier used for macOS device discovery
 	endpointID int                     // USB endpoint identifier used for non-macOS device discovery
 	makeDriver func(log.Logger) driver // Factory method to construct a vendor specific driver
+	paths      accounts.HDPathConfig   // Derivation paths of the network the hub serves
 
 	refreshed   time.Time               // Time instance when the list of wallets was last refreshed
 	wallets     []accounts.Wallet       // List of USB wallet devices currently tracking
@@ -68,8 +69,15 @@ type Hub struct {
 	enumFails atomic.Uint32 // Number of times enumeration has failed
 }
 
-// NewLedgerHub creates a new hardware wallet manager for Ledger devices.
+// NewLedgerHub creates a new hardware wallet manager for Ledger devices, deriving
+// with the process wide default coin type.
 func NewLedgerHub() (*Hub, error) {
+	return NewLedgerHubWithPaths(accounts.DefaultHDPathConfig())
+}
+
+// NewLedgerHubWithPaths creates a new hardware wallet manager for Ledger devices,
+// deriving accounts of the network described by paths.
+func NewLedgerHubWithPaths(paths accounts.HDPathConfig) (*Hub, error) {
 	return newHub(LedgerScheme, 0x2c97, []uint16{
 
 		// Device definitions taken from
@@ -93,22 +101,35 @@ func NewLedgerHub() (*Hub, error) {
 		0x4011, /* HID + WebUSB Ledger Nano X */
 		0x5011, /* HID + WebUSB Ledger Nano S Plus */
 		0x6011, /* HID + WebUSB Ledger Nano FTS */
-	}, 0xffa0, 0, newLedgerDriver)
+	}, 0xffa0, 0, func(logger log.Logger) driver { return newLedgerDriver(logger, paths) }, paths)
 }
 
 // NewTrezorHubWithHID creates a new hardware wallet manager for Trezor devices.
 func NewTrezorHubWithHID() (*Hub, error) {
-	return newHub(TrezorScheme, 0x534c, []uint16{0x0001 /* Trezor HID */}, 0xff00, 0, newTrezorDriver)
+	return NewTrezorHubWithHIDAndPaths(accounts.DefaultHDPathConfig())
+}
+
+// NewTrezorHubWithHIDAndPaths creates a new hardware wallet manager for Trezor
+// devices, deriving accounts of the network described by paths.
+func NewTrezorHubWithHIDAndPaths(paths accounts.HDPathConfig) (*Hub, error) {
+	return newHub(TrezorScheme, 0x534c, []uint16{0x0001 /* Trezor HID */}, 0xff00, 0, newTrezorDriver, paths)
 }
 
 // NewTrezorHubWithWebUSB creates a new hardware wallet manager for Trezor devices with
 // firmware version > 1.8.0
 func NewTrezorHubWithWebUSB() (*Hub, error) {
-	return newHub(TrezorScheme, 0x1209, []uint16{0x53c1 /* Trezor WebUSB */}, 0xffff /* No usage id on webusb, don't match unset (0) */, 0, newTrezorDriver)
+	return NewTrezorHubWithWebUSBAndPaths(accounts.DefaultHDPathConfig())
+}
+
+// NewTrezorHubWithWebUSBAndPaths creates a new hardware wallet manager for Trezor
+// devices with firmware version > 1.8.0, deriving accounts of the network
+// described by paths.
+func NewTrezorHubWithWebUSBAndPaths(paths accounts.HDPathConfig) (*Hub, error) {
+	return newHub(TrezorScheme, 0x1209, []uint16{0x53c1 /* Trezor WebUSB */}, 0xffff /* No usage id on webusb, don't match unset (0) */, 0, newTrezorDriver, paths)
 }
 
 // newHub creates a new hardware wallet manager for generic USB devices.
-func newHub(scheme string, vendorID uint16, productIDs []uint16, usageID uint16, endpointID int, makeDriver func(log.Logger) driver) (*Hub, error) {
+func newHub(scheme string, vendorID uint16, productIDs []uint16, usageID uint16, endpointID int, makeDriver func(log.Logger) driver, paths accounts.HDPathConfig) (*Hub, error) {
 	if !usb.Supported() {
 		return nil, errors.New("unsupported platform")
 	}
@@ -119,6 +140,7 @@ func newHub(scheme string, vendorID uint16, productIDs []uint16, usageID uint16,
 		usageID:    usageID,
 		endpointID: endpointID,
 		makeDriver: makeDriver,
+		paths:      paths,
 		quit:       make(chan chan error),
 	}
 	hub.refreshWallets()
@@ -79,12 +79,15 @@ type ledgerDriver struct {
 	browser bool          // Flag whether the Ledger is in browser mode (reply channel mismatch)
 	failure error         // Any failure that would make the device unusable
 	log     log.Logger    // Contextual logger to tag the ledger with its id
+
+	paths accounts.HDPathConfig // Derivation paths used to probe the Ethereum app
 }
 
 // newLedgerDriver creates a new instance of a Ledger USB protocol driver.
-func newLedgerDriver(logger log.Logger) driver {
+func newLedgerDriver(logger log.Logger, paths accounts.HDPathConfig) driver {
 	return &ledgerDriver{
-		log: logger,
+		log:   logger,
+		paths: paths,
 	}
 }
 
@@ -116,7 +119,7 @@ func (w *ledgerDriver) offline() bool {
 func (w *ledgerDriver) Open(device io.ReadWriter, passphrase string) error {
 	w.device, w.failure = device, nil
 
-	_, err := w.ledgerDerive(accounts.DefaultBaseDerivationPath)
+	_, err := w.ledgerDerive(w.paths.BasePath())
 	if err != nil {
 		// Ethereum app is not running or in browser mode, nothing more to do, return
 		if err == errLedgerReplyInvalidHeader {
@@ -123,6 +123,12 @@ func (w *wallet) URL() accounts.URL {
 	return *w.url // Immutable, no need for a lock
 }
 
+// HDPathConfig implements accounts.HDPathConfigurer, returning the derivation
+// paths of the network the wallet was discovered for.
+func (w *wallet) HDPathConfig() accounts.HDPathConfig {
+	return w.hub.paths // Immutable, no need for a lock
+}
+
 // Status implements accounts.Wallet, returning a custom status message from the
 // underlying vendor-specific hardware wallet implementation.
 func (w *wallet) Status() (string, error) {
@@ -1,13 +1,24 @@
 #!/usr/bin/env bash
 
+# Builds the evmone EVMC VM from source as the reference VM for the EVMC state
+# tests. The version must implement the same EVMC ABI version as the Go
+# bindings in go.mod (github.com/ethereum/evmc/v12).
+
 set -e
 
+EVMONE_VERSION=v0.12.0
+WORKSPACE=build/_workspace/evmone
+
 if [[ "$OSTYPE" != "linux"* ]]; then
     echo "This script is only currently configured to work on Linux. Please see \"https://github.com/ethereum/evmone\" documentation for instructions to build in other environments."
     exit 1
 fi
 
-mkdir -p build/_workspace/evmone
-[[ -f build/_workspace/evmone/evmone-0.5.0-linux-x86_64.tar.gz ]] && exit 0
-wget -O build/_workspace/evmone/evmone-0.5.0-linux-x86_64.tar.gz https://github.com/ethereum/evmone/releases/download/v0.5.0/evmone-0.5.0-linux-x86_64.tar.gz
-tar xzvf build/_workspace/evmone/evmone-0.5.0-linux-x86_64.tar.gz -C build/_workspace/evmone/
+main() {
+    mkdir -p "$WORKSPACE"
+    [ ! -d "$WORKSPACE/src" ] && git clone --branch "$EVMONE_VERSION" --depth 1 --recurse-submodules --shallow-submodules https://github.com/ethereum/evmone "$WORKSPACE/src" || echo "evmone exists."
+    cmake -S "$WORKSPACE/src" -B "$WORKSPACE/build" -DCMAKE_BUILD_TYPE=Release -DBUILD_SHARED_LIBS=ON -DEVMONE_TESTING=OFF
+    cmake --build "$WORKSPACE/build" --parallel
+    echo "Built library at: $(pwd)/$WORKSPACE/build/lib/libevmone.so"
+}
+main
@@ -65,9 +65,13 @@ var (
 	}
 	langFlag = &cli.StringFlag{
 		Name:  "lang",
-		Usage: "Destination language for the bindings (go)",
+		Usage: "Destination language for the bindings (go, ts, python)",
 		Value: "go",
 	}
+	indexerFlag = &cli.BoolFlag{
+		Name:  "indexer",
+		Usage: "Generate typed event indexers with checkpointing and reorg handling (go only)",
+	}
 	aliasFlag = &cli.StringFlag{
 		Name:  "alias",
 		Usage: "Comma separated aliases for function and event renaming, e.g. original1=alias1, original2=alias2",
@@ -87,6 +91,7 @@ func init() {
 		pkgFlag,
 		outFlag,
 		langFlag,
+		indexerFlag,
 		aliasFlag,
 	}
 	app.Action = abigen
@@ -95,16 +100,21 @@ func init() {
 func abigen(c *cli.Context) error {
 	utils.CheckExclusive(c, abiFlag, jsonFlag) // Only one source can be selected.
 
-	if c.String(pkgFlag.Name) == "" {
-		utils.Fatalf("No destination package specified (--pkg)")
-	}
 	var lang bind.Lang
 	switch c.String(langFlag.Name) {
 	case "go":
 		lang = bind.LangGo
+	case "ts", "typescript":
+		lang = bind.LangTypeScript
+	case "py", "python":
+		lang = bind.LangPython
 	default:
 		utils.Fatalf("Unsupported destination language \"%s\" (--lang)", c.String(langFlag.Name))
 	}
+	// Only Go bindings are generated into a package
+	if lang == bind.LangGo && c.String(pkgFlag.Name) == "" {
+		utils.Fatalf("No destination package specified (--pkg)")
+	}
 	// If the entire solidity code was specified, build and bind based on that
 	var (
 		abis    []string
@@ -146,6 +156,9 @@ func abigen(c *cli.Context) error {
 		if kind == "" {
 			kind = c.String(pkgFlag.Name)
 		}
+		if kind == "" {
+			utils.Fatalf("No contract type specified (--type)")
+		}
 		types = append(types, kind)
 	} else {
 		// Generate the list of types to exclude from binding
@@ -216,7 +229,11 @@ func abigen(c *cli.Context) error {
 		}
 	}
 	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, sigs, c.String(pkgFlag.Name), lang, libs, aliases)
+	generate := bind.Bind
+	if c.Bool(indexerFlag.Name) {
+		generate = bind.BindWithIndexer
+	}
+	code, err := generate(types, abis, bins, sigs, c.String(pkgFlag.Name), lang, libs, aliases)
 	if err != nil {
 		utils.Fatalf("Failed to generate ABI binding: %v", err)
 	}
@@ -394,7 +394,9 @@ func attestFile(ctx *cli.Context) error {
 	// Initialize the encrypted storages
 	configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confKey)
 	val := ctx.Args().First()
-	configStorage.Put("ruleset_sha256", val)
+	if err := configStorage.Put("ruleset_sha256", val); err != nil {
+		return err
+	}
 	log.Info("Ruleset attestation updated", "sha256", val)
 	return nil
 }
@@ -409,7 +411,7 @@ func initInternalApi(c *cli.Context) (*core.UIServerAPI, core.UIClientAPI, error
 		ksLoc                     = c.String(keystoreFlag.Name)
 		lightKdf                  = c.Bool(utils.LightKDFFlag.Name)
 	)
-	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "")
+	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "", accounts.DefaultHDPathConfig())
 	api := core.NewSignerAPI(am, 0, true, ui, nil, false, pwStorage)
 	internalApi := core.NewUIServerAPI(api)
 	return internalApi, ui, nil
@@ -439,7 +441,9 @@ func setCredential(ctx *cli.Context) error {
 	pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 
 	pwStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
-	pwStorage.Put(address.Hex(), password)
+	if err := pwStorage.Put(address.Hex(), password); err != nil {
+		return err
+	}
 
 	log.Info("Credential store updated", "set", address)
 	return nil
@@ -653,6 +657,7 @@ func signer(c *cli.Context) error {
 	var (
 		api       core.ExternalAPI
 		pwStorage storage.Storage = &storage.NoStorage{}
+		msStorage storage.Storage = storage.NewEphemeralStorage()
 	)
 	configDir := c.String(configdirFlag.Name)
 	if stretchedKey, err := readMasterKey(c, ui); err != nil {
@@ -664,9 +669,11 @@ func signer(c *cli.Context) error {
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
+		mskey := crypto.Keccak256([]byte("multisig"), stretchedKey)
 
 		// Initialize the encrypted storages
 		pwStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
+		msStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "multisig.json"), mskey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
 
@@ -704,7 +711,7 @@ func signer(c *cli.Context) error {
 	)
 	log.Info("Starting signer", "chainid", chainId, "keystore", ksLoc,
 		"light-kdf", lightKdf, "advanced", advanced)
-	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
+	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath, accounts.HDPathConfigForChainID(uint64(chainId)))
 	defer am.Close()
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
 
@@ -731,6 +738,10 @@ func signer(c *cli.Context) error {
 			Namespace: "account",
 			Service:   api,
 		},
+		{
+			Namespace: "account",
+			Service:   core.NewMultisigAPI(api, ui, chainId, msStorage),
+		},
 	}
 	if c.Bool(utils.HTTPEnabledFlag.Name) {
 		vhosts := utils.SplitAndTrim(c.String(utils.HTTPVirtualHostsFlag.Name))
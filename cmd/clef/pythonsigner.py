@@ -168,6 +168,25 @@ class StdIOHandler:
             "approved": False,
         }
 
+    @public
+    def approveMultisig(self, req):
+        """
+        Example request:
+
+        {"jsonrpc":"2.0","id":26,"method":"ui_approveMultisig","params":[{"action":"discard","pending":{"tx":{...},"threshold":2,"signatures":null},"meta":{"remote":"clef binary","local":"main","scheme":"in-proc","User-Agent":"","Origin":""}}]}
+        """  # noqa: E501
+        message = (
+            "Multisig {action} request:\n"
+            "\t{meta_string}\n"
+            "\n"
+            "\tAuto-rejecting request\n"
+        )
+        meta = req.get("meta", {})
+        sys.stdout.write(message.format(action=req.get("action"), meta_string=metaString(meta)))
+        return {
+            "approved": False,
+        }
+
     @public
     def showError(self, req):
         """
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/shudolab/core-geth/common/math"
+	"github.com/shudolab/core-geth/consensus/diffsim"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	simBlocksFlag = cli.Uint64Flag{
+		Name:  "blocks",
+		Usage: "Number of blocks to simulate",
+		Value: 10000,
+	}
+	simHashrateFlag = cli.StringFlag{
+		Name:  "hashrate",
+		Usage: "Network hashrate in H/s, either constant (150T) or block:hashrate points interpolated linearly (0:150T,5000:300T)",
+	}
+	simStartBlockFlag = cli.Uint64Flag{
+		Name:  "start.block",
+		Usage: "Number of the block to start from (default: genesis)",
+	}
+	simStartTimeFlag = cli.Uint64Flag{
+		Name:  "start.time",
+		Usage: "Timestamp of the block to start from (default: genesis timestamp)",
+	}
+	simStartDifficultyFlag = cli.StringFlag{
+		Name:  "start.difficulty",
+		Usage: "Difficulty of the block to start from (default: genesis difficulty)",
+	}
+	simPropagationFlag = cli.Float64Flag{
+		Name:  "propagation",
+		Usage: "Block propagation delay in seconds, determining the uncle rate",
+		Value: 1,
+	}
+	simSeedFlag = cli.Int64Flag{
+		Name:  "seed",
+		Usage: "Seed of the random source",
+		Value: 1,
+	}
+	simIntervalFlag = cli.IntFlag{
+		Name:  "interval",
+		Usage: "Number of blocks aggregated per output row",
+		Value: 1,
+	}
+	simOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Output format [csv|json]",
+		Value: "csv",
+	}
+)
+
+var difficultyCommand = cli.Command{
+	Name:  "difficulty",
+	Usage: "Simulate difficulty, block time and uncle rate for a hashrate",
+	Description: `Mines the given number of blocks on top of a start block using the difficulty
+calculation of the chain configuration, and prints the resulting time series.
+Block times are random, the --seed flag makes runs reproducible.
+
+Every output row aggregates --interval blocks: the mean block time, the
+difficulty of the last block, the mean hashrate and the uncles per block.
+A summary of the whole run is printed to stderr.`,
+	Flags: []cli.Flag{
+		simBlocksFlag,
+		simHashrateFlag,
+		simStartBlockFlag,
+		simStartTimeFlag,
+		simStartDifficultyFlag,
+		simPropagationFlag,
+		simSeedFlag,
+		simIntervalFlag,
+		simOutputFlag,
+	},
+	Action: difficulty,
+}
+
+func difficulty(ctx *cli.Context) error {
+	if !ctx.IsSet(simHashrateFlag.Name) {
+		return errors.New("missing --hashrate")
+	}
+	curve, err := diffsim.ParseCurve(ctx.String(simHashrateFlag.Name))
+	if err != nil {
+		return err
+	}
+	cfg := diffsim.Config{
+		Chain:       globalChainspecValue,
+		Number:      ctx.Uint64(simStartBlockFlag.Name),
+		Time:        globalChainspecValue.GetGenesisTimestamp(),
+		StartDiff:   globalChainspecValue.GetGenesisDifficulty(),
+		Blocks:      ctx.Uint64(simBlocksFlag.Name),
+		Hashrate:    curve,
+		Propagation: ctx.Float64(simPropagationFlag.Name),
+		Seed:        ctx.Int64(simSeedFlag.Name),
+	}
+	if ctx.IsSet(simStartTimeFlag.Name) {
+		cfg.Time = ctx.Uint64(simStartTimeFlag.Name)
+	}
+	if ctx.IsSet(simStartDifficultyFlag.Name) {
+		diff, ok := math.ParseBig256(ctx.String(simStartDifficultyFlag.Name))
+		if !ok {
+			return fmt.Errorf("invalid start difficulty: %s", ctx.String(simStartDifficultyFlag.Name))
+		}
+		cfg.StartDiff = diff
+	}
+	samples, err := diffsim.Simulate(cfg)
+	if err != nil {
+		return err
+	}
+	windows := diffsim.Aggregate(samples, ctx.Int(simIntervalFlag.Name))
+
+	switch ctx.String(simOutputFlag.Name) {
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"from", "to", "timestamp", "blockTime", "difficulty", "hashrate", "uncleRate"})
+		for _, window := range windows {
+			w.Write([]string{
+				strconv.FormatUint(window.From, 10),
+				strconv.FormatUint(window.To, 10),
+				strconv.FormatUint(window.Time, 10),
+				strconv.FormatFloat(window.BlockTime, 'f', 3, 64),
+				window.Difficulty.String(),
+				strconv.FormatFloat(window.Hashrate, 'g', -1, 64),
+				strconv.FormatFloat(window.UncleRate, 'f', 4, 64),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	case "json":
+		b, err := jsonMarshalPretty(windows)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	default:
+		return fmt.Errorf("invalid output format: %s", ctx.String(simOutputFlag.Name))
+	}
+	summary := diffsim.Summarize(samples)
+	fmt.Fprintf(os.Stderr, "Simulated blocks %d-%d: mean block time %.3fs, final difficulty %v, uncle rate %.4f\n",
+		summary.From, summary.To, summary.BlockTime, summary.Difficulty, summary.UncleRate)
+	return nil
+}
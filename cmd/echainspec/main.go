@@ -217,6 +217,7 @@ GLOBAL OPTIONS:
 		validateCommand,
 		forksCommand,
 		ipsCommand,
+		difficultyCommand,
 	}
 	app.Before = mustGetChainspecValue
 	app.Action = convertf
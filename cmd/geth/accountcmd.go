@@ -19,16 +19,25 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/hdwallet"
 	"github.com/shudolab/core-geth/accounts/keystore"
 	"github.com/shudolab/core-geth/cmd/utils"
+	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/crypto"
 	"github.com/shudolab/core-geth/log"
 	"github.com/urfave/cli/v2"
 )
 
 var (
+	mnemonicNetworkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network name (e.g. classic, mainnet) or SLIP-0044 coin type the wallet derives accounts for (default: that of the selected chain)",
+	}
+
 	walletCommand = &cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage Ethereum presale wallets",
@@ -184,6 +193,79 @@ this import mechanism is not needed when you transfer an account between
 nodes.
 `,
 			},
+			{
+				Name:  "mnemonic",
+				Usage: "Manage mnemonic (BIP-39) based HD wallets",
+				Description: `
+Mnemonic wallets derive any number of accounts from a single BIP-39 seed phrase,
+following the BIP-44 derivation path of the configured network.
+
+Wallets are stored encrypted under <KEYSTORE>/hd and have to be opened with
+their password (personal_openWallet) before their accounts can sign.`,
+				Subcommands: []*cli.Command{
+					{
+						Name:   "new",
+						Usage:  "Generate a new mnemonic wallet",
+						Action: mnemonicCreate,
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.LightKDFFlag,
+							mnemonicNetworkFlag,
+						},
+						Description: `
+    geth account mnemonic new
+
+Generates a new 24 word mnemonic, stores it encrypted with a password and
+prints it along with the first account of the wallet.
+
+You must write down the mnemonic, it is the only backup of all derived accounts.
+`,
+					},
+					{
+						Name:      "import",
+						Usage:     "Import an existing mnemonic into a new wallet",
+						Action:    mnemonicImport,
+						ArgsUsage: "<mnemonicFile>",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+							utils.LightKDFFlag,
+							mnemonicNetworkFlag,
+						},
+						Description: `
+    geth account mnemonic import <mnemonicFile>
+
+Imports the mnemonic contained in the first line of <mnemonicFile>. An optional
+BIP-39 seed passphrase may be given on the second line.
+
+The wallet is saved in encrypted format, you are prompted for a password.
+`,
+					},
+					{
+						Name:      "export",
+						Usage:     "Print the mnemonic of a wallet",
+						Action:    mnemonicExport,
+						ArgsUsage: "<address|wallet>",
+						Flags: []cli.Flag{
+							utils.DataDirFlag,
+							utils.KeyStoreDirFlag,
+							utils.PasswordFileFlag,
+						},
+						Description: `
+    geth account mnemonic export <address|wallet>
+
+Decrypts and prints the mnemonic, and the BIP-39 seed passphrase if any, of the
+wallet with the given URL or deriving the given account. You are prompted for
+the password of the wallet.
+
+For non-interactive use the password can be specified with the --password flag.
+`,
+					},
+				},
+			},
 		},
 	}
 )
@@ -382,3 +464,112 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// makeMnemonicBackend opens the HD wallet backend within the keystore defined
+// by the CLI flags, deriving accounts for the requested network.
+func makeMnemonicBackend(ctx *cli.Context) *hdwallet.Backend {
+	cfg := loadBaseConfig(ctx)
+	keydir, isEphemeral, err := cfg.Node.GetKeyStoreDir()
+	if err != nil {
+		utils.Fatalf("Failed to get the keystore directory: %v", err)
+	}
+	if isEphemeral {
+		utils.Fatalf("Can't use ephemeral directory as keystore path")
+	}
+	scryptN := keystore.StandardScryptN
+	scryptP := keystore.StandardScryptP
+	if cfg.Node.UseLightweightKDF {
+		scryptN = keystore.LightScryptN
+		scryptP = keystore.LightScryptP
+	}
+	paths := cfg.Node.HDPathConfig()
+	if ctx.IsSet(mnemonicNetworkFlag.Name) {
+		var err error
+		if paths, err = accounts.HDPathConfigForNetwork(ctx.String(mnemonicNetworkFlag.Name)); err != nil {
+			utils.Fatalf("Invalid network: %v", err)
+		}
+	}
+	return hdwallet.NewBackend(filepath.Join(keydir, hdwallet.Scheme), scryptN, scryptP, paths)
+}
+
+// mnemonicCreate generates a new mnemonic wallet.
+func mnemonicCreate(ctx *cli.Context) error {
+	backend := makeMnemonicBackend(ctx)
+	password := utils.GetPassPhraseWithList("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	wallet, mnemonic, err := backend.NewWallet(password)
+	if err != nil {
+		utils.Fatalf("Failed to create wallet: %v", err)
+	}
+	fmt.Printf("\nYour new mnemonic wallet was generated\n\n")
+	fmt.Printf("Mnemonic:                    %s\n", mnemonic)
+	fmt.Printf("First account:               %s (%s)\n", wallet.Accounts()[0].Address.Hex(), wallet.HDPathConfig().BasePath())
+	fmt.Printf("Path of the wallet file:     %s\n\n", wallet.URL().Path)
+	fmt.Printf("- You must NEVER share the mnemonic with anyone! It controls access to all derived accounts!\n")
+	fmt.Printf("- You must BACKUP the mnemonic offline! Without it, the wallet file is the only copy of your keys!\n")
+	fmt.Printf("- You must REMEMBER your password! Without the password, it's impossible to decrypt the wallet!\n\n")
+	return nil
+}
+
+// mnemonicExport decrypts and prints the mnemonic of a wallet.
+func mnemonicExport(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("wallet URL or account address must be given as the only argument")
+	}
+	var (
+		backend = makeMnemonicBackend(ctx)
+		target  = ctx.Args().First()
+		wallet  *hdwallet.Wallet
+	)
+	for _, w := range backend.Wallets() {
+		w := w.(*hdwallet.Wallet)
+		if w.URL().String() == target || (common.IsHexAddress(target) && w.Contains(accounts.Account{Address: common.HexToAddress(target)})) {
+			wallet = w
+			break
+		}
+	}
+	if wallet == nil {
+		utils.Fatalf("No mnemonic wallet found for %s", target)
+	}
+	password := utils.GetPassPhraseWithList(fmt.Sprintf("Unlocking wallet %s", wallet.URL()), false, 0, utils.MakePasswordList(ctx))
+
+	mnemonic, seedPassphrase, err := wallet.Mnemonic(password)
+	if err != nil {
+		utils.Fatalf("Could not decrypt the mnemonic: %v", err)
+	}
+	fmt.Printf("\nMnemonic:                    %s\n", mnemonic)
+	if seedPassphrase != "" {
+		fmt.Printf("Seed passphrase:             %s\n", seedPassphrase)
+	}
+	fmt.Printf("\n- You must NEVER share the mnemonic with anyone! It controls access to all derived accounts!\n\n")
+	return nil
+}
+
+// mnemonicImport imports an existing mnemonic into a new wallet.
+func mnemonicImport(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("mnemonic file must be given as the only argument")
+	}
+	blob, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read the mnemonic: %v", err)
+	}
+	var (
+		lines          = strings.Split(strings.TrimRight(string(blob), "\r\n"), "\n")
+		mnemonic       = strings.TrimSpace(lines[0])
+		seedPassphrase string
+	)
+	if len(lines) > 1 {
+		seedPassphrase = strings.TrimRight(lines[1], "\r")
+	}
+	backend := makeMnemonicBackend(ctx)
+	password := utils.GetPassPhraseWithList("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	wallet, err := backend.ImportMnemonic(mnemonic, seedPassphrase, password)
+	if err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", wallet.Accounts()[0].Address)
+	fmt.Printf("Wallet:  %s\n", wallet.URL())
+	return nil
+}
@@ -121,6 +121,46 @@ func TestAccountImport(t *testing.T) {
 	}
 }
 
+func TestMnemonicImportExport(t *testing.T) {
+	t.Parallel()
+	var (
+		datadir      = t.TempDir()
+		mnemonic     = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+		mnemonicFile = filepath.Join(datadir, "mnemonic.txt")
+		passwordFile = filepath.Join(datadir, "password.txt")
+	)
+	if err := os.WriteFile(mnemonicFile, []byte(mnemonic+"\nTREZOR\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passwordFile, []byte("foobar"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "--datadir", datadir, "--lightkdf", "account", "mnemonic", "import", "--network", "mainnet", "--password", passwordFile, mnemonicFile)
+	_, matches := geth.ExpectRegexp(`Address: \{([0-9a-f]{40})\}\nWallet:  (hd://.+)\n`)
+	geth.ExpectExit()
+	if len(matches) != 3 {
+		t.Fatalf("unexpected import output: %q", geth.Output())
+	}
+	for _, target := range []string{matches[1], matches[2]} {
+		geth = runGeth(t, "--datadir", datadir, "account", "mnemonic", "export", target)
+		geth.Expect(`
+Unlocking wallet ` + matches[2] + `
+!! Unsupported terminal, password will be echoed.
+Password: {{.InputLine "foobar"}}
+
+Mnemonic:                    ` + mnemonic + `
+Seed passphrase:             TREZOR
+
+- You must NEVER share the mnemonic with anyone! It controls access to all derived accounts!
+
+`)
+		geth.ExpectExit()
+	}
+	geth = runGeth(t, "--datadir", datadir, "account", "mnemonic", "export", "--password", passwordFile, "0x0000000000000000000000000000000000000001")
+	defer geth.ExpectExit()
+	geth.Expect("Fatal: No mnemonic wallet found for 0x0000000000000000000000000000000000000001\n")
+}
+
 func TestAccountHelp(t *testing.T) {
 	t.Parallel()
 	geth := runGeth(t, "account", "-h")
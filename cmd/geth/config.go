@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/shudolab/core-geth/accounts"
 	"github.com/shudolab/core-geth/accounts/external"
+	"github.com/shudolab/core-geth/accounts/hdwallet"
 	"github.com/shudolab/core-geth/accounts/keystore"
 	"github.com/shudolab/core-geth/accounts/scwallet"
 	"github.com/shudolab/core-geth/accounts/usbwallet"
@@ -37,6 +39,7 @@ import (
 	"github.com/shudolab/core-geth/common/hexutil"
 	"github.com/shudolab/core-geth/eth/catalyst"
 	"github.com/shudolab/core-geth/eth/ethconfig"
+	"github.com/shudolab/core-geth/eth/health"
 	"github.com/shudolab/core-geth/internal/ethapi"
 	"github.com/shudolab/core-geth/internal/flags"
 	"github.com/shudolab/core-geth/internal/version"
@@ -96,6 +99,7 @@ type gethConfig struct {
 	Node     node.Config
 	Ethstats ethstatsConfig
 	Metrics  metrics.Config
+	Health   health.Config
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -132,6 +136,7 @@ func loadBaseConfig(ctx *cli.Context) gethConfig {
 		Eth:     ethconfig.Defaults,
 		Node:    defaultNodeConfig(),
 		Metrics: metrics.DefaultConfig,
+		Health:  health.DefaultConfig,
 	}
 
 	// Load config file.
@@ -163,6 +168,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 		cfg.Ethstats.URL = ctx.String(utils.EthStatsURLFlag.Name)
 	}
 	applyMetricConfig(ctx, &cfg)
+	applyHealthConfig(ctx, &cfg)
 
 	return stack, cfg
 }
@@ -226,6 +232,10 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, backend, cfg.Ethstats.URL)
 	}
+	// Add the health and readiness endpoints if requested.
+	if cfg.Health.Enabled {
+		utils.RegisterHealthService(stack, eth, cfg.Health)
+	}
 	// Configure full-sync tester service if requested
 	if ctx.IsSet(utils.SyncTargetFlag.Name) {
 		hex := hexutil.MustDecode(ctx.String(utils.SyncTargetFlag.Name))
@@ -326,6 +336,33 @@ func applyMetricConfig(ctx *cli.Context, cfg *gethConfig) {
 	}
 }
 
+func applyHealthConfig(ctx *cli.Context, cfg *gethConfig) {
+	if ctx.IsSet(utils.HealthEnabledFlag.Name) {
+		cfg.Health.Enabled = ctx.Bool(utils.HealthEnabledFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthMaxHeadAgeFlag.Name) {
+		cfg.Health.MaxHeadAge = ctx.Duration(utils.HealthMaxHeadAgeFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthMaxBlockLagFlag.Name) {
+		cfg.Health.MaxBlockLag = ctx.Uint64(utils.HealthMaxBlockLagFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthMinPeersFlag.Name) {
+		cfg.Health.MinPeers = ctx.Int(utils.HealthMinPeersFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthSyncedFlag.Name) {
+		cfg.Health.RequireSynced = ctx.Bool(utils.HealthSyncedFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthECBP1100Flag.Name) {
+		cfg.Health.RequireECBP1100 = ctx.Bool(utils.HealthECBP1100Flag.Name)
+	}
+	if ctx.IsSet(utils.HealthMaxReorgsFlag.Name) {
+		cfg.Health.MaxReorgs = ctx.Int(utils.HealthMaxReorgsFlag.Name)
+	}
+	if ctx.IsSet(utils.HealthReorgWindowFlag.Name) {
+		cfg.Health.ReorgWindow = ctx.Duration(utils.HealthReorgWindowFlag.Name)
+	}
+}
+
 func deprecated(field string) bool {
 	switch field {
 	case "ethconfig.Config.EVMInterpreter":
@@ -364,22 +401,24 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 	// If/when we implement some form of lockfile for USB and keystore wallets,
 	// we can have both, but it's very confusing for the user to see the same
 	// accounts in both externally and locally, plus very racey.
+	paths := conf.HDPathConfig()
 	am.AddBackend(keystore.NewKeyStore(keydir, scryptN, scryptP))
+	am.AddBackend(hdwallet.NewBackend(filepath.Join(keydir, hdwallet.Scheme), scryptN, scryptP, paths))
 	if conf.USB {
 		// Start a USB hub for Ledger hardware wallets
-		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+		if ledgerhub, err := usbwallet.NewLedgerHubWithPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
 		} else {
 			am.AddBackend(ledgerhub)
 		}
 		// Start a USB hub for Trezor hardware wallets (HID version)
-		if trezorhub, err := usbwallet.NewTrezorHubWithHID(); err != nil {
+		if trezorhub, err := usbwallet.NewTrezorHubWithHIDAndPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start HID Trezor hub, disabling: %v", err))
 		} else {
 			am.AddBackend(trezorhub)
 		}
 		// Start a USB hub for Trezor hardware wallets (WebUSB version)
-		if trezorhub, err := usbwallet.NewTrezorHubWithWebUSB(); err != nil {
+		if trezorhub, err := usbwallet.NewTrezorHubWithWebUSBAndPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start WebUSB Trezor hub, disabling: %v", err))
 		} else {
 			am.AddBackend(trezorhub)
@@ -387,7 +426,7 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 	}
 	if len(conf.SmartCardDaemonPath) > 0 {
 		// Start a smart card hub
-		if schub, err := scwallet.NewHub(conf.SmartCardDaemonPath, scwallet.Scheme, keydir); err != nil {
+		if schub, err := scwallet.NewHubWithPaths(conf.SmartCardDaemonPath, scwallet.Scheme, keydir, paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start smart card hub, disabling: %v", err))
 		} else {
 			am.AddBackend(schub)
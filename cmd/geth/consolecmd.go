@@ -38,6 +38,9 @@ var (
 The Geth console is an interactive shell for the JavaScript runtime environment
 which exposes a node admin interface as well as the Ðapp JavaScript API.
 See https://geth.ethereum.org/docs/interacting-with-geth/javascript-console.`,
+		Subcommands: []*cli.Command{
+			consoleTestCommand,
+		},
 	}
 
 	attachCommand = &cli.Command{
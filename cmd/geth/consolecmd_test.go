@@ -19,6 +19,7 @@ package main
 import (
 	"crypto/rand"
 	"math/big"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -179,3 +180,56 @@ func trulyRandInt(lo, hi int) int {
 	num, _ := rand.Int(rand.Reader, big.NewInt(int64(hi-lo)))
 	return int(num.Int64()) + lo
 }
+
+// Tests that scripted console tests run against ephemeral chains, reporting
+// their results on stdout, in a JUnit report and in the exit status.
+func TestConsoleTest(t *testing.T) {
+	t.Parallel()
+
+	for _, network := range []string{"", "--mordor"} {
+		var (
+			junit = filepath.Join(t.TempDir(), "report.xml")
+			args  = []string{"console", "test", "--test.junit", junit}
+		)
+		if network != "" {
+			args = append(args, network)
+		}
+		geth := runGeth(t, append(args, "testdata/consoletest.js")...)
+		output := string(geth.Output())
+		geth.WaitExit()
+
+		if status := geth.ExitStatus(); status != 1 {
+			t.Errorf("network %q: wrong exit status: have %d, want 1", network, status)
+		}
+		for _, want := range []string{
+			"--- PASS: funded coinbase",
+			"--- PASS: automine",
+			"--- PASS: manual mining",
+			"--- PASS: time travel",
+			"--- PASS: snapshot and revert",
+			"--- FAIL: failing",
+			"sink balance: have 1001, want 0",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("network %q: output missing %q:\n%s", network, want, output)
+			}
+		}
+		report, err := os.ReadFile(junit)
+		if err != nil {
+			t.Fatalf("network %q: failed to read JUnit report: %v", network, err)
+		}
+		if !strings.Contains(string(report), `<testsuites tests="6" failures="1" errors="0" skipped="0"`) {
+			t.Errorf("network %q: wrong JUnit report:\n%s", network, report)
+		}
+	}
+	// Tests excluded by name don't fail the run
+	geth := runGeth(t, "console", "test", "--test.run", "^(automine|time)", "testdata/consoletest.js")
+	output := string(geth.Output())
+	geth.WaitExit()
+	if status := geth.ExitStatus(); status != 0 {
+		t.Errorf("wrong exit status of filtered run: have %d, want 0\n%s", status, output)
+	}
+	if strings.Contains(output, "funded coinbase") || !strings.Contains(output, "--- PASS: automine") {
+		t.Errorf("wrong tests run:\n%s", output)
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/shudolab/core-geth/accounts/keystore"
+	"github.com/shudolab/core-geth/cmd/utils"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/console"
+	"github.com/shudolab/core-geth/eth/downloader"
+	"github.com/shudolab/core-geth/eth/ethconfig"
+	"github.com/shudolab/core-geth/ethclient/simulated"
+	"github.com/shudolab/core-geth/internal/flags"
+	"github.com/shudolab/core-geth/node"
+	"github.com/shudolab/core-geth/p2p"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	testGenesisFlag = &cli.StringFlag{
+		Name:     "test.genesis",
+		Usage:    "Genesis JSON file of the chain to run the tests against (defaults to a --dev chain)",
+		Category: flags.TestingCategory,
+	}
+	testJUnitFlag = &cli.StringFlag{
+		Name:     "test.junit",
+		Usage:    "Write the test results to the given file in the JUnit XML format",
+		Category: flags.TestingCategory,
+	}
+	testRunFlag = &cli.StringFlag{
+		Name:     "test.run",
+		Usage:    "Only run the tests whose names match the given regular expression",
+		Category: flags.TestingCategory,
+	}
+
+	consoleTestCommand = &cli.Command{
+		Action:    consoleTest,
+		Name:      "test",
+		Usage:     "Run scripted JavaScript tests against ephemeral chains",
+		ArgsUsage: "<jsfile> [jsfile...]",
+		Flags: flags.Merge([]cli.Flag{
+			testGenesisFlag,
+			testJUnitFlag,
+			testRunFlag,
+			utils.DeveloperGasLimitFlag,
+			utils.JSpathFlag,
+			utils.PreloadJSFlag,
+		}, utils.NetworkFlags),
+		Description: `
+Runs each JavaScript file in a console attached to a fresh, in-memory chain
+started from the genesis of the selected network, the --test.genesis file or,
+by default, a --dev chain. Chains merged at genesis are sealed by a simulated
+beacon, all others with fake proof-of-work under the fork rules of their
+configuration. A developer account is funded in the genesis, unlocked and set
+as eth.coinbase.
+
+Files register tests with test(name, fn) and test.skip(name, fn), which run in
+order after the file is loaded, sharing its chain. The following functions are
+available in addition to the console API:
+
+  assert(value, [msg]), assert.equal(actual, expected, [msg]),
+  assert.notEqual(actual, expected, [msg]), assert.throws(fn, [msg])
+  mine([blocks])       seal blocks, returning the new head number
+  automine(enabled)    toggle sealing a block for every sent transaction (default on)
+  timeTravel(seconds)  seal a block the given seconds after the head, returning its timestamp
+  snapshot()           return an identifier of the chain head
+  revert(id)           rewind the chain to a snapshot, dropping pending transactions
+
+The command fails if any test fails.`,
+	}
+)
+
+// consoleTest runs scripted tests against ephemeral chains.
+func consoleTest(ctx *cli.Context) error {
+	if ctx.Args().Len() == 0 {
+		utils.Fatalf("need at least one JavaScript test file")
+	}
+	var filter *regexp.Regexp
+	if pattern := ctx.String(testRunFlag.Name); pattern != "" {
+		var err error
+		if filter, err = regexp.Compile(pattern); err != nil {
+			utils.Fatalf("invalid --%s pattern: %v", testRunFlag.Name, err)
+		}
+	}
+	// Test files are given relative to the working directory, not the JS path
+	files := make([]string, ctx.Args().Len())
+	for i, file := range ctx.Args().Slice() {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			utils.Fatalf("invalid test file %s: %v", file, err)
+		}
+		files[i] = abs
+	}
+	report := console.RunTests(console.TestConfig{
+		DocRoot: ctx.String(utils.JSpathFlag.Name),
+		Preload: utils.MakeConsolePreloads(ctx),
+		Filter:  filter,
+		Setup: func(string) (*rpc.Client, console.TestChain, func(), error) {
+			return newTestChain(ctx)
+		},
+	}, files)
+
+	if path := ctx.String(testJUnitFlag.Name); path != "" {
+		out, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create JUnit report: %v", err)
+		}
+		defer out.Close()
+		if err := report.WriteJUnit(out); err != nil {
+			return fmt.Errorf("failed to write JUnit report: %v", err)
+		}
+	}
+	if report.Failed() {
+		return errors.New("tests failed")
+	}
+	return nil
+}
+
+// testGenesis returns the genesis of the chain selected by the command line
+// flags, funding the given account in it.
+func testGenesis(ctx *cli.Context, faucet common.Address) (*genesisT.Genesis, error) {
+	var genesis *genesisT.Genesis
+	if path := ctx.String(testGenesisFlag.Name); path != "" {
+		blob, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read genesis file: %v", err)
+		}
+		genesis = new(genesisT.Genesis)
+		if err := genesis.UnmarshalJSON(blob); err != nil {
+			return nil, fmt.Errorf("invalid genesis file: %v", err)
+		}
+	} else if genesis = utils.MakeGenesis(ctx); genesis == nil {
+		return params.DeveloperGenesisBlock(ctx.Uint64(utils.DeveloperGasLimitFlag.Name), &faucet, false), nil
+	}
+	if genesis.Alloc == nil {
+		genesis.Alloc = make(genesisT.GenesisAlloc)
+	}
+	genesis.Alloc[faucet] = genesisT.GenesisAccount{Balance: new(big.Int).Lsh(big.NewInt(1), 128)}
+	return genesis, nil
+}
+
+// newTestChain starts an in-memory node with a funded and unlocked developer
+// account, running a simulated chain from the selected genesis.
+func newTestChain(ctx *cli.Context) (*rpc.Client, console.TestChain, func(), error) {
+	nodeConf := node.DefaultConfig
+	nodeConf.DataDir = ""
+	nodeConf.UseLightweightKDF = true
+	nodeConf.P2P = p2p.Config{NoDiscovery: true}
+
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ks := keystore.NewKeyStore(stack.KeyStoreDir(), keystore.LightScryptN, keystore.LightScryptP)
+	stack.AccountManager().AddBackend(ks)
+
+	account, err := ks.NewAccount("")
+	if err != nil {
+		stack.Close()
+		return nil, nil, nil, err
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		stack.Close()
+		return nil, nil, nil, err
+	}
+	genesis, err := testGenesis(ctx, account.Address)
+	if err != nil {
+		stack.Close()
+		return nil, nil, nil, err
+	}
+	ethConf := ethconfig.Defaults
+	ethConf.Genesis = genesis
+	ethConf.SyncMode = downloader.FullSync
+	ethConf.Miner.Etherbase = account.Address
+
+	sim, err := simulated.NewGenesisBackend(stack, &ethConf)
+	if err != nil {
+		stack.Close()
+		return nil, nil, nil, err
+	}
+	client := stack.Attach()
+	teardown := func() {
+		client.Close()
+		sim.Close()
+		stack.Close()
+	}
+	return client, &testChain{sim: sim}, teardown, nil
+}
+
+// testChain implements console.TestChain on top of a simulated backend.
+type testChain struct {
+	sim    *simulated.Backend
+	offset time.Duration // Time travelled by the tests
+	lock   sync.Mutex
+}
+
+// now returns the time of the chain, including the time travelled.
+func (c *testChain) now() time.Time {
+	return time.Now().Add(c.offset)
+}
+
+// head returns the number and timestamp of the chain head.
+func (c *testChain) head() (uint64, uint64, error) {
+	header, err := c.sim.Client().HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	return header.Number.Uint64(), header.Time, nil
+}
+
+// Mine seals the given number of blocks, the first of which includes the
+// pending transactions.
+func (c *testChain) Mine(blocks int) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	number, _, err := c.head()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < blocks; i++ {
+		if err := c.commit(c.now(), &number); err != nil {
+			return 0, err
+		}
+	}
+	return number, nil
+}
+
+// TimeTravel seals a block the given number of seconds after the head, and
+// advances the clock of later blocks to at least its timestamp.
+func (c *testChain) TimeTravel(seconds uint64) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	number, timestamp, err := c.head()
+	if err != nil {
+		return 0, err
+	}
+	target := time.Unix(int64(timestamp+seconds), 0)
+	if ahead := time.Until(target); ahead > c.offset {
+		c.offset = ahead
+	}
+	if err := c.commit(target, &number); err != nil {
+		return 0, err
+	}
+	_, timestamp, err = c.head()
+	return timestamp, err
+}
+
+// commit seals a block at the given time on top of the head with the given
+// number, which is updated to the new head. The simulated backend only logs
+// sealing failures, so they are detected by the head not advancing.
+func (c *testChain) commit(timestamp time.Time, number *uint64) error {
+	c.sim.CommitAt(timestamp)
+
+	head, _, err := c.head()
+	if err != nil {
+		return err
+	}
+	if head <= *number {
+		return fmt.Errorf("failed to seal block %d", *number+1)
+	}
+	*number = head
+	return nil
+}
+
+// Snapshot returns the hash of the chain head.
+func (c *testChain) Snapshot() (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	header, err := c.sim.Client().HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return "", err
+	}
+	return header.Hash().Hex(), nil
+}
+
+// Revert drops the pending transactions and rewinds the chain to the block with
+// the given hash.
+func (c *testChain) Revert(id string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	hash := common.HexToHash(id)
+	header, err := c.sim.Client().HeaderByHash(context.Background(), hash)
+	if err != nil {
+		return fmt.Errorf("unknown snapshot %s", id)
+	}
+	// Snapshots are restored by number, so they must not have been reorged out
+	canonical, err := c.sim.Client().HeaderByNumber(context.Background(), header.Number)
+	if err != nil || canonical.Hash() != hash {
+		return fmt.Errorf("snapshot %s is no longer canonical", id)
+	}
+	c.sim.Rollback()
+	return c.sim.Fork(hash)
+}
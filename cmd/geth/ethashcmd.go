@@ -0,0 +1,124 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/shudolab/core-geth/cmd/utils"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/internal/flags"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	ethashVerifyFlag = &cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Verify the content of every file against its checksum (reads all files)",
+	}
+
+	ethashCommand = &cli.Command{
+		Name:      "ethash",
+		Usage:     "Inspect the ethash verification caches and mining DAGs",
+		ArgsUsage: "",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "status",
+				Usage:  "Show the ethash epochs available on disk",
+				Action: ethashStatus,
+				Flags: flags.Merge(utils.NetworkFlags, []cli.Flag{
+					utils.DataDirFlag,
+					utils.EthashCacheDirFlag,
+					utils.EthashDatasetDirFlag,
+					ethashVerifyFlag,
+				}),
+				Description: `
+geth ethash status [--verify]
+
+Lists the ethash verification caches and mining DAGs stored in the cache and
+DAG directories, by epoch length and epoch. The directories may be shared by
+several nodes, files currently being generated by any of them are marked as such.
+It does not open the node, so it can be used while geth is running.`,
+			},
+		},
+	}
+)
+
+// ethashStatus prints the ethash files in the configured cache and DAG directories.
+func ethashStatus(ctx *cli.Context) error {
+	cfg := loadBaseConfig(ctx)
+	utils.SetEthashDirs(ctx, &cfg.Eth)
+
+	dirs := []struct {
+		name string
+		path string
+	}{
+		{"Verification caches", cfg.Node.ResolvePath(cfg.Eth.Ethash.CacheDir)},
+		{"Mining DAGs", cfg.Eth.Ethash.DatasetDir},
+	}
+	verify := ctx.Bool(ethashVerifyFlag.Name)
+	for i, dir := range dirs {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s in %s\n", dir.name, dir.path)
+		if dir.path == "" {
+			fmt.Println("Disk storage disabled")
+			continue
+		}
+		entries, err := ethash.ReadStore(dir.path, verify)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("No files found")
+			continue
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Epoch length", "Epoch", "Size", "Checksum", "Status"})
+		for _, entry := range entries {
+			epochLength := "unknown"
+			if entry.EpochLength != 0 {
+				epochLength = strconv.FormatUint(entry.EpochLength, 10)
+			}
+			status := "unverified"
+			switch {
+			case entry.Generating:
+				status = "generating"
+			case entry.Checksum == "":
+				status = "no checksum, will be regenerated"
+			case entry.Err != nil:
+				status = fmt.Sprintf("%v, delete to regenerate", entry.Err)
+			case verify:
+				status = "verified"
+			}
+			table.Append([]string{
+				epochLength,
+				strconv.FormatUint(entry.Epoch, 10),
+				common.StorageSize(entry.Size).String(),
+				entry.Checksum,
+				status,
+			})
+		}
+		table.Render()
+	}
+	return nil
+}
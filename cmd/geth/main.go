@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/hdwallet"
 	"github.com/shudolab/core-geth/accounts/keystore"
 	"github.com/shudolab/core-geth/cmd/utils"
 	"github.com/shudolab/core-geth/common"
@@ -149,12 +150,14 @@ var (
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DNSDiscoveryFlag,
+		utils.DiscoveryForkIDFlag,
 		utils.EthProtocolsFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperPoWFlag,
 		utils.DeveloperGasLimitFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMParallelFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
 		utils.FakePoWFlag,
@@ -188,8 +191,17 @@ var (
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
+		utils.HealthEnabledFlag,
+		utils.HealthMaxHeadAgeFlag,
+		utils.HealthMaxBlockLagFlag,
+		utils.HealthMinPeersFlag,
+		utils.HealthSyncedFlag,
+		utils.HealthECBP1100Flag,
+		utils.HealthMaxReorgsFlag,
+		utils.HealthReorgWindowFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
+		utils.HTTPStreamFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -202,9 +214,12 @@ var (
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCCacheFlag,
+		utils.RPCCacheDepthFlag,
 		utils.AllowUnprotectedTxs,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
+		utils.RPCPolicyFileFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -222,6 +237,9 @@ var (
 		utils.MetricsInfluxDBTokenFlag,
 		utils.MetricsInfluxDBBucketFlag,
 		utils.MetricsInfluxDBOrganizationFlag,
+		utils.TracingEndpointFlag,
+		utils.TracingFileFlag,
+		utils.TracingSampleRatioFlag,
 	}
 )
 
@@ -248,6 +266,8 @@ func init() {
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
+		// See ethashcmd.go:
+		ethashCommand,
 		// See misccmd.go:
 		makecacheCommand,
 		makedagCommand,
@@ -403,6 +423,7 @@ func geth(ctx *cli.Context) error {
 	stack, backend := makeFullNode(ctx)
 	defer stack.Close()
 
+	utils.SetupTracing(ctx, stack)
 	startNode(ctx, stack, backend, false)
 	stack.Wait()
 	return nil
@@ -431,6 +452,9 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 	go func() {
 		// Open any wallets already attached
 		for _, wallet := range stack.AccountManager().Wallets() {
+			if wallet.URL().Scheme == hdwallet.Scheme {
+				continue // Mnemonic wallets need a passphrase, opened via personal_openWallet
+			}
 			if err := wallet.Open(""); err != nil {
 				log.Warn("Failed to open wallet", "url", wallet.URL(), "err", err)
 			}
@@ -439,6 +463,9 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 		for event := range events {
 			switch event.Kind {
 			case accounts.WalletArrived:
+				if event.Wallet.URL().Scheme == hdwallet.Scheme {
+					continue
+				}
 				if err := event.Wallet.Open(""); err != nil {
 					log.Warn("New wallet appeared, failed to open", "url", event.Wallet.URL(), "err", err)
 				}
@@ -446,11 +473,14 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 				status, _ := event.Wallet.Status()
 				log.Info("New wallet appeared", "url", event.Wallet.URL(), "status", status)
 
-				var derivationPaths []accounts.DerivationPath
+				var (
+					paths           = accounts.WalletHDPathConfig(event.Wallet)
+					derivationPaths []accounts.DerivationPath
+				)
 				if event.Wallet.URL().Scheme == "ledger" {
-					derivationPaths = append(derivationPaths, accounts.LegacyLedgerBaseDerivationPath)
+					derivationPaths = append(derivationPaths, paths.LegacyLedgerBasePath())
 				}
-				derivationPaths = append(derivationPaths, accounts.DefaultBaseDerivationPath)
+				derivationPaths = append(derivationPaths, paths.BasePath())
 
 				event.Wallet.SelfDerive(derivationPaths, ethClient)
 
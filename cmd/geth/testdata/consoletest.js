@@ -0,0 +1,42 @@
+var dev = eth.accounts[0];
+var sink = "0x000000000000000000000000000000000000dead";
+
+test("funded coinbase", function() {
+	assert.equal(eth.coinbase, dev);
+	assert(eth.getBalance(dev).gt(0), "developer account not funded");
+});
+
+test("automine", function() {
+	var number = eth.blockNumber;
+	var hash = eth.sendTransaction({from: dev, to: sink, value: 1000});
+	assert.equal(eth.blockNumber, number + 1);
+	assert.equal(eth.getTransactionReceipt(hash).status, "0x1");
+});
+
+test("manual mining", function() {
+	automine(false);
+	var hash = eth.sendTransaction({from: dev, to: sink, value: 1});
+	assert.equal(eth.getTransactionReceipt(hash), null);
+	assert.equal(mine(), eth.blockNumber);
+	assert.notEqual(eth.getTransactionReceipt(hash), null);
+	automine(true);
+});
+
+test("time travel", function() {
+	var timestamp = eth.getBlock("latest").timestamp;
+	assert(timeTravel(86400) >= timestamp + 86400);
+});
+
+test("snapshot and revert", function() {
+	var id = snapshot();
+	var number = eth.blockNumber;
+	eth.sendTransaction({from: dev, to: sink, value: 1});
+	mine(3);
+	revert(id);
+	assert.equal(eth.blockNumber, number);
+	assert.equal(eth.getBalance(sink), 1001);
+});
+
+test("failing", function() {
+	assert.equal(eth.getBalance(sink), 0, "sink balance");
+});
@@ -55,6 +55,7 @@ import (
 	"github.com/shudolab/core-geth/eth/ethconfig"
 	"github.com/shudolab/core-geth/eth/filters"
 	"github.com/shudolab/core-geth/eth/gasprice"
+	"github.com/shudolab/core-geth/eth/health"
 	"github.com/shudolab/core-geth/eth/tracers"
 	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/ethdb/remotedb"
@@ -62,6 +63,7 @@ import (
 	"github.com/shudolab/core-geth/graphql"
 	"github.com/shudolab/core-geth/internal/ethapi"
 	"github.com/shudolab/core-geth/internal/flags"
+	"github.com/shudolab/core-geth/internal/tracing/export"
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/metrics"
 	"github.com/shudolab/core-geth/metrics/exp"
@@ -675,6 +677,11 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMParallelFlag = &cli.BoolFlag{
+		Name:     "vm.parallel",
+		Usage:    "Execute the transactions of imported blocks optimistically in parallel (experimental)",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -695,6 +702,18 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	RPCCacheFlag = &cli.IntFlag{
+		Name:     "rpc.cache",
+		Usage:    "Megabytes of memory allocated to caching immutable RPC responses (0 = disabled)",
+		Value:    ethconfig.Defaults.RPCCacheSize,
+		Category: flags.APICategory,
+	}
+	RPCCacheDepthFlag = &cli.Uint64Flag{
+		Name:     "rpc.cache.depth",
+		Usage:    "Number of blocks below the head after which RPC responses are cached as immutable",
+		Value:    ethconfig.Defaults.RPCCacheDepth,
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -802,6 +821,11 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	HTTPStreamFlag = &cli.BoolFlag{
+		Name:     "http.stream",
+		Usage:    "Enable JSON-RPC streams over HTTP/2 on the HTTP-RPC server (accepts plaintext HTTP/2 with prior knowledge)",
+		Category: flags.APICategory,
+	}
 	GraphQLEnabledFlag = &cli.BoolFlag{
 		Name:     "graphql",
 		Usage:    "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -819,6 +843,52 @@ var (
 		Value:    strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 		Category: flags.APICategory,
 	}
+	HealthEnabledFlag = &cli.BoolFlag{
+		Name:     "health",
+		Usage:    "Enable the /health and /ready endpoints on the HTTP-RPC server",
+		Category: flags.APICategory,
+	}
+	HealthMaxHeadAgeFlag = &cli.DurationFlag{
+		Name:     "health.maxheadage",
+		Usage:    "Maximum age of the head block for the node to be ready (0 = unchecked)",
+		Value:    health.DefaultConfig.MaxHeadAge,
+		Category: flags.APICategory,
+	}
+	HealthMaxBlockLagFlag = &cli.Uint64Flag{
+		Name:     "health.maxblocklag",
+		Usage:    "Maximum number of blocks behind the highest known block for the node to be ready (0 = unchecked)",
+		Value:    health.DefaultConfig.MaxBlockLag,
+		Category: flags.APICategory,
+	}
+	HealthMinPeersFlag = &cli.IntFlag{
+		Name:     "health.minpeers",
+		Usage:    "Minimum number of connected peers for the node to be healthy (0 = unchecked)",
+		Value:    health.DefaultConfig.MinPeers,
+		Category: flags.APICategory,
+	}
+	HealthSyncedFlag = &cli.BoolFlag{
+		Name:     "health.synced",
+		Usage:    "Require the initial chain sync to be complete for the node to be ready",
+		Value:    health.DefaultConfig.RequireSynced,
+		Category: flags.APICategory,
+	}
+	HealthECBP1100Flag = &cli.BoolFlag{
+		Name:     "health.ecbp1100",
+		Usage:    "Require ECBP-1100 (MESS) to be enforced while the chain configuration activates it for the node to be ready",
+		Category: flags.APICategory,
+	}
+	HealthMaxReorgsFlag = &cli.IntFlag{
+		Name:     "health.maxreorgs",
+		Usage:    "Maximum number of chain reorgs within the reorg window for the node to be healthy (0 = unchecked)",
+		Value:    health.DefaultConfig.MaxReorgs,
+		Category: flags.APICategory,
+	}
+	HealthReorgWindowFlag = &cli.DurationFlag{
+		Name:     "health.reorgwindow",
+		Usage:    "Time span over which chain reorgs are counted",
+		Value:    health.DefaultConfig.ReorgWindow,
+		Category: flags.APICategory,
+	}
 	WSEnabledFlag = &cli.BoolFlag{
 		Name:     "ws",
 		Usage:    "Enable the WS-RPC server",
@@ -881,6 +951,11 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCPolicyFileFlag = &cli.StringFlag{
+		Name:     "rpc.policy",
+		Usage:    "JSON file restricting the methods and call rates of API keys on the HTTP and WebSocket endpoints",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -956,6 +1031,11 @@ var (
 		Usage:    "Sets DNS discovery entry points (use \"\" to disable DNS)",
 		Category: flags.NetworkingCategory,
 	}
+	DiscoveryForkIDFlag = &cli.BoolFlag{
+		Name:     "discovery.forkid",
+		Usage:    "Skips dialing discovered nodes advertising an incompatible fork ID",
+		Category: flags.NetworkingCategory,
+	}
 	DiscoveryPortFlag = &cli.IntFlag{
 		Name:     "discovery.port",
 		Usage:    "Use a custom UDP port for P2P discovery",
@@ -1122,6 +1202,24 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Value:    metrics.DefaultConfig.InfluxDBOrganization,
 		Category: flags.MetricsCategory,
 	}
+
+	// Tracing flags
+	TracingEndpointFlag = &cli.StringFlag{
+		Name:     "tracing.endpoint",
+		Usage:    "Export OpenTelemetry spans to this OTLP/HTTP collector URL (e.g. http://localhost:4318)",
+		Category: flags.MetricsCategory,
+	}
+	TracingFileFlag = &cli.StringFlag{
+		Name:     "tracing.file",
+		Usage:    "Write OpenTelemetry spans to this file as JSON lines ('stdout' for the standard output)",
+		Category: flags.MetricsCategory,
+	}
+	TracingSampleRatioFlag = &cli.Float64Flag{
+		Name:     "tracing.sample",
+		Usage:    "Fraction of RPC calls and block imports to trace",
+		Value:    1,
+		Category: flags.MetricsCategory,
+	}
 )
 
 var (
@@ -1352,6 +1450,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(HTTPPathPrefixFlag.Name) {
 		cfg.HTTPPathPrefix = ctx.String(HTTPPathPrefixFlag.Name)
 	}
+	if ctx.IsSet(HTTPStreamFlag.Name) {
+		cfg.HTTPStream = ctx.Bool(HTTPStreamFlag.Name)
+	}
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
@@ -1363,6 +1464,10 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(RPCPolicyFileFlag.Name) {
+		cfg.RPCPolicyFile = ctx.String(RPCPolicyFileFlag.Name)
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1618,40 +1723,18 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 	if ctx.IsSet(USBFlag.Name) {
 		cfg.USB = ctx.Bool(USBFlag.Name)
-
-		// We handle configuration of HD paths only if --usb is set to a truthy value.
-		if cfg.USB {
-			// Flag --usb.pathid allows configuration for arbitrary SLIP-0044 values.
-			if ctx.IsSet(USBPathIDFlag.Name) {
-				pathID := ctx.Uint64(USBPathIDFlag.Name)
-				if pathID > math.MaxUint32 {
-					Fatalf("Invalid USB path ID (exceeds uint32): %d", pathID)
-				}
-				accounts.SetCoinTypeConfiguration(uint32(pathID))
-				log.Info("Using custom HD derivation path", "pathid", uint32(pathID), "basepath", accounts.DefaultBaseDerivationPath)
-			} else {
-				// Set default hd path based on --chain configuration flags, if any.
-				// Set default derivation path to a testnet value if we're configuring for a testnet.
-				for _, f := range TestnetFlags {
-					name := f.Names()[0] // This won't overflow because the flags always have a Name, because they are defined as --name.
-					if ctx.IsSet(name) && ctx.Bool(name) {
-						accounts.SetCoinTypeConfiguration(accounts.BIP0044CoinTypeTestnet)
-						log.Info("Using testnet HD derivation path", "basepath", accounts.DefaultBaseDerivationPath)
-						break
-					}
-				}
-
-				// Configure the HD derivation path for Ethereum Classic (ETC) if
-				// the client is configuring for ETC.
-				// This will not conflict with the testnet configuration handling above
-				// because we trust that the network configuration flags are checked to
-				// be exclusive.
-				if ctx.IsSet(ClassicFlag.Name) && ctx.Bool(ClassicFlag.Name) {
-					accounts.SetCoinTypeConfiguration(accounts.BIP0044CoinTypeEtherClassic)
-					log.Info("Using Ethereum Classic (ETC) HD derivation path", "basepath", accounts.DefaultBaseDerivationPath)
-				}
-			}
-		}
+	}
+	// Derive wallet accounts for the selected network, unless the config file
+	// already specifies a coin type and no flag overrides it.
+	if cfg.HDPath == nil || ctx.IsSet(USBPathIDFlag.Name) || IsNetworkPreset(ctx) {
+		paths := MakeHDPathConfig(ctx)
+		cfg.HDPath = &paths
+	}
+	// We handle configuration of the process wide HD paths only if --usb is set
+	// to a truthy value.
+	if ctx.IsSet(USBFlag.Name) && cfg.USB {
+		accounts.SetCoinTypeConfiguration(cfg.HDPath.CoinType)
+		log.Info("Using HD derivation path", "cointype", cfg.HDPath.CoinType, "basepath", accounts.DefaultBaseDerivationPath)
 	}
 	if ctx.IsSet(InsecureUnlockAllowedFlag.Name) {
 		cfg.InsecureUnlockAllowed = ctx.Bool(InsecureUnlockAllowedFlag.Name)
@@ -1673,6 +1756,31 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// MakeHDPathConfig returns the BIP-44 derivation paths of HD and hardware
+// wallets: those of the SLIP-0044 coin type given by --usb.pathid, if set, or
+// those of the network selected on the command line otherwise.
+func MakeHDPathConfig(ctx *cli.Context) accounts.HDPathConfig {
+	// Flag --usb.pathid allows configuration for arbitrary SLIP-0044 values.
+	if ctx.IsSet(USBPathIDFlag.Name) {
+		pathID := ctx.Uint64(USBPathIDFlag.Name)
+		if pathID > math.MaxUint32 {
+			Fatalf("Invalid USB path ID (exceeds uint32): %d", pathID)
+		}
+		return accounts.NewHDPathConfig(uint32(pathID))
+	}
+	// All testnets share a single coin type.
+	for _, f := range TestnetFlags {
+		name := f.Names()[0] // This won't overflow because the flags always have a Name, because they are defined as --name.
+		if ctx.IsSet(name) && ctx.Bool(name) {
+			return accounts.NewHDPathConfig(accounts.BIP0044CoinTypeTestnet)
+		}
+	}
+	if ctx.IsSet(ClassicFlag.Name) && ctx.Bool(ClassicFlag.Name) {
+		return accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEtherClassic)
+	}
+	return accounts.NewHDPathConfig(accounts.BIP0044CoinTypeEther)
+}
+
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	// Skip enabling smartcards if no path is set
 	path := ctx.String(SmartCardDaemonPathFlag.Name)
@@ -1827,10 +1935,16 @@ func setEthashCacheDir(ctx *cli.Context, cfg *eth.Config) {
 	}
 }
 
-func setEthash(ctx *cli.Context, cfg *eth.Config) {
-	// ECIP-1099
+// SetEthashDirs applies the ethash cache and DAG directory flags to the config,
+// defaulting to the etchash directories on the networks using ECIP-1099.
+func SetEthashDirs(ctx *cli.Context, cfg *ethconfig.Config) {
 	setEthashCacheDir(ctx, cfg)
 	setEthashDatasetDir(ctx, cfg)
+}
+
+func setEthash(ctx *cli.Context, cfg *eth.Config) {
+	// ECIP-1099
+	SetEthashDirs(ctx, cfg)
 
 	if ctx.Bool(FakePoWPoissonFlag.Name) {
 		cfg.Ethash.PowMode = ethash.ModePoissonFake
@@ -2074,6 +2188,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	if ctx.IsSet(VMParallelFlag.Name) {
+		cfg.ParallelTxExecution = ctx.Bool(VMParallelFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -2102,6 +2219,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(RPCCacheFlag.Name) {
+		cfg.RPCCacheSize = ctx.Int(RPCCacheFlag.Name)
+	}
+	if ctx.IsSet(RPCCacheDepthFlag.Name) {
+		cfg.RPCCacheDepth = ctx.Uint64(RPCCacheDepthFlag.Name)
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -2112,6 +2235,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.EthDiscoveryURLs = SplitAndTrim(urls)
 		}
 	}
+	if ctx.IsSet(DiscoveryForkIDFlag.Name) {
+		cfg.DiscoveryForkIDFilter = ctx.Bool(DiscoveryForkIDFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 
@@ -2330,6 +2456,14 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 	}
 }
 
+// RegisterHealthService adds the health and readiness endpoints to the node.
+func RegisterHealthService(stack *node.Node, backend *eth.Ethereum, cfg health.Config) {
+	if stack.Config().HTTPHost == "" {
+		log.Warn("Health endpoints enabled without the HTTP-RPC server, they will not be served")
+	}
+	health.Register(stack, backend, cfg)
+}
+
 // RegisterGraphQLService adds the GraphQL API to the node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cfg *node.Config) {
 	err := graphql.New(stack, backend, filterSystem, cfg.GraphQLCors, cfg.GraphQLVirtualHosts)
@@ -2356,6 +2490,40 @@ func RegisterFullSyncTester(stack *node.Node, eth *eth.Ethereum, target common.H
 	log.Info("Registered full-sync tester", "hash", target)
 }
 
+// tracingService flushes the spans when the node stops.
+type tracingService struct {
+	shutdown func(context.Context) error
+}
+
+func (s *tracingService) Start() error { return nil }
+
+func (s *tracingService) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.shutdown(ctx)
+}
+
+// SetupTracing enables the OpenTelemetry span exporters configured by the tracing
+// flags. The spans are flushed when the node stops.
+func SetupTracing(ctx *cli.Context, stack *node.Node) {
+	config := export.Config{
+		Endpoint:       ctx.String(TracingEndpointFlag.Name),
+		File:           ctx.String(TracingFileFlag.Name),
+		SampleRatio:    ctx.Float64(TracingSampleRatioFlag.Name),
+		ServiceName:    stack.Config().Name,
+		ServiceVersion: stack.Config().Version,
+	}
+	if config.Endpoint == "" && config.File == "" {
+		return
+	}
+	shutdown, err := export.Setup(config)
+	if err != nil {
+		Fatalf("Failed to set up tracing: %v", err)
+	}
+	log.Info("Enabled OpenTelemetry tracing", "endpoint", config.Endpoint, "file", config.File, "sample", config.SampleRatio)
+	stack.RegisterLifecycle(&tracingService{shutdown})
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -2556,6 +2724,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	// ETC-specific configuration: ECIP1099 modifies the original Ethash algo, doubling the epoch size.
 	if gspec != nil && gspec.Config != nil {
 		ethashConfig.ECIP1099Block = gspec.GetEthashECIP1099Transition() // This will panic if the genesis config field is not nil.
+		ethashConfig.ECIP1049Block = gspec.GetEthashECIP1049Transition()
 	}
 
 	var lyra2Config *lyra2.Config
@@ -2607,7 +2776,10 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheGCFlag.Name) {
 		cache.TrieDirtyLimit = ctx.Int(CacheFlag.Name) * ctx.Int(CacheGCFlag.Name) / 100
 	}
-	vmcfg := vm.Config{EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name)}
+	vmcfg := vm.Config{
+		EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name),
+		ParallelTxExecution:     ctx.Bool(VMParallelFlag.Name),
+	}
 
 	// Disable transaction indexing/unindexing by default.
 	chain, err := core.NewBlockChain(chainDb, cache, gspec, nil, engine, vmcfg, nil, nil)
@@ -17,15 +17,30 @@
 package clique
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/common/hexutil"
 	"github.com/shudolab/core-geth/consensus"
 	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/rlp"
 	"github.com/shudolab/core-geth/rpc"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// defaultStatusBlocks is the number of blocks clique_status reports on if
+	// no window is requested.
+	defaultStatusBlocks = 64
+
+	// maxStatusBlocks is the maximum number of blocks clique_status reports on,
+	// which is also the maximum gap the missed turn subscription catches up on.
+	maxStatusBlocks = 10000
 )
 
 // API is a user facing RPC API to allow controlling the signer and voting
@@ -99,9 +114,31 @@ func (api *API) Proposals() map[common.Address]bool {
 	defer api.clique.lock.RUnlock()
 
 	proposals := make(map[common.Address]bool)
-	for address, auth := range api.clique.proposals {
-		proposals[address] = auth
+	for address, proposal := range api.clique.proposals {
+		proposals[address] = proposal.authorize
+	}
+	return proposals
+}
+
+// Proposal is an authorization proposal the signer votes on in the blocks it
+// seals, until the vote passes, the proposal is discarded or it expires.
+type Proposal struct {
+	Address   common.Address `json:"address"`          // Account to change the authorization of
+	Authorize bool           `json:"authorize"`        // Whether to authorize or deauthorize the account
+	Expiry    uint64         `json:"expiry,omitempty"` // Last block to vote in, 0 if the proposal never expires
+}
+
+// GetProposals returns the current proposals the node tries to uphold and vote
+// on, including their expiry, sorted by address.
+func (api *API) GetProposals() []Proposal {
+	api.clique.lock.RLock()
+	defer api.clique.lock.RUnlock()
+
+	proposals := make([]Proposal, 0, len(api.clique.proposals))
+	for address, proposal := range api.clique.proposals {
+		proposals = append(proposals, Proposal{Address: address, Authorize: proposal.authorize, Expiry: proposal.expiry})
 	}
+	slices.SortFunc(proposals, func(a, b Proposal) int { return a.Address.Cmp(b.Address) })
 	return proposals
 }
 
@@ -111,7 +148,28 @@ func (api *API) Propose(address common.Address, auth bool) {
 	api.clique.lock.Lock()
 	defer api.clique.lock.Unlock()
 
-	api.clique.proposals[address] = auth
+	api.clique.proposals[address] = proposal{authorize: auth}
+}
+
+// ProposeBatch injects a batch of authorization proposals, replacing any
+// current proposals on the same accounts. Proposals with an expiry are dropped
+// once the chain passes that block, so signers voting on the same batch stop
+// together even if the vote doesn't pass. Either all proposals are accepted or
+// none if any of them already expired.
+func (api *API) ProposeBatch(proposals []Proposal) error {
+	head := api.chain.CurrentHeader().Number.Uint64()
+	for _, p := range proposals {
+		if p.Expiry != 0 && p.Expiry <= head {
+			return fmt.Errorf("proposal for %v expires at block %d, chain is at %d", p.Address, p.Expiry, head)
+		}
+	}
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	for _, p := range proposals {
+		api.clique.proposals[p.Address] = proposal{authorize: p.Authorize, expiry: p.Expiry}
+	}
+	return nil
 }
 
 // Discard drops a currently running proposal, stopping the signer from casting
@@ -126,57 +184,195 @@ func (api *API) Discard(address common.Address) {
 type status struct {
 	InturnPercent float64                `json:"inturnPercent"`
 	SigningStatus map[common.Address]int `json:"sealerActivity"`
+	MissedTurns   map[common.Address]int `json:"missedTurns"`
 	NumBlocks     uint64                 `json:"numBlocks"`
 }
 
-// Status returns the status of the last N blocks,
-// - the number of active signers,
-// - the number of signers,
+// Status returns the status of the last N blocks, 64 unless requested otherwise,
+// - the number of blocks sealed by each signer,
+// - the number of turns each signer missed,
 // - the percentage of in-turn blocks
-func (api *API) Status() (*status, error) {
+func (api *API) Status(numBlocks *uint64) (*status, error) {
 	var (
-		numBlocks = uint64(64)
-		header    = api.chain.CurrentHeader()
-		diff      = uint64(0)
-		optimals  = 0
+		blocks = uint64(defaultStatusBlocks)
+		header = api.chain.CurrentHeader()
 	)
+	if numBlocks != nil {
+		if *numBlocks == 0 || *numBlocks > maxStatusBlocks {
+			return nil, fmt.Errorf("invalid number of blocks %d, must be between 1 and %d", *numBlocks, maxStatusBlocks)
+		}
+		blocks = *numBlocks
+	}
 	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
 		return nil, err
 	}
 	var (
-		signers = snap.signers()
-		end     = header.Number.Uint64()
-		start   = end - numBlocks
+		end   = header.Number.Uint64()
+		start = end - blocks
 	)
-	if numBlocks > end {
-		start = 1
-		numBlocks = end - start
+	if blocks >= end {
+		// The genesis block is not sealed, start after it
+		start, blocks = 1, 0
+		if end > start {
+			blocks = end - start
+		}
+	}
+	result := &status{
+		SigningStatus: make(map[common.Address]int),
+		MissedTurns:   make(map[common.Address]int),
+		NumBlocks:     blocks,
 	}
-	signStatus := make(map[common.Address]int)
-	for _, s := range signers {
-		signStatus[s] = 0
+	for _, s := range snap.signers() {
+		result.SigningStatus[s] = 0
+		result.MissedTurns[s] = 0
 	}
+	if blocks == 0 {
+		return result, nil
+	}
+	headers := make([]*types.Header, 0, blocks)
 	for n := start; n < end; n++ {
 		h := api.chain.GetHeaderByNumber(n)
 		if h == nil {
 			return nil, fmt.Errorf("missing block %d", n)
 		}
-		if h.Difficulty.Cmp(diffInTurn) == 0 {
+		headers = append(headers, h)
+	}
+	optimals := 0
+	err = api.turns(headers, func(header *types.Header, inturn, sealer common.Address) {
+		if sealer == inturn {
 			optimals++
+		} else {
+			result.MissedTurns[inturn]++
 		}
-		diff += h.Difficulty.Uint64()
-		sealer, err := api.clique.Author(h)
+		result.SigningStatus[sealer]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.InturnPercent = float64(100*optimals) / float64(blocks)
+	return result, nil
+}
+
+// turns calls fn with the in-turn signer and the actual sealer of consecutive
+// headers, tracking the signer set through the voting snapshots.
+func (api *API) turns(headers []*types.Header, fn func(header *types.Header, inturn, sealer common.Address)) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	snap, err := api.clique.snapshot(api.chain, headers[0].Number.Uint64()-1, headers[0].ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	for _, header := range headers {
+		sealer, err := api.clique.Author(header)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		inturn := sealer
+		if header.Difficulty.Cmp(diffInTurn) != 0 {
+			inturn = snap.inturnSigner(header.Number.Uint64())
+		}
+		fn(header, inturn, sealer)
+
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			return err
 		}
-		signStatus[sealer]++
 	}
-	return &status{
-		InturnPercent: float64(100*optimals) / float64(numBlocks),
-		SigningStatus: signStatus,
-		NumBlocks:     numBlocks,
-	}, nil
+	return nil
+}
+
+// MissedTurn is a canonical block sealed out of turn, meaning that the in-turn
+// signer missed its turn.
+type MissedTurn struct {
+	Number uint64         `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+	Signer common.Address `json:"signer"` // In-turn signer which missed its turn
+	Sealer common.Address `json:"sealer"` // Signer which sealed the block instead
+}
+
+// MissedTurns creates a subscription that is notified about every block added
+// to the canonical chain which was sealed out of turn. The chain is checked
+// for new blocks once per block period.
+func (api *API) MissedTurns(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		interval := time.Duration(api.clique.config.Period) * time.Second
+		if interval == 0 {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := api.chain.CurrentHeader()
+		for {
+			select {
+			case <-ticker.C:
+				head := api.chain.CurrentHeader()
+				missed, err := api.missedTurns(last, head)
+				if err != nil {
+					log.Warn("Failed to track missed clique turns", "number", head.Number, "hash", head.Hash(), "err", err)
+				}
+				for _, m := range missed {
+					notifier.Notify(rpcSub.ID, m)
+				}
+				last = head
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// missedTurns returns the blocks sealed out of turn after the last processed
+// header up to the new head, at most maxStatusBlocks of them.
+func (api *API) missedTurns(last, head *types.Header) ([]MissedTurn, error) {
+	from := last.Number.Uint64() + 1
+	if to := head.Number.Uint64(); to < from {
+		return nil, nil
+	} else if to-from >= maxStatusBlocks {
+		from = to - maxStatusBlocks + 1
+	}
+	// Collect the new canonical headers, which might be on a different branch
+	headers := make([]*types.Header, head.Number.Uint64()-from+1)
+	for i, header := len(headers)-1, head; i >= 0; i-- {
+		if header == nil {
+			return nil, errors.New("missing ancestor of chain head")
+		}
+		headers[i] = header
+		header = api.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	var missed []MissedTurn
+	err := api.turns(headers, func(header *types.Header, inturn, sealer common.Address) {
+		if inturn != sealer {
+			missed = append(missed, MissedTurn{Number: header.Number.Uint64(), Hash: header.Hash(), Signer: inturn, Sealer: sealer})
+		}
+	})
+	return missed, err
+}
+
+// ExportCheckpoint returns the voting snapshot at an epoch checkpoint block,
+// which other nodes can import to verify blocks on top of it. If no block is
+// given, the latest checkpoint is exported.
+func (api *API) ExportCheckpoint(number *rpc.BlockNumber) (*Checkpoint, error) {
+	head := api.chain.CurrentHeader().Number.Uint64()
+	target := head - head%api.clique.config.Epoch
+	if number != nil && *number >= 0 {
+		target = uint64(number.Int64())
+	}
+	return api.clique.ExportCheckpoint(api.chain, target)
+}
+
+// ImportCheckpoint verifies a checkpoint exported by another node and stores
+// its voting snapshot as trusted.
+func (api *API) ImportCheckpoint(checkpoint Checkpoint) error {
+	return api.clique.ImportCheckpoint(&checkpoint)
 }
 
 type blockNumberOrHashOrRLP struct {
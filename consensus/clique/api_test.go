@@ -0,0 +1,275 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/params/vars"
+	"github.com/shudolab/core-geth/rlp"
+	"github.com/shudolab/core-geth/rpc"
+)
+
+// newTurnTestChain creates a chain with three signers A, B and C (sorted by
+// address) and an epoch length of 3, where the blocks are sealed by the signers
+// at the given indices. Blocks are sealed with the in-turn difficulty if the
+// sealer is in turn.
+func newTurnTestChain(t *testing.T, sealers []int) (*core.BlockChain, *Clique, []common.Address) {
+	accounts := newTesterAccountPool()
+
+	names := []string{"A", "B", "C"}
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	// Sort the signers, keeping the names in sync
+	for i := 0; i < len(signers); i++ {
+		for j := i + 1; j < len(signers); j++ {
+			if signers[i].Cmp(signers[j]) > 0 {
+				signers[i], signers[j] = signers[j], signers[i]
+				names[i], names[j] = names[j], names[i]
+			}
+		}
+	}
+	genesis := &genesisT.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(signers)+extraSeal),
+		BaseFee:   big.NewInt(vars.InitialBaseFee),
+	}
+	for i, signer := range signers {
+		copy(genesis.ExtraData[extraVanity+i*common.AddressLength:], signer[:])
+	}
+	config := *params.TestChainConfig
+	config.Clique = &ctypes.CliqueConfig{Period: 1, Epoch: 3}
+	genesis.Config = &config
+
+	generator := New(config.Clique, rawdb.NewMemoryDatabase())
+	generator.fakeDiff = true
+
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, generator, len(sealers), func(int, *core.BlockGen) {})
+	for i, block := range blocks {
+		header := block.Header()
+		if i > 0 {
+			header.ParentHash = blocks[i-1].Hash()
+		}
+		header.Extra = make([]byte, extraVanity+extraSeal)
+		if header.Number.Uint64()%config.Clique.Epoch == 0 {
+			header.Extra = make([]byte, extraVanity+len(signers)*common.AddressLength+extraSeal)
+			accounts.checkpoint(header, names)
+		}
+		header.Difficulty = diffNoTurn
+		if header.Number.Uint64()%uint64(len(signers)) == uint64(sealers[i]) {
+			header.Difficulty = diffInTurn
+		}
+		accounts.sign(header, names[sealers[i]])
+		blocks[i] = block.WithSeal(header)
+	}
+	engine := New(config.Clique, rawdb.NewMemoryDatabase())
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create test chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	return chain, engine, signers
+}
+
+// Tests that the status reports missed turns of the requested window.
+func TestStatusMissedTurns(t *testing.T) {
+	// Blocks 2 and 3 are sealed out of turn
+	chain, engine, signers := newTurnTestChain(t, []int{1, 0, 2, 1, 2, 0})
+	defer chain.Stop()
+
+	api := &API{chain: chain, clique: engine}
+
+	// The default window covers blocks 1-5
+	status, err := api.Status(nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+	if status.NumBlocks != 5 || status.InturnPercent != 60 {
+		t.Errorf("status mismatch: have %d blocks %v%% in turn, want 5 blocks 60%% in turn", status.NumBlocks, status.InturnPercent)
+	}
+	want := map[common.Address]int{signers[0]: 1, signers[1]: 0, signers[2]: 1}
+	for signer, missed := range want {
+		if status.MissedTurns[signer] != missed {
+			t.Errorf("signer %x: missed turns mismatch: have %d, want %d", signer, status.MissedTurns[signer], missed)
+		}
+	}
+	// A window of 3 covers blocks 3-5
+	window := uint64(3)
+	if status, err = api.Status(&window); err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+	want = map[common.Address]int{signers[0]: 1, signers[1]: 0, signers[2]: 0}
+	for signer, missed := range want {
+		if status.MissedTurns[signer] != missed {
+			t.Errorf("signer %x: windowed missed turns mismatch: have %d, want %d", signer, status.MissedTurns[signer], missed)
+		}
+	}
+	window = maxStatusBlocks + 1
+	if _, err := api.Status(&window); err == nil {
+		t.Error("oversized window accepted")
+	}
+	// The subscription reports the same blocks
+	missed, err := api.missedTurns(chain.GetHeaderByNumber(0), chain.CurrentHeader())
+	if err != nil {
+		t.Fatalf("failed to track missed turns: %v", err)
+	}
+	wantMissed := []MissedTurn{
+		{Number: 2, Hash: chain.GetHeaderByNumber(2).Hash(), Signer: signers[2], Sealer: signers[0]},
+		{Number: 3, Hash: chain.GetHeaderByNumber(3).Hash(), Signer: signers[0], Sealer: signers[2]},
+	}
+	if len(missed) != len(wantMissed) {
+		t.Fatalf("missed turn count mismatch: have %d, want %d", len(missed), len(wantMissed))
+	}
+	for i := range missed {
+		if missed[i] != wantMissed[i] {
+			t.Errorf("missed turn %d mismatch: have %+v, want %+v", i, missed[i], wantMissed[i])
+		}
+	}
+}
+
+// Tests that expired proposals are dropped and not voted on.
+func TestProposalExpiry(t *testing.T) {
+	chain, engine, _ := newTurnTestChain(t, []int{1, 2, 0, 1})
+	defer chain.Stop()
+
+	api := &API{chain: chain, clique: engine}
+
+	var (
+		expiring  = common.Address{0x01}
+		permanent = common.Address{0x02}
+	)
+	if err := api.ProposeBatch([]Proposal{{Address: expiring, Authorize: true, Expiry: 4}}); err == nil {
+		t.Fatal("expired proposal accepted")
+	}
+	if err := api.ProposeBatch([]Proposal{{Address: expiring, Authorize: true, Expiry: 5}, {Address: permanent, Authorize: true}}); err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	if have := api.GetProposals(); len(have) != 2 || have[0].Expiry != 5 || have[1].Expiry != 0 {
+		t.Fatalf("proposals mismatch: %+v", have)
+	}
+	// Block 5 still votes on both proposals, pretend the expiring one ends earlier
+	engine.proposals[expiring] = proposal{authorize: true, expiry: 4}
+
+	header := &types.Header{Number: big.NewInt(5), ParentHash: chain.CurrentHeader().Hash()}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	if header.Coinbase != permanent {
+		t.Errorf("voted on %x, want %x", header.Coinbase, permanent)
+	}
+	if proposals := api.Proposals(); len(proposals) != 1 || !proposals[permanent] {
+		t.Errorf("expired proposal not dropped: %v", proposals)
+	}
+}
+
+// Tests that checkpoints exported from one node can be imported by another,
+// and that inconsistent checkpoints are rejected.
+func TestCheckpointExportImport(t *testing.T) {
+	chain, engine, signers := newTurnTestChain(t, []int{1, 2, 0, 1, 2})
+	defer chain.Stop()
+
+	api := &API{chain: chain, clique: engine}
+
+	// The latest checkpoint is block 3
+	checkpoint, err := api.ExportCheckpoint(nil)
+	if err != nil {
+		t.Fatalf("failed to export checkpoint: %v", err)
+	}
+	if checkpoint.Snapshot.Number != 3 {
+		t.Fatalf("checkpoint number mismatch: have %d, want 3", checkpoint.Snapshot.Number)
+	}
+	four := rpc.BlockNumber(4)
+	if _, err := api.ExportCheckpoint(&four); err == nil {
+		t.Fatal("non-checkpoint block exported")
+	}
+	// Round trip the checkpoint through JSON as it would be over RPC
+	blob, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importCheckpoint := func() (*Clique, error) {
+		var cp Checkpoint
+		if err := json.Unmarshal(blob, &cp); err != nil {
+			t.Fatal(err)
+		}
+		importer := New(engine.config, rawdb.NewMemoryDatabase())
+		return importer, importer.ImportCheckpoint(&cp)
+	}
+	importer, err := importCheckpoint()
+	if err != nil {
+		t.Fatalf("failed to import checkpoint: %v", err)
+	}
+	snap, err := loadSnapshot(importer.config, importer.signatures, importer.db, checkpoint.Snapshot.Hash)
+	if err != nil {
+		t.Fatalf("imported checkpoint not stored: %v", err)
+	}
+	if have := snap.signers(); len(have) != len(signers) {
+		t.Errorf("imported signers mismatch: have %x, want %x", have, signers)
+	}
+	// Tamper with the checkpoint in various ways
+	var (
+		original  = blob
+		tampering = []struct {
+			name   string
+			modify func(cp *Checkpoint)
+			err    error
+		}{
+			{"dropped signer", func(cp *Checkpoint) { delete(cp.Snapshot.Signers, signers[0]) }, errMismatchingCheckpointSigners},
+			{"wrong hash", func(cp *Checkpoint) { cp.Snapshot.Hash = common.Hash{0x01} }, errMismatchingCheckpointSnapshot},
+			{"votes", func(cp *Checkpoint) { cp.Snapshot.Votes = []*Vote{{Signer: signers[0]}} }, errCheckpointVotes},
+			{"recents", func(cp *Checkpoint) { cp.Snapshot.Recents = map[uint64]common.Address{3: signers[1]} }, errInvalidCheckpointRecents},
+			{"no snapshot", func(cp *Checkpoint) { cp.Snapshot = nil }, errMissingCheckpointSnapshot},
+			{"unsealed header", func(cp *Checkpoint) {
+				header := new(types.Header)
+				rlp.DecodeBytes(cp.Header, header)
+				header.Extra[len(header.Extra)-1] ^= 0xff
+				cp.Header, _ = rlp.EncodeToBytes(header)
+				cp.Snapshot.Hash = header.Hash()
+			}, nil},
+		}
+	)
+	for _, tt := range tampering {
+		var cp Checkpoint
+		if err := json.Unmarshal(original, &cp); err != nil {
+			t.Fatal(err)
+		}
+		tt.modify(&cp)
+		if blob, err = json.Marshal(&cp); err != nil {
+			t.Fatal(err)
+		}
+		_, err := importCheckpoint()
+		if err == nil {
+			t.Errorf("%s: tampered checkpoint imported", tt.name)
+		} else if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/consensus"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/rlp"
+)
+
+var (
+	// errMissingCheckpointSnapshot is returned if an imported checkpoint doesn't
+	// contain a voting snapshot.
+	errMissingCheckpointSnapshot = errors.New("missing checkpoint snapshot")
+
+	// errMismatchingCheckpointSnapshot is returned if the voting snapshot of an
+	// imported checkpoint doesn't belong to its header.
+	errMismatchingCheckpointSnapshot = errors.New("mismatching checkpoint snapshot")
+
+	// errCheckpointVotes is returned if the voting snapshot of an imported
+	// checkpoint contains votes, which are reset at every checkpoint.
+	errCheckpointVotes = errors.New("votes in checkpoint snapshot")
+
+	// errInvalidCheckpointRecents is returned if the recent signers of the voting
+	// snapshot of an imported checkpoint are inconsistent with its header.
+	errInvalidCheckpointRecents = errors.New("invalid recent signers in checkpoint snapshot")
+)
+
+// Checkpoint is the voting snapshot at an epoch checkpoint block together with
+// the block header. Checkpoints exported from a synced node can be imported by
+// other nodes of the network, which can then verify the blocks on top of it
+// without replaying the votes cast since genesis.
+type Checkpoint struct {
+	Header   hexutil.Bytes `json:"header"`   // RLP encoded checkpoint block header
+	Snapshot *Snapshot     `json:"snapshot"` // Voting snapshot at the checkpoint block
+}
+
+// ExportCheckpoint returns the checkpoint at the given epoch checkpoint block.
+func (c *Clique) ExportCheckpoint(chain consensus.ChainHeaderReader, number uint64) (*Checkpoint, error) {
+	if number%c.config.Epoch != 0 {
+		return nil, fmt.Errorf("block %d is not an epoch checkpoint, epoch length %d", number, c.config.Epoch)
+	}
+	header := chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{Header: blob, Snapshot: snap}, nil
+}
+
+// ImportCheckpoint verifies that a checkpoint is consistent and stores its
+// voting snapshot as trusted. Only the checkpoint block itself can be verified,
+// the snapshot is trusted to be the outcome of the votes before it, so it must
+// come from a trusted source.
+func (c *Clique) ImportCheckpoint(checkpoint *Checkpoint) error {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(checkpoint.Header, header); err != nil {
+		return fmt.Errorf("invalid checkpoint header: %v", err)
+	}
+	snap := checkpoint.Snapshot
+	if snap == nil {
+		return errMissingCheckpointSnapshot
+	}
+	number := header.Number.Uint64()
+	if number == 0 || number%c.config.Epoch != 0 {
+		return fmt.Errorf("block %d is not an epoch checkpoint, epoch length %d", number, c.config.Epoch)
+	}
+	if snap.Number != number || snap.Hash != header.Hash() {
+		return errMismatchingCheckpointSnapshot
+	}
+	// The signer list in the header must match the snapshot
+	signersBytes := len(header.Extra) - extraVanity - extraSeal
+	if signersBytes <= 0 || signersBytes%common.AddressLength != 0 {
+		return errInvalidCheckpointSigners
+	}
+	signers := make([]byte, 0, len(snap.Signers)*common.AddressLength)
+	for _, signer := range snap.signers() {
+		signers = append(signers, signer[:]...)
+	}
+	if !bytes.Equal(header.Extra[extraVanity:extraVanity+signersBytes], signers) {
+		return errMismatchingCheckpointSigners
+	}
+	if len(snap.Votes) > 0 || len(snap.Tally) > 0 {
+		return errCheckpointVotes
+	}
+	// The checkpoint must be sealed by an authorized signer, which is recorded
+	// as the most recent one
+	sealer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[sealer]; !ok {
+		return errUnauthorizedSigner
+	}
+	limit := uint64(len(snap.Signers)/2 + 1)
+	for seen := range snap.Recents {
+		if seen > number || number-seen >= limit {
+			return errInvalidCheckpointRecents
+		}
+	}
+	if snap.Recents[number] != sealer {
+		return errInvalidCheckpointRecents
+	}
+	snap.config = c.config
+	snap.sigcache = c.signatures
+
+	if err := snap.store(c.db); err != nil {
+		return err
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	log.Info("Imported clique checkpoint", "number", number, "hash", snap.Hash, "signers", len(snap.Signers))
+	return nil
+}
@@ -169,6 +169,18 @@ func ecrecover(header *types.Header, sigcache *sigLRU) (common.Address, error) {
 	return signer, nil
 }
 
+// proposal is an authorization vote the signer casts in the blocks it seals,
+// until the vote passes, the proposal is discarded or it expires.
+type proposal struct {
+	authorize bool   // Whether to authorize or deauthorize the voted account
+	expiry    uint64 // Last block to vote in, 0 if the proposal never expires
+}
+
+// expired returns whether the proposal can't be voted on in the given block.
+func (p proposal) expired(number uint64) bool {
+	return p.expiry != 0 && number > p.expiry
+}
+
 // Clique is the proof-of-authority consensus engine proposed to support the
 // Ethereum testnet following the Ropsten attacks.
 type Clique struct {
@@ -178,7 +190,7 @@ type Clique struct {
 	recents    *lru.Cache[common.Hash, *Snapshot] // Snapshots for recent block to speed up reorgs
 	signatures *sigLRU                            // Signatures of recent blocks to speed up mining
 
-	proposals map[common.Address]bool // Current list of proposals we are pushing
+	proposals map[common.Address]proposal // Current list of proposals we are pushing
 
 	signer common.Address // Ethereum address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
@@ -205,7 +217,7 @@ func New(config *ctypes.CliqueConfig, db ethdb.Database) *Clique {
 		db:         db,
 		recents:    recents,
 		signatures: signatures,
-		proposals:  make(map[common.Address]bool),
+		proposals:  make(map[common.Address]proposal),
 	}
 }
 
@@ -395,8 +407,9 @@ func (c *Clique) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 			snap = s
 			break
 		}
-		// If an on-disk checkpoint snapshot can be found, use that
-		if number%checkpointInterval == 0 {
+		// If an on-disk checkpoint snapshot can be found, use that. Epoch checkpoints
+		// might have been imported from another node.
+		if number%checkpointInterval == 0 || number%c.config.Epoch == 0 {
 			if s, err := loadSnapshot(c.config, c.signatures, c.db, hash); err == nil {
 				log.Trace("Loaded voting snapshot from disk", "number", number, "hash", hash)
 				snap = s
@@ -524,19 +537,24 @@ func (c *Clique) Prepare(chain consensus.ChainHeaderReader, header *types.Header
 	if err != nil {
 		return err
 	}
-	c.lock.RLock()
+	c.lock.Lock()
 	if number%c.config.Epoch != 0 {
-		// Gather all the proposals that make sense voting on
+		// Gather all the proposals that make sense voting on, dropping expired ones
 		addresses := make([]common.Address, 0, len(c.proposals))
-		for address, authorize := range c.proposals {
-			if snap.validVote(address, authorize) {
+		for address, proposal := range c.proposals {
+			if proposal.expired(number) {
+				log.Info("Dropping expired clique proposal", "address", address, "authorize", proposal.authorize, "expiry", proposal.expiry)
+				delete(c.proposals, address)
+				continue
+			}
+			if snap.validVote(address, proposal.authorize) {
 				addresses = append(addresses, address)
 			}
 		}
 		// If there's pending proposals, cast a vote on them
 		if len(addresses) > 0 {
 			header.Coinbase = addresses[rand.Intn(len(addresses))]
-			if c.proposals[header.Coinbase] {
+			if c.proposals[header.Coinbase].authorize {
 				copy(header.Nonce[:], nonceAuthVote)
 			} else {
 				copy(header.Nonce[:], nonceDropVote)
@@ -546,7 +564,7 @@ func (c *Clique) Prepare(chain consensus.ChainHeaderReader, header *types.Header
 
 	// Copy signer protected by mutex to avoid race condition
 	signer := c.signer
-	c.lock.RUnlock()
+	c.lock.Unlock()
 
 	// Set the correct difficulty
 	header.Difficulty = calcDifficulty(snap, signer)
@@ -312,6 +312,12 @@ func (s *Snapshot) signers() []common.Address {
 	return sigs
 }
 
+// inturnSigner returns the signer whose turn it is to seal the given block.
+func (s *Snapshot) inturnSigner(number uint64) common.Address {
+	signers := s.signers()
+	return signers[number%uint64(len(signers))]
+}
+
 // inturn returns if a signer at a given block height is in-turn or not.
 func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
 	signers, offset := s.signers(), 0
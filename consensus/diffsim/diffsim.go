@@ -0,0 +1,277 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package diffsim simulates the difficulty adjustment of proof-of-work chain
+// configurations, using the same difficulty calculation as the consensus
+// engines, for a given network hashrate.
+package diffsim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/consensus/lyra2"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+const (
+	// maxUncles is the maximum number of uncles a block can include.
+	maxUncles = 2
+
+	// adjustmentWindow is the block time in seconds after which none of the
+	// supported difficulty algorithms adjusts the difficulty any further (EIP-2
+	// clamps at a time delta of 1000 seconds, the others earlier). Beyond it the
+	// difficulty is treated as constant instead of recalculated per second.
+	adjustmentWindow = 1000
+)
+
+var errNoBlocks = errors.New("no blocks to simulate")
+
+// DifficultyFunc calculates the difficulty of a block given its timestamp and
+// its parent, like the CalcDifficulty functions of the consensus engines.
+type DifficultyFunc func(config ctypes.ChainConfigurator, time uint64, parent *types.Header) *big.Int
+
+// DifficultyCalculator returns the difficulty calculation of the consensus
+// engine of the chain configuration.
+func DifficultyCalculator(config ctypes.ChainConfigurator) (DifficultyFunc, error) {
+	switch engine := config.GetConsensusEngineType(); engine {
+	case ctypes.ConsensusEngineT_Ethash:
+		return ethash.CalcDifficulty, nil
+	case ctypes.ConsensusEngineT_Lyra2:
+		return lyra2.CalcDifficulty, nil
+	default:
+		return nil, fmt.Errorf("unsupported consensus engine: %v", engine)
+	}
+}
+
+// Config is the configuration of a simulation.
+type Config struct {
+	Chain      ctypes.ChainConfigurator // Chain configuration to simulate
+	Difficulty DifficultyFunc           // Difficulty calculation, defaults to the one of the consensus engine
+
+	Number      uint64   // Number of the block the simulation starts from
+	Time        uint64   // Timestamp of the block the simulation starts from
+	StartDiff   *big.Int // Difficulty of the block the simulation starts from
+	Blocks      uint64   // Number of blocks to simulate
+	Hashrate    Curve    // Network hashrate in hashes per second
+	Propagation float64  // Block propagation delay in seconds, determining the uncle rate
+	Seed        int64    // Seed of the random source, simulations are reproducible
+}
+
+// Sample is a simulated block.
+type Sample struct {
+	Number     uint64   `json:"number"`
+	Time       uint64   `json:"timestamp"`
+	BlockTime  float64  `json:"blockTime"` // Seconds it took to mine the block
+	Difficulty *big.Int `json:"difficulty"`
+	Hashrate   float64  `json:"hashrate"` // Network hashrate while mining the block
+	Uncles     int      `json:"uncles"`   // Number of uncles included in the block
+}
+
+// Simulate mines the configured number of blocks on top of the start block and
+// returns them. Block times are drawn from the exponential distribution given
+// by the difficulty and hashrate. As the difficulty depends on the timestamp,
+// miners are assumed to update the timestamp of their work every second.
+//
+// Blocks found by other miners within the propagation delay of a block become
+// uncles, which are included by its child.
+func Simulate(cfg Config) ([]Sample, error) {
+	if cfg.Blocks == 0 {
+		return nil, errNoBlocks
+	}
+	if cfg.StartDiff == nil || cfg.StartDiff.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid start difficulty: %v", cfg.StartDiff)
+	}
+	if cfg.Hashrate == nil {
+		return nil, errors.New("no hashrate given")
+	}
+	calc := cfg.Difficulty
+	if calc == nil {
+		var err error
+		if calc, err = DifficultyCalculator(cfg.Chain); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		rng     = rand.New(rand.NewSource(cfg.Seed))
+		samples = make([]Sample, 0, cfg.Blocks)
+		clock   = float64(cfg.Time)
+		uncles  int
+		parent  = &types.Header{
+			Number:     new(big.Int).SetUint64(cfg.Number),
+			Time:       cfg.Time,
+			Difficulty: new(big.Int).Set(cfg.StartDiff),
+			UncleHash:  types.EmptyUncleHash,
+		}
+	)
+	for i := uint64(0); i < cfg.Blocks; i++ {
+		hashrate := cfg.Hashrate(i)
+		if hashrate <= 0 || math.IsNaN(hashrate) || math.IsInf(hashrate, 0) {
+			return nil, fmt.Errorf("invalid hashrate at block %d: %v", cfg.Number+i+1, hashrate)
+		}
+		start := clock
+
+		// Consume an exponentially distributed amount of work, one timestamp at a time
+		var (
+			work = rng.ExpFloat64()
+			time uint64
+			diff *big.Int
+		)
+		for {
+			time = uint64(clock)
+			if time <= parent.Time {
+				time = parent.Time + 1
+			}
+			diff = calc(cfg.Chain, time, parent)
+			rate := hashrate / bigToFloat(diff)
+
+			if time-parent.Time >= adjustmentWindow {
+				// The difficulty doesn't change any more, finish the work at
+				// once and stamp the block with the time it was found
+				clock += work / rate
+				time = max(uint64(clock), parent.Time+1)
+				break
+			}
+			next := math.Floor(clock) + 1
+			if avail := (next - clock) * rate; avail >= work {
+				clock += work / rate
+				break
+			} else {
+				work -= avail
+			}
+			clock = next
+		}
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(parent.Number.Uint64() + 1),
+			Time:       time,
+			Difficulty: diff,
+			UncleHash:  types.EmptyUncleHash,
+		}
+		if uncles > 0 {
+			header.UncleHash = uncleHash(uncles)
+		}
+		samples = append(samples, Sample{
+			Number:     header.Number.Uint64(),
+			Time:       header.Time,
+			BlockTime:  clock - start,
+			Difficulty: diff,
+			Hashrate:   hashrate,
+			Uncles:     uncles,
+		})
+		// Blocks mined by others while this one propagates become uncles of the next
+		uncles = poisson(rng, cfg.Propagation*hashrate/bigToFloat(diff), maxUncles)
+		parent = header
+	}
+	return samples, nil
+}
+
+// Window aggregates consecutive simulated blocks.
+type Window struct {
+	From       uint64   `json:"from"`
+	To         uint64   `json:"to"`
+	Time       uint64   `json:"timestamp"`  // Timestamp of the last block
+	BlockTime  float64  `json:"blockTime"`  // Mean block time in seconds
+	Difficulty *big.Int `json:"difficulty"` // Difficulty of the last block
+	Hashrate   float64  `json:"hashrate"`   // Mean network hashrate
+	UncleRate  float64  `json:"uncleRate"`  // Uncles per block
+}
+
+// Aggregate groups the samples into windows of the given number of blocks. The
+// last window may be smaller.
+func Aggregate(samples []Sample, size int) []Window {
+	if size < 1 {
+		size = 1
+	}
+	windows := make([]Window, 0, (len(samples)+size-1)/size)
+	for start := 0; start < len(samples); start += size {
+		end := start + size
+		if end > len(samples) {
+			end = len(samples)
+		}
+		windows = append(windows, summarize(samples[start:end]))
+	}
+	return windows
+}
+
+// Summarize aggregates all samples into a single window.
+func Summarize(samples []Sample) Window {
+	if len(samples) == 0 {
+		return Window{}
+	}
+	return summarize(samples)
+}
+
+func summarize(samples []Sample) Window {
+	var (
+		last   = samples[len(samples)-1]
+		window = Window{
+			From:       samples[0].Number,
+			To:         last.Number,
+			Time:       last.Time,
+			Difficulty: last.Difficulty,
+		}
+		uncles int
+	)
+	for _, sample := range samples {
+		window.BlockTime += sample.BlockTime
+		window.Hashrate += sample.Hashrate
+		uncles += sample.Uncles
+	}
+	n := float64(len(samples))
+	window.BlockTime /= n
+	window.Hashrate /= n
+	window.UncleRate = float64(uncles) / n
+	return window
+}
+
+// uncleHash returns the uncle hash of a block including the given number of
+// uncles. Only the emptiness of the uncle list matters to the difficulty.
+func uncleHash(uncles int) common.Hash {
+	headers := make([]*types.Header, uncles)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i))}
+	}
+	return types.CalcUncleHash(headers)
+}
+
+// poisson draws from the Poisson distribution with the given mean, capping the
+// result at max.
+func poisson(rng *rand.Rand, mean float64, max int) int {
+	if mean <= 0 {
+		return 0
+	}
+	var (
+		limit = math.Exp(-mean)
+		p     = rng.Float64()
+		k     int
+	)
+	for p > limit && k < max {
+		k++
+		p *= rng.Float64()
+	}
+	return k
+}
+
+func bigToFloat(x *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(x).Float64()
+	return f
+}
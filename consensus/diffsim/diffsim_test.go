@@ -0,0 +1,191 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package diffsim
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/shudolab/core-geth/params"
+)
+
+func TestSimulateClassic(t *testing.T) {
+	hashrate := 150e12
+	cfg := Config{
+		Chain:       params.ClassicChainConfig,
+		Number:      15_000_000,
+		Time:        1_650_000_000,
+		StartDiff:   big.NewInt(int64(hashrate * 20)),
+		Blocks:      5000,
+		Hashrate:    Constant(hashrate),
+		Propagation: 1,
+		Seed:        1,
+	}
+	samples, err := Simulate(cfg)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if len(samples) != int(cfg.Blocks) {
+		t.Fatalf("sample count mismatch: have %d, want %d", len(samples), cfg.Blocks)
+	}
+	for i, sample := range samples {
+		if want := cfg.Number + uint64(i) + 1; sample.Number != want {
+			t.Fatalf("sample %d: number mismatch: have %d, want %d", i, sample.Number, want)
+		}
+		if i > 0 && sample.Time <= samples[i-1].Time {
+			t.Fatalf("sample %d: timestamp %d not after parent %d", i, sample.Time, samples[i-1].Time)
+		}
+	}
+	// The difficulty starts too high, blocks must speed up as it adjusts
+	windows := Aggregate(samples, 1000)
+	first, last := windows[0], windows[len(windows)-1]
+	t.Logf("first window: %+v", first)
+	t.Logf("last window: %+v", last)
+
+	if first.BlockTime <= last.BlockTime {
+		t.Errorf("block time not adjusted: first %.2fs, last %.2fs", first.BlockTime, last.BlockTime)
+	}
+	if last.BlockTime < 11 || last.BlockTime > 16 {
+		t.Errorf("block time out of range: %.2fs", last.BlockTime)
+	}
+	if rate := Summarize(samples).UncleRate; rate <= 0 || rate > 0.2 {
+		t.Errorf("uncle rate out of range: %.3f", rate)
+	}
+	// Simulations are reproducible with the same seed
+	again, err := Simulate(cfg)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if !reflect.DeepEqual(samples, again) {
+		t.Error("simulation not reproducible")
+	}
+}
+
+func TestSimulateNoUncles(t *testing.T) {
+	samples, err := Simulate(Config{
+		Chain:     params.MordorChainConfig,
+		StartDiff: big.NewInt(1_000_000),
+		Blocks:    500,
+		Hashrate:  Constant(100_000),
+	})
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if rate := Summarize(samples).UncleRate; rate != 0 {
+		t.Errorf("uncles without propagation delay: rate %.3f", rate)
+	}
+}
+
+// Tests that blocks taking longer than the difficulty adjustment window after a
+// hashrate drop are stamped with the time they were found.
+func TestSimulateHashrateDrop(t *testing.T) {
+	hashrate := 150e12
+	cfg := Config{
+		Chain:     params.ClassicChainConfig,
+		Number:    15_000_000,
+		Time:      1_650_000_000,
+		StartDiff: big.NewInt(int64(hashrate * 13)),
+		Blocks:    300,
+		Hashrate:  Piecewise(Point{100, hashrate}, Point{101, hashrate / 500}),
+		Seed:      1,
+	}
+	samples, err := Simulate(cfg)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	var (
+		elapsed float64
+		long    int
+	)
+	for i, sample := range samples {
+		elapsed += sample.BlockTime
+		if sample.BlockTime > adjustmentWindow {
+			long++
+		}
+		// Timestamps may run ahead of the clock, but never behind it
+		if want := cfg.Time + uint64(elapsed); sample.Time+1 < want {
+			t.Fatalf("sample %d: timestamp %d behind clock %d", i, sample.Time, want)
+		}
+		if i > 0 && sample.Time <= samples[i-1].Time {
+			t.Fatalf("sample %d: timestamp %d not after parent %d", i, sample.Time, samples[i-1].Time)
+		}
+	}
+	if long == 0 {
+		t.Fatal("no block took longer than the adjustment window")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	samples := []Sample{
+		{Number: 1, Time: 10, BlockTime: 10, Difficulty: big.NewInt(1), Hashrate: 1, Uncles: 0},
+		{Number: 2, Time: 30, BlockTime: 20, Difficulty: big.NewInt(2), Hashrate: 3, Uncles: 2},
+		{Number: 3, Time: 36, BlockTime: 6, Difficulty: big.NewInt(3), Hashrate: 5, Uncles: 1},
+	}
+	want := []Window{
+		{From: 1, To: 2, Time: 30, BlockTime: 15, Difficulty: big.NewInt(2), Hashrate: 2, UncleRate: 1},
+		{From: 3, To: 3, Time: 36, BlockTime: 6, Difficulty: big.NewInt(3), Hashrate: 5, UncleRate: 1},
+	}
+	if have := Aggregate(samples, 2); !reflect.DeepEqual(have, want) {
+		t.Errorf("windows mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
+func TestParseCurve(t *testing.T) {
+	tests := []struct {
+		spec string
+		at   map[uint64]float64
+		err  bool
+	}{
+		{spec: "150T", at: map[uint64]float64{0: 150e12, 1000: 150e12}},
+		{spec: "1.5e6", at: map[uint64]float64{7: 1.5e6}},
+		{spec: "100:2k,0:1k", at: map[uint64]float64{0: 1e3, 50: 1.5e3, 100: 2e3, 500: 2e3}},
+		{spec: "10:1M,20:3M", at: map[uint64]float64{0: 1e6, 15: 2e6, 30: 3e6}},
+		{spec: "", err: true},
+		{spec: "-1", err: true},
+		{spec: "5X", err: true},
+		{spec: "0:1k,0:2k", err: true},
+		{spec: "0:1k,x", err: true},
+	}
+	for _, tt := range tests {
+		curve, err := ParseCurve(tt.spec)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		for block, want := range tt.at {
+			if have := curve(block); have != want {
+				t.Errorf("%q: hashrate at %d mismatch: have %v, want %v", tt.spec, block, have, want)
+			}
+		}
+	}
+}
+
+func TestPiecewiseEmpty(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for curve without points")
+		}
+	}()
+	Piecewise()
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package diffsim
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Curve returns the network hashrate in hashes per second while mining the
+// i-th simulated block, counting from zero.
+type Curve func(i uint64) float64
+
+// Constant returns a curve of a constant hashrate.
+func Constant(hashrate float64) Curve {
+	return func(uint64) float64 { return hashrate }
+}
+
+// Point is a hashrate at a simulated block.
+type Point struct {
+	Block    uint64
+	Hashrate float64
+}
+
+// Piecewise returns a curve interpolating linearly between the given points.
+// Before the first and after the last point the hashrate is constant. It panics
+// if no points are given.
+func Piecewise(points ...Point) Curve {
+	if len(points) == 0 {
+		panic("diffsim: piecewise hashrate curve without points")
+	}
+	points = append([]Point(nil), points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].Block < points[j].Block })
+
+	return func(i uint64) float64 {
+		n := sort.Search(len(points), func(j int) bool { return points[j].Block > i })
+		switch {
+		case n == 0:
+			return points[0].Hashrate
+		case n == len(points):
+			return points[n-1].Hashrate
+		}
+		a, b := points[n-1], points[n]
+		frac := float64(i-a.Block) / float64(b.Block-a.Block)
+		return a.Hashrate + frac*(b.Hashrate-a.Hashrate)
+	}
+}
+
+// ParseCurve parses a hashrate curve. It is either a single hashrate, or a
+// comma separated list of block:hashrate points interpolated linearly, with
+// blocks counted from the start of the simulation. Hashrates are in hashes per
+// second and may have one of the suffixes k, M, G, T, P or E, for example
+//
+//	150T
+//	0:150T,10000:300T,20000:100T
+func ParseCurve(s string) (Curve, error) {
+	if !strings.Contains(s, ":") {
+		hashrate, err := ParseHashrate(s)
+		if err != nil {
+			return nil, err
+		}
+		return Constant(hashrate), nil
+	}
+	var points []Point
+	for _, field := range strings.Split(s, ",") {
+		block, hashrate, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid hashrate point %q, want block:hashrate", field)
+		}
+		number, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hashrate point %q: %v", field, err)
+		}
+		rate, err := ParseHashrate(hashrate)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range points {
+			if point.Block == number {
+				return nil, fmt.Errorf("duplicate hashrate point for block %d", number)
+			}
+		}
+		points = append(points, Point{Block: number, Hashrate: rate})
+	}
+	return Piecewise(points...), nil
+}
+
+var hashrateSuffixes = map[byte]float64{
+	'k': 1e3,
+	'K': 1e3,
+	'M': 1e6,
+	'G': 1e9,
+	'T': 1e12,
+	'P': 1e15,
+	'E': 1e18,
+}
+
+// ParseHashrate parses a positive hashrate in hashes per second, with an
+// optional SI suffix.
+func ParseHashrate(s string) (float64, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "H/s")
+	if s == "" {
+		return 0, errors.New("empty hashrate")
+	}
+	multiplier := 1.0
+	if m, ok := hashrateSuffixes[s[len(s)-1]]; ok {
+		multiplier, s = m, s[:len(s)-1]
+	}
+	hashrate, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hashrate: %v", err)
+	}
+	if hashrate <= 0 {
+		return 0, fmt.Errorf("invalid hashrate: %v", hashrate)
+	}
+	return hashrate * multiplier, nil
+}
@@ -565,8 +565,13 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 		digest []byte
 		result []byte
 	)
+	// If the proof-of-work function was replaced for this block, no DAG is needed
+	hashed := ethash.usesHasher(number)
+	if hashed {
+		digest, result = ethash.hasher(ethash.SealHash(header).Bytes(), header.Nonce.Uint64())
+	}
 	// If fast-but-heavy PoW verification was requested, use an ethash dataset
-	if fulldag {
+	if fulldag && !hashed {
 		dataset := ethash.dataset(number, true)
 		if dataset.generated() {
 			digest, result = hashimotoFull(dataset.dataset, ethash.SealHash(header).Bytes(), header.Nonce.Uint64())
@@ -580,7 +585,7 @@ func (ethash *Ethash) verifySeal(chain consensus.ChainHeaderReader, header *type
 		}
 	}
 	// If slow-but-light PoW verification was requested (or DAG not yet ready), use an ethash cache
-	if !fulldag {
+	if !fulldag && !hashed {
 		cache := ethash.cache(number)
 		epochLength := calcEpochLength(number, ethash.config.ECIP1099Block)
 		epoch := calcEpoch(number, epochLength)
@@ -20,7 +20,7 @@ package ethash
 import (
 	"encoding/binary"
 	"errors"
-	"fmt"
+	"hash/crc32"
 	"math"
 	"math/big"
 	"math/rand"
@@ -149,7 +149,7 @@ func memoryMapFile(file *os.File, write bool) (mmap.MMap, []uint32, error) {
 
 // memoryMapAndGenerate tries to memory map a temporary file of uint32s for write
 // access, fill it with the data from a generator and then move it into the final
-// path requested.
+// path requested, next to the checksum of its content.
 func memoryMapAndGenerate(path string, size uint64, lock bool, generator func(buffer []uint32)) (*os.File, mmap.MMap, []uint32, error) {
 	// Ensure the data folder exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
@@ -178,6 +178,7 @@ func memoryMapAndGenerate(path string, size uint64, lock bool, generator func(bu
 
 	data := buffer[len(dumpMagic):]
 	generator(data)
+	sum := crc32.Checksum(mem, castagnoliTable)
 
 	if err := mem.Unmap(); err != nil {
 		return nil, nil, nil, err
@@ -185,6 +186,10 @@ func memoryMapAndGenerate(path string, size uint64, lock bool, generator func(bu
 	if err := dump.Close(); err != nil {
 		return nil, nil, nil, err
 	}
+	if err := writeChecksum(path, sum); err != nil {
+		os.Remove(temp)
+		return nil, nil, nil, err
+	}
 	if err := os.Rename(temp, path); err != nil {
 		return nil, nil, nil, err
 	}
@@ -318,16 +323,7 @@ func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 			return
 		}
 		// Disk storage is needed, this will get fancy
-		var endian string
-		if !isLittleEndian() {
-			endian = ".be"
-		}
-		// The file path naming scheme was changed to include epoch values in the filename,
-		// which enables a filepath glob with scan to identify out-of-bounds caches and remove them.
-		// The legacy path declaration is provided below as a comment for reference.
-		//
-		// path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))                 // LEGACY
-		path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%d-%x%s", algorithmRevision, c.epoch, seed[:8], endian)) // CURRENT
+		path := storePath(dir, storeKindCache, c.epoch, seed)
 		logger := log.New("epoch", c.epoch, "epochLength", c.epochLength)
 
 		// We're about to mmap the file, ensure that the mapping is cleaned up when the
@@ -336,7 +332,7 @@ func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 
 		// Try to load the file from disk and memory map it
 		var err error
-		c.dump, c.mmap, c.cache, err = memoryMap(path, lock)
+		c.dump, c.mmap, c.cache, err = memoryMapStored(path, storeKindCache, lock)
 		if err == nil {
 			logger.Debug("Loaded old ethash cache from disk")
 			return
@@ -344,122 +340,20 @@ func (c *cache) generate(dir string, limit int, lock bool, test bool) {
 		logger.Debug("Failed to load old ethash cache", "err", err)
 
 		// No usable previous cache available, create a new cache file to fill
-		c.dump, c.mmap, c.cache, err = memoryMapAndGenerate(path, size, lock, func(buffer []uint32) { generateCache(buffer, c.epoch, c.epochLength, seed) })
+		c.dump, c.mmap, c.cache, err = generateLocked(path, storeKindCache, size, lock, logger, func(buffer []uint32) { generateCache(buffer, c.epoch, c.epochLength, seed) })
 		if err != nil {
 			logger.Error("Failed to generate mapped ethash cache", "err", err)
 
 			c.cache = make([]uint32, size/4)
 			generateCache(c.cache, c.epoch, c.epochLength, seed)
 		}
-
-		// Iterate over all cache file instances, deleting any out of bounds (where epoch is below lower limit, or above upper limit).
-		matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("cache-R%d*", algorithmRevision)))
-		for _, file := range matches {
-			var ar int   // algorithm revision
-			var e uint64 // epoch
-			var s string // seed
-			if _, err := fmt.Sscanf(filepath.Base(file), "cache-R%d-%d-%s"+endian, &ar, &e, &s); err != nil {
-				// There is an unrecognized file in this directory.
-				// See if the name matches the expected pattern of the legacy naming scheme.
-				if _, err := fmt.Sscanf(filepath.Base(file), "cache-R%d-%s"+endian, &ar, &s); err == nil {
-					// This file matches the previous generation naming pattern (sans epoch).
-					if err := os.Remove(file); err != nil {
-						logger.Error("Failed to remove legacy ethash cache file", "file", file, "err", err)
-					} else {
-						logger.Warn("Deleted legacy ethash cache file", "path", file)
-					}
-				}
-				// Else the file is unrecognized (unknown name format), leave it alone.
-				continue
-			}
-			if e <= c.epoch-uint64(limit) || e > c.epoch+1 {
-				if err := os.Remove(file); err == nil {
-					logger.Debug("Deleted ethash cache file", "target.epoch", e, "file", file)
-				} else {
-					logger.Error("Failed to delete ethash cache file", "target.epoch", e, "file", file, "err", err)
-				}
-			}
-		}
+		pruneStore(dir, storeKindCache, c.epoch, c.epochLength, limit, logger)
 	})
 }
 
+// Generate ensures that the cache content is generated before use.
 func (c *Cache) Generate(dir string, limit int, lock bool, test bool) {
-	c.once.Do(func() {
-		size := cacheSize(c.epoch)
-		seed := seedHash(c.epoch, c.epochLength)
-		if test {
-			size = 1024
-		}
-		// If we don't store anything on disk, generate and return.
-		if dir == "" {
-			c.cache = make([]uint32, size/4)
-			generateCache(c.cache, c.epoch, c.epochLength, seed)
-			return
-		}
-		// Disk storage is needed, this will get fancy
-		var endian string
-		if !isLittleEndian() {
-			endian = ".be"
-		}
-		// The file path naming scheme was changed to include epoch values in the filename,
-		// which enables a filepath glob with scan to identify out-of-bounds caches and remove them.
-		// The legacy path declaration is provided below as a comment for reference.
-		//
-		// path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))                 // LEGACY
-		path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%d-%x%s", algorithmRevision, c.epoch, seed[:8], endian)) // CURRENT
-		logger := log.New("epoch", c.epoch, "epochLength", c.epochLength)
-
-		// We're about to mmap the file, ensure that the mapping is cleaned up when the
-		// cache becomes unused.
-		runtime.SetFinalizer(c, (*cache).finalizer)
-
-		// Try to load the file from disk and memory map it
-		var err error
-		c.dump, c.mmap, c.cache, err = memoryMap(path, lock)
-		if err == nil {
-			logger.Debug("Loaded old ethash cache from disk")
-			return
-		}
-		logger.Debug("Failed to load old ethash cache", "err", err)
-
-		// No usable previous cache available, create a new cache file to fill
-		c.dump, c.mmap, c.cache, err = memoryMapAndGenerate(path, size, lock, func(buffer []uint32) { generateCache(buffer, c.epoch, c.epochLength, seed) })
-		if err != nil {
-			logger.Error("Failed to generate mapped ethash cache", "err", err)
-
-			c.cache = make([]uint32, size/4)
-			generateCache(c.cache, c.epoch, c.epochLength, seed)
-		}
-
-		// Iterate over all cache file instances, deleting any out of bounds (where epoch is below lower limit, or above upper limit).
-		matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("cache-R%d*", algorithmRevision)))
-		for _, file := range matches {
-			var ar int   // algorithm revision
-			var e uint64 // epoch
-			var s string // seed
-			if _, err := fmt.Sscanf(filepath.Base(file), "cache-R%d-%d-%s"+endian, &ar, &e, &s); err != nil {
-				// There is an unrecognized file in this directory.
-				// See if the name matches the expected pattern of the legacy naming scheme.
-				if _, err := fmt.Sscanf(filepath.Base(file), "cache-R%d-%s"+endian, &ar, &s); err == nil {
-					// This file matches the previous generation naming pattern (sans epoch).
-					if err := os.Remove(file); err != nil {
-						logger.Error("Failed to remove legacy ethash cache file", "file", file, "err", err)
-					} else {
-						logger.Warn("Deleted legacy ethash cache file", "path", file)
-					}
-				}
-				// Else the file is unrecognized (unknown name format), leave it alone.
-				continue
-			}
-			if e <= c.epoch-uint64(limit) || e > c.epoch+1 {
-				if err := os.Remove(file); err == nil {
-					logger.Debug("Deleted ethash cache file", "target.epoch", e, "file", file)
-				} else {
-					logger.Error("Failed to delete ethash cache file", "target.epoch", e, "file", file, "err", err)
-				}
-			}
-		}
-	})
+	(*cache)(c).generate(dir, limit, lock, test)
 }
 
 // finalizer unmaps the memory and closes the file.
@@ -512,12 +406,8 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 			return
 		}
 		// Disk storage is needed, this will get fancy
-		var endian string
-		if !isLittleEndian() {
-			endian = ".be"
-		}
-		path := filepath.Join(dir, fmt.Sprintf("full-R%d-%d-%x%s", algorithmRevision, d.epoch, seed[:8], endian))
-		logger := log.New("epoch", d.epoch)
+		path := storePath(dir, storeKindDataset, d.epoch, seed)
+		logger := log.New("epoch", d.epoch, "epochLength", d.epochLength)
 
 		// We're about to mmap the file, ensure that the mapping is cleaned up when the
 		// cache becomes unused.
@@ -525,7 +415,7 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 
 		// Try to load the file from disk and memory map it
 		var err error
-		d.dump, d.mmap, d.dataset, err = memoryMap(path, lock)
+		d.dump, d.mmap, d.dataset, err = memoryMapStored(path, storeKindDataset, lock)
 		if err == nil {
 			logger.Debug("Loaded old ethash dataset from disk", "path", path)
 			return
@@ -536,42 +426,14 @@ func (d *dataset) generate(dir string, limit int, lock bool, test bool) {
 		cache := make([]uint32, csize/4)
 		generateCache(cache, d.epoch, d.epochLength, seed)
 
-		d.dump, d.mmap, d.dataset, err = memoryMapAndGenerate(path, dsize, lock, func(buffer []uint32) { generateDataset(buffer, d.epoch, d.epochLength, cache) })
+		d.dump, d.mmap, d.dataset, err = generateLocked(path, storeKindDataset, dsize, lock, logger, func(buffer []uint32) { generateDataset(buffer, d.epoch, d.epochLength, cache) })
 		if err != nil {
 			logger.Error("Failed to generate mapped ethash dataset", "err", err)
 
 			d.dataset = make([]uint32, dsize/4)
 			generateDataset(d.dataset, d.epoch, d.epochLength, cache)
 		}
-
-		// Iterate over all full file instances, deleting any out of bounds (where epoch is below lower limit, or above upper limit).
-		matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("full-R%d*", algorithmRevision)))
-		for _, file := range matches {
-			var ar int   // algorithm revision
-			var e uint64 // epoch
-			var s string // seed
-			if _, err := fmt.Sscanf(filepath.Base(file), "full-R%d-%d-%s"+endian, &ar, &e, &s); err != nil {
-				// There is an unrecognized file in this directory.
-				// See if the name matches the expected pattern of the legacy naming scheme.
-				if _, err := fmt.Sscanf(filepath.Base(file), "full-R%d-%s"+endian, &ar, &s); err == nil {
-					// This file matches the previous generation naming pattern (sans epoch).
-					if err := os.Remove(file); err != nil {
-						logger.Error("Failed to remove legacy ethash full file", "file", file, "err", err)
-					} else {
-						logger.Warn("Deleted legacy ethash full file", "path", file)
-					}
-				}
-				// Else the file is unrecognized (unknown name format), leave it alone.
-				continue
-			}
-			if e <= d.epoch-uint64(limit) || e > d.epoch+1 {
-				if err := os.Remove(file); err == nil {
-					logger.Debug("Deleted ethash full file", "target.epoch", e, "file", file)
-				} else {
-					logger.Error("Failed to delete ethash full file", "target.epoch", e, "file", file, "err", err)
-				}
-			}
-		}
+		pruneStore(dir, storeKindDataset, d.epoch, d.epochLength, limit, logger)
 	})
 }
 
@@ -654,8 +516,15 @@ type Config struct {
 	Log log.Logger `toml:"-"`
 	// ECIP-1099
 	ECIP1099Block *uint64 `toml:"-"`
+	// ECIP-1049, the block from which the hasher of NewWithHasher replaces hashimoto
+	ECIP1049Block *uint64 `toml:"-"`
 }
 
+// Hasher is a proof-of-work function replacing hashimoto, returning the mix
+// digest and the result to check against the difficulty target for the given
+// seal hash and nonce.
+type Hasher func(sealHash []byte, nonce uint64) (digest []byte, result []byte)
+
 // Ethash is a consensus engine based on proof-of-work implementing the ethash
 // algorithm.
 type Ethash struct {
@@ -670,6 +539,7 @@ type Ethash struct {
 	update   chan struct{} // Notification channel to update mining parameters
 	hashrate metrics.Meter // Meter tracking the average hashrate
 	remote   *remoteSealer
+	hasher   Hasher // Proof-of-work function replacing hashimoto from ECIP1049Block, if set
 
 	// The fields below are hooks for testing
 	shared    *Ethash       // Shared PoW verifier to avoid cache regeneration
@@ -711,6 +581,16 @@ func New(config Config, notify []string, noverify bool) *Ethash {
 	return ethash
 }
 
+// NewWithHasher creates an ethash PoW scheme like New, which seals and verifies
+// the blocks from config.ECIP1049Block on, or all blocks if it isn't set, with
+// the given proof-of-work function instead of hashimoto. All of the other
+// consensus rules are the ethash ones.
+func NewWithHasher(config Config, hasher Hasher, notify []string, noverify bool) *Ethash {
+	ethash := New(config, notify, noverify)
+	ethash.hasher = hasher
+	return ethash
+}
+
 // NewTester creates a small sized ethash PoW scheme useful only for testing
 // purposes.
 func NewTester(notify []string, noverify bool) *Ethash {
@@ -802,6 +682,15 @@ func (ethash *Ethash) StopRemoteSealer() error {
 	return nil
 }
 
+// usesHasher reports whether the block is sealed with the hasher instead of
+// hashimoto.
+func (ethash *Ethash) usesHasher(block uint64) bool {
+	if ethash.hasher == nil {
+		return false
+	}
+	return ethash.config.ECIP1049Block == nil || block >= *ethash.config.ECIP1049Block
+}
+
 // cache tries to retrieve a verification cache for the specified block number
 // by first checking against a list of in-memory caches, then against caches
 // stored on disk, and finally generating one if none can be found.
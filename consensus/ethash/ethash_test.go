@@ -166,13 +166,20 @@ func TestEthashCaches(t *testing.T) {
 		}
 
 		entries, _ := os.ReadDir(conf.CacheDir)
+		// Only count the data files, not their checksum and lock files.
+		files := 0
+		for _, entry := range entries {
+			if storeDataName(entry.Name()) == entry.Name() {
+				files++
+			}
+		}
 		// We add +1 to CachesOnDisk because the future epoch cache is also created and can still
 		// be in-progress generating as a goroutine.
-		if len(entries) > conf.CachesOnDisk+1 {
+		if files > conf.CachesOnDisk+1 {
 			for _, entry := range entries {
 				t.Logf(`  - %s`, entry.Name())
 			}
-			t.Fatalf("Too many cache files: %d", len(entries))
+			t.Fatalf("Too many cache files: %d", files)
 		}
 	}
 }
@@ -321,6 +328,26 @@ func TestTestMode(t *testing.T) {
 	}
 }
 
+// Tests that the hasher replaces hashimoto in the seal verification from the
+// transition block on, and only from there.
+func TestHasherTransition(t *testing.T) {
+	// The test hasher commits to the seal hash and accepts any nonce.
+	hasher := func(hash []byte, nonce uint64) ([]byte, []byte) {
+		return hash, make([]byte, 32)
+	}
+	transition := uint64(5)
+	ethash := NewWithHasher(Config{PowMode: ModeTest, ECIP1049Block: &transition}, hasher, nil, false)
+	defer ethash.Close()
+
+	for number, want := range map[int64]error{4: errInvalidMixDigest, 5: nil} {
+		header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(100)}
+		header.MixDigest = ethash.SealHash(header)
+		if err := ethash.verifySeal(nil, header, false); err != want {
+			t.Errorf("block %d: have error %v, want %v", number, err, want)
+		}
+	}
+}
+
 // This test checks that cache lru logic doesn't crash under load.
 // It reproduces https://github.com/shudolab/core-geth/issues/14943
 func TestCacheFileEvict(t *testing.T) {
@@ -182,8 +182,15 @@ func (ethash *Ethash) mine(block *types.Block, id int, seed uint64, abort chan s
 		hash    = ethash.SealHash(header).Bytes()
 		target  = new(big.Int).Div(two256, header.Difficulty)
 		number  = header.Number.Uint64()
-		dataset = ethash.dataset(number, false)
+		hasher  = ethash.hasher
+		dataset *dataset
 	)
+	if !ethash.usesHasher(number) {
+		dataset = ethash.dataset(number, false)
+		hasher = func(hash []byte, nonce uint64) ([]byte, []byte) {
+			return hashimotoFull(dataset.dataset, hash, nonce)
+		}
+	}
 	// Start generating random nonces until we abort or find a good one
 	var (
 		attempts  = int64(0)
@@ -209,7 +216,7 @@ search:
 				attempts = 0
 			}
 			// Compute the PoW value of this nonce
-			digest, result := hashimotoFull(dataset.dataset, hash, nonce)
+			digest, result := hasher(hash, nonce)
 			if powBuffer.SetBytes(result).Cmp(target) <= 0 {
 				// Correct nonce found, create a new header with it
 				header = types.CopyHeader(header)
@@ -387,7 +394,7 @@ func (s *remoteSealer) loop() {
 // The work package consists of 3 strings:
 //
 //	result[0], 32 bytes hex encoded current block header pow-hash
-//	result[1], 32 bytes hex encoded seed hash used for DAG
+//	result[1], 32 bytes hex encoded seed hash used for DAG, zero if no DAG is used
 //	result[2], 32 bytes hex encoded boundary condition ("target"), 2^256/difficulty
 //	result[3], hex encoded block number
 func (s *remoteSealer) makeWork(block *types.Block) {
@@ -396,6 +403,9 @@ func (s *remoteSealer) makeWork(block *types.Block) {
 	epoch := calcEpoch(block.NumberU64(), epochLength)
 	s.currentWork[0] = hash.Hex()
 	s.currentWork[1] = common.BytesToHash(SeedHash(epoch, epochLength)).Hex()
+	if s.ethash.usesHasher(block.NumberU64()) {
+		s.currentWork[1] = common.Hash{}.Hex()
+	}
 	s.currentWork[2] = common.BytesToHash(new(big.Int).Div(two256, block.Difficulty()).Bytes()).Hex()
 	s.currentWork[3] = hexutil.EncodeBig(block.Number())
 
@@ -0,0 +1,482 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/gofrs/flock"
+	"github.com/shudolab/core-geth/log"
+)
+
+// The cache and dataset directories may be shared by any number of processes
+// running on the same host. Next to every data file the store keeps up to three
+// sidecar files:
+//
+//   - <file>.sum holds the CRC-32 (Castagnoli) checksum of the data file, written
+//     by the generator before the data file is moved into place. Caches are
+//     checked against it every time they are loaded. Files without a checksum,
+//     e.g. generated by older versions, can't be trusted and are regenerated.
+//   - <file>.verified records the checksum, size and modification time of a
+//     dataset which matched its checksum, so that the multi-gigabyte file is
+//     only read in full once, under the file lock, rather than on every load.
+//   - <file>.lock is an advisory file lock held while the file is generated,
+//     verified or deleted, so that processes wait for each other instead of
+//     generating the same multi-gigabyte dataset concurrently. Lock files are
+//     never deleted: unlinking one while another process waits on it would let
+//     a third one lock a fresh file at the same path.
+
+const (
+	storeKindCache   = "cache"
+	storeKindDataset = "full"
+
+	checksumSuffix = ".sum"
+	verifiedSuffix = ".verified"
+	lockSuffix     = ".lock"
+)
+
+var (
+	errMissingChecksum  = errors.New("missing checksum")
+	errChecksumMismatch = errors.New("checksum mismatch")
+
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// storeEpochLengths are the epoch lengths the store can tell apart by the
+	// seed included in the file names.
+	storeEpochLengths = []uint64{epochLengthDefault, epochLengthECIP1099}
+)
+
+// endianSuffix returns the file name suffix of data files generated on this
+// platform.
+func endianSuffix() string {
+	if !isLittleEndian() {
+		return ".be"
+	}
+	return ""
+}
+
+// storePath returns the path of the data file of the given kind and epoch.
+//
+// The file path naming scheme was changed to include epoch values in the filename,
+// which enables a filepath glob with scan to identify out-of-bounds files and remove them.
+// The legacy naming scheme, which is still recognized for removal, was
+//
+//	<kind>-R<revision>-<seed><endian>
+func storePath(dir string, kind string, epoch uint64, seed []byte) string {
+	return filepath.Join(dir, fmt.Sprintf("%s-R%d-%d-%x%s", kind, algorithmRevision, epoch, seed[:8], endianSuffix()))
+}
+
+// storeDataName strips the sidecar and temporary file suffixes from a file
+// name in the store, returning the name of the data file it belongs to.
+func storeDataName(name string) string {
+	// Temporary files are named <file>.<random number> during generation, and
+	// <file>.sum.<random number> or <file>.verified.<random number> while
+	// writing a sidecar.
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		if _, err := strconv.ParseUint(name[i+1:], 10, 64); err == nil {
+			name = name[:i]
+		}
+	}
+	if base, ok := strings.CutSuffix(name, checksumSuffix); ok {
+		return base
+	}
+	if base, ok := strings.CutSuffix(name, verifiedSuffix); ok {
+		return base
+	}
+	if base, ok := strings.CutSuffix(name, lockSuffix); ok {
+		return base
+	}
+	return name
+}
+
+// parseStoreName parses the name of a data file of the given kind. Legacy
+// reports whether the name follows the legacy naming scheme without an epoch.
+func parseStoreName(kind string, name string) (epoch uint64, seed string, legacy bool, ok bool) {
+	rest, found := strings.CutPrefix(name, fmt.Sprintf("%s-R%d-", kind, algorithmRevision))
+	if !found {
+		return 0, "", false, false
+	}
+	if rest, found = strings.CutSuffix(rest, endianSuffix()); !found {
+		return 0, "", false, false
+	}
+	parts := strings.Split(rest, "-")
+	seed = parts[len(parts)-1]
+	if len(seed) != 16 {
+		return 0, "", false, false
+	}
+	if _, err := hex.DecodeString(seed); err != nil {
+		return 0, "", false, false
+	}
+	switch len(parts) {
+	case 1:
+		return 0, seed, true, true
+	case 2:
+		epoch, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return 0, "", false, false
+		}
+		return epoch, seed, false, true
+	}
+	return 0, "", false, false
+}
+
+// storeEpochLength returns the epoch length the seed of a file name belongs
+// to, or 0 if it matches none. The first epoch is the same for all lengths.
+func storeEpochLength(epoch uint64, seed string) uint64 {
+	for _, epochLength := range storeEpochLengths {
+		if fmt.Sprintf("%x", seedHash(epoch, epochLength)[:8]) == seed {
+			return epochLength
+		}
+	}
+	return 0
+}
+
+// writeSidecar atomically writes a sidecar file of a data file.
+func writeSidecar(path string, suffix string, content string) error {
+	temp := path + suffix + "." + strconv.Itoa(rand.Int())
+	if err := os.WriteFile(temp, []byte(content), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, path+suffix); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+// writeChecksum atomically writes the checksum sidecar of a data file.
+func writeChecksum(path string, sum uint32) error {
+	return writeSidecar(path, checksumSuffix, fmt.Sprintf("%08x\n", sum))
+}
+
+// readChecksum reads the checksum sidecar of a data file.
+func readChecksum(path string) (uint32, error) {
+	blob, err := os.ReadFile(path + checksumSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, errMissingChecksum
+	} else if err != nil {
+		return 0, err
+	}
+	sum, err := strconv.ParseUint(strings.TrimSpace(string(blob)), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checksum file: %v", err)
+	}
+	return uint32(sum), nil
+}
+
+// fileChecksum computes the checksum of a data file without memory mapping it.
+func fileChecksum(path string) (uint32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	hasher := crc32.New(castagnoliTable)
+	if _, err := io.Copy(hasher, file); err != nil {
+		return 0, err
+	}
+	return hasher.Sum32(), nil
+}
+
+// verifyChecksum checks the content of a data file against its checksum
+// sidecar.
+func verifyChecksum(path string) error {
+	want, err := readChecksum(path)
+	if err != nil {
+		return err
+	}
+	have, err := fileChecksum(path)
+	if err != nil {
+		return err
+	}
+	if have != want {
+		return fmt.Errorf("%w: have %08x, want %08x", errChecksumMismatch, have, want)
+	}
+	return nil
+}
+
+// verifiedMarker returns the content of the verified marker of a data file,
+// identifying the file content that matched the given checksum.
+func verifiedMarker(path string, sum uint32) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08x %d %d\n", sum, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// writeVerified records that a data file matches the given checksum.
+func writeVerified(path string, sum uint32) error {
+	marker, err := verifiedMarker(path, sum)
+	if err != nil {
+		return err
+	}
+	return writeSidecar(path, verifiedSuffix, marker)
+}
+
+// isVerified reports whether a data file was verified before and hasn't been
+// replaced or modified since, going by its size and modification time.
+func isVerified(path string) bool {
+	sum, err := readChecksum(path)
+	if err != nil {
+		return false
+	}
+	want, err := verifiedMarker(path, sum)
+	if err != nil {
+		return false
+	}
+	have, err := os.ReadFile(path + verifiedSuffix)
+	return err == nil && string(have) == want
+}
+
+// memoryMapStored memory maps a data file of the store like memoryMap, if it
+// matches its checksum. Caches are verified on every load. Datasets are only
+// loaded if they were verified before, verification is left to loadLocked.
+func memoryMapStored(path string, kind string, lock bool) (*os.File, mmap.MMap, []uint32, error) {
+	switch kind {
+	case storeKindCache:
+		if err := verifyChecksum(path); err != nil {
+			return nil, nil, nil, err
+		}
+	case storeKindDataset:
+		if !isVerified(path) {
+			return nil, nil, nil, errors.New("dataset not verified")
+		}
+	}
+	return memoryMap(path, lock)
+}
+
+// loadLocked memory maps a data file of the store after verifying it against
+// its checksum. It must be called with the file lock held. The verification of
+// datasets is recorded, so that memoryMapStored loads them without reading
+// them in full again.
+func loadLocked(path string, kind string, lock bool) (*os.File, mmap.MMap, []uint32, error) {
+	if err := verifyChecksum(path); err != nil {
+		return nil, nil, nil, err
+	}
+	if kind == storeKindDataset && !isVerified(path) {
+		sum, err := readChecksum(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err := writeVerified(path, sum); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return memoryMap(path, lock)
+}
+
+// generateLocked loads a data file while holding its file lock, generating it
+// unless it exists and matches its checksum. If another process is already
+// generating the same file, it waits for it to finish and maps its result
+// instead.
+func generateLocked(path string, kind string, size uint64, lock bool, logger log.Logger, generator func(buffer []uint32)) (*os.File, mmap.MMap, []uint32, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, nil, err
+	}
+	fileLock := flock.New(path + lockSuffix)
+	locked, err := fileLock.TryLock()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !locked {
+		logger.Info("Waiting for ethash file generation by another process", "path", path)
+		if err := fileLock.Lock(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	defer fileLock.Unlock()
+
+	// Another process might have finished the file while we were waiting, or
+	// the file exists but wasn't verified yet
+	dump, mem, buffer, err := loadLocked(path, kind, lock)
+	if err == nil {
+		logger.Debug("Loaded verified ethash file", "path", path)
+		return dump, mem, buffer, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("Regenerating unverifiable ethash file", "path", path, "err", err)
+	}
+	os.Remove(path + verifiedSuffix)
+	dump, mem, buffer, err = memoryMapAndGenerate(path, size, lock, generator)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if kind == storeKindDataset {
+		// The checksum was computed while generating, no need to read it back
+		if sum, err := readChecksum(path); err == nil {
+			if err := writeVerified(path, sum); err != nil {
+				logger.Warn("Failed to record ethash file verification", "path", path, "err", err)
+			}
+		}
+	}
+	return dump, mem, buffer, nil
+}
+
+// pruneStore deletes the data files of the given kind that are out of bounds
+// (where epoch is below lower limit, or above upper limit), together with their
+// checksums and leftover temporary files. Lock files are left in place. As
+// epoch numbers of different epoch lengths aren't comparable, the bounds are
+// checked on the blocks covered by the files. Files whose seed matches no known
+// epoch length are left alone, as are files which are locked by another
+// process. Files using the legacy naming scheme are always deleted.
+func pruneStore(dir string, kind string, epoch uint64, epochLength uint64, limit int, logger log.Logger) {
+	matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%s-R%d*", kind, algorithmRevision)))
+
+	// Keep the files covering the blocks of the last limit epochs and the next
+	// one. Files of other epoch lengths are compared by the blocks they cover.
+	var lower uint64
+	if epoch >= uint64(limit) {
+		lower = (epoch - uint64(limit) + 1) * epochLength
+	}
+	upper := (epoch + 2) * epochLength
+
+	groups := make(map[string][]string)
+	for _, file := range matches {
+		name := storeDataName(filepath.Base(file))
+		groups[name] = append(groups[name], file)
+	}
+	for name, files := range groups {
+		e, seed, legacy, ok := parseStoreName(kind, name)
+		if !ok {
+			// The file is unrecognized (unknown name format), leave it alone.
+			continue
+		}
+		if legacy {
+			for _, file := range files {
+				if err := os.Remove(file); err != nil {
+					logger.Error("Failed to remove legacy ethash file", "file", file, "err", err)
+				} else {
+					logger.Warn("Deleted legacy ethash file", "path", file)
+				}
+			}
+			continue
+		}
+		fileLength := storeEpochLength(e, seed)
+		if fileLength == 0 {
+			continue
+		}
+		start, end := e*fileLength, (e+1)*fileLength
+		if end > lower && start < upper {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		fileLock := flock.New(path + lockSuffix)
+		if locked, err := fileLock.TryLock(); err != nil || !locked {
+			logger.Debug("Skipping locked ethash file", "target.epoch", e, "file", path, "err", err)
+			continue
+		}
+		for _, file := range files {
+			if file == path+lockSuffix {
+				continue
+			}
+			if err := os.Remove(file); err == nil {
+				logger.Debug("Deleted ethash file", "target.epoch", e, "file", file)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				logger.Error("Failed to delete ethash file", "target.epoch", e, "file", file, "err", err)
+			}
+		}
+		fileLock.Unlock()
+	}
+}
+
+// StoreEntry describes a cache or dataset file in an ethash directory.
+type StoreEntry struct {
+	Kind        string // Either "cache" or "full"
+	Epoch       uint64 // Epoch of the file
+	EpochLength uint64 // Epoch length the seed belongs to, 0 if unknown
+	Path        string // Path of the data file
+	Size        int64  // Size of the data file, 0 while generating
+	Checksum    string // Checksum recorded by the generator, empty if missing
+	Generating  bool   // Whether a process currently holds the file lock
+	Err         error  // Verification error, only set when requested
+}
+
+// ReadStore lists the cache and dataset files in an ethash directory, sorted
+// by kind, epoch length and epoch. If verify is set, the content of every file
+// is checked against its checksum, which reads the entire files.
+func ReadStore(dir string, verify bool) ([]StoreEntry, error) {
+	var entries []StoreEntry
+	for _, kind := range []string{storeKindCache, storeKindDataset} {
+		matches, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%s-R%d*", kind, algorithmRevision)))
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]struct{})
+		for _, file := range matches {
+			names[storeDataName(filepath.Base(file))] = struct{}{}
+		}
+		for name := range names {
+			epoch, seed, legacy, ok := parseStoreName(kind, name)
+			if !ok || legacy {
+				continue
+			}
+			entry := StoreEntry{
+				Kind:        kind,
+				Epoch:       epoch,
+				EpochLength: storeEpochLength(epoch, seed),
+				Path:        filepath.Join(dir, name),
+			}
+			if info, err := os.Stat(entry.Path); err == nil {
+				entry.Size = info.Size()
+			}
+			if sum, err := readChecksum(entry.Path); err == nil {
+				entry.Checksum = fmt.Sprintf("%08x", sum)
+			}
+			if _, err := os.Stat(entry.Path + lockSuffix); err == nil {
+				fileLock := flock.New(entry.Path + lockSuffix)
+				if locked, err := fileLock.TryLock(); err == nil {
+					entry.Generating = !locked
+					if locked {
+						fileLock.Unlock()
+					}
+				}
+			}
+			if entry.Size == 0 && !entry.Generating {
+				// Sidecars without data, or leftovers of an aborted generation
+				continue
+			}
+			if verify && !entry.Generating {
+				entry.Err = verifyChecksum(entry.Path)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.EpochLength != b.EpochLength {
+			return a.EpochLength < b.EpochLength
+		}
+		return a.Epoch < b.Epoch
+	})
+	return entries, nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/shudolab/core-geth/log"
+)
+
+// Tests that generated files are recorded with a checksum, that corruption is
+// detected by verification, and that caches which are corrupt or have no
+// checksum are regenerated rather than loaded.
+func TestStoreChecksum(t *testing.T) {
+	dir := t.TempDir()
+
+	c := newCache(5, epochLengthDefault)
+	c.generate(dir, 10, false, true)
+	want := append([]uint32{}, c.cache...)
+	c.finalizer()
+
+	path := storePath(dir, storeKindCache, 5, seedHash(5, epochLengthDefault))
+	if err := verifyChecksum(path); err != nil {
+		t.Fatalf("generated cache failed verification: %v", err)
+	}
+	// Corrupt the cache and ensure verification catches it
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob[len(blob)-1] ^= 0xff
+	if err := os.WriteFile(path, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksum(path); !errors.Is(err, errChecksumMismatch) {
+		t.Fatalf("corrupt cache error mismatch: have %v, want %v", err, errChecksumMismatch)
+	}
+	load := func() {
+		t.Helper()
+
+		c := newCache(5, epochLengthDefault)
+		c.generate(dir, 10, false, true)
+		defer c.finalizer()
+
+		for i := range want {
+			if c.cache[i] != want[i] {
+				t.Fatalf("loaded cache mismatch at %d: have %x, want %x", i, c.cache[i], want[i])
+			}
+		}
+		if err := verifyChecksum(path); err != nil {
+			t.Fatalf("regenerated cache failed verification: %v", err)
+		}
+	}
+	load()
+
+	// Files without a checksum, e.g. generated by older versions, are not
+	// trusted either, even if their content happens to be correct
+	os.Remove(path + checksumSuffix)
+	if err := verifyChecksum(path); !errors.Is(err, errMissingChecksum) {
+		t.Fatalf("unrecorded cache error mismatch: have %v, want %v", err, errMissingChecksum)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load()
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(before, after) {
+		t.Fatal("cache without checksum loaded")
+	}
+}
+
+// Tests that datasets are verified once and then loaded by their verified
+// marker, and that datasets modified since are verified again.
+func TestStoreVerifiedDataset(t *testing.T) {
+	dir := t.TempDir()
+
+	d := newDataset(0, epochLengthDefault)
+	d.generate(dir, 10, false, true)
+	want := append([]uint32{}, d.dataset...)
+	d.finalizer()
+
+	path := storePath(dir, storeKindDataset, 0, seedHash(0, epochLengthDefault))
+	if !isVerified(path) {
+		t.Fatal("generated dataset not marked verified")
+	}
+	load := func() *dataset {
+		t.Helper()
+
+		d := newDataset(0, epochLengthDefault)
+		d.generate(dir, 10, false, true)
+		for i := range want {
+			if d.dataset[i] != want[i] {
+				t.Fatalf("loaded dataset mismatch at %d: have %x, want %x", i, d.dataset[i], want[i])
+			}
+		}
+		return d
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load().finalizer()
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Fatal("verified dataset regenerated")
+	}
+	// Corrupt the dataset, which changes its modification time, and ensure
+	// it's verified again and regenerated
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob[len(blob)-1] ^= 0xff
+	if err := os.WriteFile(path, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, time.Now(), before.ModTime().Add(time.Second))
+	if isVerified(path) {
+		t.Fatal("modified dataset still marked verified")
+	}
+	load().finalizer()
+
+	if err := verifyChecksum(path); err != nil {
+		t.Fatalf("regenerated dataset failed verification: %v", err)
+	}
+	if !isVerified(path) {
+		t.Fatal("regenerated dataset not marked verified")
+	}
+	// Datasets without a checksum are regenerated and never marked verified
+	// from their unverified content
+	os.Remove(path + checksumSuffix)
+	os.Remove(path + verifiedSuffix)
+	before, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load().finalizer()
+
+	after, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(before, after) {
+		t.Fatal("dataset without checksum loaded")
+	}
+	if !isVerified(path) {
+		t.Fatal("regenerated dataset not marked verified")
+	}
+}
+
+// Tests that a process waits for another one generating the same file and
+// loads its result instead of generating it again.
+func TestStoreGenerationLock(t *testing.T) {
+	dir := t.TempDir()
+
+	seed := seedHash(3, epochLengthDefault)
+	path := storePath(dir, storeKindCache, 3, seed)
+
+	fileLock := flock.New(path + lockSuffix)
+	if err := fileLock.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan *cache)
+	go func() {
+		c := newCache(3, epochLengthDefault)
+		c.generate(dir, 10, false, true)
+		done <- c
+	}()
+	select {
+	case <-done:
+		t.Fatal("cache generated while the file was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// Generate the file as the lock holder and release it
+	dump, mem, _, err := memoryMapAndGenerate(path, 1024, false, func(buffer []uint32) { generateCache(buffer, 3, epochLengthDefault, seed) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem.Unmap()
+	dump.Close()
+
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileLock.Unlock()
+
+	c := <-done
+	defer c.finalizer()
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Fatal("cache regenerated after waiting for the lock holder")
+	}
+}
+
+// Tests that pruning deletes out of bounds files of all epoch lengths unless
+// they are locked, and that the remaining files are listed by ReadStore.
+func TestStorePrune(t *testing.T) {
+	dir := t.TempDir()
+
+	create := func(epoch uint64, epochLength uint64) string {
+		path := storePath(dir, storeKindCache, epoch, seedHash(epoch, epochLength))
+		dump, mem, _, err := memoryMapAndGenerate(path, 1024, false, func(buffer []uint32) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mem.Unmap()
+		dump.Close()
+		return path
+	}
+	var (
+		old     = create(1, epochLengthDefault)
+		locked  = create(2, epochLengthDefault)
+		current = create(9, epochLengthDefault)
+		other   = create(4, epochLengthECIP1099)
+		passed  = create(1, epochLengthECIP1099)
+		legacy  = filepath.Join(dir, "cache-R23-0123456789abcdef"+endianSuffix())
+		unknown = filepath.Join(dir, "cache-R23-unexpected")
+	)
+	for _, path := range []string{legacy, unknown} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fileLock := flock.New(locked + lockSuffix)
+	if err := fileLock.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	defer fileLock.Unlock()
+
+	pruneStore(dir, storeKindCache, 9, epochLengthDefault, 3, log.Root())
+
+	for _, path := range []string{old, old + checksumSuffix, passed, legacy} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("file %s not removed", filepath.Base(path))
+		}
+	}
+	for _, path := range []string{old + lockSuffix, locked, current, other, other + checksumSuffix, unknown} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("file %s removed: %v", filepath.Base(path), err)
+		}
+	}
+	entries, err := ReadStore(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		epoch, epochLength uint64
+		generating         bool
+	}{
+		{2, epochLengthDefault, true},
+		{9, epochLengthDefault, false},
+		{4, epochLengthECIP1099, false},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Epoch != want[i].epoch || entry.EpochLength != want[i].epochLength || entry.Generating != want[i].generating {
+			t.Errorf("entry %d: have epoch %d/%d generating %v, want %d/%d generating %v", i,
+				entry.Epoch, entry.EpochLength, entry.Generating, want[i].epoch, want[i].epochLength, want[i].generating)
+		}
+		if entry.Err != nil {
+			t.Errorf("entry %d: verification failed: %v", i, entry.Err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package keccak implements the Keccak-256 proof-of-work of ECIP-1049, which
+// replaces hashimoto in the ethash engine while keeping all of the other ethash
+// consensus rules, the sealers and the work APIs.
+package keccak
+
+import (
+	"encoding/binary"
+
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/crypto"
+)
+
+// Hash is the ECIP-1049 proof-of-work function: the Keccak-256 hash of the seal
+// hash and the big endian nonce, which is both the mix digest and the result
+// checked against the difficulty target.
+func Hash(sealHash []byte, nonce uint64) ([]byte, []byte) {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], nonce)
+	result := crypto.Keccak256(sealHash, enc[:])
+	return result, result
+}
+
+// New creates an ethash engine which seals and verifies the blocks from
+// config.ECIP1049Block on with Keccak-256, or all blocks if it isn't set. The
+// blocks before the transition are sealed with ethash.
+func New(config ethash.Config, notify []string, noverify bool) *ethash.Ethash {
+	return ethash.NewWithHasher(config, Hash, notify, noverify)
+}
+
+// NewTester creates a small sized engine sealing all blocks with Keccak-256,
+// useful only for testing purposes.
+func NewTester(notify []string, noverify bool) *ethash.Ethash {
+	return New(ethash.Config{PowMode: ethash.ModeTest}, notify, noverify)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package keccak
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core/types"
+)
+
+// two256 is a big integer representing 2^256
+var two256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
+
+// Tests that a block sealed by the local CPU miner carries a valid Keccak-256
+// proof-of-work.
+func TestSeal(t *testing.T) {
+	engine := NewTester(nil, false)
+	defer engine.Close()
+	engine.SetThreads(1)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)}
+	results := make(chan *types.Block)
+	if err := engine.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	var sealed *types.Block
+	select {
+	case sealed = <-results:
+	case <-time.NewTimer(10 * time.Second).C:
+		t.Fatalf("sealing result timeout")
+	}
+	digest, result := Hash(engine.SealHash(header).Bytes(), sealed.Nonce())
+	if sealed.MixDigest() != common.BytesToHash(digest) {
+		t.Errorf("mix digest mismatch: have %x, want %x", sealed.MixDigest(), digest)
+	}
+	if new(big.Int).SetBytes(result).Cmp(new(big.Int).Div(two256, header.Difficulty)) > 0 {
+		t.Errorf("result %x above target", result)
+	}
+}
+
+// Tests that blocks before the ECIP-1049 transition are sealed by ethash.
+func TestSealTransition(t *testing.T) {
+	transition := uint64(5)
+	engine := New(ethash.Config{PowMode: ethash.ModeTest, ECIP1049Block: &transition}, nil, false)
+	defer engine.Close()
+	engine.SetThreads(1)
+
+	for _, number := range []int64{4, 5} {
+		header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(100)}
+		results := make(chan *types.Block, 1)
+		if err := engine.Seal(nil, types.NewBlockWithHeader(header), results, nil); err != nil {
+			t.Fatalf("block %d: failed to seal: %v", number, err)
+		}
+		select {
+		case block := <-results:
+			digest, _ := Hash(engine.SealHash(header).Bytes(), block.Nonce())
+			keccakSealed := block.MixDigest() == common.BytesToHash(digest)
+			if want := number >= 5; keccakSealed != want {
+				t.Errorf("block %d: sealed by keccak %v, want %v", number, keccakSealed, want)
+			}
+		case <-time.NewTimer(10 * time.Second).C:
+			t.Fatalf("block %d: sealing result timeout", number)
+		}
+	}
+}
+
+// Tests that remote miners can fetch Keccak-256 work packages and submit their
+// solutions.
+func TestRemoteSealer(t *testing.T) {
+	engine := NewTester(nil, false)
+	defer engine.Close()
+	engine.SetThreads(-1)
+
+	api := engine.APIs(nil)[0].Service.(*ethash.API)
+	if _, err := api.GetWork(); err == nil {
+		t.Fatalf("got work before sealing")
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	results := make(chan *types.Block, 1)
+	engine.Seal(nil, types.NewBlockWithHeader(header), results, nil)
+
+	work, err := api.GetWork()
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	sealhash := engine.SealHash(header)
+	if work[0] != sealhash.Hex() || work[1] != (common.Hash{}).Hex() || work[3] != "0x1" {
+		t.Fatalf("unexpected work package %v", work)
+	}
+	// Search the solution like a remote miner would.
+	target := new(big.Int).Div(two256, header.Difficulty)
+	var nonce uint64
+	for ; ; nonce++ {
+		if _, result := Hash(sealhash.Bytes(), nonce); new(big.Int).SetBytes(result).Cmp(target) <= 0 {
+			break
+		}
+	}
+	digest, _ := Hash(sealhash.Bytes(), nonce)
+	if api.SubmitWork(types.EncodeNonce(nonce+1), sealhash, common.BytesToHash(digest)) {
+		t.Errorf("invalid solution accepted")
+	}
+	if !api.SubmitWork(types.EncodeNonce(nonce), sealhash, common.BytesToHash(digest)) {
+		t.Fatalf("valid solution rejected")
+	}
+	select {
+	case block := <-results:
+		if block.Nonce() != nonce || block.MixDigest() != common.BytesToHash(digest) {
+			t.Errorf("have seal %d/%x, want %d/%x", block.Nonce(), block.MixDigest(), nonce, digest)
+		}
+	case <-time.NewTimer(time.Second).C:
+		t.Fatalf("remote sealing result timeout")
+	}
+}
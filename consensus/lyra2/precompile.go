@@ -0,0 +1,54 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package lyra2
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/vm"
+)
+
+const (
+	precompileBaseGas = 2000 // Base cost of the Lyra2 precompile
+	precompileWordGas = 12   // Cost per word of the hashed input
+)
+
+var errPrecompileInput = errors.New("lyra2: input must end with an 8 byte nonce")
+
+func init() {
+	vm.RegisterPrecompile("lyra2", &precompile{})
+}
+
+// precompile exposes the Lyra2 proof-of-work hash to contracts of chains which
+// activate it as a custom precompile. The input is the sealed header followed
+// by the 8 byte big endian nonce, the output the 32 byte hash.
+type precompile struct{}
+
+func (c *precompile) RequiredGas(input []byte) uint64 {
+	return precompileBaseGas + uint64(len(input)+31)/32*precompileWordGas
+}
+
+func (c *precompile) Run(input []byte) ([]byte, error) {
+	if len(input) < 8 {
+		return nil, errPrecompileInput
+	}
+	data := common.CopyBytes(input)
+	nonce := binary.BigEndian.Uint64(data[len(data)-8:])
+	return common.BigToHash(new(Lyra2).calcHash(data, nonce, 1)).Bytes(), nil
+}
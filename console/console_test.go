@@ -90,7 +90,7 @@ func newTester(t *testing.T, confOverride func(*ethconfig.Config)) *tester {
 	workspace := t.TempDir()
 
 	// Create a networkless protocol stack and start an Ethereum service within
-	stack, err := node.New(&node.Config{DataDir: workspace, UseLightweightKDF: true, Name: testInstance})
+	stack, err := node.New(&node.Config{DataDir: workspace, UseLightweightKDF: true, Name: testInstance, EnablePersonal: true})
 	if err != nil {
 		t.Fatalf("failed to create node: %v", err)
 	}
@@ -239,6 +239,26 @@ func TestPreload(t *testing.T) {
 	}
 }
 
+// Tests that personal.deriveAccount keeps its three argument form, while the
+// network is selected through personal.deriveAccountForNetwork.
+func TestDeriveAccountArity(t *testing.T) {
+	tester := newTester(t, nil)
+	defer tester.Close(t)
+
+	for _, call := range []string{
+		`personal.deriveAccount("ledger://", "m/44'/61'/0'/0", false)`,
+		`personal.deriveAccountForNetwork("ledger://", "0", false, "classic")`,
+	} {
+		tester.output.Reset()
+		tester.console.Evaluate(call)
+
+		// Both reach the node, which has no such wallet
+		if output := tester.output.String(); !strings.Contains(output, "unknown wallet") {
+			t.Errorf("%s: have output %q, want unknown wallet error", call, output)
+		}
+	}
+}
+
 // Tests that the JavaScript objects returned by statement executions are properly
 // pretty printed instead of just displaying "[object]".
 func TestPrettyPrint(t *testing.T) {
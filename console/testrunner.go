@@ -0,0 +1,569 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package console
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/mattn/go-colorable"
+	"github.com/shudolab/core-geth/internal/jsre"
+	"github.com/shudolab/core-geth/rpc"
+)
+
+// testingJS defines the assertion helpers available to scripted tests. Failed
+// assertions throw an AssertionError, which is reported as a test failure
+// rather than an error.
+const testingJS = `
+function AssertionError(message) {
+	this.name = 'AssertionError';
+	this.message = message;
+	this.stack = new Error(message).stack;
+}
+AssertionError.prototype = Object.create(Error.prototype);
+AssertionError.prototype.constructor = AssertionError;
+
+function _assertEqual(actual, expected) {
+	if (actual === expected) {
+		return true;
+	}
+	if (actual != null && typeof actual.equals === 'function') {
+		try { return actual.equals(expected); } catch (e) { return false; }
+	}
+	if (expected != null && typeof expected.equals === 'function') {
+		try { return expected.equals(actual); } catch (e) { return false; }
+	}
+	if (actual != null && expected != null && typeof actual === 'object' && typeof expected === 'object') {
+		return JSON.stringify(actual) === JSON.stringify(expected);
+	}
+	return false;
+}
+
+function _assertFormat(v) {
+	if (v != null && typeof v === 'object' && typeof v.equals !== 'function') {
+		return JSON.stringify(v);
+	}
+	return String(v);
+}
+
+function assert(value, message) {
+	if (!value) {
+		throw new AssertionError(message || 'assertion failed');
+	}
+}
+assert.ok = assert;
+assert.equal = function(actual, expected, message) {
+	if (!_assertEqual(actual, expected)) {
+		throw new AssertionError((message ? message + ': ' : '') + 'have ' + _assertFormat(actual) + ', want ' + _assertFormat(expected));
+	}
+};
+assert.notEqual = function(actual, expected, message) {
+	if (_assertEqual(actual, expected)) {
+		throw new AssertionError((message ? message + ': ' : '') + 'both are ' + _assertFormat(actual));
+	}
+};
+assert.throws = function(fn, message) {
+	try {
+		fn();
+	} catch (e) {
+		return e;
+	}
+	throw new AssertionError(message || 'expected exception');
+};
+`
+
+// settleJS makes transactions sent through the console be mined right away while
+// automining is enabled, so that their receipts are available to the next
+// statement of the test.
+const settleJS = `
+(function() {
+	function settled(send) {
+		return function() {
+			var args = Array.prototype.slice.call(arguments);
+			if (args.length > 0 && typeof args[args.length - 1] === 'function') {
+				var callback = args.pop();
+				args.push(function(err, result) {
+					if (!err) {
+						_settle();
+					}
+					callback(err, result);
+				});
+				return send.apply(this, args);
+			}
+			var result = send.apply(this, args);
+			_settle();
+			return result;
+		};
+	}
+	var apis = [eth, typeof personal !== 'undefined' ? personal : null];
+	var methods = ['sendTransaction', 'sendRawTransaction'];
+	for (var i = 0; i < apis.length; i++) {
+		for (var j = 0; apis[i] && j < methods.length; j++) {
+			if (typeof apis[i][methods[j]] === 'function') {
+				apis[i][methods[j]] = settled(apis[i][methods[j]]);
+			}
+		}
+	}
+})();
+`
+
+// TestChain controls the chain scripted tests run against.
+type TestChain interface {
+	// Mine seals the given number of blocks, the first of which includes the
+	// pending transactions, and returns the number of the new head.
+	Mine(blocks int) (uint64, error)
+
+	// TimeTravel seals a block the given number of seconds after the head and
+	// moves the clock of subsequent blocks forward accordingly. It returns the
+	// timestamp of the new head.
+	TimeTravel(seconds uint64) (uint64, error)
+
+	// Snapshot returns an identifier of the current head.
+	Snapshot() (string, error)
+
+	// Revert rewinds the chain to a snapshot, dropping pending transactions.
+	Revert(id string) error
+}
+
+// TestConfig is the collection of configurations of a scripted test run.
+type TestConfig struct {
+	DocRoot string         // Filesystem path from where to load JavaScript files from
+	Printer io.Writer      // Output writer for test results and logs (defaults to os.Stdout)
+	Preload []string       // Absolute paths to JavaScript files to preload into every test file
+	Filter  *regexp.Regexp // Only tests with a matching name are run, if set
+
+	// Setup is called for every test file, returning the RPC client and the
+	// chain the file runs against, and a function tearing them down afterwards.
+	Setup func(file string) (*rpc.Client, TestChain, func(), error)
+}
+
+// TestCase is the result of a single scripted test.
+type TestCase struct {
+	Name     string
+	Duration time.Duration
+	Skipped  bool
+	Failure  string // Failed assertion, if any
+	Error    string // Exception other than a failed assertion, if any
+}
+
+// TestSuite is the result of all tests of a file.
+type TestSuite struct {
+	File     string
+	Duration time.Duration
+	Error    string // Set if the file could not be set up or loaded
+	Cases    []*TestCase
+}
+
+// failed reports whether the file could not be loaded or any of its tests failed.
+func (s *TestSuite) failed() bool {
+	if s.Error != "" {
+		return true
+	}
+	for _, c := range s.Cases {
+		if c.Failure != "" || c.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TestReport is the result of a scripted test run.
+type TestReport struct {
+	Suites []*TestSuite
+}
+
+// Failed reports whether any file could not be loaded or any test failed.
+func (r *TestReport) Failed() bool {
+	for _, s := range r.Suites {
+		if s.failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// RunTests runs the scripted tests of the given files in order. Each file is
+// evaluated in a fresh console against the chain returned by config.Setup, with
+// the following additions to the usual console environment:
+//
+//	test(name, fn), test.skip(name, fn)  register a test, run in order after the file is loaded
+//	assert(value, [msg]), assert.equal(actual, expected, [msg]), assert.notEqual(...), assert.throws(fn, [msg])
+//	mine([blocks])                       seal blocks, returning the new head number
+//	automine(enabled)                    toggle sealing a block for every sent transaction (default on)
+//	timeTravel(seconds)                  seal a block the given seconds after the head, returning its timestamp
+//	snapshot(), revert(id)               save and restore the chain head
+//
+// The tests of a file share the chain, so later tests observe the effects of
+// earlier ones.
+func RunTests(config TestConfig, files []string) *TestReport {
+	if config.Printer == nil {
+		config.Printer = colorable.NewColorableStdout()
+	}
+	report := new(TestReport)
+	for _, file := range files {
+		suite := runTestFile(config, file)
+		report.Suites = append(report.Suites, suite)
+
+		status := "ok  "
+		if suite.failed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(config.Printer, "%s\t%s\t%.3fs\n", status, file, suite.Duration.Seconds())
+	}
+	return report
+}
+
+// testRunner is the scripted test environment of a single file.
+type testRunner struct {
+	config   TestConfig
+	console  *Console
+	chain    TestChain
+	automine bool
+	tests    []*scriptedTest
+}
+
+// scriptedTest is a test registered by a file.
+type scriptedTest struct {
+	name string
+	fn   goja.Callable
+	skip bool
+}
+
+// runTestFile loads a file and runs the tests it registers.
+func runTestFile(config TestConfig, file string) *TestSuite {
+	var (
+		suite = &TestSuite{File: file}
+		start = time.Now()
+	)
+	defer func() { suite.Duration = time.Since(start) }()
+
+	fmt.Fprintf(config.Printer, "=== FILE %s\n", file)
+	client, chain, teardown, err := config.Setup(file)
+	if err != nil {
+		suite.Error = fmt.Sprintf("setup failed: %v", err)
+		fmt.Fprintf(config.Printer, "    %s\n", suite.Error)
+		return suite
+	}
+	defer teardown()
+
+	r, err := newTestRunner(config, client, chain)
+	if err != nil {
+		suite.Error = err.Error()
+		fmt.Fprintf(config.Printer, "    %s\n", suite.Error)
+		return suite
+	}
+	defer r.console.jsre.Stop(false)
+
+	if err := r.console.jsre.Exec(file); err != nil {
+		suite.Error = exceptionString(err)
+		fmt.Fprintf(config.Printer, "    %s\n", indent(suite.Error))
+		return suite
+	}
+	for _, t := range r.tests {
+		if config.Filter != nil && !config.Filter.MatchString(t.name) {
+			continue
+		}
+		suite.Cases = append(suite.Cases, r.run(t))
+	}
+	return suite
+}
+
+// newTestRunner creates a console for running the tests of a file, with the
+// testing functions added to its environment.
+func newTestRunner(config TestConfig, client *rpc.Client, chain TestChain) (*testRunner, error) {
+	r := &testRunner{
+		config: config,
+		console: &Console{
+			client:  client,
+			jsre:    jsre.New(config.DocRoot, config.Printer),
+			printer: config.Printer,
+		},
+		chain:    chain,
+		automine: true,
+	}
+	if err := r.console.init(nil); err != nil {
+		r.console.jsre.Stop(false)
+		return nil, err
+	}
+	if err := r.console.jsre.Compile("testing.js", testingJS); err != nil {
+		r.console.jsre.Stop(false)
+		return nil, fmt.Errorf("testing.js: %v", err)
+	}
+	r.console.jsre.Do(func(vm *goja.Runtime) {
+		test := jsre.MakeCallback(vm, r.register(false))
+		test.ToObject(vm).Set("skip", jsre.MakeCallback(vm, r.register(true)))
+		vm.Set("test", test)
+
+		if chain != nil {
+			vm.Set("mine", jsre.MakeCallback(vm, r.mine))
+			vm.Set("automine", jsre.MakeCallback(vm, r.setAutomine))
+			vm.Set("timeTravel", jsre.MakeCallback(vm, r.timeTravel))
+			vm.Set("snapshot", jsre.MakeCallback(vm, r.snapshot))
+			vm.Set("revert", jsre.MakeCallback(vm, r.revert))
+			vm.Set("_settle", jsre.MakeCallback(vm, r.settle))
+		}
+	})
+	if chain != nil {
+		if err := r.console.jsre.Compile("settle.js", settleJS); err != nil {
+			r.console.jsre.Stop(false)
+			return nil, fmt.Errorf("settle.js: %v", err)
+		}
+	}
+	for _, path := range config.Preload {
+		if err := r.console.jsre.Exec(path); err != nil {
+			r.console.jsre.Stop(false)
+			return nil, fmt.Errorf("%s: %v", path, exceptionString(err))
+		}
+	}
+	return r, nil
+}
+
+// register returns the JS test function, registering a test to run after the
+// file has been loaded.
+func (r *testRunner) register(skip bool) func(call jsre.Call) (goja.Value, error) {
+	return func(call jsre.Call) (goja.Value, error) {
+		if !call.Argument(0).ToBoolean() {
+			return nil, errors.New("missing test name")
+		}
+		fn, ok := goja.AssertFunction(call.Argument(1))
+		if !ok {
+			return nil, errors.New("second argument must be the test function")
+		}
+		r.tests = append(r.tests, &scriptedTest{name: call.Argument(0).String(), fn: fn, skip: skip})
+		return goja.Undefined(), nil
+	}
+}
+
+// run runs a registered test and prints its result.
+func (r *testRunner) run(t *scriptedTest) *TestCase {
+	tc := &TestCase{Name: t.name}
+	if t.skip {
+		tc.Skipped = true
+		fmt.Fprintf(r.config.Printer, "--- SKIP: %s\n", t.name)
+		return tc
+	}
+	start := time.Now()
+	r.console.jsre.Do(func(vm *goja.Runtime) {
+		_, err := t.fn(goja.Undefined())
+		if err == nil {
+			return
+		}
+		if ex, ok := err.(*goja.Exception); ok {
+			if obj, ok := ex.Value().(*goja.Object); ok {
+				if name := obj.Get("name"); name != nil && name.String() == "AssertionError" {
+					tc.Failure = exceptionString(err)
+					return
+				}
+			}
+		}
+		tc.Error = exceptionString(err)
+	})
+	tc.Duration = time.Since(start)
+
+	switch {
+	case tc.Failure != "":
+		fmt.Fprintf(r.config.Printer, "--- FAIL: %s (%.2fs)\n    %s\n", t.name, tc.Duration.Seconds(), indent(tc.Failure))
+	case tc.Error != "":
+		fmt.Fprintf(r.config.Printer, "--- FAIL: %s (%.2fs)\n    %s\n", t.name, tc.Duration.Seconds(), indent(tc.Error))
+	default:
+		fmt.Fprintf(r.config.Printer, "--- PASS: %s (%.2fs)\n", t.name, tc.Duration.Seconds())
+	}
+	return tc
+}
+
+// mine implements the JS mine function.
+func (r *testRunner) mine(call jsre.Call) (goja.Value, error) {
+	blocks := 1
+	if !goja.IsUndefined(call.Argument(0)) {
+		blocks = int(call.Argument(0).ToInteger())
+	}
+	if blocks < 1 {
+		return nil, errors.New("number of blocks must be positive")
+	}
+	number, err := r.chain.Mine(blocks)
+	if err != nil {
+		return nil, err
+	}
+	return call.VM.ToValue(number), nil
+}
+
+// setAutomine implements the JS automine function.
+func (r *testRunner) setAutomine(call jsre.Call) (goja.Value, error) {
+	r.automine = call.Argument(0).ToBoolean()
+	return goja.Undefined(), nil
+}
+
+// settle seals a block after a transaction has been sent, if automining.
+func (r *testRunner) settle(call jsre.Call) (goja.Value, error) {
+	if r.automine {
+		if _, err := r.chain.Mine(1); err != nil {
+			return nil, err
+		}
+	}
+	return goja.Undefined(), nil
+}
+
+// timeTravel implements the JS timeTravel function.
+func (r *testRunner) timeTravel(call jsre.Call) (goja.Value, error) {
+	seconds := call.Argument(0).ToInteger()
+	if seconds <= 0 {
+		return nil, errors.New("number of seconds must be positive")
+	}
+	timestamp, err := r.chain.TimeTravel(uint64(seconds))
+	if err != nil {
+		return nil, err
+	}
+	return call.VM.ToValue(timestamp), nil
+}
+
+// snapshot implements the JS snapshot function.
+func (r *testRunner) snapshot(call jsre.Call) (goja.Value, error) {
+	id, err := r.chain.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return call.VM.ToValue(id), nil
+}
+
+// revert implements the JS revert function.
+func (r *testRunner) revert(call jsre.Call) (goja.Value, error) {
+	if err := r.chain.Revert(call.Argument(0).String()); err != nil {
+		return nil, err
+	}
+	return goja.Undefined(), nil
+}
+
+// exceptionString returns the description of a JS error, including the stack
+// trace if available.
+func exceptionString(err error) string {
+	if ex, ok := err.(*goja.Exception); ok {
+		return strings.TrimSpace(ex.String())
+	}
+	return err.Error()
+}
+
+// indent indents all but the first line of s for nesting under a result line.
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n    ")
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	Classname string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Skipped   *struct{}    `xml:"skipped"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func newJUnitResult(details string) *junitResult {
+	message, _, _ := strings.Cut(details, "\n")
+	return &junitResult{Message: message, Body: details}
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report in the JUnit XML format understood by most CI
+// systems. Files which could not be loaded are reported as a single erroring
+// test named after the file.
+func (r *TestReport) WriteJUnit(w io.Writer) error {
+	var (
+		report junitTestSuites
+		total  time.Duration
+	)
+	for _, s := range r.Suites {
+		suite := junitTestSuite{Name: s.File, Time: junitTime(s.Duration)}
+		if s.Error != "" {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      s.File,
+				Classname: s.File,
+				Time:      junitTime(s.Duration),
+				Error:     newJUnitResult(s.Error),
+			})
+			suite.Errors++
+		}
+		for _, c := range s.Cases {
+			tc := junitTestCase{Name: c.Name, Classname: s.File, Time: junitTime(c.Duration)}
+			switch {
+			case c.Skipped:
+				tc.Skipped = new(struct{})
+				suite.Skipped++
+			case c.Failure != "":
+				tc.Failure = newJUnitResult(c.Failure)
+				suite.Failures++
+			case c.Error != "":
+				tc.Error = newJUnitResult(c.Error)
+				suite.Errors++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		total += s.Duration
+	}
+	report.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
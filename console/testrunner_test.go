@@ -0,0 +1,263 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package console
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/rpc"
+)
+
+// stubChain is a TestChain recording the calls of the scripted tests.
+type stubChain struct {
+	head      uint64
+	time      uint64
+	snapshots []uint64
+}
+
+func (c *stubChain) Mine(blocks int) (uint64, error) {
+	c.head += uint64(blocks)
+	return c.head, nil
+}
+
+func (c *stubChain) TimeTravel(seconds uint64) (uint64, error) {
+	c.head++
+	c.time += seconds
+	return c.time, nil
+}
+
+func (c *stubChain) Snapshot() (string, error) {
+	c.snapshots = append(c.snapshots, c.head)
+	return strconv.FormatUint(c.head, 10), nil
+}
+
+func (c *stubChain) Revert(id string) error {
+	head, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return errors.New("unknown snapshot")
+	}
+	c.head = head
+	return nil
+}
+
+// stubEthAPI accepts transactions sent by the scripted tests.
+type stubEthAPI struct{}
+
+func (stubEthAPI) SendRawTransaction(data hexutil.Bytes) common.Hash {
+	return crypto.Keccak256Hash(data)
+}
+
+// runScripts runs the given test scripts against a stub chain.
+func runScripts(t *testing.T, filter *regexp.Regexp, scripts ...string) (*TestReport, *stubChain, string) {
+	t.Helper()
+
+	var (
+		dir    = t.TempDir()
+		files  []string
+		chain  = new(stubChain)
+		output = new(bytes.Buffer)
+	)
+	for i, script := range scripts {
+		file := filepath.Join(dir, "test"+strconv.Itoa(i)+".js")
+		if err := os.WriteFile(file, []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", stubEthAPI{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+
+	report := RunTests(TestConfig{
+		DocRoot: dir,
+		Printer: output,
+		Filter:  filter,
+		Setup: func(file string) (*rpc.Client, TestChain, func(), error) {
+			client := rpc.DialInProc(server)
+			return client, chain, client.Close, nil
+		},
+	}, files)
+	return report, chain, output.String()
+}
+
+func TestRunTests(t *testing.T) {
+	report, chain, output := runScripts(t, nil, `
+		test("passes", function() {
+			assert(true);
+			assert.equal(web3.toBigNumber(10), 10);
+			assert.equal({a: 1}, {a: 1});
+			assert.notEqual(1, 2);
+			assert.throws(function() { throw new Error("boom"); });
+		});
+		test("fails", function() {
+			assert.equal(1, 2, "numbers");
+		});
+		test("errors", function() {
+			undefinedFunction();
+		});
+		test.skip("skipped", function() {
+			throw new Error("must not run");
+		});
+		test("chain", function() {
+			assert.equal(mine(), 1);
+			assert.equal(mine(2), 3);
+			var id = snapshot();
+			assert.equal(timeTravel(3600), 3600);
+			revert(id);
+			assert.equal(mine(), 4);
+		});
+	`)
+	if !report.Failed() {
+		t.Fatal("report not failed")
+	}
+	if len(report.Suites) != 1 {
+		t.Fatalf("wrong number of suites: have %d, want 1", len(report.Suites))
+	}
+	suite := report.Suites[0]
+	if suite.Error != "" {
+		t.Fatalf("suite error: %s", suite.Error)
+	}
+	if len(suite.Cases) != 5 {
+		t.Fatalf("wrong number of cases: have %d, want 5", len(suite.Cases))
+	}
+	if c := suite.Cases[0]; c.Failure != "" || c.Error != "" {
+		t.Errorf("test %q: unexpected failure %q, error %q", c.Name, c.Failure, c.Error)
+	}
+	if c := suite.Cases[1]; !strings.Contains(c.Failure, "numbers: have 1, want 2") || c.Error != "" {
+		t.Errorf("test %q: wrong failure %q, error %q", c.Name, c.Failure, c.Error)
+	}
+	if c := suite.Cases[2]; c.Failure != "" || !strings.Contains(c.Error, "undefinedFunction") {
+		t.Errorf("test %q: wrong failure %q, error %q", c.Name, c.Failure, c.Error)
+	}
+	if c := suite.Cases[3]; !c.Skipped {
+		t.Errorf("test %q not skipped", c.Name)
+	}
+	if c := suite.Cases[4]; c.Failure != "" || c.Error != "" {
+		t.Errorf("test %q: unexpected failure %q, error %q", c.Name, c.Failure, c.Error)
+	}
+	if chain.head != 4 {
+		t.Errorf("wrong chain head: have %d, want 4", chain.head)
+	}
+	for _, want := range []string{"--- PASS: passes", "--- FAIL: fails", "--- FAIL: errors", "--- SKIP: skipped", "--- PASS: chain", "FAIL\t"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunTestsAutomine(t *testing.T) {
+	report, chain, output := runScripts(t, nil, `
+		test("automine", function() {
+			eth.sendRawTransaction("0x01");
+			automine(false);
+			eth.sendRawTransaction("0x02");
+			automine(true);
+			eth.sendRawTransaction("0x03", function(err, hash) {
+				assert(!err);
+			});
+		});
+	`)
+	if report.Failed() {
+		t.Fatalf("tests failed:\n%s", output)
+	}
+	if chain.head != 2 {
+		t.Errorf("wrong number of mined blocks: have %d, want 2", chain.head)
+	}
+}
+
+func TestRunTestsFilter(t *testing.T) {
+	report, _, output := runScripts(t, regexp.MustCompile("^run"), `
+		test("runs", function() {});
+		test("filtered", function() { throw new Error("must not run"); });
+	`)
+	if report.Failed() {
+		t.Fatalf("tests failed:\n%s", output)
+	}
+	if cases := report.Suites[0].Cases; len(cases) != 1 || cases[0].Name != "runs" {
+		t.Fatalf("wrong tests run: %v", cases)
+	}
+}
+
+func TestRunTestsLoadError(t *testing.T) {
+	report, _, _ := runScripts(t, nil, `test("broken", function() {`, `test("ok", function() {});`)
+	if !report.Failed() {
+		t.Fatal("report not failed")
+	}
+	if report.Suites[0].Error == "" {
+		t.Error("broken file loaded without error")
+	}
+	if suite := report.Suites[1]; suite.Error != "" || len(suite.Cases) != 1 {
+		t.Errorf("file after broken one not run: error %q, %d cases", suite.Error, len(suite.Cases))
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	report := &TestReport{Suites: []*TestSuite{
+		{
+			File:     "a.js",
+			Duration: 1500 * time.Millisecond,
+			Cases: []*TestCase{
+				{Name: "pass", Duration: time.Second},
+				{Name: "fail", Failure: "AssertionError: bad\n\tat a.js:1"},
+				{Name: "error", Error: "ReferenceError: x is not defined"},
+				{Name: "skip", Skipped: true},
+			},
+		},
+		{File: "b.js", Error: "SyntaxError: unexpected end of input"},
+	}}
+	var buf bytes.Buffer
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var parsed junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if parsed.Tests != 5 || parsed.Failures != 1 || parsed.Errors != 2 || parsed.Skipped != 1 {
+		t.Errorf("wrong totals: tests %d, failures %d, errors %d, skipped %d", parsed.Tests, parsed.Failures, parsed.Errors, parsed.Skipped)
+	}
+	if len(parsed.Suites) != 2 {
+		t.Fatalf("wrong number of suites: have %d, want 2", len(parsed.Suites))
+	}
+	a := parsed.Suites[0]
+	if a.Name != "a.js" || a.Time != "1.500" {
+		t.Errorf("wrong suite attributes: name %q, time %q", a.Name, a.Time)
+	}
+	if f := a.Cases[1].Failure; f == nil || f.Message != "AssertionError: bad" {
+		t.Errorf("wrong failure: %+v", f)
+	}
+	if a.Cases[3].Skipped == nil {
+		t.Error("skipped test not marked")
+	}
+	if b := parsed.Suites[1]; len(b.Cases) != 1 || b.Cases[0].Error == nil {
+		t.Errorf("load error not reported: %+v", b)
+	}
+}
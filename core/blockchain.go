@@ -18,6 +18,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -42,6 +43,7 @@ import (
 	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/event"
 	"github.com/shudolab/core-geth/internal/syncx"
+	"github.com/shudolab/core-geth/internal/tracing"
 	"github.com/shudolab/core-geth/internal/version"
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/metrics"
@@ -52,6 +54,8 @@ import (
 	"github.com/shudolab/core-geth/triedb"
 	"github.com/shudolab/core-geth/triedb/hashdb"
 	"github.com/shudolab/core-geth/triedb/pathdb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 )
 
@@ -148,6 +152,8 @@ type CacheConfig struct {
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
+
+	StateDatabase func(state.Database) state.Database // Optional wrapper around the state database (e.g. to load state remotely)
 }
 
 // triedbConfig derives the configures for trie database.
@@ -283,6 +289,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *genesis
 	if _, ok := genesisErr.(*confp.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	if err := vm.ValidateCustomPrecompiles(chainConfig); err != nil {
+		return nil, err
+	}
 	log.Info("")
 	log.Info(strings.Repeat("-", 153))
 	// TODO meowsbits implement prettier Strings (aka 'Description()') for chain configurator implementations.
@@ -312,6 +321,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *genesis
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
 	bc.forker = NewForkChoice(bc, shouldPreserve)
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
+	if cacheConfig.StateDatabase != nil {
+		bc.stateCache = cacheConfig.StateDatabase(bc.stateCache)
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -1353,7 +1365,7 @@ func (bc *BlockChain) writeKnownBlockAsHead(block *types.Block) error {
 
 // writeBlockWithState writes block, metadata and corresponding state data to the
 // database.
-func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, state *state.StateDB) error {
+func (bc *BlockChain) writeBlockWithState(ctx context.Context, block *types.Block, receipts []*types.Receipt, state *state.StateDB) error {
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 	if ptd == nil {
@@ -1375,7 +1387,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		log.Crit("Failed to write block into disk", "err", err)
 	}
 	// Commit all cached state changes into underlying memory database.
+	_, span := tracing.Start(ctx, "state.commit")
 	root, err := state.Commit(block.NumberU64(), bc.chainConfig.IsEnabled(bc.chainConfig.GetEIP161dTransition, block.Number()))
+	tracing.End(span, err)
 	if err != nil {
 		return err
 	}
@@ -1386,7 +1400,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	}
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.TrieDirtyDisabled {
-		return bc.triedb.Commit(root, false)
+		_, span := tracing.Start(ctx, "triedb.commit")
+		err := bc.triedb.Commit(root, false)
+		tracing.End(span, err)
+		return err
 	}
 	// Full but not archive node, do proper garbage collection
 	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
@@ -1422,7 +1439,8 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 				log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/TriesInMemory)
 			}
 			// Flush an entire trie and restart the counters
-			bc.triedb.Commit(header.Root, true)
+			_, span := tracing.Start(ctx, "triedb.commit", attribute.Int64("block.number", int64(chosen)))
+			tracing.End(span, bc.triedb.Commit(header.Root, true))
 			bc.lastWrite = chosen
 			bc.gcproc = 0
 		}
@@ -1447,13 +1465,13 @@ func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types
 	}
 	defer bc.chainmu.Unlock()
 
-	return bc.writeBlockAndSetHead(block, receipts, logs, state, emitHeadEvent)
+	return bc.writeBlockAndSetHead(context.Background(), block, receipts, logs, state, emitHeadEvent)
 }
 
 // writeBlockAndSetHead is the internal implementation of WriteBlockAndSetHead.
 // This function expects the chain mutex to be held.
-func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
-	if err := bc.writeBlockWithState(block, receipts, state); err != nil {
+func (bc *BlockChain) writeBlockAndSetHead(ctx context.Context, block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
+	if err := bc.writeBlockWithState(ctx, block, receipts, state); err != nil {
 		return NonStatTy, err
 	}
 	currentBlock := bc.CurrentBlock()
@@ -1560,11 +1578,23 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 // racey behaviour. If a sidechain import is in progress, and the historic state
 // is imported, but then new canon-head is added before the actual sidechain
 // completes, then the historic state could be pruned again
-func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool) (int, error) {
+func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool) (_ int, err error) {
 	// If the chain is terminating, don't even bother starting up.
 	if bc.insertStopped() {
 		return 0, nil
 	}
+	ctx, span := tracing.Start(context.Background(), "core.insertChain",
+		attribute.Int64("chain.first", chain[0].Number().Int64()),
+		attribute.Int("chain.blocks", len(chain)),
+	)
+	// The span of the block being imported is ended when the import fails.
+	var blockSpan trace.Span
+	defer func() {
+		if blockSpan != nil {
+			tracing.End(blockSpan, err)
+		}
+		tracing.End(span, err)
+	}()
 
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number(), chain[0].Time()), chain)
@@ -1684,7 +1714,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		bc.futureBlocks.Remove(block.Hash())
 		stats.ignored += len(it.chain)
 		bc.reportBlock(block, nil, err)
-		return it.index, err
+		return it.index, badBlockError(err)
 	}
 	// No validation errors for the first block (or chain prefix skipped)
 	var activeState *state.StateDB
@@ -1707,7 +1737,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		// If the header is a banned one, straight out abort
 		if BadHashes[block.Hash()] {
 			bc.reportBlock(block, nil, ErrBannedHash)
-			return it.index, ErrBannedHash
+			return it.index, badBlockError(ErrBannedHash)
 		}
 		// If the block is known (in the middle of the chain), it's a special case for
 		// Clique blocks where they can share state among each other, so importing an
@@ -1759,6 +1789,12 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		if err != nil {
 			return it.index, err
 		}
+		var blockCtx context.Context
+		blockCtx, blockSpan = tracing.Start(ctx, "core.insertBlock",
+			attribute.Int64("block.number", block.Number().Int64()),
+			attribute.String("block.hash", block.Hash().Hex()),
+			attribute.Int("block.txs", len(block.Transactions())),
+		)
 
 		// Enable prefetching to pull in trie node paths while processing transactions
 		statedb.StartPrefetcher("chain")
@@ -1784,19 +1820,25 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 
 		// Process block using the parent state as reference point
 		pstart := time.Now()
+		_, pspan := tracing.Start(blockCtx, "core.process")
 		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		pspan.SetAttributes(attribute.Int64("block.gasUsed", int64(usedGas)))
+		tracing.End(pspan, err)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			followupInterrupt.Store(true)
-			return it.index, err
+			return it.index, badBlockError(err)
 		}
 		ptime := time.Since(pstart)
 
 		vstart := time.Now()
-		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+		_, vspan := tracing.Start(blockCtx, "core.validateState")
+		err = bc.validator.ValidateState(block, statedb, receipts, usedGas)
+		tracing.End(vspan, err)
+		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			followupInterrupt.Store(true)
-			return it.index, err
+			return it.index, badBlockError(err)
 		}
 		vtime := time.Since(vstart)
 		proctime := time.Since(start) // processing + validation
@@ -1824,9 +1866,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		)
 		if !setHead {
 			// Don't set the head, only insert the block
-			err = bc.writeBlockWithState(block, receipts, statedb)
+			err = bc.writeBlockWithState(blockCtx, block, receipts, statedb)
 		} else {
-			status, err = bc.writeBlockAndSetHead(block, receipts, logs, statedb, false)
+			status, err = bc.writeBlockAndSetHead(blockCtx, block, receipts, logs, statedb, false)
 		}
 		followupInterrupt.Store(true)
 		if err != nil {
@@ -1852,6 +1894,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 		trieDiffNodes, trieBufNodes, _ := bc.triedb.Size()
 		stats.report(chain, it.index, snapDiffItems, snapBufItems, trieDiffNodes, trieBufNodes, setHead)
 
+		blockSpan.End()
+		blockSpan = nil
+
 		if !setHead {
 			// After merge we expect few side chains. Simply count
 			// all blocks the CL gives us for GC processing time
@@ -1903,7 +1948,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals, setHead bool)
 	}
 	stats.ignored += it.remaining()
 
-	return it.index, err
+	// Errors left here come from validating the remaining blocks
+	return it.index, badBlockError(err)
 }
 
 // insertSideChain is called when an import batch hits upon a pruned ancestor
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -90,6 +91,33 @@ func (bc *BlockChain) IsArtificialFinalityEnabled() bool {
 	return atomic.LoadInt32(&bc.artificialFinalityEnabledStatus) == 1
 }
 
+// ArtificialFinalityHeader returns the newest canonical header which ECBP-1100
+// treats as final. Replacing it requires a competing segment forking at least
+// ecbp1100PolynomialVXCap seconds before the head, which must have the maximum
+// anti-gravity of 31 times the total difficulty of the local segment. It returns
+// nil if artificial finality is disabled or inactive.
+func (bc *BlockChain) ArtificialFinalityHeader() *types.Header {
+	head := bc.CurrentHeader()
+	if !bc.IsArtificialFinalityEnabled() || !bc.chainConfig.IsEnabled(bc.chainConfig.GetECBP1100Transition, head.Number) {
+		return nil
+	}
+	xcap := ecbp1100PolynomialVXCap.Uint64()
+	if head.Time < xcap {
+		return nil
+	}
+	cutoff := head.Time - xcap
+
+	// Find the first block newer than the cutoff, block timestamps are increasing.
+	n := sort.Search(int(head.Number.Uint64())+1, func(i int) bool {
+		header := bc.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Time > cutoff
+	})
+	if n == 0 {
+		return nil
+	}
+	return bc.GetHeaderByNumber(uint64(n - 1))
+}
+
 // getTDRatio is a helper function returning the total difficulty ratio of
 // proposed over current chain segments.
 // nolint:unused
@@ -246,6 +246,43 @@ func TestAFKnownBlock(t *testing.T) {
 	}
 }
 
+func TestArtificialFinalityHeader(t *testing.T) {
+	engine := ethash.NewFaker()
+
+	db := rawdb.NewMemoryDatabase()
+	genesis := params.DefaultMessNetGenesisBlock()
+	genesisB := MustCommitGenesis(db, triedb.NewDatabase(db, nil), genesis)
+
+	chain, err := NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	blocks, _ := GenerateChain(genesis.Config, genesisB, engine, db, 1000, func(i int, gen *BlockGen) {
+		gen.OffsetTime(20)
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	if h := chain.ArtificialFinalityHeader(); h != nil {
+		t.Fatalf("final header %d with artificial finality disabled", h.Number)
+	}
+	chain.EnableArtificialFinality(true)
+
+	final := chain.ArtificialFinalityHeader()
+	if final == nil {
+		t.Fatal("no final header")
+	}
+	cutoff := chain.CurrentHeader().Time - ecbp1100PolynomialVXCap.Uint64()
+	if final.Time > cutoff {
+		t.Errorf("final header %d too new: time %d, cutoff %d", final.Number, final.Time, cutoff)
+	}
+	if next := chain.GetHeaderByNumber(final.Number.Uint64() + 1); next.Time <= cutoff {
+		t.Errorf("final header %d not the newest: next time %d, cutoff %d", final.Number, next.Time, cutoff)
+	}
+}
+
 // TestEcbp1100PolynomialV tests the general shape and return values of the ECBP1100 polynomial curve.
 // It makes sure domain values above the 'cap' do indeed get limited, as well
 // as sanity check some normal domain values.
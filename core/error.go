@@ -19,6 +19,7 @@ package core
 import (
 	"errors"
 
+	"github.com/shudolab/core-geth/consensus"
 	"github.com/shudolab/core-geth/core/types"
 )
 
@@ -35,6 +36,26 @@ var (
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
 )
 
+// BadBlockError is returned when an imported block or header fails validation.
+// It tells such failures apart from local ones, like an interrupted insertion or
+// a failed database write, which aren't caused by the imported data.
+type BadBlockError struct {
+	Err error
+}
+
+func (e *BadBlockError) Error() string { return e.Err.Error() }
+func (e *BadBlockError) Unwrap() error { return e.Err }
+
+// badBlockError marks a validation error as a bad block. Missing and pruned
+// ancestors, and blocks from the future, depend on the local chain and clock
+// rather than the imported data, so they are returned unmarked.
+func badBlockError(err error) error {
+	if err == nil || errors.Is(err, consensus.ErrUnknownAncestor) || errors.Is(err, consensus.ErrPrunedAncestor) || errors.Is(err, consensus.ErrFutureBlock) {
+		return err
+	}
+	return &BadBlockError{Err: err}
+}
+
 // List of evm-call-message pre-checking errors. All state transition messages will
 // be pre-checked before execution. If any invalidation detected, the corresponding
 // error should be returned which is defined here.
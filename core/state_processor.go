@@ -26,6 +26,7 @@ import (
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/core/vm"
 	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/params/mutations"
 	"github.com/shudolab/core-geth/params/types/ctypes"
 	"github.com/shudolab/core-geth/params/vars"
@@ -82,19 +83,32 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
 		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
 	}
-	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
-		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+	// Iterate over and process the individual transactions. Tracing and preimage
+	// recording rely on the sequential execution and disable parallelism.
+	if cfg.ParallelTxExecution && cfg.Tracer == nil && !cfg.EnablePreimageRecording && len(block.Transactions()) > 1 {
+		var (
+			stats parallelStats
+			err   error
+		)
+		receipts, allLogs, stats, err = p.processParallel(block, statedb, cfg, gp, usedGas)
 		if err != nil {
-			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			return nil, nil, 0, err
 		}
-		statedb.SetTxContext(tx.Hash(), i)
-		receipt, err := applyTransaction(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
-		if err != nil {
-			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		log.Debug("Executed transactions in parallel", "number", blockNumber, "hash", blockHash, "merged", stats.merged, "reexecuted", stats.reexecuted)
+	} else {
+		for i, tx := range block.Transactions() {
+			msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+			if err != nil {
+				return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			statedb.SetTxContext(tx.Hash(), i)
+			receipt, err := applyTransaction(msg, p.config, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
+			if err != nil {
+				return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			receipts = append(receipts, receipt)
+			allLogs = append(allLogs, receipt.Logs...)
 		}
-		receipts = append(receipts, receipt)
-		allLogs = append(allLogs, receipt.Logs...)
 	}
 	// Fail if Shanghai not enabled and len(withdrawals) is non-zero.
 	withdrawals := block.Withdrawals()
@@ -118,7 +132,12 @@ func applyTransaction(msg *Message, config ctypes.ChainConfigurator, gp *GasPool
 	if err != nil {
 		return nil, err
 	}
+	return makeReceipt(msg, config, result, statedb, blockNumber, blockHash, tx, usedGas, evm), nil
+}
 
+// makeReceipt finalises the state changes of an executed transaction and
+// creates its receipt.
+func makeReceipt(msg *Message, config ctypes.ChainConfigurator, result *ExecutionResult, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM) *types.Receipt {
 	// Update the state with pending changes.
 	var root []byte
 	eip161d := config.IsEnabled(config.GetEIP161dTransition, blockNumber)
@@ -160,7 +179,7 @@ func applyTransaction(msg *Message, config ctypes.ChainConfigurator, gp *GasPool
 	receipt.BlockHash = blockHash
 	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(statedb.TxIndex())
-	return receipt, err
+	return receipt
 }
 
 // ApplyTransaction attempts to apply a transaction to the given state database
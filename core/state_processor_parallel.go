@@ -0,0 +1,534 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+	"github.com/shudolab/core-geth/metrics"
+)
+
+var (
+	parallelMergedMeter     = metrics.NewRegisteredMeter("chain/parallel/merged", nil)
+	parallelReexecutedMeter = metrics.NewRegisteredMeter("chain/parallel/reexecuted", nil)
+)
+
+// ripemdAddress is the RIPEMD-160 precompile, which the state keeps touched
+// even if the touching call frame is reverted.
+var ripemdAddress = common.BytesToAddress([]byte{3})
+
+// accessSet is a set of accounts and storage slots.
+type accessSet struct {
+	accounts map[common.Address]struct{}
+	slots    map[common.Address]map[common.Hash]struct{}
+}
+
+func newAccessSet() accessSet {
+	return accessSet{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (s accessSet) addAccount(addr common.Address) {
+	s.accounts[addr] = struct{}{}
+}
+
+func (s accessSet) addSlot(addr common.Address, slot common.Hash) {
+	slots, ok := s.slots[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		s.slots[addr] = slots
+	}
+	slots[slot] = struct{}{}
+}
+
+func (s accessSet) hasAccount(addr common.Address) bool {
+	_, ok := s.accounts[addr]
+	return ok
+}
+
+func (s accessSet) hasSlot(addr common.Address, slot common.Hash) bool {
+	_, ok := s.slots[addr][slot]
+	return ok
+}
+
+type writeKind uint8
+
+const (
+	writeBalance writeKind = iota
+	writeNonce
+	writeCode
+	writeCreate
+	writeDestruct
+	writeSlot
+)
+
+// stateWrite is a modification of the state made by a transaction.
+type stateWrite struct {
+	kind writeKind
+	addr common.Address
+	slot common.Hash // Only set for writeSlot
+}
+
+// accountWrite summarises the modifications of an account by a transaction.
+type accountWrite struct {
+	created    bool
+	destructed bool
+	balance    bool
+	nonce      bool
+	code       bool
+	slots      map[common.Hash]struct{}
+}
+
+// writeSet summarises the modifications of the state by a transaction.
+type writeSet struct {
+	order    []common.Address // Accounts in the order of their first modification
+	accounts map[common.Address]*accountWrite
+}
+
+// destructs reports whether the transaction self-destructed any account.
+func (ws *writeSet) destructs() bool {
+	for _, acc := range ws.accounts {
+		if acc.destructed {
+			return true
+		}
+	}
+	return false
+}
+
+// txStateView is the state of a single transaction, recording the accounts
+// and storage slots it reads and writes. Account reads cover the balance,
+// nonce, code and existence of an account, storage reads a single slot.
+//
+// Writes are journaled along with the state, so that the modifications of
+// reverted call frames are forgotten. Reads are never forgotten since they
+// affected the execution regardless of the outcome.
+type txStateView struct {
+	*state.StateDB
+
+	reads     accessSet
+	writes    []stateWrite
+	revisions map[int]int // Number of writes at each state snapshot
+}
+
+func newTxStateView(statedb *state.StateDB) *txStateView {
+	return &txStateView{
+		StateDB:   statedb,
+		reads:     newAccessSet(),
+		revisions: make(map[int]int),
+	}
+}
+
+func (v *txStateView) write(kind writeKind, addr common.Address, slot common.Hash) {
+	v.writes = append(v.writes, stateWrite{kind: kind, addr: addr, slot: slot})
+}
+
+func (v *txStateView) CreateAccount(addr common.Address) {
+	v.reads.addAccount(addr) // The balance of the previous account is carried over
+	v.write(writeCreate, addr, common.Hash{})
+	v.StateDB.CreateAccount(addr)
+}
+
+func (v *txStateView) SubBalance(addr common.Address, amount *uint256.Int) {
+	v.write(writeBalance, addr, common.Hash{})
+	v.StateDB.SubBalance(addr, amount)
+}
+
+func (v *txStateView) AddBalance(addr common.Address, amount *uint256.Int) {
+	v.write(writeBalance, addr, common.Hash{})
+	v.StateDB.AddBalance(addr, amount)
+}
+
+func (v *txStateView) GetBalance(addr common.Address) *uint256.Int {
+	v.reads.addAccount(addr)
+	return v.StateDB.GetBalance(addr)
+}
+
+func (v *txStateView) GetNonce(addr common.Address) uint64 {
+	v.reads.addAccount(addr)
+	return v.StateDB.GetNonce(addr)
+}
+
+func (v *txStateView) SetNonce(addr common.Address, nonce uint64) {
+	v.reads.addAccount(addr)
+	v.write(writeNonce, addr, common.Hash{})
+	v.StateDB.SetNonce(addr, nonce)
+}
+
+func (v *txStateView) GetCodeHash(addr common.Address) common.Hash {
+	v.reads.addAccount(addr)
+	return v.StateDB.GetCodeHash(addr)
+}
+
+func (v *txStateView) GetCode(addr common.Address) []byte {
+	v.reads.addAccount(addr)
+	return v.StateDB.GetCode(addr)
+}
+
+func (v *txStateView) SetCode(addr common.Address, code []byte) {
+	v.reads.addAccount(addr)
+	v.write(writeCode, addr, common.Hash{})
+	v.StateDB.SetCode(addr, code)
+}
+
+func (v *txStateView) GetCodeSize(addr common.Address) int {
+	v.reads.addAccount(addr)
+	return v.StateDB.GetCodeSize(addr)
+}
+
+func (v *txStateView) GetCommittedState(addr common.Address, slot common.Hash) common.Hash {
+	v.reads.addSlot(addr, slot)
+	return v.StateDB.GetCommittedState(addr, slot)
+}
+
+func (v *txStateView) GetState(addr common.Address, slot common.Hash) common.Hash {
+	v.reads.addSlot(addr, slot)
+	return v.StateDB.GetState(addr, slot)
+}
+
+func (v *txStateView) SetState(addr common.Address, slot, value common.Hash) {
+	v.write(writeSlot, addr, slot)
+	v.StateDB.SetState(addr, slot, value)
+}
+
+func (v *txStateView) SelfDestruct(addr common.Address) {
+	v.reads.addAccount(addr)
+	v.write(writeDestruct, addr, common.Hash{})
+	v.StateDB.SelfDestruct(addr)
+}
+
+func (v *txStateView) HasSelfDestructed(addr common.Address) bool {
+	v.reads.addAccount(addr)
+	return v.StateDB.HasSelfDestructed(addr)
+}
+
+func (v *txStateView) Selfdestruct6780(addr common.Address) {
+	v.reads.addAccount(addr)
+	v.write(writeDestruct, addr, common.Hash{})
+	v.StateDB.Selfdestruct6780(addr)
+}
+
+func (v *txStateView) Exist(addr common.Address) bool {
+	v.reads.addAccount(addr)
+	return v.StateDB.Exist(addr)
+}
+
+func (v *txStateView) Empty(addr common.Address) bool {
+	v.reads.addAccount(addr)
+	return v.StateDB.Empty(addr)
+}
+
+func (v *txStateView) Snapshot() int {
+	id := v.StateDB.Snapshot()
+	v.revisions[id] = len(v.writes)
+	return id
+}
+
+func (v *txStateView) RevertToSnapshot(id int) {
+	v.StateDB.RevertToSnapshot(id)
+
+	kept := v.writes[:v.revisions[id]:v.revisions[id]]
+	for _, w := range v.writes[len(kept):] {
+		if w.addr == ripemdAddress && w.kind == writeBalance {
+			kept = append(kept, w) // Touched regardless of the revert, see state.touchChange
+		}
+	}
+	v.writes = kept
+}
+
+// writeSet returns the modifications made by the transaction.
+func (v *txStateView) writeSet() *writeSet {
+	ws := &writeSet{accounts: make(map[common.Address]*accountWrite)}
+	for _, w := range v.writes {
+		acc, ok := ws.accounts[w.addr]
+		if !ok {
+			acc = new(accountWrite)
+			ws.accounts[w.addr] = acc
+			ws.order = append(ws.order, w.addr)
+		}
+		switch w.kind {
+		case writeBalance:
+			acc.balance = true
+		case writeNonce:
+			acc.nonce = true
+		case writeCode:
+			acc.code = true
+		case writeCreate:
+			acc.created = true
+		case writeDestruct:
+			acc.destructed = true
+		case writeSlot:
+			if acc.slots == nil {
+				acc.slots = make(map[common.Hash]struct{})
+			}
+			acc.slots[w.slot] = struct{}{}
+		}
+	}
+	return ws
+}
+
+// merge applies the modifications of a transaction executed on a copy of the
+// parent state to dst. The balances of accounts the transaction did not read
+// are merged as deltas against the parent state, so that transactions paying
+// the same coinbase don't conflict. All other modifications are merged as
+// values, which the caller must ensure were not modified since the parent
+// state. Self-destructs are not supported.
+func (v *txStateView) merge(dst, parent *state.StateDB, ws *writeSet) {
+	src := v.StateDB
+	for _, addr := range ws.order {
+		acc := ws.accounts[addr]
+		if !src.Exist(addr) {
+			continue // Only left touched by a reverted call frame
+		}
+		if acc.created {
+			dst.CreateAccount(addr)
+		}
+		if acc.balance {
+			if v.reads.hasAccount(addr) {
+				dst.SetBalance(addr, src.GetBalance(addr))
+			} else {
+				have, prev := src.GetBalance(addr), parent.GetBalance(addr)
+				if have.Cmp(prev) >= 0 {
+					dst.AddBalance(addr, new(uint256.Int).Sub(have, prev))
+				} else {
+					dst.SubBalance(addr, new(uint256.Int).Sub(prev, have))
+				}
+			}
+		}
+		if acc.nonce {
+			dst.SetNonce(addr, src.GetNonce(addr))
+		}
+		if acc.code {
+			dst.SetCode(addr, src.GetCode(addr))
+		}
+		for slot := range acc.slots {
+			dst.SetState(addr, slot, src.GetState(addr, slot))
+		}
+	}
+}
+
+// blockWrites accumulates the modifications of the transactions of a block
+// which were already applied, to detect the speculatively executed ones which
+// read outdated state.
+type blockWrites struct {
+	accounts accessSet                   // Modified accounts and storage slots
+	reset    map[common.Address]struct{} // Accounts whose storage was cleared
+}
+
+func newBlockWrites() *blockWrites {
+	return &blockWrites{accounts: newAccessSet(), reset: make(map[common.Address]struct{})}
+}
+
+func (bw *blockWrites) record(ws *writeSet) {
+	for addr, acc := range ws.accounts {
+		if acc.balance || acc.nonce || acc.code || acc.created || acc.destructed {
+			bw.accounts.addAccount(addr)
+		}
+		if acc.created || acc.destructed {
+			bw.reset[addr] = struct{}{}
+		}
+		for slot := range acc.slots {
+			bw.accounts.addSlot(addr, slot)
+		}
+	}
+}
+
+// conflicts reports whether any of the reads were modified.
+func (bw *blockWrites) conflicts(reads accessSet) bool {
+	for addr := range reads.accounts {
+		if bw.accounts.hasAccount(addr) {
+			return true
+		}
+	}
+	for addr, slots := range reads.slots {
+		if _, ok := bw.reset[addr]; ok {
+			return true
+		}
+		for slot := range slots {
+			if bw.accounts.hasSlot(addr, slot) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// speculativeTx is a transaction executed against the parent state of its block.
+type speculativeTx struct {
+	view   *txStateView
+	msg    *Message
+	evm    *vm.EVM
+	result *ExecutionResult
+	err    error
+	done   chan struct{}
+}
+
+// parallelStats are the outcomes of executing the transactions of a block in parallel.
+type parallelStats struct {
+	merged     int // Transactions whose speculative execution was merged
+	reexecuted int // Transactions executed again on the up-to-date state
+}
+
+// processParallel applies the transactions of a block optimistically in
+// parallel. Every transaction is first executed speculatively against the
+// state preceding the transactions, recording what it reads and writes. The
+// results are then applied in block order: a transaction whose reads were not
+// modified by the preceding ones has its writes merged, any other is executed
+// again on the up-to-date state. The receipts and the resulting state are
+// identical to the ones of sequential execution.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config, gp *GasPool, usedGas *uint64) (types.Receipts, []*types.Log, parallelStats, error) {
+	var (
+		header      = block.Header()
+		blockHash   = block.Hash()
+		blockNumber = block.Number()
+		txs         = block.Transactions()
+		signer      = types.MakeSigner(p.config, header.Number, header.Time)
+		parent      = statedb.Copy()
+		specs       = make([]*speculativeTx, len(txs))
+		tasks       = make(chan int, len(txs))
+		abort       = make(chan struct{})
+		wg          sync.WaitGroup
+	)
+	// Every transaction executes on its own copy of the parent state, as the
+	// state is not safe for concurrent use. The copies are made in block order
+	// only a window ahead of the applied transactions, bounding the number held
+	// in memory at once.
+	workers := min(runtime.NumCPU(), len(txs))
+	window := min(2*workers, len(txs))
+	schedule := func(i int) {
+		specs[i] = &speculativeTx{view: newTxStateView(parent.Copy()), done: make(chan struct{})}
+		tasks <- i
+	}
+	for i := 0; i < window; i++ {
+		schedule(i)
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-abort:
+					return
+				case i := <-tasks:
+					p.speculate(specs[i], txs[i], i, header, signer, cfg)
+					close(specs[i].done)
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(abort)
+		wg.Wait()
+	}()
+
+	var (
+		receipts = make(types.Receipts, 0, len(txs))
+		allLogs  []*types.Log
+		written  = newBlockWrites()
+		stats    parallelStats
+		vmenv    *vm.EVM
+	)
+	for i, tx := range txs {
+		spec := specs[i]
+		<-spec.done
+
+		statedb.SetTxContext(tx.Hash(), i)
+
+		var receipt *types.Receipt
+		if ws := spec.mergeable(gp, written); ws != nil {
+			spec.view.merge(statedb, parent, ws)
+			for _, l := range spec.view.GetLogs(tx.Hash(), 0, common.Hash{}) {
+				statedb.AddLog(&types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+			}
+			if err := gp.SubGas(spec.result.UsedGas); err != nil {
+				return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			receipt = makeReceipt(spec.msg, p.config, spec.result, statedb, blockNumber, blockHash, tx, usedGas, spec.evm)
+			written.record(ws)
+			stats.merged++
+		} else {
+			msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+			if err != nil {
+				return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			view := newTxStateView(statedb)
+			if vmenv == nil {
+				vmenv = vm.NewEVM(NewEVMBlockContext(header, p.bc, nil), vm.TxContext{}, view, p.config, cfg)
+			}
+			vmenv.Reset(NewEVMTxContext(msg), view)
+			result, err := ApplyMessage(vmenv, msg, gp)
+			if err != nil {
+				return nil, nil, stats, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			receipt = makeReceipt(msg, p.config, result, statedb, blockNumber, blockHash, tx, usedGas, vmenv)
+			written.record(view.writeSet())
+			stats.reexecuted++
+		}
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+
+		// Release the applied copy and speculate a transaction further ahead
+		specs[i] = nil
+		if next := i + window; next < len(txs) {
+			schedule(next)
+		}
+	}
+	parallelMergedMeter.Mark(int64(stats.merged))
+	parallelReexecutedMeter.Mark(int64(stats.reexecuted))
+	return receipts, allLogs, stats, nil
+}
+
+// speculate executes a transaction against the parent state of its block.
+func (p *StateProcessor) speculate(spec *speculativeTx, tx *types.Transaction, index int, header *types.Header, signer types.Signer, cfg vm.Config) {
+	spec.msg, spec.err = TransactionToMessage(tx, signer, header.BaseFee)
+	if spec.err != nil {
+		return
+	}
+	spec.view.SetTxContext(tx.Hash(), index)
+	spec.evm = vm.NewEVM(NewEVMBlockContext(header, p.bc, nil), NewEVMTxContext(spec.msg), spec.view, p.config, cfg)
+	spec.result, spec.err = ApplyMessage(spec.evm, spec.msg, new(GasPool).AddGas(header.GasLimit))
+	if spec.err == nil {
+		spec.err = spec.view.Error()
+	}
+}
+
+// mergeable returns the modifications of the speculative execution if they can
+// be merged, or nil if the transaction must be executed again.
+func (spec *speculativeTx) mergeable(gp *GasPool, written *blockWrites) *writeSet {
+	// Failed transactions are executed again to report the accurate error,
+	// likewise when running out of block gas.
+	if spec.err != nil || gp.Gas() < spec.msg.GasLimit {
+		return nil
+	}
+	if written.conflicts(spec.view.reads) {
+		return nil
+	}
+	ws := spec.view.writeSet()
+	if ws.destructs() {
+		return nil
+	}
+	return ws
+}
@@ -0,0 +1,234 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/params/types/goethereum"
+	"github.com/shudolab/core-geth/params/vars"
+	"github.com/shudolab/core-geth/trie"
+)
+
+var (
+	parallelCounter   = common.HexToAddress("0xc001") // Increments slot 0
+	parallelRegistry  = common.HexToAddress("0xc002") // Stores the caller at the caller's slot
+	parallelLogger    = common.HexToAddress("0xc003") // Logs the caller
+	parallelReverter  = common.HexToAddress("0xc004") // Sends 1 wei to parallelEmpty and reverts
+	parallelDestroyer = common.HexToAddress("0xc005") // Self-destructs to the caller
+	parallelEmpty     = common.HexToAddress("0xe4")   // Empty account
+	parallelCoinbase  = common.HexToAddress("0xcb")
+)
+
+// makeParallelChain generates blocks of transactions which both conflict with
+// and are independent of each other.
+func makeParallelChain(t *testing.T, config ctypes.ChainConfigurator) (*genesisT.Genesis, []*types.Block) {
+	keys := make([]*ecdsa.PrivateKey, 9)
+	addrs := make([]common.Address, len(keys))
+	alloc := genesisT.GenesisAlloc{
+		parallelCounter:   {Code: common.FromHex("60005460010160005500")},
+		parallelRegistry:  {Code: common.FromHex("33335500")},
+		parallelLogger:    {Code: common.FromHex("3360006000a100")},
+		parallelReverter:  {Code: common.FromHex("600060006000600060017300000000000000000000000000000000000000e45af15060006000fd")},
+		parallelDestroyer: {Code: common.FromHex("33ff"), Balance: big.NewInt(1000)},
+		parallelEmpty:     {Balance: new(big.Int)},
+	}
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = genesisT.GenesisAccount{Balance: big.NewInt(vars.Ether)}
+	}
+	genesis := &genesisT.Genesis{Config: config, Alloc: alloc, GasLimit: 10_000_000}
+	signer := types.HomesteadSigner{}
+
+	_, blocks, _ := GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		b.SetCoinbase(parallelCoinbase)
+		send := func(key int, to *common.Address, value int64, gas uint64, data []byte) {
+			tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    b.TxNonce(addrs[key]),
+				To:       to,
+				Value:    big.NewInt(value),
+				Gas:      gas,
+				GasPrice: big.NewInt(10 * vars.GWei),
+				Data:     data,
+			}), signer, keys[key])
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.AddTx(tx)
+		}
+		recipient := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		for j := 0; j < 3; j++ {
+			send(0, &recipient, 1, vars.TxGas, nil)
+		}
+		send(1, &parallelCounter, 0, 100_000, nil)
+		send(2, &parallelCounter, 0, 100_000, nil)
+		send(3, &parallelRegistry, 0, 100_000, nil)
+		send(4, &parallelLogger, 0, 100_000, nil)
+		send(4, &parallelLogger, 0, 100_000, nil)
+		send(5, &parallelReverter, 10, 100_000, nil)
+		send(6, nil, 0, 100_000, common.FromHex("600160005500"))
+		send(7, &parallelCoinbase, 1, vars.TxGas, nil)
+		switch i {
+		case 1:
+			send(8, &parallelDestroyer, 0, 100_000, nil)
+		case 2:
+			send(8, &parallelEmpty, 0, vars.TxGas, nil)
+		}
+	})
+	return genesis, blocks
+}
+
+func TestParallelStateProcessor(t *testing.T) {
+	configs := map[string]ctypes.ChainConfigurator{
+		"london": params.TestChainConfig,
+		"homestead": &goethereum.ChainConfig{
+			ChainID:        big.NewInt(1),
+			HomesteadBlock: big.NewInt(0),
+			Ethash:         new(ctypes.EthashConfig),
+		},
+	}
+	for name, config := range configs {
+		genesis, blocks := makeParallelChain(t, config)
+
+		// Importing the chain validates the receipts and state roots.
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{ParallelTxExecution: true}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to create chain: %v", name, err)
+		}
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("%s: failed to insert block %d: %v", name, n, err)
+		}
+		// Execute the blocks again to ensure that both speculative execution
+		// and re-execution took place.
+		var (
+			processor = NewStateProcessor(config, chain, chain.Engine())
+			total     parallelStats
+		)
+		for _, block := range blocks {
+			parent := chain.GetHeaderByHash(block.ParentHash())
+			statedb, err := state.New(parent.Root, chain.StateCache(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var usedGas uint64
+			receipts, _, stats, err := processor.processParallel(block, statedb, vm.Config{}, new(GasPool).AddGas(block.GasLimit()), &usedGas)
+			if err != nil {
+				t.Fatalf("%s: block %d: %v", name, block.NumberU64(), err)
+			}
+			if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+				t.Errorf("%s: block %d: receipt hash mismatch: have %x, want %x", name, block.NumberU64(), hash, block.ReceiptHash())
+			}
+			if usedGas != block.GasUsed() {
+				t.Errorf("%s: block %d: gas used mismatch: have %d, want %d", name, block.NumberU64(), usedGas, block.GasUsed())
+			}
+			total.merged += stats.merged
+			total.reexecuted += stats.reexecuted
+		}
+		if total.merged == 0 || total.reexecuted == 0 {
+			t.Errorf("%s: have %d merged and %d re-executed transactions, want both", name, total.merged, total.reexecuted)
+		}
+		chain.Stop()
+	}
+}
+
+// applyMessageIsolated applies a message like ApplyMessage, but executes it on
+// a copy of the state and merges the recorded modifications back the way the
+// parallel StateProcessor does.
+func applyMessageIsolated(evm *vm.EVM, msg *Message, gp *GasPool, statedb *state.StateDB) (*ExecutionResult, error) {
+	view := newTxStateView(statedb.Copy())
+	view.SetTxContext(common.Hash{}, statedb.TxIndex())
+
+	evm.Reset(NewEVMTxContext(msg), view)
+	pool := *gp
+	result, err := ApplyMessage(evm, msg, &pool)
+	if err == nil {
+		err = view.Error()
+	}
+	ws := view.writeSet()
+	if err != nil || ws.destructs() {
+		evm.Reset(NewEVMTxContext(msg), statedb)
+		return ApplyMessage(evm, msg, gp)
+	}
+	*gp = pool
+	view.merge(statedb, statedb, ws)
+	for _, l := range view.GetLogs(common.Hash{}, 0, common.Hash{}) {
+		statedb.AddLog(&types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+	}
+	return result, nil
+}
+
+// TestApplyMessageIsolated checks that merging the modifications of every
+// transaction on its own leads to the same state as sequential execution.
+func TestApplyMessageIsolated(t *testing.T) {
+	genesis, blocks := makeParallelChain(t, params.TestChainConfig)
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for _, block := range blocks {
+		parent := chain.GetHeaderByHash(block.ParentHash())
+		statedb, err := state.New(parent.Root, chain.StateCache(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var (
+			header  = block.Header()
+			evm     = vm.NewEVM(NewEVMBlockContext(header, chain, nil), vm.TxContext{}, statedb, chain.Config(), vm.Config{})
+			signer  = types.MakeSigner(chain.Config(), header.Number, header.Time)
+			gp      = new(GasPool).AddGas(block.GasLimit())
+			usedGas uint64
+		)
+		for i, tx := range block.Transactions() {
+			msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+			if err != nil {
+				t.Fatal(err)
+			}
+			statedb.SetTxContext(tx.Hash(), i)
+			result, err := applyMessageIsolated(evm, msg, gp, statedb)
+			if err != nil {
+				t.Fatalf("block %d: tx %d: %v", block.NumberU64(), i, err)
+			}
+			statedb.Finalise(true)
+			usedGas += result.UsedGas
+		}
+		chain.Engine().Finalize(chain, header, statedb, block.Transactions(), block.Uncles(), block.Withdrawals())
+
+		if usedGas != block.GasUsed() {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", block.NumberU64(), usedGas, block.GasUsed())
+		}
+		if root := statedb.IntermediateRoot(true); root != block.Root() {
+			t.Errorf("block %d: state root mismatch: have %x, want %x", block.NumberU64(), root, block.Root())
+		}
+	}
+}
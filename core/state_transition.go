@@ -145,6 +145,10 @@ type Message struct {
 	// account nonce in state. It also disables checking that the sender is an EOA.
 	// This field will be set to true for operations like RPC eth_call.
 	SkipAccountChecks bool
+
+	// When SkipFromEOACheck is true, the sender is allowed to have code even if
+	// its nonce is checked. It is used to simulate calls from contract wallets.
+	SkipFromEOACheck bool
 }
 
 // TransactionToMessage converts a transaction into a Message.
@@ -288,10 +292,12 @@ func (st *StateTransition) preCheck() error {
 				msg.From.Hex(), stNonce)
 		}
 		// Make sure the sender is an EOA
-		codeHash := st.state.GetCodeHash(msg.From)
-		if codeHash != (common.Hash{}) && codeHash != types.EmptyCodeHash {
-			return fmt.Errorf("%w: address %v, codehash: %s", ErrSenderNoEOA,
-				msg.From.Hex(), codeHash)
+		if !msg.SkipFromEOACheck {
+			codeHash := st.state.GetCodeHash(msg.From)
+			if codeHash != (common.Hash{}) && codeHash != types.EmptyCodeHash {
+				return fmt.Errorf("%w: address %v, codehash: %s", ErrSenderNoEOA,
+					msg.From.Hex(), codeHash)
+			}
 		}
 	}
 	// Make sure that transaction gasFeeCap is greater than the baseFee (post london)
@@ -48,14 +48,20 @@ type Contract struct {
 	caller        ContractRef
 	self          ContractRef
 
-	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
-	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
+	jumpdests  map[common.Hash]bitvec     // Aggregated result of JUMPDEST analysis.
+	analysis   bitvec                     // Locally cached result of JUMPDEST analysis
+	containers map[common.Hash]*Container // Aggregated decoded EOF containers
 
 	Code     []byte
 	CodeHash common.Hash
 	CodeAddr *common.Address
 	Input    []byte
 
+	Container   *Container // Decoded EOF container, nil for legacy code
+	CodeSection uint64     // Index of the executing EOF code section
+	container   []byte     // Entire EOF code while Code holds the executing section
+	returns     []returnContext
+
 	Gas   uint64
 	value *uint256.Int
 }
@@ -67,8 +73,10 @@ func NewContract(caller ContractRef, object ContractRef, value *uint256.Int, gas
 	if parent, ok := caller.(*Contract); ok {
 		// Reuse JUMPDEST analysis from parent context if available.
 		c.jumpdests = parent.jumpdests
+		c.containers = parent.containers
 	} else {
 		c.jumpdests = make(map[common.Hash]bitvec)
+		c.containers = make(map[common.Hash]*Container)
 	}
 
 	// Gas should be a pointer so it can safely be reduced through the run
@@ -127,6 +135,25 @@ func (c *Contract) isCode(udest uint64) bool {
 	return c.analysis.codeSegment(udest)
 }
 
+// decodeContainer returns the decoded EOF container of the code. Containers of
+// code in the state trie are shared with the parent context, as for the JUMPDEST
+// analysis, initcode is decoded on every execution.
+func (c *Contract) decodeContainer() (*Container, error) {
+	if c.CodeHash != (common.Hash{}) {
+		if container, exist := c.containers[c.CodeHash]; exist {
+			return container, nil
+		}
+	}
+	container := new(Container)
+	if err := container.UnmarshalBinary(c.Code); err != nil {
+		return nil, err
+	}
+	if c.CodeHash != (common.Hash{}) {
+		c.containers[c.CodeHash] = container
+	}
+	return container, nil
+}
+
 // AsDelegate sets the contract to be a delegate call and returns the current
 // contract (for chaining calls)
 func (c *Contract) AsDelegate() *Contract {
@@ -148,6 +175,19 @@ func (c *Contract) GetOp(n uint64) OpCode {
 	return STOP
 }
 
+// returnContext is the point an EOF code section returns to from the function
+// called by CALLF.
+type returnContext struct {
+	section uint64
+	pc      uint64
+}
+
+// setCodeSection switches the executing code to the given EOF code section.
+func (c *Contract) setCodeSection(section uint64) {
+	c.CodeSection = section
+	c.Code = c.Container.Code[section]
+}
+
 // Caller returns the caller of the contract.
 //
 // Caller will recursively call caller when the contract is a delegate
@@ -43,6 +43,10 @@ type PrecompiledContract interface {
 	Run(input []byte) ([]byte, error) // Run runs the precompiled contract
 }
 
+// PrecompiledContracts contains the precompiled contracts supported at a given
+// point of a chain, keyed by address.
+type PrecompiledContracts map[common.Address]PrecompiledContract
+
 var basePrecompiledContracts = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{1}): &ecrecover{},
 	common.BytesToAddress([]byte{2}): &sha256hash{},
@@ -69,7 +73,7 @@ func mergeContracts(base, target map[common.Address]PrecompiledContract) {
 }
 
 // PrecompiledContractsForConfig returns a map containing valid precompiled contracts for a given point in a chain config.
-func PrecompiledContractsForConfig(config ctypes.ChainConfigurator, bn *big.Int, bt *uint64) map[common.Address]PrecompiledContract {
+func PrecompiledContractsForConfig(config ctypes.ChainConfigurator, bn *big.Int, bt *uint64) PrecompiledContracts {
 	// Copying to a new map is necessary because assigning to the original map
 	// creates a memory reference. Further, setting the vals to nil in case of nonconfiguration causes
 	// a panic during tests because they run asynchronously (also a valid reason for using an explicit copy).
@@ -105,6 +109,7 @@ func PrecompiledContractsForConfig(config ctypes.ChainConfigurator, bn *big.Int,
 	if config.IsEnabledByTime(config.GetEIP4844TransitionTime, bt) || config.IsEnabled(config.GetEIP4844Transition, bn) {
 		precompileds[common.BytesToAddress([]byte{0x0a})] = &kzgPointEvaluation{}
 	}
+	mergeCustomContracts(precompileds, config, bn, bt)
 
 	return precompileds
 }
@@ -0,0 +1,179 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+// Gas costs of the built-in custom precompiles, which are fixed regardless of
+// the size of the input.
+const (
+	keccak256FixedGas = 60
+	keccak512FixedGas = 80
+	keccakPoWGas      = 200
+)
+
+var (
+	registryLock sync.RWMutex
+
+	// precompileRegistry holds the precompile implementations which chain
+	// configurations can activate by name as ctypes.CustomPrecompile.
+	precompileRegistry = map[string]PrecompiledContract{
+		"keccak256": &keccak256Fixed{},
+		"keccak512": &keccak512Fixed{},
+		"keccakPoW": &keccakPoW{},
+	}
+)
+
+// RegisterPrecompile makes a precompiled contract implementation available to
+// chain configurations under the given name. Implementations must be stateless
+// as they are shared by all EVMs. It panics if the name is already registered.
+func RegisterPrecompile(name string, p PrecompiledContract) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := precompileRegistry[name]; ok {
+		panic(fmt.Sprintf("precompile %q registered twice", name))
+	}
+	precompileRegistry[name] = p
+}
+
+// RegisteredPrecompiles returns the sorted names of the registered precompiles.
+func RegisteredPrecompiles() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(precompileRegistry))
+	for name := range precompileRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registeredPrecompile returns the implementation registered under a name.
+func registeredPrecompile(name string) (PrecompiledContract, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	p, ok := precompileRegistry[name]
+	return p, ok
+}
+
+// mergeCustomContracts adds the custom precompiles of the chain configuration
+// active at the given block and time. Unregistered names are skipped, chains
+// using them are refused by ValidateCustomPrecompiles.
+func mergeCustomContracts(precompiles PrecompiledContracts, config ctypes.ChainConfigurator, bn *big.Int, bt *uint64) {
+	for _, custom := range config.GetCustomPrecompiles() {
+		if !custom.IsActive(bn, bt) {
+			continue
+		}
+		if p, ok := registeredPrecompile(custom.Name); ok {
+			precompiles[custom.Address] = p
+		}
+	}
+}
+
+// standardPrecompileAddresses are the addresses of the precompiles defined by
+// Ethereum, which custom precompiles cannot replace.
+var standardPrecompileAddresses = func() map[common.Address]struct{} {
+	addrs := make(map[common.Address]struct{})
+	for i := byte(1); i <= 9; i++ {
+		addrs[common.BytesToAddress([]byte{i})] = struct{}{}
+	}
+	for addr := range PrecompiledContractsBLS {
+		addrs[addr] = struct{}{}
+	}
+	return addrs
+}()
+
+// ValidateCustomPrecompiles checks that the custom precompiles of the chain
+// configuration are well-formed, registered and don't replace any of the
+// standard precompiles.
+func ValidateCustomPrecompiles(config ctypes.ChainConfigurator) error {
+	customs := config.GetCustomPrecompiles()
+	if err := customs.Validate(); err != nil {
+		return err
+	}
+	for i := range customs {
+		custom := &customs[i]
+		if _, ok := registeredPrecompile(custom.Name); !ok {
+			return fmt.Errorf("custom precompile %v: unknown implementation, have %v", custom, RegisteredPrecompiles())
+		}
+		if _, ok := standardPrecompileAddresses[custom.Address]; ok {
+			return fmt.Errorf("custom precompile %v: address of a standard precompile", custom)
+		}
+	}
+	return nil
+}
+
+// keccak256Fixed implements the Keccak-256 hash at a fixed cost.
+type keccak256Fixed struct{}
+
+func (c *keccak256Fixed) RequiredGas(input []byte) uint64 {
+	return keccak256FixedGas
+}
+
+func (c *keccak256Fixed) Run(input []byte) ([]byte, error) {
+	return crypto.Keccak256(input), nil
+}
+
+// keccak512Fixed implements the Keccak-512 hash at a fixed cost.
+type keccak512Fixed struct{}
+
+func (c *keccak512Fixed) RequiredGas(input []byte) uint64 {
+	return keccak512FixedGas
+}
+
+func (c *keccak512Fixed) Run(input []byte) ([]byte, error) {
+	return crypto.Keccak512(input), nil
+}
+
+// keccakPoW verifies a Keccak-256 proof-of-work. The input is the 32 byte seal
+// hash, the 8 byte big endian nonce and the 32 byte difficulty. It returns 1 as
+// a 32 byte word if keccak256(hash ‖ nonce) meets the difficulty, 0 otherwise.
+type keccakPoW struct{}
+
+var two256 = new(big.Int).Lsh(common.Big1, 256)
+
+func (c *keccakPoW) RequiredGas(input []byte) uint64 {
+	return keccakPoWGas
+}
+
+func (c *keccakPoW) Run(input []byte) ([]byte, error) {
+	input = common.RightPadBytes(input, 72)
+
+	difficulty := new(big.Int).SetBytes(input[40:72])
+	if difficulty.Sign() == 0 {
+		return make([]byte, 32), nil
+	}
+	result := new(big.Int).SetBytes(crypto.Keccak256(input[:40]))
+
+	out := make([]byte, 32)
+	if result.Cmp(new(big.Int).Div(two256, difficulty)) <= 0 {
+		out[31] = 1
+	}
+	return out, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/coregeth"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+var (
+	customBlockAddr = common.HexToAddress("0x0000000000000000000000000000000000000100")
+	customTimeAddr  = common.HexToAddress("0x0000000000000000000000000000000000000101")
+)
+
+// customPrecompileConfig returns a Classic configuration activating a custom
+// precompile at block 10 and another one at time 1000.
+func customPrecompileConfig() *coregeth.CoreGethChainConfig {
+	config := *params.ClassicChainConfig
+	block, time := uint64(10), uint64(1000)
+	config.CustomPrecompiles = ctypes.CustomPrecompiles{
+		{Name: "keccak256", Address: customBlockAddr, Block: &block},
+		{Name: "keccakPoW", Address: customTimeAddr, Time: &time},
+	}
+	return &config
+}
+
+func TestCustomPrecompileActivation(t *testing.T) {
+	config := customPrecompileConfig()
+	for i, test := range []struct {
+		number          int64
+		time            uint64
+		block, timeAddr bool
+	}{
+		{9, 0, false, false},
+		{10, 0, true, false},
+		{10, 999, true, false},
+		{11, 1000, true, true},
+	} {
+		bt := test.time
+		precompiles := PrecompiledContractsForConfig(config, big.NewInt(test.number), &bt)
+		if _, ok := precompiles[customBlockAddr]; ok != test.block {
+			t.Errorf("test %d: block activated precompile present: %v, want %v", i, ok, test.block)
+		}
+		if _, ok := precompiles[customTimeAddr]; ok != test.timeAddr {
+			t.Errorf("test %d: time activated precompile present: %v, want %v", i, ok, test.timeAddr)
+		}
+		// The standard precompiles are left untouched.
+		if _, ok := precompiles[common.BytesToAddress([]byte{1})]; !ok {
+			t.Errorf("test %d: ecrecover missing", i)
+		}
+	}
+
+	evm := NewEVM(BlockContext{BlockNumber: big.NewInt(10), Time: 0}, TxContext{}, nil, config, Config{})
+	var found bool
+	for _, addr := range evm.ActivePrecompiles() {
+		if addr == customBlockAddr {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("custom precompile missing from active precompiles")
+	}
+}
+
+func TestValidateCustomPrecompiles(t *testing.T) {
+	block := uint64(1)
+	for i, test := range []struct {
+		customs ctypes.CustomPrecompiles
+		valid   bool
+	}{
+		{ctypes.CustomPrecompiles{{Name: "keccak256", Address: customBlockAddr, Block: &block}}, true},
+		{ctypes.CustomPrecompiles{{Name: "unknown", Address: customBlockAddr, Block: &block}}, false},
+		{ctypes.CustomPrecompiles{{Name: "keccak256", Address: customBlockAddr}}, false},
+		{ctypes.CustomPrecompiles{{Name: "keccak256", Address: customBlockAddr, Block: &block, Time: &block}}, false},
+		{ctypes.CustomPrecompiles{{Name: "keccak256", Address: common.BytesToAddress([]byte{1}), Block: &block}}, false},
+		{ctypes.CustomPrecompiles{
+			{Name: "keccak256", Address: customBlockAddr, Block: &block},
+			{Name: "keccak512", Address: customBlockAddr, Block: &block},
+		}, false},
+	} {
+		config := *params.ClassicChainConfig
+		config.CustomPrecompiles = test.customs
+		if err := ValidateCustomPrecompiles(&config); (err == nil) != test.valid {
+			t.Errorf("test %d: have error %v, want valid %v", i, err, test.valid)
+		}
+	}
+}
+
+func TestRegisterPrecompileTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic registering a precompile twice")
+		}
+	}()
+	RegisterPrecompile("keccak256", &keccak256Fixed{})
+}
+
+func TestKeccakPoW(t *testing.T) {
+	var (
+		hash  = crypto.Keccak256([]byte("header"))
+		nonce = make([]byte, 8)
+	)
+	binary.BigEndian.PutUint64(nonce, 42)
+	result := new(big.Int).SetBytes(crypto.Keccak256(hash, nonce))
+
+	// The highest difficulty the result meets.
+	met := new(big.Int).Div(two256, result)
+	for i, test := range []struct {
+		difficulty *big.Int
+		want       byte
+	}{
+		{big.NewInt(0), 0},
+		{big.NewInt(1), 1},
+		{met, 1},
+		{new(big.Int).Add(met, common.Big1), 0},
+		{new(big.Int).Sub(two256, common.Big1), 0},
+	} {
+		input := append(append(common.CopyBytes(hash), nonce...), common.LeftPadBytes(test.difficulty.Bytes(), 32)...)
+		out, err := (&keccakPoW{}).Run(input)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if want := common.LeftPadBytes([]byte{test.want}, 32); !bytes.Equal(out, want) {
+			t.Errorf("test %d: have %x, want %x", i, out, want)
+		}
+	}
+}
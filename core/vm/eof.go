@@ -0,0 +1,261 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	eofFormatByte  = 0xef
+	eofMagicByte   = 0x00
+	eof1Version    = 0x01
+	kindTypes      = 0x01
+	kindCode       = 0x02
+	kindData       = 0x04
+	kindTerminator = 0x00
+
+	eofTypeSize        = 4    // Size of a code section's entry in the types section
+	eofMaxCodeSections = 1024 // Maximum number of code sections
+	eofMaxStackHeight  = 1023 // Maximum stack height of a code section
+	eofMaxIO           = 127  // Maximum number of inputs or outputs of a code section
+	eofNonReturning    = 0x80 // Outputs of a code section which never returns
+
+	// eofMaxReturnStack is the maximum number of nested CALLFs.
+	eofMaxReturnStack = 1024
+)
+
+var eofMagic = []byte{eofFormatByte, eofMagicByte}
+
+var (
+	ErrInvalidMagic           = errors.New("invalid magic")
+	ErrInvalidVersion         = errors.New("invalid version")
+	ErrMissingTypeHeader      = errors.New("missing type header")
+	ErrInvalidTypeSize        = errors.New("invalid type section size")
+	ErrMissingCodeHeader      = errors.New("missing code header")
+	ErrInvalidCodeHeader      = errors.New("invalid code header")
+	ErrInvalidCodeSize        = errors.New("invalid code size")
+	ErrMissingDataHeader      = errors.New("missing data header")
+	ErrMissingTerminator      = errors.New("missing header terminator")
+	ErrTooManyCodeSections    = errors.New("too many code sections")
+	ErrInvalidContainerSize   = errors.New("invalid container size")
+	ErrInvalidSection0Type    = errors.New("invalid section 0 type, input must be 0 and output non-returning")
+	ErrTooLargeMaxStackHeight = errors.New("invalid max stack height")
+	ErrTooManyInputs          = errors.New("invalid type content, too many inputs")
+	ErrTooManyOutputs         = errors.New("invalid type content, too many outputs")
+
+	ErrUndefinedInstruction    = errors.New("undefined instruction")
+	ErrTruncatedImmediate      = errors.New("truncated immediate")
+	ErrInvalidJumpDest         = errors.New("invalid jump destination")
+	ErrInvalidSectionArgument  = errors.New("invalid section argument")
+	ErrInvalidDataLoadN        = errors.New("invalid data section offset")
+	ErrInvalidCodeTermination  = errors.New("invalid code termination")
+	ErrInvalidRetf             = errors.New("invalid RETF")
+	ErrUnreachableCode         = errors.New("unreachable code")
+	ErrUnreachableCodeSections = errors.New("unreachable code sections")
+	ErrConflictingStack        = errors.New("conflicting stack height")
+	ErrInvalidMaxStackHeight   = errors.New("invalid max stack height")
+	ErrEOFStackUnderflow       = errors.New("stack underflow in validation")
+	ErrEOFStackOverflow        = errors.New("stack overflow in validation")
+	ErrInvalidOutputs          = errors.New("invalid number of outputs")
+
+	ErrEOFInitcode           = errors.New("EOF initcode must deploy EOF code")
+	ErrReturnStackExceeded   = errors.New("return stack limit reached")
+	ErrEOFCodeSectionMissing = errors.New("code section out of range")
+)
+
+// hasEOFMagic reports whether the code starts with the EOF magic.
+func hasEOFMagic(code []byte) bool {
+	return len(code) >= len(eofMagic) && bytes.Equal(eofMagic, code[:len(eofMagic)])
+}
+
+// isEOFVersion1 reports whether the code is an EOF container of version 1.
+func isEOFVersion1(code []byte) bool {
+	return hasEOFMagic(code) && len(code) > 2 && code[2] == eof1Version
+}
+
+// Container is an EOF container object, holding the sections of EOF code.
+type Container struct {
+	Types []*FunctionMetadata
+	Code  [][]byte
+	Data  []byte
+}
+
+// FunctionMetadata is the entry of a code section in the types section.
+type FunctionMetadata struct {
+	Inputs         uint8
+	Outputs        uint8
+	MaxStackHeight uint16
+}
+
+// nonReturning reports whether the code section never returns to its caller.
+func (meta *FunctionMetadata) nonReturning() bool {
+	return meta.Outputs == eofNonReturning
+}
+
+// MarshalBinary encodes an EOF container into binary format.
+func (c *Container) MarshalBinary() []byte {
+	b := make([]byte, 0, 15+eofTypeSize*len(c.Types)+2*len(c.Code))
+	b = append(b, eofMagic...)
+	b = append(b, eof1Version)
+
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Types)*eofTypeSize))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Code)))
+	for _, code := range c.Code {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Data)))
+	b = append(b, kindTerminator)
+
+	for _, ty := range c.Types {
+		b = append(b, ty.Inputs, ty.Outputs)
+		b = binary.BigEndian.AppendUint16(b, ty.MaxStackHeight)
+	}
+	for _, code := range c.Code {
+		b = append(b, code...)
+	}
+	return append(b, c.Data...)
+}
+
+// UnmarshalBinary decodes an EOF container and checks its format. The code
+// sections are not validated, which is done by ValidateCode.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	if !hasEOFMagic(b) {
+		return fmt.Errorf("%w: want %x", ErrInvalidMagic, eofMagic)
+	}
+	if len(b) < 3 || b[2] != eof1Version {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidVersion, versionOf(b), eof1Version)
+	}
+	var (
+		offset    = 3
+		typesSize int
+		codeSizes []int
+		dataSize  int
+		err       error
+	)
+	// Parse the section headers.
+	if typesSize, offset, err = parseSectionHeader(b, offset, kindTypes, ErrMissingTypeHeader); err != nil {
+		return err
+	}
+	if typesSize < eofTypeSize || typesSize%eofTypeSize != 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidTypeSize, typesSize)
+	}
+	if codeSizes, offset, err = parseCodeHeader(b, offset); err != nil {
+		return err
+	}
+	if len(codeSizes) != typesSize/eofTypeSize {
+		return fmt.Errorf("%w: mismatch of code sections (%d) and types (%d)", ErrInvalidCodeHeader, len(codeSizes), typesSize/eofTypeSize)
+	}
+	if dataSize, offset, err = parseSectionHeader(b, offset, kindData, ErrMissingDataHeader); err != nil {
+		return err
+	}
+	if offset >= len(b) || b[offset] != kindTerminator {
+		return fmt.Errorf("%w at position %d", ErrMissingTerminator, offset)
+	}
+	offset++
+
+	// The body must consist of exactly the declared sections.
+	expected := offset + typesSize + dataSize
+	for _, size := range codeSizes {
+		expected += size
+	}
+	if len(b) != expected {
+		return fmt.Errorf("%w: have %d, want %d", ErrInvalidContainerSize, len(b), expected)
+	}
+	// Parse the types section.
+	types := make([]*FunctionMetadata, 0, typesSize/eofTypeSize)
+	for i := 0; i < typesSize; i += eofTypeSize {
+		sig := &FunctionMetadata{
+			Inputs:         b[offset+i],
+			Outputs:        b[offset+i+1],
+			MaxStackHeight: binary.BigEndian.Uint16(b[offset+i+2:]),
+		}
+		switch {
+		case sig.Inputs > eofMaxIO:
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyInputs, len(types), sig.Inputs)
+		case sig.Outputs > eofMaxIO && !sig.nonReturning():
+			return fmt.Errorf("%w for section %d: have %d", ErrTooManyOutputs, len(types), sig.Outputs)
+		case sig.MaxStackHeight > eofMaxStackHeight:
+			return fmt.Errorf("%w for section %d: have %d", ErrTooLargeMaxStackHeight, len(types), sig.MaxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].Inputs != 0 || !types[0].nonReturning() {
+		return fmt.Errorf("%w: have %d, %d", ErrInvalidSection0Type, types[0].Inputs, types[0].Outputs)
+	}
+	offset += typesSize
+
+	// Split off the code and data sections.
+	code := make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		code[i] = b[offset : offset+size]
+		offset += size
+	}
+	c.Types = types
+	c.Code = code
+	c.Data = b[offset:]
+	return nil
+}
+
+// parseSectionHeader parses a section header of the given kind, returning the
+// size of the section and the offset of the next header.
+func parseSectionHeader(b []byte, offset int, kind byte, missing error) (int, int, error) {
+	if offset+3 > len(b) || b[offset] != kind {
+		return 0, 0, fmt.Errorf("%w at position %d", missing, offset)
+	}
+	return int(binary.BigEndian.Uint16(b[offset+1:])), offset + 3, nil
+}
+
+// parseCodeHeader parses the code section header, returning the sizes of the
+// code sections and the offset of the next header.
+func parseCodeHeader(b []byte, offset int) ([]int, int, error) {
+	if offset+3 > len(b) || b[offset] != kindCode {
+		return nil, 0, fmt.Errorf("%w at position %d", ErrMissingCodeHeader, offset)
+	}
+	count := int(binary.BigEndian.Uint16(b[offset+1:]))
+	switch {
+	case count == 0:
+		return nil, 0, fmt.Errorf("%w: no code sections", ErrInvalidCodeHeader)
+	case count > eofMaxCodeSections:
+		return nil, 0, fmt.Errorf("%w: have %d", ErrTooManyCodeSections, count)
+	}
+	offset += 3
+	if offset+2*count > len(b) {
+		return nil, 0, fmt.Errorf("%w: truncated code sizes", ErrInvalidCodeHeader)
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = int(binary.BigEndian.Uint16(b[offset+2*i:]))
+		if sizes[i] == 0 {
+			return nil, 0, fmt.Errorf("%w for section %d: size must not be 0", ErrInvalidCodeSize, i)
+		}
+	}
+	return sizes, offset + 2*count, nil
+}
+
+func versionOf(b []byte) int {
+	if len(b) < 3 {
+		return -1
+	}
+	return int(b[2])
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/vars"
+)
+
+// eofInstructionSetForConfig derives the instruction set of EOF code from the
+// instruction set of legacy code, or returns nil if EOF is not enabled at the
+// given block.
+func eofInstructionSetForConfig(config ctypes.ChainConfigurator, bn *big.Int, legacy *JumpTable) *JumpTable {
+	rules := eofRulesForConfig(config, bn)
+	if !rules.container {
+		return nil
+	}
+	jt := copyJumpTable(legacy)
+	enable3540(jt)
+	if rules.validation {
+		enable3670(jt)
+	}
+	if config.IsEnabled(config.GetEIP4200Transition, bn) {
+		enable4200(jt)
+	}
+	if rules.functions {
+		enable4750(jt)
+	}
+	if config.IsEnabled(config.GetEIP7480Transition, bn) {
+		enable7480(jt)
+	}
+	return validate(jt)
+}
+
+// undefinedInstruction returns the operation of an instruction which is not
+// defined in EOF code.
+func undefinedInstruction() *operation {
+	return &operation{execute: opUndefined, maxStack: maxStack(0, 0), undefined: true}
+}
+
+// enable3540 applies EIP-3540 (EOF - EVM Object Format v1)
+// - CODESIZE and CODECOPY operate on the entire container
+func enable3540(jt *JumpTable) {
+	jt[CODESIZE].execute = opEOFCodeSize
+	jt[CODECOPY].execute = opEOFCodeCopy
+}
+
+// enable3670 applies EIP-3670 (EOF - Code Validation)
+// - CALLCODE and SELFDESTRUCT are undefined in EOF code
+func enable3670(jt *JumpTable) {
+	jt[CALLCODE] = undefinedInstruction()
+	jt[SELFDESTRUCT] = undefinedInstruction()
+}
+
+// enable4200 applies EIP-4200 (EOF - Static relative jumps)
+// - Adds RJUMP, RJUMPI and RJUMPV
+func enable4200(jt *JumpTable) {
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: 4,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: 4,
+		minStack:    minStack(1, 0),
+		maxStack:    maxStack(1, 0),
+	}
+}
+
+// enable4750 applies EIP-4750 (EOF - Functions)
+// - Adds CALLF and RETF
+// - JUMP, JUMPI and PC are undefined in EOF code
+func enable4750(jt *JumpTable) {
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 0),
+		maxStack:    maxStack(0, 0),
+	}
+	jt[JUMP] = undefinedInstruction()
+	jt[JUMPI] = undefinedInstruction()
+	jt[PC] = undefinedInstruction()
+}
+
+// enable7480 applies EIP-7480 (EOF - Data section access instructions)
+// - Adds DATALOAD, DATALOADN, DATASIZE and DATACOPY
+func enable7480(jt *JumpTable) {
+	jt[DATALOAD] = &operation{
+		execute:     opDataLoad,
+		constantGas: 4,
+		minStack:    minStack(1, 1),
+		maxStack:    maxStack(1, 1),
+	}
+	jt[DATALOADN] = &operation{
+		execute:     opDataLoadN,
+		constantGas: GasFastestStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	jt[DATASIZE] = &operation{
+		execute:     opDataSize,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+	jt[DATACOPY] = &operation{
+		execute:     opDataCopy,
+		constantGas: GasFastestStep,
+		dynamicGas:  memoryCopierGas(2),
+		minStack:    minStack(3, 0),
+		maxStack:    maxStack(3, 0),
+		memorySize:  memoryDataCopy,
+	}
+}
+
+// readImmediate16 reads the two byte immediate argument of the instruction at
+// pc, zero padded if the code is truncated.
+func readImmediate16(code []byte, pc uint64) uint16 {
+	return binary.BigEndian.Uint16(getData(code, pc+1, 2))
+}
+
+// relativeJump moves pc to the destination of a relative jump, which is
+// relative to the end of the immediates.
+func relativeJump(pc *uint64, end uint64, offset int16) {
+	*pc = end + uint64(int64(offset)) - 1 // pc will be increased by the interpreter loop
+}
+
+// opRjump implements the RJUMP opcode
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.evm.abort.Load() {
+		return nil, errStopToken
+	}
+	offset := int16(readImmediate16(scope.Contract.Code, *pc))
+	relativeJump(pc, *pc+3, offset)
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.evm.abort.Load() {
+		return nil, errStopToken
+	}
+	cond := scope.Stack.pop()
+	if cond.IsZero() {
+		*pc += 2 // Skip the immediate
+		return nil, nil
+	}
+	offset := int16(readImmediate16(scope.Contract.Code, *pc))
+	relativeJump(pc, *pc+3, offset)
+	return nil, nil
+}
+
+// opRjumpv implements the RJUMPV opcode
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.evm.abort.Load() {
+		return nil, errStopToken
+	}
+	var (
+		code  = scope.Contract.Code
+		index = scope.Stack.pop()
+		count = uint64(getData(code, *pc+1, 1)[0]) + 1
+		end   = *pc + 2 + 2*count
+	)
+	if !index.IsUint64() || index.Uint64() >= count {
+		*pc = end - 1 // Fall through, pc will be increased by the interpreter loop
+		return nil, nil
+	}
+	offset := int16(readImmediate16(code, *pc+1+2*index.Uint64()))
+	relativeJump(pc, end, offset)
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		section  = uint64(readImmediate16(contract.Code, *pc))
+	)
+	if section >= uint64(len(contract.Container.Types)) {
+		return nil, ErrEOFCodeSectionMissing
+	}
+	if len(contract.returns) >= eofMaxReturnStack {
+		return nil, ErrReturnStackExceeded
+	}
+	typ := contract.Container.Types[section]
+	if sLen := scope.Stack.len(); sLen+int(typ.MaxStackHeight)-int(typ.Inputs) > int(vars.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: sLen, limit: int(vars.StackLimit) - int(typ.MaxStackHeight) + int(typ.Inputs)}
+	}
+	contract.returns = append(contract.returns, returnContext{
+		section: contract.CodeSection,
+		pc:      *pc + 2, // Resume at the instruction following the immediate
+	})
+	contract.setCodeSection(section)
+	*pc = math.MaxUint64 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	contract := scope.Contract
+	if len(contract.returns) == 0 {
+		return nil, ErrInvalidRetf
+	}
+	ret := contract.returns[len(contract.returns)-1]
+	contract.returns = contract.returns[:len(contract.returns)-1]
+	contract.setCodeSection(ret.section)
+	*pc = ret.pc // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opDataLoad implements the DATALOAD opcode
+func opDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	x := scope.Stack.peek()
+	offset, overflow := x.Uint64WithOverflow()
+	if overflow {
+		offset = math.MaxUint64
+	}
+	x.SetBytes(getData(scope.Contract.Container.Data, offset, 32))
+	return nil, nil
+}
+
+// opDataLoadN implements the DATALOADN opcode
+func opDataLoadN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := uint64(readImmediate16(scope.Contract.Code, *pc))
+	scope.Stack.push(new(uint256.Int).SetBytes(getData(scope.Contract.Container.Data, offset, 32)))
+	*pc += 2 // Skip the immediate
+	return nil, nil
+}
+
+// opDataSize implements the DATASIZE opcode
+func opDataSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.push(new(uint256.Int).SetUint64(uint64(len(scope.Contract.Container.Data))))
+	return nil, nil
+}
+
+// opDataCopy implements the DATACOPY opcode
+func opDataCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.pop()
+		offset    = scope.Stack.pop()
+		size      = scope.Stack.pop()
+	)
+	uint64Offset, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		uint64Offset = math.MaxUint64
+	}
+	scope.Memory.Set(memOffset.Uint64(), size.Uint64(), getData(scope.Contract.Container.Data, uint64Offset, size.Uint64()))
+	return nil, nil
+}
+
+// opEOFCodeSize implements the CODESIZE opcode of EOF code
+func opEOFCodeSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.push(new(uint256.Int).SetUint64(uint64(len(scope.Contract.container))))
+	return nil, nil
+}
+
+// opEOFCodeCopy implements the CODECOPY opcode of EOF code
+func opEOFCodeCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset  = scope.Stack.pop()
+		codeOffset = scope.Stack.pop()
+		length     = scope.Stack.pop()
+	)
+	uint64CodeOffset, overflow := codeOffset.Uint64WithOverflow()
+	if overflow {
+		uint64CodeOffset = math.MaxUint64
+	}
+	scope.Memory.Set(memOffset.Uint64(), length.Uint64(), getData(scope.Contract.container, uint64CodeOffset, length.Uint64()))
+	return nil, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/goethereum"
+)
+
+// eofTestConfig returns a Shanghai chain configuration with all EOF EIPs enabled.
+func eofTestConfig() *goethereum.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.ShanghaiTime = new(uint64)
+	config.EIP3540Transition = big.NewInt(0)
+	config.EIP3670Transition = big.NewInt(0)
+	config.EIP4200Transition = big.NewInt(0)
+	config.EIP4750Transition = big.NewInt(0)
+	config.EIP5450Transition = big.NewInt(0)
+	config.EIP7480Transition = big.NewInt(0)
+	return &config
+}
+
+// eofMain returns the types entry of a first code section.
+func eofMain(maxStackHeight uint16) *FunctionMetadata {
+	return &FunctionMetadata{Inputs: 0, Outputs: eofNonReturning, MaxStackHeight: maxStackHeight}
+}
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, want := range []Container{
+		{
+			Types: []*FunctionMetadata{eofMain(0)},
+			Code:  [][]byte{common.FromHex("00")},
+			Data:  []byte{},
+		},
+		{
+			Types: []*FunctionMetadata{eofMain(2), {Inputs: 2, Outputs: 1, MaxStackHeight: 2}},
+			Code:  [][]byte{common.FromHex("60026003e3000100"), common.FromHex("01e4")},
+			Data:  common.FromHex("0102030405"),
+		},
+	} {
+		var have Container
+		if err := have.UnmarshalBinary(want.MarshalBinary()); err != nil {
+			t.Fatalf("test %d: failed to unmarshal: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d: have %+v, want %+v", i, have, want)
+		}
+	}
+}
+
+func TestEOFUnmarshalErrors(t *testing.T) {
+	for i, test := range []struct {
+		code string
+		want error
+	}{
+		{"ef", ErrInvalidMagic},
+		{"ef01010004020001000104000000800000" + "00", ErrInvalidMagic},
+		{"ef0002010004020001000104000000800000" + "00", ErrInvalidVersion},
+		{"ef000102000400", ErrMissingTypeHeader},
+		{"ef000101000302000100010400000000800000" + "00", ErrInvalidTypeSize},
+		{"ef000101000404000000", ErrMissingCodeHeader},
+		{"ef00010100040200000400000000800000", ErrInvalidCodeHeader},
+		{"ef000101000402000100000400000000800000", ErrInvalidCodeSize},
+		{"ef000101000402000100010300000000800000" + "00", ErrMissingDataHeader},
+		{"ef000101000402000100010400000100800000" + "00", ErrMissingTerminator},
+		{"ef000101000402000100010400000000800000", ErrInvalidContainerSize},
+		{"ef000101000402000100010400000000800000" + "0000", ErrInvalidContainerSize},
+		{"ef000101000402000100010400000001800000" + "00", ErrInvalidSection0Type},
+		{"ef000101000402000100010400000000800400" + "00", ErrTooLargeMaxStackHeight},
+	} {
+		var c Container
+		if err := c.UnmarshalBinary(common.FromHex(test.code)); !errors.Is(err, test.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.want)
+		}
+	}
+}
+
+func TestEOFValidation(t *testing.T) {
+	var (
+		config = eofTestConfig()
+		jt     = eofInstructionSetForConfig(config, common.Big0, instructionSetForConfig(config, false, common.Big0, nil))
+		rules  = eofRulesForConfig(config, common.Big0)
+		adder  = &FunctionMetadata{Inputs: 2, Outputs: 1, MaxStackHeight: 2}
+	)
+	for i, test := range []struct {
+		types []*FunctionMetadata
+		code  []string
+		data  string
+		want  error
+	}{
+		{[]*FunctionMetadata{eofMain(0)}, []string{"00"}, "", nil},
+		{[]*FunctionMetadata{eofMain(2)}, []string{"60016002f3"}, "", nil},
+		{[]*FunctionMetadata{eofMain(2), adder}, []string{"60026003e3000100", "01e4"}, "", nil},
+		{[]*FunctionMetadata{eofMain(0), adder}, []string{"00", "01e4"}, "", ErrUnreachableCodeSections},
+		{[]*FunctionMetadata{eofMain(2), adder}, []string{"60026003e3000200", "01e4"}, "", ErrInvalidSectionArgument},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"e4"}, "", ErrInvalidRetf},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"d100005000"}, "00", ErrInvalidDataLoadN},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"d100005000"}, "0000000000000000000000000000000000000000000000000000000000000000", nil},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"0c00"}, "", ErrUndefinedInstruction},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"600056"}, "", ErrUndefinedInstruction},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"ff"}, "", ErrUndefinedInstruction},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"6100"}, "", ErrTruncatedImmediate},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"e200"}, "", ErrTruncatedImmediate},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"6001"}, "", ErrInvalidCodeTermination},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"e0fffd"}, "", nil},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"6001e1fffe00"}, "", ErrInvalidJumpDest},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"6001e1000100"}, "", ErrInvalidJumpDest},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"6001e200000000"}, "", nil},
+		{[]*FunctionMetadata{eofMain(0)}, []string{"e000010000"}, "", ErrUnreachableCode},
+		{[]*FunctionMetadata{eofMain(1)}, []string{"0100"}, "", ErrEOFStackUnderflow},
+		{[]*FunctionMetadata{eofMain(2)}, []string{"60016001e1fff900"}, "", ErrConflictingStack},
+		{[]*FunctionMetadata{eofMain(3)}, []string{"60016002f3"}, "", ErrInvalidMaxStackHeight},
+		{[]*FunctionMetadata{eofMain(2), {Inputs: 2, Outputs: 2, MaxStackHeight: 2}}, []string{"60026003e3000100", "01e4"}, "", ErrInvalidOutputs},
+	} {
+		c := &Container{Types: test.types, Data: common.FromHex(test.data)}
+		for _, code := range test.code {
+			c.Code = append(c.Code, common.FromHex(code))
+		}
+		_, err := parseEOF(c.MarshalBinary(), jt, rules)
+		if !errors.Is(err, test.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.want)
+		}
+	}
+}
+
+func TestEOFExecution(t *testing.T) {
+	data := make([]byte, 32)
+	data[31] = 10
+	for i, test := range []struct {
+		container *Container
+		want      uint64
+	}{
+		{
+			// Relative jumps: 1 ? 20 : 10
+			container: &Container{
+				Types: []*FunctionMetadata{eofMain(2)},
+				Code:  [][]byte{common.FromHex("6001e10005600ae0000260145f5260205ff3")},
+			},
+			want: 20,
+		},
+		{
+			// Functions and data: add(2, 3) + data[0:32]
+			container: &Container{
+				Types: []*FunctionMetadata{eofMain(2), {Inputs: 2, Outputs: 1, MaxStackHeight: 2}},
+				Code:  [][]byte{common.FromHex("60026003e30001d10000015f5260205ff3"), common.FromHex("01e4")},
+				Data:  data,
+			},
+			want: 15,
+		},
+	} {
+		var (
+			address = common.BytesToAddress([]byte("eof"))
+			code    = test.container.MarshalBinary()
+		)
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, code)
+
+		evm := NewEVM(BlockContext{BlockNumber: common.Big0, Transfer: func(StateDB, common.Address, common.Address, *uint256.Int) {}}, TxContext{}, statedb, eofTestConfig(), Config{})
+		ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100_000, new(uint256.Int))
+		if err != nil {
+			t.Fatalf("test %d: execution failed: %v", i, err)
+		}
+		if have := new(uint256.Int).SetBytes(ret); !have.Eq(uint256.NewInt(test.want)) {
+			t.Errorf("test %d: have result %v, want %d", i, have, test.want)
+		}
+	}
+}
+
+func TestEOFCreate(t *testing.T) {
+	runtime := (&Container{
+		Types: []*FunctionMetadata{eofMain(0)},
+		Code:  [][]byte{{byte(STOP)}},
+	}).MarshalBinary()
+
+	// The initcode returns its data section as code.
+	eofInitcode := (&Container{
+		Types: []*FunctionMetadata{eofMain(3)},
+		Code:  [][]byte{common.FromHex("d25f5fd3d25ff3")},
+		Data:  runtime,
+	}).MarshalBinary()
+	eofInitcodeLegacy := (&Container{
+		Types: []*FunctionMetadata{eofMain(2)},
+		Code:  [][]byte{common.FromHex("60015ff3")},
+	}).MarshalBinary()
+
+	// The legacy initcode returns the code at the end of it.
+	legacyInitcode := append(common.FromHex("6014600a5f3960145ff3"), runtime...)
+
+	for i, test := range []struct {
+		initcode []byte
+		want     error
+	}{
+		{initcode: eofInitcode},
+		{initcode: eofInitcodeLegacy, want: ErrEOFInitcode},
+		{initcode: common.FromHex("ef000101000402000100020400000000800000" + "0c00"), want: ErrUndefinedInstruction},
+		{initcode: legacyInitcode, want: ErrInvalidCode},
+	} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		evm := NewEVM(BlockContext{
+			BlockNumber: common.Big0,
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		}, TxContext{}, statedb, eofTestConfig(), Config{})
+
+		_, address, gas, err := evm.Create(AccountRef(common.Address{}), test.initcode, 100_000, new(uint256.Int))
+		if !errors.Is(err, test.want) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.want)
+			continue
+		}
+		if err != nil {
+			if gas != 0 {
+				t.Errorf("test %d: have %d gas left after failure, want 0", i, gas)
+			}
+			continue
+		}
+		if code := statedb.GetCode(address); !bytes.Equal(code, runtime) {
+			t.Errorf("test %d: have code %x, want %x", i, code, runtime)
+		}
+	}
+}
+
+func TestEOFContainerCache(t *testing.T) {
+	code := (&Container{
+		Types: []*FunctionMetadata{eofMain(0)},
+		Code:  [][]byte{{byte(STOP)}},
+	}).MarshalBinary()
+
+	// Containers of deployed code are decoded once and shared with the callees.
+	parent := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{1}), new(uint256.Int), 0)
+	parent.SetCallCode(&common.Address{1}, common.Hash{1}, code)
+	child := NewContract(parent, AccountRef(common.Address{1}), new(uint256.Int), 0)
+	child.SetCallCode(&common.Address{1}, common.Hash{1}, code)
+
+	have, err := parent.decodeContainer()
+	if err != nil {
+		t.Fatalf("failed to decode container: %v", err)
+	}
+	if cached, _ := child.decodeContainer(); cached != have {
+		t.Error("container not shared with the callee")
+	}
+	// Initcode has no hash and is decoded on every execution.
+	initcode := NewContract(parent, AccountRef(common.Address{2}), new(uint256.Int), 0)
+	initcode.Code = code
+	first, _ := initcode.decodeContainer()
+	if second, _ := initcode.decodeContainer(); first == second {
+		t.Error("initcode container cached")
+	}
+	if len(parent.containers) != 1 {
+		t.Errorf("have %d cached containers, want 1", len(parent.containers))
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/vars"
+)
+
+// eofRules are the EOF rules enabled at a given point of the chain. EIP-3540
+// introduces the container, the other EIPs define its code and are only
+// meaningful in combination with it.
+type eofRules struct {
+	container  bool // EIP-3540: EOF - EVM Object Format v1
+	validation bool // EIP-3670: EOF - Code Validation
+	functions  bool // EIP-4750: EOF - Functions
+	stack      bool // EIP-5450: EOF - Stack Validation
+}
+
+// eofRulesForConfig returns the EOF rules enabled at the given block.
+func eofRulesForConfig(config ctypes.ChainConfigurator, bn *big.Int) eofRules {
+	if !config.IsEnabled(config.GetEIP3540Transition, bn) {
+		return eofRules{}
+	}
+	return eofRules{
+		container:  true,
+		validation: config.IsEnabled(config.GetEIP3670Transition, bn),
+		functions:  config.IsEnabled(config.GetEIP4750Transition, bn),
+		stack:      config.IsEnabled(config.GetEIP5450Transition, bn),
+	}
+}
+
+// parseEOF decodes and validates EOF code against the given instruction set.
+func parseEOF(code []byte, jt *JumpTable, rules eofRules) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := c.validateCode(jt, rules); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// validateCode validates the code sections of the container. Instructions are
+// checked if EIP-3670 is enabled, the stack if EIP-5450 is enabled in addition.
+func (c *Container) validateCode(jt *JumpTable, rules eofRules) error {
+	if !rules.functions && len(c.Code) > 1 {
+		return fmt.Errorf("%w: have %d, functions are not enabled", ErrTooManyCodeSections, len(c.Code))
+	}
+	if !rules.validation {
+		return nil
+	}
+	// Validate all sections and ensure they are reachable from the first one.
+	callees := make([][]int, len(c.Code))
+	for i, code := range c.Code {
+		var err error
+		if callees[i], err = validateInstructions(code, i, c, jt); err != nil {
+			return fmt.Errorf("section %d: %w", i, err)
+		}
+		if rules.stack {
+			if err := validateStack(code, i, c, jt); err != nil {
+				return fmt.Errorf("section %d: %w", i, err)
+			}
+		}
+	}
+	var (
+		reached = make([]bool, len(c.Code))
+		queue   = []int{0}
+	)
+	reached[0] = true
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, callee := range callees[current] {
+			if !reached[callee] {
+				reached[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	for i := range reached {
+		if !reached[i] {
+			return fmt.Errorf("%w: section %d", ErrUnreachableCodeSections, i)
+		}
+	}
+	return nil
+}
+
+// immediateSize returns the size of the immediate data of the instruction at
+// pos, which may exceed the code if the instruction is truncated.
+func immediateSize(code []byte, pos int) int {
+	switch op := OpCode(code[pos]); {
+	case op >= PUSH1 && op <= PUSH32:
+		return int(op - PUSH1 + 1)
+	case op == RJUMP, op == RJUMPI, op == CALLF, op == DATALOADN:
+		return 2
+	case op == RJUMPV:
+		if pos+1 < len(code) {
+			return 1 + 2*(int(code[pos+1])+1)
+		}
+		return 1
+	}
+	return 0
+}
+
+// isTerminal reports whether the instruction ends the execution of a section.
+func isTerminal(op OpCode) bool {
+	switch op {
+	case STOP, RETURN, REVERT, INVALID, RETF:
+		return true
+	}
+	return false
+}
+
+// relativeJumpTargets returns the destinations of the relative jump at pos,
+// whose immediates are known to be complete.
+func relativeJumpTargets(code []byte, pos int) []int {
+	switch OpCode(code[pos]) {
+	case RJUMP, RJUMPI:
+		return []int{pos + 3 + int(int16(binary.BigEndian.Uint16(code[pos+1:])))}
+	case RJUMPV:
+		var (
+			count   = int(code[pos+1]) + 1
+			next    = pos + 2 + 2*count
+			targets = make([]int, count)
+		)
+		for i := range targets {
+			targets[i] = next + int(int16(binary.BigEndian.Uint16(code[pos+2+2*i:])))
+		}
+		return targets
+	}
+	return nil
+}
+
+// validateInstructions checks the instructions of a code section according to
+// EIP-3670, the relative jumps of EIP-4200, the functions of EIP-4750 and the
+// data access of EIP-7480. It returns the sections called by the code.
+func validateInstructions(code []byte, section int, c *Container, jt *JumpTable) ([]int, error) {
+	var (
+		boundaries = make([]bool, len(code))
+		targets    []int
+		callees    []int
+		hasRetf    bool
+		last       OpCode
+	)
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		if jt[op].undefined {
+			return nil, fmt.Errorf("%w: %v at position %d", ErrUndefinedInstruction, op, pos)
+		}
+		size := immediateSize(code, pos)
+		if pos+size >= len(code) && size > 0 {
+			return nil, fmt.Errorf("%w: %v at position %d", ErrTruncatedImmediate, op, pos)
+		}
+		boundaries[pos] = true
+
+		switch op {
+		case RJUMP, RJUMPI, RJUMPV:
+			targets = append(targets, relativeJumpTargets(code, pos)...)
+		case CALLF:
+			callee := int(binary.BigEndian.Uint16(code[pos+1:]))
+			if callee >= len(c.Types) {
+				return nil, fmt.Errorf("%w: CALLF to section %d at position %d", ErrInvalidSectionArgument, callee, pos)
+			}
+			if c.Types[callee].nonReturning() {
+				return nil, fmt.Errorf("%w: CALLF to non-returning section %d at position %d", ErrInvalidSectionArgument, callee, pos)
+			}
+			callees = append(callees, callee)
+		case RETF:
+			if c.Types[section].nonReturning() {
+				return nil, fmt.Errorf("%w: non-returning section at position %d", ErrInvalidRetf, pos)
+			}
+			hasRetf = true
+		case DATALOADN:
+			if offset := int(binary.BigEndian.Uint16(code[pos+1:])); offset+32 > len(c.Data) {
+				return nil, fmt.Errorf("%w: offset %d at position %d, data size %d", ErrInvalidDataLoadN, offset, pos, len(c.Data))
+			}
+		}
+		last = op
+		pos += 1 + size
+	}
+	if !isTerminal(last) && last != RJUMP {
+		return nil, fmt.Errorf("%w: last instruction %v", ErrInvalidCodeTermination, last)
+	}
+	if !c.Types[section].nonReturning() && !hasRetf {
+		return nil, fmt.Errorf("%w: returning section without RETF", ErrInvalidRetf)
+	}
+	for _, target := range targets {
+		if target < 0 || target >= len(code) || !boundaries[target] {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidJumpDest, target)
+		}
+	}
+	return callees, nil
+}
+
+// validateStack checks that every instruction of a code section is reachable
+// and executed with a fixed stack height which neither underflows nor exceeds
+// the declared maximum, as specified by EIP-5450. The instructions must have
+// been validated before.
+func validateStack(code []byte, section int, c *Container, jt *JumpTable) error {
+	heights := make([]int, len(code))
+	for i := range heights {
+		heights[i] = -1
+	}
+	var (
+		meta      = c.Types[section]
+		maxHeight = int(meta.Inputs)
+		worklist  = []int{0}
+	)
+	heights[0] = int(meta.Inputs)
+
+	for len(worklist) > 0 {
+		pos := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+
+		var (
+			op     = OpCode(code[pos])
+			height = heights[pos]
+			pops   = jt[op].minStack
+			pushes = pops + int(vars.StackLimit) - jt[op].maxStack
+		)
+		switch op {
+		case CALLF:
+			callee := c.Types[binary.BigEndian.Uint16(code[pos+1:])]
+			pops, pushes = int(callee.Inputs), int(callee.Outputs)
+			if height+int(callee.MaxStackHeight)-pops > int(vars.StackLimit) {
+				return fmt.Errorf("%w: CALLF at position %d", ErrEOFStackOverflow, pos)
+			}
+		case RETF:
+			if height != int(meta.Outputs) {
+				return fmt.Errorf("%w: have %d, want %d at position %d", ErrInvalidOutputs, height, meta.Outputs, pos)
+			}
+		}
+		if height < pops {
+			return fmt.Errorf("%w: %v at position %d requires %d items, have %d", ErrEOFStackUnderflow, op, pos, pops, height)
+		}
+		next := height - pops + pushes
+		if next > eofMaxStackHeight {
+			return fmt.Errorf("%w: %v at position %d", ErrEOFStackOverflow, op, pos)
+		}
+		if next > maxHeight {
+			maxHeight = next
+		}
+		// Propagate the stack height to the successors of the instruction.
+		var successors []int
+		if !isTerminal(op) && op != RJUMP {
+			successors = append(successors, pos+1+immediateSize(code, pos))
+		}
+		successors = append(successors, relativeJumpTargets(code, pos)...)
+		for _, succ := range successors {
+			if succ >= len(code) {
+				return fmt.Errorf("%w: execution runs off the end of the code", ErrInvalidCodeTermination)
+			}
+			switch heights[succ] {
+			case -1:
+				heights[succ] = next
+				worklist = append(worklist, succ)
+			case next:
+			default:
+				return fmt.Errorf("%w: have %d and %d at position %d", ErrConflictingStack, heights[succ], next, succ)
+			}
+		}
+	}
+	// Every instruction must have been reached.
+	for pos := 0; pos < len(code); pos += 1 + immediateSize(code, pos) {
+		if heights[pos] == -1 {
+			return fmt.Errorf("%w: position %d", ErrUnreachableCode, pos)
+		}
+	}
+	if maxHeight != int(meta.MaxStackHeight) {
+		return fmt.Errorf("%w: have %d, declared %d", ErrInvalidMaxStackHeight, maxHeight, meta.MaxStackHeight)
+	}
+	return nil
+}
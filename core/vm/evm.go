@@ -21,7 +21,7 @@ import (
 	"math/big"
 	"sync/atomic"
 
-	"github.com/ethereum/evmc/v7/bindings/go/evmc"
+	"github.com/ethereum/evmc/v12/bindings/go/evmc"
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/crypto"
@@ -42,17 +42,21 @@ type (
 
 // ActivePrecompiles returns the addresses of the precompiles enabled with the current configuration.
 func (evm *EVM) ActivePrecompiles() []common.Address {
-	p := PrecompiledContractsForConfig(evm.chainConfig, evm.Context.BlockNumber, &evm.Context.Time)
 	addresses := []common.Address{}
-	for k := range p {
+	for k := range evm.precompiles {
 		addresses = append(addresses, k)
 	}
 	return addresses
 }
 
+// SetPrecompiles replaces the precompiled contracts of the EVM, e.g. to move them
+// to different addresses during simulations. The EVM takes ownership of the set.
+func (evm *EVM) SetPrecompiles(precompiles PrecompiledContracts) {
+	evm.precompiles = precompiles
+}
+
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
-	var precompiles = PrecompiledContractsForConfig(evm.ChainConfig(), evm.Context.BlockNumber, &evm.Context.Time)
-	p, ok := precompiles[addr]
+	p, ok := evm.precompiles[addr]
 	return p, ok
 }
 
@@ -126,6 +130,9 @@ type EVM struct {
 
 	// chainConfig contains information about the current chain
 	chainConfig ctypes.ChainConfigurator
+	// precompiles holds the precompiled contracts active in the current
+	// block, shared between all EVMs of the same fork range
+	precompiles PrecompiledContracts
 	// virtual machine configuration options used to initialise the
 	// evm.
 	Config Config
@@ -164,21 +171,22 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		StateDB:     statedb,
 		Config:      config,
 		chainConfig: chainConfig,
-		// The list of interpreters, space reserved for both EVM and EWASM ones.
-		interpreters: make([]Interpreter, 0, 2),
+		precompiles: cachedPrecompiledContracts(chainConfig, blockCtx.BlockNumber, &blockCtx.Time),
+		// The list of interpreters, space reserved for EWASM, EVMC and native ones.
+		interpreters: make([]Interpreter, 0, 3),
 	}
 
 	// In some implementations, EWASM may be configured with a block number.
 	// In this implementation, the interpreter is configured globally instead.
 	if config.EWASMInterpreter != "" {
-		evm.interpreters = append(evm.interpreters, &EVMC{ewasmModule, evm, evmc.CapabilityEWASM, false})
+		evm.interpreters = append(evm.interpreters, newEVMC(ewasmModule, evm, evmc.CapabilityEWASM))
 	}
 
 	if config.EVMInterpreter != "" {
-		evm.interpreters = append(evm.interpreters, &EVMC{evmModule, evm, evmc.CapabilityEVM1, false})
-	} else {
-		evm.interpreters = append(evm.interpreters, NewEVMInterpreter(evm))
+		evm.interpreters = append(evm.interpreters, newEVMC(evmModule, evm, evmc.CapabilityEVM1))
 	}
+	// The native interpreter is kept last, running the code EVMC VMs can't.
+	evm.interpreters = append(evm.interpreters, NewEVMInterpreter(evm))
 
 	evm.interpreter = evm.interpreters[0]
 
@@ -502,15 +510,29 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	ret, err := run(evm, contract, nil, false)
+	// EOF initcode is validated before its execution and must deploy valid EOF
+	// code, while legacy initcode cannot deploy it.
+	var (
+		ret         []byte
+		err         error
+		eofInitcode = hasEOFMagic(codeAndHash.code) && evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP3540Transition, evm.Context.BlockNumber)
+	)
+	if eofInitcode {
+		err = evm.validateEOF(codeAndHash.code)
+	}
+	if err == nil {
+		ret, err = run(evm, contract, nil, false)
+	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
 	if err == nil && evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP170Transition, evm.Context.BlockNumber) && uint64(len(ret)) > vars.MaxCodeSize {
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP3541Transition, evm.Context.BlockNumber) {
+	if err == nil && eofInitcode {
+		err = evm.validateEOF(ret)
+	} else if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.ChainConfig().IsEnabled(evm.chainConfig.GetEIP3541Transition, evm.Context.BlockNumber) {
+		// Reject code starting with 0xEF if EIP-3541 is enabled.
 		err = ErrInvalidCode
 	}
 
@@ -547,6 +569,19 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	return ret, address, contract.Gas, err
 }
 
+// validateEOF decodes and validates EOF code with the rules of the current block.
+func (evm *EVM) validateEOF(code []byte) error {
+	if !hasEOFMagic(code) {
+		return ErrEOFInitcode
+	}
+	jt := cachedEOFInstructionSet(evm.chainConfig, evm.Context.Random != nil, evm.Context.BlockNumber, &evm.Context.Time)
+	if in, ok := evm.interpreter.(*EVMInterpreter); ok && in.eofTable != nil {
+		jt = in.eofTable
+	}
+	_, err := parseEOF(code, jt, eofRulesForConfig(evm.chainConfig, evm.Context.BlockNumber))
+	return err
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	if evm.ChainConfig().IsEnabled(evm.ChainConfig().GetLyra2NonceTransition, evm.Context.BlockNumber) {
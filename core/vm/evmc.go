@@ -21,18 +21,25 @@ package vm
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"sync"
 
-	"github.com/ethereum/evmc/v7/bindings/go/evmc"
-	"github.com/shudolab/core-geth/params/vars"
+	"github.com/ethereum/evmc/v12/bindings/go/evmc"
 
+	"github.com/holiman/uint256"
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/log"
-	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+var (
+	// ErrEVMCRevisionMismatch is returned when the EIPs enabled for a block do
+	// not add up to any revision an EVMC VM can execute.
+	ErrEVMCRevisionMismatch = errors.New("chain configuration does not match an EVMC revision")
 )
 
 // EVMC represents the reference to a common EVMC-based VM instance and
@@ -42,6 +49,17 @@ type EVMC struct {
 	env      *EVM            // The execution context.
 	cap      evmc.Capability // The supported EVMC capability (EVM or Ewasm)
 	readOnly bool            // The readOnly flag (TODO: Try to get rid of it).
+
+	rev    evmc.Revision // The revision for the block of the execution context.
+	revErr error         // The error resolving the revision, if any.
+	revSet bool          // Whether the revision has been resolved.
+
+	refund int64 // The gas refund of the most recently finished child frame.
+}
+
+// newEVMC creates an interpreter running the given EVMC VM instance.
+func newEVMC(instance *evmc.VM, env *EVM, cap evmc.Capability) *EVMC {
+	return &EVMC{instance: instance, env: env, cap: cap}
 }
 
 var (
@@ -106,6 +124,7 @@ func initEVMC(cap evmc.Capability, config string) *evmc.VM {
 
 // hostContext implements evmc.HostContext interface.
 type hostContext struct {
+	vm       *EVMC     // The interpreter running the current frame.
 	env      *EVM      // The reference to the EVM execution context.
 	contract *Contract // The reference to the current contract, needed by Call-like methods.
 }
@@ -123,87 +142,57 @@ func (host *hostContext) AccountExists(evmcAddr evmc.Address) bool {
 }
 
 func (host *hostContext) GetStorage(addr evmc.Address, evmcKey evmc.Hash) evmc.Hash {
-	var value uint256.Int
-	key := common.Hash(evmcKey)
-	value.SetBytes(host.env.StateDB.GetState(common.Address(addr), key).Bytes())
-	return evmc.Hash(value.Bytes32())
+	return evmc.Hash(host.env.StateDB.GetState(common.Address(addr), common.Hash(evmcKey)))
 }
 
-func (host *hostContext) SetStorage(evmcAddr evmc.Address, evmcKey evmc.Hash, evmcValue evmc.Hash) (status evmc.StorageStatus) {
-	addr := common.Address(evmcAddr)
-	key := common.Hash(evmcKey)
-	value := new(uint256.Int).SetBytes(evmcValue[:])
-	var oldValue uint256.Int
-	oldValue.SetBytes(host.env.StateDB.GetState(addr, key).Bytes())
-	if oldValue.Eq(value) {
-		return evmc.StorageUnchanged
-	}
-
-	var current, original uint256.Int
-	current.SetBytes(host.env.StateDB.GetState(addr, key).Bytes())
-	original.SetBytes(host.env.StateDB.GetCommittedState(addr, key).Bytes())
-
-	host.env.StateDB.SetState(addr, key, common.BytesToHash(value.Bytes()))
-
-	// Here's a great example of one of the limits of our (core-geth) current chainconfig interface model.
-	// Should we handle the logic here about historic-featuro logic (which really is nice, because when reading the strange-incantation implemations, it's nice to see why it is),
-	// or should we handle the question where we handle the rest of the questions like this, since this logic is
-	// REALLY logic that belongs to the abstract idea of a chainconfiguration (aka chainconfig), which makes sense
-	// but depends on ECIPs having steadier and more predictable logic.
-	hasEIP2200 := host.env.ChainConfig().IsEnabled(host.env.ChainConfig().GetEIP2200Transition, host.env.Context.BlockNumber)
-	hasEIP1884 := host.env.ChainConfig().IsEnabled(host.env.ChainConfig().GetEIP1884Transition, host.env.Context.BlockNumber)
-
-	// Here's an example where to me, it makes sense to use individual EIPs in the code...
-	// when they don't specify each other in the spec.
-	hasNetStorageCostEIP := hasEIP2200 && hasEIP1884
-	if !hasNetStorageCostEIP {
-		status = evmc.StorageModified
-		if oldValue.IsZero() {
-			return evmc.StorageAdded
-		} else if value.IsZero() {
-			host.env.StateDB.AddRefund(vars.SstoreRefundGas)
-			return evmc.StorageDeleted
-		}
-		return evmc.StorageModified
-	}
-
-	resetClearRefund := vars.NetSstoreResetClearRefund
-	cleanRefund := vars.NetSstoreResetRefund
-
-	if hasEIP2200 {
-		resetClearRefund = vars.SstoreSetGasEIP2200 - vars.SloadGasEIP2200 // 19200
-		cleanRefund = vars.SstoreResetGasEIP2200 - vars.SloadGasEIP2200    // 4200
+// SetStorage writes the storage slot and classifies the write by the
+// original, current and new values of the slot. The VM derives both the gas
+// cost and the refund of the write from the status and its own revision.
+func (host *hostContext) SetStorage(evmcAddr evmc.Address, evmcKey evmc.Hash, evmcValue evmc.Hash) evmc.StorageStatus {
+	var (
+		addr     = common.Address(evmcAddr)
+		key      = common.Hash(evmcKey)
+		value    = common.Hash(evmcValue)
+		current  = host.env.StateDB.GetState(addr, key)
+		original = host.env.StateDB.GetCommittedState(addr, key)
+	)
+	if current == value {
+		return evmc.StorageAssigned
 	}
+	host.env.StateDB.SetState(addr, key, value)
 
 	if original == current {
-		if original.IsZero() { // create slot (2.1.1)
+		switch {
+		case original == (common.Hash{}): // 0 -> 0 -> Z
 			return evmc.StorageAdded
-		}
-		if value.IsZero() { // delete slot (2.1.2b)
-			host.env.StateDB.AddRefund(vars.NetSstoreClearRefund)
+		case value == (common.Hash{}): // X -> X -> 0
 			return evmc.StorageDeleted
+		default: // X -> X -> Z
+			return evmc.StorageModified
 		}
-		return evmc.StorageModified
 	}
-	if !original.IsZero() {
-		if current.IsZero() { // recreate slot (2.2.1.1)
-			host.env.StateDB.SubRefund(vars.NetSstoreClearRefund)
-		} else if value.IsZero() { // delete slot (2.2.1.2)
-			host.env.StateDB.AddRefund(vars.NetSstoreClearRefund)
+	if original == (common.Hash{}) {
+		if value == (common.Hash{}) { // 0 -> Y -> 0
+			return evmc.StorageAddedDeleted
 		}
+		return evmc.StorageAssigned // 0 -> Y -> Z
 	}
-	if original.Eq(value) {
-		if original.IsZero() { // reset to original inexistent slot (2.2.2.1)
-			host.env.StateDB.AddRefund(resetClearRefund)
-		} else { // reset to original existing slot (2.2.2.2)
-			host.env.StateDB.AddRefund(cleanRefund)
-		}
+	switch {
+	case current == (common.Hash{}) && value == original: // X -> 0 -> X
+		return evmc.StorageDeletedRestored
+	case current == (common.Hash{}): // X -> 0 -> Z
+		return evmc.StorageDeletedAdded
+	case value == (common.Hash{}): // X -> Y -> 0
+		return evmc.StorageModifiedDeleted
+	case value == original: // X -> Y -> X
+		return evmc.StorageModifiedRestored
+	default: // X -> Y -> Z
+		return evmc.StorageAssigned
 	}
-	return evmc.StorageModifiedAgain
 }
 
 func (host *hostContext) GetBalance(addr evmc.Address) evmc.Hash {
-	return evmc.Hash(common.BigToHash(host.env.StateDB.GetBalance(common.Address(addr)).ToBig()))
+	return evmc.Hash(host.env.StateDB.GetBalance(common.Address(addr)).Bytes32())
 }
 
 func (host *hostContext) GetCodeSize(addr evmc.Address) int {
@@ -222,28 +211,52 @@ func (host *hostContext) GetCode(addr evmc.Address) []byte {
 	return host.env.StateDB.GetCode(common.Address(addr))
 }
 
-func (host *hostContext) Selfdestruct(evmcAddr evmc.Address, evmcBeneficiary evmc.Address) {
-	addr := common.Address(evmcAddr)
-	beneficiary := common.Address(evmcBeneficiary)
-	db := host.env.StateDB
-	if !db.HasSelfDestructed(addr) {
-		db.AddRefund(vars.SelfdestructRefundGas)
+// Selfdestruct transfers the balance of the account to the beneficiary and
+// marks the account as destructed. It reports whether this is the first time
+// the account self-destructs, which the VM needs to grant the pre-London
+// refund.
+func (host *hostContext) Selfdestruct(evmcAddr evmc.Address, evmcBeneficiary evmc.Address) bool {
+	var (
+		addr        = common.Address(evmcAddr)
+		beneficiary = common.Address(evmcBeneficiary)
+		db          = host.env.StateDB
+		first       = !db.HasSelfDestructed(addr)
+		balance     = db.GetBalance(addr)
+	)
+	conf := host.env.ChainConfig()
+	if conf.IsEnabledByTime(conf.GetEIP6780TransitionTime, &host.env.Context.Time) || conf.IsEnabled(conf.GetEIP6780Transition, host.env.Context.BlockNumber) {
+		db.SubBalance(addr, balance)
+		db.AddBalance(beneficiary, balance)
+		db.Selfdestruct6780(addr)
+	} else {
+		db.AddBalance(beneficiary, balance)
+		db.SelfDestruct(addr)
 	}
-	db.AddBalance(beneficiary, db.GetBalance(addr))
-	db.SelfDestruct(addr)
+	return first
 }
 
 func (host *hostContext) GetTxContext() evmc.TxContext {
-	return evmc.TxContext{
-		GasPrice:   evmc.Hash(common.BigToHash(host.env.GasPrice)),
-		Origin:     evmc.Address(host.env.TxContext.Origin),
-		Coinbase:   evmc.Address(host.env.Context.Coinbase),
-		Number:     host.env.Context.BlockNumber.Int64(),
-		Timestamp:  int64(host.env.Context.Time),
-		GasLimit:   int64(host.env.Context.GasLimit),
-		Difficulty: evmc.Hash(common.BigToHash(host.env.Context.Difficulty)),
-		ChainID:    evmc.Hash(common.BigToHash(host.env.chainConfig.GetChainID())),
+	ctx := evmc.TxContext{
+		GasPrice:  evmc.Hash(common.BigToHash(host.env.GasPrice)),
+		Origin:    evmc.Address(host.env.TxContext.Origin),
+		Coinbase:  evmc.Address(host.env.Context.Coinbase),
+		Number:    host.env.Context.BlockNumber.Int64(),
+		Timestamp: int64(host.env.Context.Time),
+		GasLimit:  int64(host.env.Context.GasLimit),
+		ChainID:   evmc.Hash(common.BigToHash(host.env.chainConfig.GetChainID())),
+	}
+	if host.env.Context.Random != nil {
+		ctx.PrevRandao = evmc.Hash(*host.env.Context.Random)
+	} else if host.env.Context.Difficulty != nil {
+		ctx.PrevRandao = evmc.Hash(common.BigToHash(host.env.Context.Difficulty))
+	}
+	if host.env.Context.BaseFee != nil {
+		ctx.BaseFee = evmc.Hash(common.BigToHash(host.env.Context.BaseFee))
 	}
+	if host.env.Context.BlobBaseFee != nil {
+		ctx.BlobBaseFee = evmc.Hash(common.BigToHash(host.env.Context.BlobBaseFee))
+	}
+	return ctx
 }
 
 func (host *hostContext) GetBlockHash(number int64) evmc.Hash {
@@ -268,33 +281,32 @@ func (host *hostContext) EmitLog(addr evmc.Address, evmcTopics []evmc.Hash, data
 }
 
 func (host *hostContext) Call(kind evmc.CallKind,
-	evmcDestination evmc.Address, evmcSender evmc.Address, valueBytes evmc.Hash, input []byte, gas int64, depth int,
-	static bool, saltBytes evmc.Hash) (output []byte, gasLeft int64, createAddrEvmc evmc.Address, err error) {
-
-	destination := common.Address(evmcDestination)
-
-	var createAddr common.Address
-
-	gasU := uint64(gas)
-	var gasLeftU uint64
-
-	value := new(uint256.Int)
-	value.SetBytes(valueBytes[:])
-
-	salt := big.NewInt(0)
-	salt.SetBytes(saltBytes[:])
+	evmcRecipient evmc.Address, evmcSender evmc.Address, valueBytes evmc.Hash, input []byte, gas int64, depth int,
+	static bool, saltBytes evmc.Hash, evmcCodeAddress evmc.Address) (output []byte, gasLeft int64, gasRefund int64, createAddrEvmc evmc.Address, err error) {
+
+	var (
+		recipient   = common.Address(evmcRecipient)
+		codeAddress = common.Address(evmcCodeAddress)
+		createAddr  common.Address
+		gasU        = uint64(gas)
+		gasLeftU    uint64
+		value       = new(uint256.Int).SetBytes(valueBytes[:])
+	)
+	// The refund of the child frame is handed over by the interpreter running
+	// it, frames which don't run any code leave it at zero.
+	host.vm.refund = 0
 
 	switch kind {
 	case evmc.Call:
 		if static {
-			output, gasLeftU, err = host.env.StaticCall(host.contract, destination, input, gasU)
+			output, gasLeftU, err = host.env.StaticCall(host.contract, recipient, input, gasU)
 		} else {
-			output, gasLeftU, err = host.env.Call(host.contract, destination, input, gasU, value)
+			output, gasLeftU, err = host.env.Call(host.contract, recipient, input, gasU, value)
 		}
 	case evmc.DelegateCall:
-		output, gasLeftU, err = host.env.DelegateCall(host.contract, destination, input, gasU)
+		output, gasLeftU, err = host.env.DelegateCall(host.contract, codeAddress, input, gasU)
 	case evmc.CallCode:
-		output, gasLeftU, err = host.env.CallCode(host.contract, destination, input, gasU, value)
+		output, gasLeftU, err = host.env.CallCode(host.contract, codeAddress, input, gasU, value)
 	case evmc.Create:
 		var createOutput []byte
 		createOutput, createAddr, gasLeftU, err = host.env.Create(host.contract, input, gasU, value)
@@ -311,11 +323,8 @@ func (host *hostContext) Call(kind evmc.CallKind,
 		}
 	case evmc.Create2:
 		var createOutput []byte
-
-		saltInt256 := new(uint256.Int)
-		saltInt256.SetBytes(salt.Bytes())
-
-		createOutput, createAddr, gasLeftU, err = host.env.Create2(host.contract, input, gasU, value, saltInt256)
+		salt := new(uint256.Int).SetBytes(saltBytes[:])
+		createOutput, createAddr, gasLeftU, err = host.env.Create2(host.contract, input, gasU, value, salt)
 		createAddrEvmc = evmc.Address(createAddr)
 		if err == ErrExecutionReverted {
 			// Assign return buffer from REVERT.
@@ -332,40 +341,227 @@ func (host *hostContext) Call(kind evmc.CallKind,
 		err = evmc.Revert
 	} else if err != nil {
 		err = evmc.Failure
+	} else {
+		gasRefund = host.vm.refund
 	}
+	host.vm.refund = 0
 
 	gasLeft = int64(gasLeftU)
-	return output, gasLeft, createAddrEvmc, err
+	return output, gasLeft, gasRefund, createAddrEvmc, err
 }
 
-// getRevision translates ChainConfig's HF block information into EVMC revision.
-func getRevision(env *EVM) evmc.Revision {
-	n := env.Context.BlockNumber
-	conf := env.ChainConfig()
-	switch {
-	// This is an example of choosing to use an "abstracted" idea
-	// about chain config, where I'm choosing to prioritize "indicative" features
-	// as identifiers for Fork-Feature-Groups. Note that this is very different
-	// than using Feature-complete sets to assert "did Forkage."
-	case conf.IsEnabled(conf.GetEIP2565Transition, n):
-		panic("berlin is unsupported by EVMCv7")
-	case conf.IsEnabled(conf.GetEIP1884Transition, n):
-		return evmc.Istanbul
-	case conf.IsEnabled(conf.GetEIP1283DisableTransition, n):
-		return evmc.Petersburg
-	case conf.IsEnabled(conf.GetEIP145Transition, n):
-		return evmc.Constantinople
-	case conf.IsEnabled(conf.GetEIP198Transition, n):
-		return evmc.Byzantium
-	case conf.IsEnabled(conf.GetEIP155Transition, n):
-		return evmc.SpuriousDragon
-	case conf.IsEnabled(conf.GetEIP150Transition, n):
-		return evmc.TangerineWhistle
-	case conf.IsEnabled(conf.GetEIP7Transition, n):
-		return evmc.Homestead
-	default:
-		return evmc.Frontier
+func (host *hostContext) AccessAccount(evmcAddr evmc.Address) evmc.AccessStatus {
+	addr := common.Address(evmcAddr)
+	if host.env.StateDB.AddressInAccessList(addr) {
+		return evmc.WarmAccess
 	}
+	host.env.StateDB.AddAddressToAccessList(addr)
+	return evmc.ColdAccess
+}
+
+func (host *hostContext) AccessStorage(evmcAddr evmc.Address, evmcKey evmc.Hash) evmc.AccessStatus {
+	addr, key := common.Address(evmcAddr), common.Hash(evmcKey)
+	if _, ok := host.env.StateDB.SlotInAccessList(addr, key); ok {
+		return evmc.WarmAccess
+	}
+	host.env.StateDB.AddSlotToAccessList(addr, key)
+	return evmc.ColdAccess
+}
+
+func (host *hostContext) GetTransientStorage(addr evmc.Address, key evmc.Hash) evmc.Hash {
+	return evmc.Hash(host.env.StateDB.GetTransientState(common.Address(addr), common.Hash(key)))
+}
+
+func (host *hostContext) SetTransientStorage(addr evmc.Address, key evmc.Hash, value evmc.Hash) {
+	host.env.StateDB.SetTransientState(common.Address(addr), common.Hash(key), common.Hash(value))
+}
+
+// evmcRevisionNames maps the EVMC revisions to their fork names.
+var evmcRevisionNames = map[evmc.Revision]string{
+	evmc.Frontier:         "Frontier",
+	evmc.Homestead:        "Homestead",
+	evmc.TangerineWhistle: "Tangerine Whistle",
+	evmc.SpuriousDragon:   "Spurious Dragon",
+	evmc.Byzantium:        "Byzantium",
+	evmc.Constantinople:   "Constantinople",
+	evmc.Petersburg:       "Petersburg",
+	evmc.Istanbul:         "Istanbul",
+	evmc.Berlin:           "Berlin",
+	evmc.London:           "London",
+	evmc.Paris:            "Paris",
+	evmc.Shanghai:         "Shanghai",
+	evmc.Cancun:           "Cancun",
+	evmc.Prague:           "Prague",
+}
+
+// evmcFeature is an EIP which changes the semantics of the EVM, and the EVMC
+// revision which introduced it.
+type evmcFeature struct {
+	name    string
+	since   evmc.Revision
+	until   evmc.Revision // The revision which removed the feature, zero if never removed
+	enabled func(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) bool
+}
+
+// active returns whether the feature is part of the given revision.
+func (f *evmcFeature) active(rev evmc.Revision) bool {
+	return rev >= f.since && (f.until == 0 || rev < f.until)
+}
+
+// blockFeature returns a feature enabled by block number.
+func blockFeature(name string, since evmc.Revision, fn func(ctypes.ChainConfigurator) func() *uint64) evmcFeature {
+	return evmcFeature{name: name, since: since, enabled: func(config ctypes.ChainConfigurator, _ bool, bn *big.Int, _ *uint64) bool {
+		return config.IsEnabled(fn(config), bn)
+	}}
+}
+
+// timeFeature returns a feature enabled either by block number or by time.
+func timeFeature(name string, since evmc.Revision, fn func(ctypes.ChainConfigurator) (func() *uint64, func() *uint64)) evmcFeature {
+	return evmcFeature{name: name, since: since, enabled: func(config ctypes.ChainConfigurator, _ bool, bn *big.Int, bt *uint64) bool {
+		byTime, byBlock := fn(config)
+		return config.IsEnabledByTime(byTime, bt) || config.IsEnabled(byBlock, bn)
+	}}
+}
+
+// evmcFeatures lists the EIPs which tell the EVMC revisions apart. EIPs which
+// don't affect the execution of code (e.g. difficulty, transaction types or
+// withdrawals) are left out, and so don't need to match.
+var evmcFeatures = []evmcFeature{
+	blockFeature("EIP-2", evmc.Homestead, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP2Transition }),
+	blockFeature("EIP-7", evmc.Homestead, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP7Transition }),
+	blockFeature("EIP-150", evmc.TangerineWhistle, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP150Transition }),
+	blockFeature("EIP-160", evmc.SpuriousDragon, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP160Transition }),
+	blockFeature("EIP-161abc", evmc.SpuriousDragon, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP161abcTransition }),
+	blockFeature("EIP-161d", evmc.SpuriousDragon, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP161dTransition }),
+	blockFeature("EIP-170", evmc.SpuriousDragon, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP170Transition }),
+	blockFeature("EIP-140", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP140Transition }),
+	blockFeature("EIP-198", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP198Transition }),
+	blockFeature("EIP-211", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP211Transition }),
+	blockFeature("EIP-212", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP212Transition }),
+	blockFeature("EIP-213", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP213Transition }),
+	blockFeature("EIP-214", evmc.Byzantium, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP214Transition }),
+	blockFeature("EIP-145", evmc.Constantinople, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP145Transition }),
+	blockFeature("EIP-1014", evmc.Constantinople, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP1014Transition }),
+	blockFeature("EIP-1052", evmc.Constantinople, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP1052Transition }),
+	{
+		// Net gas metering was shipped in Constantinople and pulled again in
+		// Petersburg, before it returned in Istanbul as EIP-2200.
+		name: "EIP-1283", since: evmc.Constantinople, until: evmc.Petersburg,
+		enabled: func(config ctypes.ChainConfigurator, _ bool, bn *big.Int, _ *uint64) bool {
+			return config.IsEnabled(config.GetEIP1283Transition, bn) && !config.IsEnabled(config.GetEIP1283DisableTransition, bn)
+		},
+	},
+	blockFeature("EIP-152", evmc.Istanbul, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP152Transition }),
+	blockFeature("EIP-1108", evmc.Istanbul, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP1108Transition }),
+	blockFeature("EIP-1344", evmc.Istanbul, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP1344Transition }),
+	blockFeature("EIP-1884", evmc.Istanbul, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP1884Transition }),
+	{
+		name: "EIP-2200", since: evmc.Istanbul,
+		enabled: func(config ctypes.ChainConfigurator, _ bool, bn *big.Int, _ *uint64) bool {
+			return config.IsEnabled(config.GetEIP2200Transition, bn) && !config.IsEnabled(config.GetEIP2200DisableTransition, bn)
+		},
+	},
+	blockFeature("EIP-2565", evmc.Berlin, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP2565Transition }),
+	blockFeature("EIP-2929", evmc.Berlin, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP2929Transition }),
+	blockFeature("EIP-3198", evmc.London, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP3198Transition }),
+	blockFeature("EIP-3529", evmc.London, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP3529Transition }),
+	blockFeature("EIP-3541", evmc.London, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP3541Transition }),
+	{
+		name: "EIP-4399", since: evmc.Paris,
+		enabled: func(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, _ *uint64) bool {
+			return isPostMerge || config.IsEnabled(config.GetEIP4399Transition, bn)
+		},
+	},
+	timeFeature("EIP-3651", evmc.Shanghai, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP3651TransitionTime, c.GetEIP3651Transition
+	}),
+	timeFeature("EIP-3855", evmc.Shanghai, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP3855TransitionTime, c.GetEIP3855Transition
+	}),
+	timeFeature("EIP-3860", evmc.Shanghai, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP3860TransitionTime, c.GetEIP3860Transition
+	}),
+	timeFeature("EIP-1153", evmc.Cancun, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP1153TransitionTime, c.GetEIP1153Transition
+	}),
+	timeFeature("EIP-4844", evmc.Cancun, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP4844TransitionTime, c.GetEIP4844Transition
+	}),
+	timeFeature("EIP-5656", evmc.Cancun, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP5656TransitionTime, c.GetEIP5656Transition
+	}),
+	timeFeature("EIP-6780", evmc.Cancun, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP6780TransitionTime, c.GetEIP6780Transition
+	}),
+	timeFeature("EIP-7516", evmc.Cancun, func(c ctypes.ChainConfigurator) (func() *uint64, func() *uint64) {
+		return c.GetEIP7516TransitionTime, c.GetEIP7516Transition
+	}),
+	blockFeature("EIP-2537", evmc.Prague, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP2537Transition }),
+
+	// EOF is only partially specified by the revisions of EVMC, whose VMs
+	// follow a later version of it, so chains enabling it are not supported.
+	blockFeature("EIP-3540", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP3540Transition }),
+	blockFeature("EIP-3670", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP3670Transition }),
+	blockFeature("EIP-4200", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP4200Transition }),
+	blockFeature("EIP-4750", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP4750Transition }),
+	blockFeature("EIP-5450", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP5450Transition }),
+	blockFeature("EIP-7480", evmc.Osaka, func(c ctypes.ChainConfigurator) func() *uint64 { return c.GetEIP7480Transition }),
+}
+
+// EVMCRevision returns the EVMC revision matching the EIPs enabled by the
+// chain configuration at the given block. If the enabled EIPs don't add up to
+// exactly one revision, as for chains which adopted a fork only in part, the
+// closest revision is returned along with an ErrEVMCRevisionMismatch error
+// naming the differences.
+func EVMCRevision(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) (evmc.Revision, error) {
+	enabled := make([]bool, len(evmcFeatures))
+	for i := range evmcFeatures {
+		enabled[i] = evmcFeatures[i].enabled(config, isPostMerge, bn, bt)
+	}
+	// Look for the revision enabling exactly the same EIPs, and otherwise
+	// settle for the latest one with all of its EIPs enabled.
+	var (
+		closest = evmc.Frontier
+		found   bool
+	)
+	for rev := evmc.Prague; rev >= evmc.Frontier; rev-- {
+		exact, complete := true, true
+		for i := range evmcFeatures {
+			if active := evmcFeatures[i].active(rev); active != enabled[i] {
+				exact = false
+				complete = complete && !active
+			}
+		}
+		if exact {
+			return rev, nil
+		}
+		if complete && !found {
+			closest, found = rev, true
+		}
+	}
+	var missing, extra []string
+	for i := range evmcFeatures {
+		switch active := evmcFeatures[i].active(closest); {
+		case active && !enabled[i]:
+			missing = append(missing, evmcFeatures[i].name)
+		case !active && enabled[i]:
+			extra = append(extra, evmcFeatures[i].name)
+		}
+	}
+	return closest, fmt.Errorf("%w: closest revision %s, missing %v, unexpected %v", ErrEVMCRevisionMismatch, evmcRevisionNames[closest], missing, extra)
+}
+
+// revision resolves the EVMC revision of the block being executed.
+func (evm *EVMC) revision() (evmc.Revision, error) {
+	if !evm.revSet {
+		ctx := &evm.env.Context
+		evm.rev, evm.revErr = EVMCRevision(evm.env.ChainConfig(), ctx.Random != nil, ctx.BlockNumber, &ctx.Time)
+		if evm.revErr != nil {
+			log.Error("EVMC VM cannot execute block", "number", ctx.BlockNumber, "err", evm.revErr)
+		}
+		evm.revSet = true
+	}
+	return evm.rev, evm.revErr
 }
 
 // Run implements Interpreter.Run().
@@ -377,6 +573,10 @@ func (evm *EVMC) Run(contract *Contract, input []byte, readOnly bool) (ret []byt
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	rev, err := evm.revision()
+	if err != nil {
+		return nil, err
+	}
 
 	kind := evmc.Call
 	if evm.env.StateDB.GetCodeSize(contract.Address()) == 0 {
@@ -391,21 +591,21 @@ func (evm *EVMC) Run(contract *Contract, input []byte, readOnly bool) (ret []byt
 		defer func() { evm.readOnly = false }()
 	}
 
-	output, gasLeft, err := evm.instance.Execute(
-		&hostContext{evm.env, contract},
-		getRevision(evm.env),
+	result, err := evm.instance.Execute(
+		&hostContext{evm, evm.env, contract},
+		rev,
 		kind,
 		evm.readOnly,
+		false,
 		evm.env.depth-1,
 		int64(contract.Gas),
 		evmc.Address(contract.Address()),
 		evmc.Address(contract.Caller()),
 		input,
-		evmc.Hash(common.BigToHash(contract.value.ToBig())),
-		contract.Code,
-		evmc.Hash{})
+		evmc.Hash(contract.value.Bytes32()),
+		contract.Code)
 
-	contract.Gas = uint64(gasLeft)
+	contract.Gas = uint64(result.GasLeft)
 
 	if err == evmc.Revert {
 		err = ErrExecutionReverted
@@ -413,11 +613,31 @@ func (evm *EVMC) Run(contract *Contract, input []byte, readOnly bool) (ret []byt
 		panic(fmt.Sprintf("EVMC VM internal error: %s", evmcError.Error()))
 	}
 
-	return output, err
+	// The refund of a frame includes those of its children, and may be
+	// negative when a child undoes a refunded write of its parent. It's only
+	// settled with the state once the outermost frame finishes.
+	if err != nil {
+		result.GasRefund = 0
+	}
+	if evm.env.depth > 1 {
+		evm.refund = result.GasRefund
+	} else if result.GasRefund > 0 {
+		evm.env.StateDB.AddRefund(uint64(result.GasRefund))
+	} else if result.GasRefund < 0 {
+		evm.env.StateDB.SubRefund(uint64(-result.GasRefund))
+	}
+	return result.Output, err
 }
 
 // CanRun implements Interpreter.CanRun().
+//
+// The Go bindings do not pass blob hashes through to the VM, which would make
+// BLOBHASH read zeroes. Transactions carrying them are left to the native
+// interpreter instead.
 func (evm *EVMC) CanRun(code []byte) bool {
+	if len(evm.env.TxContext.BlobHashes) > 0 {
+		return false
+	}
 	required := evmc.CapabilityEVM1
 	wasmPreamble := []byte("\x00asm")
 	if bytes.HasPrefix(code, wasmPreamble) {
@@ -0,0 +1,143 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"flag"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/evmc/v12/bindings/go/evmc"
+	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/types/goethereum"
+)
+
+var testEVMC = flag.String("evmc.evm", "", "EVMC EVM1 configuration")
+
+func TestEVMCRevision(t *testing.T) {
+	constantinople := &goethereum.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		Ethash:              new(ctypes.EthashConfig),
+	}
+	tests := []struct {
+		config     ctypes.ChainConfigurator
+		postMerge  bool
+		number     uint64
+		time       uint64
+		want       evmc.Revision
+		mismatched bool
+	}{
+		{config: params.MainnetChainConfig, number: 0, want: evmc.Frontier},
+		{config: params.MainnetChainConfig, number: 1_150_000, want: evmc.Homestead},
+		{config: params.MainnetChainConfig, number: 2_463_000, want: evmc.TangerineWhistle},
+		{config: params.MainnetChainConfig, number: 2_675_000, want: evmc.SpuriousDragon},
+		{config: params.MainnetChainConfig, number: 4_370_000, want: evmc.Byzantium},
+		{config: params.MainnetChainConfig, number: 7_280_000, want: evmc.Petersburg},
+		{config: params.MainnetChainConfig, number: 9_069_000, want: evmc.Istanbul},
+		{config: params.MainnetChainConfig, number: 12_244_000, want: evmc.Berlin},
+		{config: params.MainnetChainConfig, number: 12_965_000, want: evmc.London},
+		{config: params.MainnetChainConfig, number: 15_537_394, postMerge: true, want: evmc.Paris},
+		{config: params.MainnetChainConfig, number: 17_034_870, time: 1681338455, postMerge: true, want: evmc.Shanghai},
+		{config: params.MainnetChainConfig, number: 19_426_587, time: 1710338135, postMerge: true, want: evmc.Cancun},
+		{config: constantinople, number: 0, want: evmc.Constantinople},
+
+		// Ethereum Classic
+		{config: params.ClassicChainConfig, number: 1_150_000, want: evmc.Homestead},
+		{config: params.ClassicChainConfig, number: 2_500_000, want: evmc.TangerineWhistle},
+		{config: params.ClassicChainConfig, number: 3_000_000, want: evmc.TangerineWhistle, mismatched: true}, // Die Hard: EIP-160 only
+		{config: params.ClassicChainConfig, number: 8_772_000, want: evmc.Byzantium},                          // Atlantis
+		{config: params.ClassicChainConfig, number: 9_573_000, want: evmc.Petersburg},                         // Agharta
+		{config: params.ClassicChainConfig, number: 10_500_839, want: evmc.Istanbul},                          // Phoenix
+		{config: params.ClassicChainConfig, number: 13_189_133, want: evmc.Berlin},                            // Magneto
+		{config: params.ClassicChainConfig, number: 14_525_000, want: evmc.Berlin, mismatched: true},          // Mystique: no BASEFEE
+		{config: params.ClassicChainConfig, number: 19_250_000, want: evmc.Berlin, mismatched: true},          // Spiral
+	}
+	for i, test := range tests {
+		rev, err := EVMCRevision(test.config, test.postMerge, new(big.Int).SetUint64(test.number), &test.time)
+		if rev != test.want {
+			t.Errorf("test %d: have revision %s, want %s", i, evmcRevisionNames[rev], evmcRevisionNames[test.want])
+		}
+		if mismatched := errors.Is(err, ErrEVMCRevisionMismatch); mismatched != test.mismatched {
+			t.Errorf("test %d: have error %v, want mismatch %v", i, err, test.mismatched)
+		}
+	}
+}
+
+// TestEVMCHostBlobHash runs BLOBHASH through the configured EVMC VM, storing
+// and returning the first blob hash. Transactions carrying blob hashes must
+// fall back to the native interpreter, as the bindings don't pass them on.
+func TestEVMCHostBlobHash(t *testing.T) {
+	if *testEVMC == "" {
+		t.Skip("no EVMC VM configured, set -evmc.evm=/path/to/libevmone.so")
+	}
+	InitEVMCEVM(*testEVMC)
+
+	// PUSH0 BLOBHASH PUSH0 SSTORE PUSH0 SLOAD PUSH0 MSTORE PUSH1 32 PUSH0 RETURN
+	code := common.FromHex("5f495f555f545f5260205ff3")
+	address := common.BytesToAddress([]byte("blobhash"))
+
+	for i, blobHashes := range [][]common.Hash{
+		nil,
+		{common.HexToHash("0x01c0ffee")},
+	} {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, code)
+
+		evm := NewEVM(BlockContext{
+			BlockNumber: common.Big0,
+			Random:      new(common.Hash),
+			BaseFee:     common.Big0,
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		}, TxContext{BlobHashes: blobHashes}, statedb, params.MergedTestChainConfig, Config{EVMInterpreter: *testEVMC})
+
+		if _, ok := evm.interpreters[0].(*EVMC); !ok {
+			t.Fatalf("test %d: first interpreter is %T, want EVMC", i, evm.interpreters[0])
+		}
+		if have, want := evm.interpreters[0].CanRun(code), len(blobHashes) == 0; have != want {
+			t.Errorf("test %d: EVMC VM runs the code %v, want %v", i, have, want)
+		}
+		ret, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100_000, new(uint256.Int))
+		if err != nil {
+			t.Fatalf("test %d: execution failed: %v", i, err)
+		}
+		var want common.Hash
+		if len(blobHashes) > 0 {
+			want = blobHashes[0]
+		}
+		if have := common.BytesToHash(ret); have != want {
+			t.Errorf("test %d: have returned hash %x, want %x", i, have, want)
+		}
+		if have := statedb.GetState(address, common.Hash{}); have != want {
+			t.Errorf("test %d: have stored hash %x, want %x", i, have, want)
+		}
+	}
+}
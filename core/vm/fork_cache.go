@@ -0,0 +1,192 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/shudolab/core-geth/common/lru"
+	"github.com/shudolab/core-geth/params/confp"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+// forkCacheLimit is the number of chain configurations for which resolved jump
+// tables and precompile sets are retained.
+const forkCacheLimit = 32
+
+// forkCaches maps chain configurations to their resolved EVM rule sets.
+var forkCaches = lru.NewCache[ctypes.ChainConfigurator, *forkCache](forkCacheLimit)
+
+// forkRange identifies the span between two consecutive forks of a chain
+// configuration, within which the rules of the EVM do not change.
+type forkRange struct {
+	blockForks int  // Number of block based forks passed, -1 if the block is unknown
+	timeForks  int  // Number of time based forks passed, -1 if the time is unknown
+	postMerge  bool // Whether the chain transitioned to proof-of-stake
+}
+
+// forkCache holds the jump tables and precompile sets of a chain configuration,
+// resolved once per fork range. The cached values are shared between all EVMs
+// and must not be modified.
+//
+// The fork schedule is read once when the configuration is first seen, so a
+// configuration must not be changed after it was used to run the EVM.
+type forkCache struct {
+	blockForks []uint64 // Sorted block numbers of the forks, from confp.BlockForks
+	timeForks  []uint64 // Sorted timestamps of the forks, from confp.TimeForks
+
+	tables      map[forkRange]*JumpTable
+	eofTables   map[forkRange]*JumpTable // Nil entries if EOF is not enabled
+	precompiles map[forkRange]PrecompiledContracts
+	lock        sync.RWMutex
+}
+
+// forkCacheFor returns the rule cache of a chain configuration, or nil if the
+// configuration cannot be cached.
+func forkCacheFor(config ctypes.ChainConfigurator) *forkCache {
+	if config == nil || !reflect.TypeOf(config).Comparable() {
+		return nil
+	}
+	if cache, ok := forkCaches.Get(config); ok {
+		return cache
+	}
+	cache := &forkCache{
+		blockForks:  confp.BlockForks(config),
+		timeForks:   confp.TimeForks(config, 0),
+		tables:      make(map[forkRange]*JumpTable),
+		eofTables:   make(map[forkRange]*JumpTable),
+		precompiles: make(map[forkRange]PrecompiledContracts),
+	}
+	forkCaches.Add(config, cache)
+	return cache
+}
+
+// rangeOf returns the fork range containing the given block number and time.
+func (c *forkCache) rangeOf(bn *big.Int, bt *uint64, postMerge bool) forkRange {
+	r := forkRange{blockForks: -1, timeForks: -1, postMerge: postMerge}
+	if bn != nil {
+		if bn.IsUint64() {
+			n := bn.Uint64()
+			r.blockForks = sort.Search(len(c.blockForks), func(i int) bool { return c.blockForks[i] > n })
+		} else if bn.Sign() > 0 {
+			r.blockForks = len(c.blockForks)
+		}
+	}
+	if bt != nil {
+		t := *bt
+		r.timeForks = sort.Search(len(c.timeForks), func(i int) bool { return c.timeForks[i] > t })
+	}
+	return r
+}
+
+// instructionSet returns the jump table active at the given point of the chain,
+// building it on first use of the fork range.
+func (c *forkCache) instructionSet(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) *JumpTable {
+	r := c.rangeOf(bn, bt, isPostMerge)
+
+	c.lock.RLock()
+	table := c.tables[r]
+	c.lock.RUnlock()
+	if table != nil {
+		return table
+	}
+	table = instructionSetForConfig(config, isPostMerge, bn, bt)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if have := c.tables[r]; have != nil {
+		return have
+	}
+	c.tables[r] = table
+	return table
+}
+
+// eofInstructionSet returns the jump table of EOF code active at the given point
+// of the chain, or nil if EOF is not enabled.
+func (c *forkCache) eofInstructionSet(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) *JumpTable {
+	r := c.rangeOf(bn, bt, isPostMerge)
+
+	c.lock.RLock()
+	table, ok := c.eofTables[r]
+	c.lock.RUnlock()
+	if ok {
+		return table
+	}
+	table = eofInstructionSetForConfig(config, bn, c.instructionSet(config, isPostMerge, bn, bt))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if have, ok := c.eofTables[r]; ok {
+		return have
+	}
+	c.eofTables[r] = table
+	return table
+}
+
+// precompiledContracts returns the precompiles active at the given point of the
+// chain, resolving them on first use of the fork range.
+func (c *forkCache) precompiledContracts(config ctypes.ChainConfigurator, bn *big.Int, bt *uint64) PrecompiledContracts {
+	r := c.rangeOf(bn, bt, false)
+
+	c.lock.RLock()
+	precompiles, ok := c.precompiles[r]
+	c.lock.RUnlock()
+	if ok {
+		return precompiles
+	}
+	precompiles = PrecompiledContractsForConfig(config, bn, bt)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if have, ok := c.precompiles[r]; ok {
+		return have
+	}
+	c.precompiles[r] = precompiles
+	return precompiles
+}
+
+// cachedInstructionSet returns the shared jump table active at the given point
+// of the chain. The returned table must not be modified.
+func cachedInstructionSet(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) *JumpTable {
+	if cache := forkCacheFor(config); cache != nil {
+		return cache.instructionSet(config, isPostMerge, bn, bt)
+	}
+	return instructionSetForConfig(config, isPostMerge, bn, bt)
+}
+
+// cachedEOFInstructionSet returns the shared jump table of EOF code active at
+// the given point of the chain, or nil if EOF is not enabled. The returned
+// table must not be modified.
+func cachedEOFInstructionSet(config ctypes.ChainConfigurator, isPostMerge bool, bn *big.Int, bt *uint64) *JumpTable {
+	if cache := forkCacheFor(config); cache != nil {
+		return cache.eofInstructionSet(config, isPostMerge, bn, bt)
+	}
+	return eofInstructionSetForConfig(config, bn, instructionSetForConfig(config, isPostMerge, bn, bt))
+}
+
+// cachedPrecompiledContracts returns the shared set of precompiles active at
+// the given point of the chain. The returned map must not be modified, use
+// PrecompiledContractsForConfig to obtain a private copy.
+func cachedPrecompiledContracts(config ctypes.ChainConfigurator, bn *big.Int, bt *uint64) PrecompiledContracts {
+	if cache := forkCacheFor(config); cache != nil {
+		return cache.precompiledContracts(config, bn, bt)
+	}
+	return PrecompiledContractsForConfig(config, bn, bt)
+}
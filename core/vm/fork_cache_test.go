@@ -0,0 +1,153 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/confp"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+// Tests that the cached jump tables and precompile sets are identical to the
+// freshly resolved ones on both sides of every fork of the configurations.
+func TestForkCacheConsistency(t *testing.T) {
+	configs := map[string]ctypes.ChainConfigurator{
+		"classic": params.ClassicChainConfig,
+		"mordor":  params.MordorChainConfig,
+		"mainnet": params.MainnetChainConfig,
+	}
+	for name, config := range configs {
+		var numbers []uint64
+		for _, fork := range confp.BlockForks(config) {
+			numbers = append(numbers, fork-1, fork, fork+1)
+		}
+		times := []uint64{0}
+		for _, fork := range confp.TimeForks(config, 0) {
+			times = append(times, fork-1, fork)
+		}
+		for _, n := range numbers {
+			for _, ts := range times {
+				bn, bt := new(big.Int).SetUint64(n), ts
+
+				have := cachedInstructionSet(config, false, bn, &bt)
+				want := instructionSetForConfig(config, false, bn, &bt)
+				if err := compareJumpTables(have, want); err != nil {
+					t.Errorf("%s: jump table mismatch at block %d, time %d: %v", name, n, ts, err)
+				}
+				if err := comparePrecompiles(cachedPrecompiledContracts(config, bn, &bt), PrecompiledContractsForConfig(config, bn, &bt)); err != nil {
+					t.Errorf("%s: precompile mismatch at block %d, time %d: %v", name, n, ts, err)
+				}
+			}
+		}
+	}
+}
+
+// Tests that blocks within the same fork range share the same resolved rules.
+func TestForkCacheSharing(t *testing.T) {
+	config := params.ClassicChainConfig
+	forks := confp.BlockForks(config)
+
+	var (
+		first  = new(big.Int).SetUint64(forks[len(forks)-1])
+		second = new(big.Int).Add(first, big.NewInt(1000))
+		before = new(big.Int).Sub(first, big.NewInt(1))
+		time   = uint64(0)
+	)
+	if cachedInstructionSet(config, false, first, &time) != cachedInstructionSet(config, false, second, &time) {
+		t.Error("jump table not shared within fork range")
+	}
+	if cachedInstructionSet(config, false, first, &time) == cachedInstructionSet(config, false, before, &time) {
+		t.Error("jump table shared across fork boundary")
+	}
+	if reflect.ValueOf(cachedPrecompiledContracts(config, first, &time)).Pointer() != reflect.ValueOf(cachedPrecompiledContracts(config, second, &time)).Pointer() {
+		t.Error("precompile set not shared within fork range")
+	}
+}
+
+func compareJumpTables(have, want *JumpTable) error {
+	for i := range have {
+		h, w := have[i], want[i]
+		if reflect.ValueOf(h.execute).Pointer() != reflect.ValueOf(w.execute).Pointer() {
+			return fmt.Errorf("op %v: execute mismatch", OpCode(i))
+		}
+		if reflect.ValueOf(h.dynamicGas).Pointer() != reflect.ValueOf(w.dynamicGas).Pointer() {
+			return fmt.Errorf("op %v: dynamic gas mismatch", OpCode(i))
+		}
+		if h.constantGas != w.constantGas || h.minStack != w.minStack || h.maxStack != w.maxStack {
+			return fmt.Errorf("op %v: have gas %d stack %d/%d, want gas %d stack %d/%d", OpCode(i),
+				h.constantGas, h.minStack, h.maxStack, w.constantGas, w.minStack, w.maxStack)
+		}
+	}
+	return nil
+}
+
+func comparePrecompiles(have, want map[common.Address]PrecompiledContract) error {
+	if len(have) != len(want) {
+		return fmt.Errorf("have %d precompiles, want %d", len(have), len(want))
+	}
+	for addr, w := range want {
+		h, ok := have[addr]
+		if !ok {
+			return fmt.Errorf("missing precompile %x", addr)
+		}
+		if !reflect.DeepEqual(h, w) {
+			return fmt.Errorf("precompile %x: have %#v, want %#v", addr, h, w)
+		}
+	}
+	return nil
+}
+
+// forkCacheBenchBlock is a block past all the forks of ETC mainnet (Spiral).
+var forkCacheBenchBlock = big.NewInt(20_000_000)
+
+func BenchmarkInstructionSetUncached(b *testing.B) {
+	var time uint64
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		instructionSetForConfig(params.ClassicChainConfig, false, forkCacheBenchBlock, &time)
+	}
+}
+
+func BenchmarkInstructionSetCached(b *testing.B) {
+	var time uint64
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cachedInstructionSet(params.ClassicChainConfig, false, forkCacheBenchBlock, &time)
+	}
+}
+
+func BenchmarkPrecompilesUncached(b *testing.B) {
+	var time uint64
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		PrecompiledContractsForConfig(params.ClassicChainConfig, forkCacheBenchBlock, &time)
+	}
+}
+
+func BenchmarkPrecompilesCached(b *testing.B) {
+	var time uint64
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cachedPrecompiledContracts(params.ClassicChainConfig, forkCacheBenchBlock, &time)
+	}
+}
@@ -31,6 +31,7 @@ type Config struct {
 	ExtraEips               []int     // Additional EIPS that are to be enabled
 	EWASMInterpreter        string    // External EWASM interpreter options -- PTAL-meowsbits Is this the best place for these additional fields?
 	EVMInterpreter          string    // External EVM interpreter options
+	ParallelTxExecution     bool      // Executes the transactions of blocks optimistically in parallel (core.StateProcessor)
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
@@ -65,8 +66,9 @@ type ScopeContext struct {
 
 // EVMInterpreter represents an EVM interpreter
 type EVMInterpreter struct {
-	evm   *EVM
-	table *JumpTable
+	evm      *EVM
+	table    *JumpTable
+	eofTable *JumpTable // Instruction set of EOF code, nil if EOF is not enabled
 
 	hasher    crypto.KeccakState // Keccak256 hasher instance shared across opcodes
 	hasherBuf common.Hash        // Keccak256 hasher result array shared across opcodes
@@ -79,7 +81,7 @@ type EVMInterpreter struct {
 func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 	// If jump table was not initialised we set the default one.
 	var table *JumpTable
-	table = instructionSetForConfig(evm.chainConfig, evm.Context.Random != nil, evm.Context.BlockNumber, &evm.Context.Time)
+	table = cachedInstructionSet(evm.chainConfig, evm.Context.Random != nil, evm.Context.BlockNumber, &evm.Context.Time)
 	var extraEips []int
 	if len(evm.Config.ExtraEips) > 0 {
 		// Deep-copy jumptable to prevent modification of opcodes in other tables
@@ -94,7 +96,14 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
-	return &EVMInterpreter{evm: evm, table: table}
+
+	var eofTable *JumpTable
+	if len(extraEips) > 0 {
+		eofTable = eofInstructionSetForConfig(evm.chainConfig, evm.Context.BlockNumber, table)
+	} else {
+		eofTable = cachedEOFInstructionSet(evm.chainConfig, evm.Context.Random != nil, evm.Context.BlockNumber, &evm.Context.Time)
+	}
+	return &EVMInterpreter{evm: evm, table: table, eofTable: eofTable}
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -123,6 +132,18 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	// EOF code is executed section by section with its own instruction set.
+	table := in.table
+	if in.eofTable != nil && hasEOFMagic(contract.Code) {
+		container, err := contract.decodeContainer()
+		if err != nil {
+			return nil, err
+		}
+		contract.Container, contract.container = container, contract.Code
+		contract.setCodeSection(0)
+		defer func() { contract.Code = contract.container }()
+		table = in.eofTable
+	}
 
 	var (
 		op          OpCode        // current opcode
@@ -180,7 +201,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
-		operation := in.table[op]
+		operation := table[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := stack.len(); sLen < operation.minStack {
@@ -166,3 +166,9 @@ func (api *AdminAPI) MaxPeers(n int) (bool, error) {
 	}
 	return true, nil
 }
+
+// ChainSplits returns the chains followed by recently seen peers, grouped by the
+// fork ID they advertise. Peers rejected during the handshake are included too.
+func (api *AdminAPI) ChainSplits() []*ChainSplit {
+	return api.eth.handler.chainSplits.splits()
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/shudolab/core-geth/core"
 	"github.com/shudolab/core-geth/core/bloombits"
 	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
 	"github.com/shudolab/core-geth/core/state/pruner"
 	"github.com/shudolab/core-geth/core/txpool"
 	"github.com/shudolab/core-geth/core/txpool/blobpool"
@@ -45,6 +46,7 @@ import (
 	"github.com/shudolab/core-geth/eth/gasprice"
 	"github.com/shudolab/core-geth/eth/protocols/eth"
 	"github.com/shudolab/core-geth/eth/protocols/snap"
+	"github.com/shudolab/core-geth/eth/rpccache"
 	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/event"
 	"github.com/shudolab/core-geth/internal/ethapi"
@@ -111,6 +113,13 @@ type Ethereum struct {
 // New creates a new Ethereum object (including the
 // initialisation of the common Ethereum object)
 func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
+	return NewWithDatabase(stack, config, nil, nil)
+}
+
+// NewWithDatabase is like New, but runs on the given pre-opened chain database
+// instead of the node's, if chainDb is non-nil. If wrapState is non-nil, the
+// blockchain's state database is wrapped with it, e.g. to load state remotely.
+func NewWithDatabase(stack *node.Node, config *ethconfig.Config, chainDb ethdb.Database, wrapState func(state.Database) state.Database) (*Ethereum, error) {
 	// Ensure configuration values are compatible and sane
 	if config.SyncMode == downloader.LightSync {
 		return nil, errors.New("can't run eth.Ethereum in light sync mode, light mode has been deprecated")
@@ -134,9 +143,12 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	log.Info("Allocated trie memory caches", "clean", common.StorageSize(config.TrieCleanCache)*1024*1024, "dirty", common.StorageSize(config.TrieDirtyCache)*1024*1024)
 
 	// Assemble the Ethereum object
-	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/", false)
-	if err != nil {
-		return nil, err
+	if chainDb == nil {
+		var err error
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/", false)
+		if err != nil {
+			return nil, err
+		}
 	}
 	scheme, err := rawdb.ParseStateScheme(config.StateScheme, chainDb)
 	if err != nil {
@@ -154,6 +166,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 
 	if config.Genesis != nil && config.Genesis.Config != nil {
 		ethashConfig.ECIP1099Block = config.Genesis.GetEthashECIP1099Transition()
+		ethashConfig.ECIP1049Block = config.Genesis.GetEthashECIP1049Transition()
 	}
 
 	cliqueConfig, err := core.LoadCliqueConfig(chainDb, config.Genesis)
@@ -216,6 +229,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EWASMInterpreter:        config.EWASMInterpreter,
 			EVMInterpreter:          config.EVMInterpreter,
+			ParallelTxExecution:     config.ParallelTxExecution,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -227,6 +241,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 			StateHistory:        config.StateHistory,
 			StateScheme:         scheme,
+			StateDatabase:       wrapState,
 		}
 	)
 	// Override the chain config with provided settings.
@@ -326,6 +341,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	// Start the RPC service
 	eth.netRPCService = ethapi.NewNetAPI(eth.p2pServer, networkID)
 
+	if config.RPCCacheSize > 0 {
+		stack.SetRPCResponseCache(rpccache.New(eth.blockchain, config.RPCCacheSize*1024*1024, config.RPCCacheDepth))
+	}
+
 	// Register the backend on the node
 	stack.RegisterAPIs(eth.APIs())
 	stack.RegisterProtocols(eth.Protocols())
@@ -549,6 +568,7 @@ func (s *Ethereum) NetVersion() uint64                 { return s.networkID }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.handler.downloader }
 func (s *Ethereum) Synced() bool                       { return s.handler.synced.Load() }
 func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedFeatures() }
+func (s *Ethereum) HighestBlock() uint64               { return s.handler.highestBlock() }
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
 func (s *Ethereum) Merger() *consensus.Merger          { return s.merger }
@@ -561,6 +581,12 @@ func (s *Ethereum) SyncMode() downloader.SyncMode {
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
 	protos := eth.MakeProtocols((*ethHandler)(s.handler), s.networkID, s.config.ProtocolVersions, s.ethDialCandidates)
+	if s.config.DiscoveryForkIDFilter {
+		filter := eth.NewNodeFilter(s.blockchain)
+		for i := range protos {
+			protos[i].DialFilter = filter
+		}
+	}
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
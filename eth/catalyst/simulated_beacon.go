@@ -154,6 +154,12 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, timestamp u
 		c.setCurrentState(header.Hash(), *finalizedHash)
 	}
 
+	// Transactions are added to the pool and promoted on a background thread.
+	// Wait for it to settle, so that blocks sealed right after submitting a
+	// transaction include it.
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return err
+	}
 	var random [32]byte
 	rand.Read(random[:])
 	fcResponse, err := c.engineAPI.forkchoiceUpdated(c.curForkchoiceState, &engine.PayloadAttributes{
@@ -252,6 +258,16 @@ func (c *SimulatedBeacon) Commit() common.Hash {
 	return c.eth.BlockChain().CurrentBlock().Hash()
 }
 
+// CommitAt seals a block on demand with the given timestamp, which is bumped
+// past the parent's if it isn't later.
+func (c *SimulatedBeacon) CommitAt(timestamp uint64) common.Hash {
+	withdrawals := c.withdrawals.gatherPending(10)
+	if err := c.sealBlock(withdrawals, timestamp); err != nil {
+		log.Warn("Error performing sealing work", "err", err)
+	}
+	return c.eth.BlockChain().CurrentBlock().Hash()
+}
+
 // Rollback un-sends previously added transactions.
 func (c *SimulatedBeacon) Rollback() {
 	// Flush all transactions from the transaction pools
@@ -0,0 +1,229 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/core/forkid"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/metrics"
+)
+
+const (
+	// chainSplitInterval is the interval at which the heads of connected peers
+	// are sampled into the chain split monitor.
+	chainSplitInterval = 30 * time.Second
+
+	// chainSplitExpiry is the time after which a peer that was not seen any
+	// more is no longer accounted for.
+	chainSplitExpiry = time.Hour
+)
+
+var (
+	chainSplitChainsGauge       = metrics.NewRegisteredGauge("eth/chainsplit/chains", nil)
+	chainSplitLocalGauge        = metrics.NewRegisteredGauge("eth/chainsplit/peers/local", nil)
+	chainSplitCompatibleGauge   = metrics.NewRegisteredGauge("eth/chainsplit/peers/compatible", nil)
+	chainSplitIncompatibleGauge = metrics.NewRegisteredGauge("eth/chainsplit/peers/incompatible", nil)
+	chainSplitRejectedMeter     = metrics.NewRegisteredMeter("eth/chainsplit/rejected", nil)
+)
+
+// ChainSplit describes a group of peers advertising the same fork ID, i.e.
+// following the same chain as far as the fork history is concerned.
+type ChainSplit struct {
+	ForkHash   hexutil.Bytes `json:"forkHash"`   // CRC32 checksum of the genesis and passed forks
+	ForkNext   uint64        `json:"forkNext"`   // Next upcoming fork block or time, 0 if none
+	Local      bool          `json:"local"`      // Whether the fork ID equals ours
+	Compatible bool          `json:"compatible"` // Whether our fork filter accepts the fork ID
+	Minority   bool          `json:"minority"`   // Whether less than half of the tracked peers follow this chain
+	Peers      int           `json:"peers"`      // Number of peers seen recently on this chain
+	Connected  int           `json:"connected"`  // Number of currently connected peers on this chain
+	Head       common.Hash   `json:"head"`       // Head of the peer with the highest total difficulty
+	TD         *hexutil.Big  `json:"totalDifficulty"`
+	LastSeen   time.Time     `json:"lastSeen"`
+}
+
+// splitPeer is the last known chain state advertised by a remote peer.
+type splitPeer struct {
+	forkID     forkid.ID
+	head       common.Hash
+	td         *big.Int
+	compatible bool
+	connected  bool
+	seen       time.Time
+}
+
+// chainSplitMonitor tracks the fork IDs, heads and total difficulties that
+// peers advertise over time, grouping them by the chain they follow. Unlike the
+// fork ID filter, which only drops peers at handshake, it keeps a record of the
+// rejected peers too, so that contentious forks of the network become visible.
+type chainSplitMonitor struct {
+	localID func() forkid.ID // Current local fork ID
+	filter  forkid.Filter    // Filter to classify remote fork IDs with
+
+	peers map[string]*splitPeer
+	lock  sync.Mutex
+}
+
+func newChainSplitMonitor(localID func() forkid.ID, filter forkid.Filter) *chainSplitMonitor {
+	return &chainSplitMonitor{
+		localID: localID,
+		filter:  filter,
+		peers:   make(map[string]*splitPeer),
+	}
+}
+
+// observe records the chain state advertised by a peer during the handshake.
+func (m *chainSplitMonitor) observe(id string, forkID forkid.ID, head common.Hash, td *big.Int, connected bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	compatible := m.filter(forkID) == nil
+	if !compatible {
+		chainSplitRejectedMeter.Mark(1)
+		log.Debug("Peer on incompatible chain", "id", id, "forkhash", hexutil.Bytes(forkID.Hash[:]), "forknext", forkID.Next, "head", head)
+	}
+	m.peers[id] = &splitPeer{
+		forkID:     forkID,
+		head:       head,
+		td:         new(big.Int).Set(td),
+		compatible: compatible,
+		connected:  connected,
+		seen:       time.Now(),
+	}
+}
+
+// update refreshes the head and total difficulty of a connected peer.
+func (m *chainSplitMonitor) update(id string, head common.Hash, td *big.Int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if p := m.peers[id]; p != nil {
+		p.head, p.td, p.seen = head, new(big.Int).Set(td), time.Now()
+	}
+}
+
+// disconnected marks a peer as no longer connected, retaining its last state
+// until it expires.
+func (m *chainSplitMonitor) disconnected(id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if p := m.peers[id]; p != nil {
+		p.connected = false
+	}
+}
+
+// splits groups the recently seen peers by fork ID, ordered by the number of
+// peers following each chain.
+func (m *chainSplitMonitor) splits() []*ChainSplit {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var (
+		local  = m.localID()
+		groups = make(map[forkid.ID]*ChainSplit)
+		total  int
+	)
+	for id, p := range m.peers {
+		if time.Since(p.seen) > chainSplitExpiry {
+			delete(m.peers, id)
+			continue
+		}
+		total++
+		split := groups[p.forkID]
+		if split == nil {
+			split = &ChainSplit{
+				ForkHash:   common.CopyBytes(p.forkID.Hash[:]),
+				ForkNext:   p.forkID.Next,
+				Local:      p.forkID == local,
+				Compatible: p.compatible,
+				TD:         (*hexutil.Big)(new(big.Int)),
+			}
+			groups[p.forkID] = split
+		}
+		split.Peers++
+		if p.connected {
+			split.Connected++
+		}
+		if p.td.Cmp(split.TD.ToInt()) > 0 {
+			split.Head, split.TD = p.head, (*hexutil.Big)(new(big.Int).Set(p.td))
+		}
+		if p.seen.After(split.LastSeen) {
+			split.LastSeen = p.seen
+		}
+	}
+	splits := make([]*ChainSplit, 0, len(groups))
+	for _, split := range groups {
+		split.Minority = 2*split.Peers < total
+		splits = append(splits, split)
+	}
+	sort.Slice(splits, func(i, j int) bool {
+		if splits[i].Peers != splits[j].Peers {
+			return splits[i].Peers > splits[j].Peers
+		}
+		return splits[i].ForkNext < splits[j].ForkNext
+	})
+	return splits
+}
+
+// report updates the chain split metrics.
+func (m *chainSplitMonitor) report() {
+	var local, compatible, incompatible int64
+	splits := m.splits()
+	for _, split := range splits {
+		switch {
+		case split.Local:
+			local += int64(split.Peers)
+		case split.Compatible:
+			compatible += int64(split.Peers)
+		default:
+			incompatible += int64(split.Peers)
+		}
+	}
+	chainSplitChainsGauge.Update(int64(len(splits)))
+	chainSplitLocalGauge.Update(local)
+	chainSplitCompatibleGauge.Update(compatible)
+	chainSplitIncompatibleGauge.Update(incompatible)
+}
+
+// chainSplitLoop periodically samples the heads of all connected peers into the
+// chain split monitor and updates the associated metrics.
+func (h *handler) chainSplitLoop() {
+	defer h.wg.Done()
+
+	t := time.NewTicker(chainSplitInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			for _, p := range h.peers.all() {
+				head, td, _ := p.Head()
+				h.chainSplits.update(p.ID(), head, td)
+			}
+			h.chainSplits.report()
+		case <-h.quitSync:
+			return
+		}
+	}
+}
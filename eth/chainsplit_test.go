@@ -0,0 +1,89 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/forkid"
+)
+
+// Tests that the chain split monitor groups peers by fork ID, classifies them
+// against the local fork ID and the filter, and flags minority chains.
+func TestChainSplitMonitor(t *testing.T) {
+	var (
+		local    = forkid.ID{Hash: [4]byte{0x01}, Next: 100}
+		upcoming = forkid.ID{Hash: [4]byte{0x01}, Next: 0}
+		foreign  = forkid.ID{Hash: [4]byte{0x02}, Next: 0}
+	)
+	monitor := newChainSplitMonitor(
+		func() forkid.ID { return local },
+		func(id forkid.ID) error {
+			if id.Hash != local.Hash {
+				return forkid.ErrLocalIncompatibleOrStale
+			}
+			return nil
+		},
+	)
+	monitor.observe("a", local, common.Hash{0xa}, big.NewInt(10), true)
+	monitor.observe("b", local, common.Hash{0xb}, big.NewInt(30), true)
+	monitor.observe("c", local, common.Hash{0xc}, big.NewInt(20), true)
+	monitor.observe("d", upcoming, common.Hash{0xd}, big.NewInt(5), true)
+	monitor.observe("e", foreign, common.Hash{0xe}, big.NewInt(50), false)
+
+	monitor.update("a", common.Hash{0xaa}, big.NewInt(40))
+	monitor.disconnected("c")
+
+	splits := monitor.splits()
+	if len(splits) != 3 {
+		t.Fatalf("split count mismatch: have %d, want 3", len(splits))
+	}
+	main := splits[0]
+	if !main.Local || !main.Compatible || main.Minority {
+		t.Errorf("local chain misclassified: local %v, compatible %v, minority %v", main.Local, main.Compatible, main.Minority)
+	}
+	if main.Peers != 3 || main.Connected != 2 {
+		t.Errorf("local chain peer count mismatch: have %d/%d, want 3/2", main.Peers, main.Connected)
+	}
+	if main.Head != (common.Hash{0xaa}) || main.TD.ToInt().Int64() != 40 {
+		t.Errorf("local chain head mismatch: have %x/%v, want %x/40", main.Head, main.TD, common.Hash{0xaa})
+	}
+	for _, split := range splits[1:] {
+		if split.Local || !split.Minority || split.Peers != 1 {
+			t.Errorf("chain %x misclassified: local %v, minority %v, peers %d", split.ForkHash, split.Local, split.Minority, split.Peers)
+		}
+		switch split.ForkHash[0] {
+		case 0x01:
+			if !split.Compatible {
+				t.Errorf("upcoming fork chain reported incompatible")
+			}
+		case 0x02:
+			if split.Compatible || split.Connected != 0 {
+				t.Errorf("foreign chain misclassified: compatible %v, connected %d", split.Compatible, split.Connected)
+			}
+		}
+	}
+
+	// Expired peers should be dropped from the report
+	monitor.peers["e"].seen = time.Now().Add(-2 * chainSplitExpiry)
+	if splits := monitor.splits(); len(splits) != 2 {
+		t.Fatalf("split count mismatch after expiry: have %d, want 2", len(splits))
+	}
+}
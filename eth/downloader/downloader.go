@@ -27,6 +27,7 @@ import (
 
 	"github.com/shudolab/core-geth"
 	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core"
 	"github.com/shudolab/core-geth/core/rawdb"
 	"github.com/shudolab/core-geth/core/state/snapshot"
 	"github.com/shudolab/core-geth/core/types"
@@ -34,6 +35,7 @@ import (
 	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/event"
 	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/params/vars"
 	"github.com/shudolab/core-geth/triedb"
 )
@@ -75,6 +77,7 @@ var (
 	errPeersUnavailable        = errors.New("no peers available or all tried for download")
 	errInvalidAncestor         = errors.New("retrieved ancestor is invalid")
 	errInvalidChain            = errors.New("retrieved hash chain is invalid")
+	errBadChain                = errors.New("retrieved chain failed validation")
 	errInvalidBody             = errors.New("retrieved block body is invalid")
 	errInvalidReceipt          = errors.New("retrieved receipt is invalid")
 	errCancelStateFetch        = errors.New("state data download canceled (requested)")
@@ -86,8 +89,9 @@ var (
 	ErrMergeTransition         = errors.New("legacy sync reached the merge")
 )
 
-// peerDropFn is a callback type for dropping a peer detected as malicious.
-type peerDropFn func(id string)
+// peerDropFn is a callback type for dropping a peer detected as malicious,
+// along with the offense to penalize its reputation for.
+type peerDropFn func(id string, offense p2p.Offense, reason string)
 
 // badBlockFn is a callback for the async beacon sync to notify the caller that
 // the origin header requested to sync to, produced a chain with a bad block.
@@ -338,6 +342,36 @@ func (d *Downloader) UnregisterPeer(id string) error {
 	return nil
 }
 
+// chainError wraps an error importing delivered headers or blocks. Validation
+// failures are blamed on the delivered chain, other import errors, such as an
+// interrupted insertion or a failed database write, are local failures.
+func chainError(err error) error {
+	var bad *core.BadBlockError
+	if errors.As(err, &bad) {
+		return fmt.Errorf("%w: %v", errBadChain, err)
+	}
+	return fmt.Errorf("%w: %v", errInvalidChain, err)
+}
+
+// offenseOf classifies a synchronisation failure blamed on a peer. Headers and
+// blocks failing validation are reported as bad chains, other invalid chain
+// errors may be caused by local import failures, so they drop the peer without
+// penalizing it. Running out of peers to fetch from is a local condition too.
+func offenseOf(err error) p2p.Offense {
+	switch {
+	case errors.Is(err, errTimeout), errors.Is(err, errStallingPeer):
+		return p2p.OffenseStall
+	case errors.Is(err, errBadPeer):
+		return p2p.OffenseInvalidMessage
+	case errors.Is(err, errEmptyHeaderSet), errors.Is(err, errInvalidAncestor), errors.Is(err, errBadChain):
+		return p2p.OffenseInvalidData
+	case errors.Is(err, errInvalidChain), errors.Is(err, errPeersUnavailable):
+		return p2p.OffenseNone
+	default:
+		return p2p.OffenseUseless
+	}
+}
+
 // LegacySync tries to sync up our local block chain with a remote peer, both
 // adding various sanity checks as well as wrapping it with various log entries.
 func (d *Downloader) LegacySync(id string, head common.Hash, td, ttd *big.Int, mode SyncMode) error {
@@ -347,7 +381,7 @@ func (d *Downloader) LegacySync(id string, head common.Hash, td, ttd *big.Int, m
 	case nil, errBusy, errCanceled:
 		return err
 	}
-	if errors.Is(err, errInvalidChain) || errors.Is(err, errBadPeer) || errors.Is(err, errTimeout) ||
+	if errors.Is(err, errInvalidChain) || errors.Is(err, errBadChain) || errors.Is(err, errBadPeer) || errors.Is(err, errTimeout) ||
 		errors.Is(err, errStallingPeer) || errors.Is(err, errUnsyncedPeer) || errors.Is(err, errEmptyHeaderSet) ||
 		errors.Is(err, errPeersUnavailable) || errors.Is(err, errTooOld) || errors.Is(err, errInvalidAncestor) {
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
@@ -356,7 +390,7 @@ func (d *Downloader) LegacySync(id string, head common.Hash, td, ttd *big.Int, m
 			// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
 			log.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", id)
 		} else {
-			d.dropPeer(id)
+			d.dropPeer(id, offenseOf(err), err.Error())
 		}
 		return err
 	}
@@ -1083,7 +1117,7 @@ func (d *Downloader) fetchTotalDifficulty(p *peerConnection, latest *types.Heade
 
 			if newTd.Cmp(d.td) > 0 {
 				head = headers[len(headers)-1]
-				p.peer.SetHead(head.Hash(), newTd, head.Difficulty)
+				p.peer.SetHead(head.Hash(), head.Number.Uint64(), newTd, head.Difficulty)
 				log.Debug("Updating sync target total difficulty", "old", d.td, "new", newTd)
 				d.td = newTd
 			}
@@ -1148,7 +1182,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, head uint64) e
 		default:
 			// Header retrieval either timed out, or the peer failed in some strange way
 			// (e.g. disconnect). Consider the master peer bad and drop
-			d.dropPeer(p.id)
+			d.dropPeer(p.id, offenseOf(err), err.Error())
 
 			// Finish the sync gracefully instead of dumping the gathered data though
 			for _, ch := range []chan bool{d.queue.blockWakeCh, d.queue.receiptWakeCh} {
@@ -1529,7 +1563,7 @@ func (d *Downloader) processHeaders(origin uint64, td, ttd *big.Int, beaconMode
 								rollback = chunkHeaders[0].Number.Uint64()
 							}
 							log.Warn("Invalid header encountered", "number", chunkHeaders[n].Number, "hash", chunkHashes[n], "parent", chunkHeaders[n].ParentHash, "err", err)
-							return fmt.Errorf("%w: %v", errInvalidChain, err)
+							return chainError(err)
 						}
 						// All verifications passed, track all headers within the allowed limits
 						if mode == SnapSync {
@@ -1676,13 +1710,13 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 					d.badBlock(blocks[index].Header(), head)
 				}
 			}
-		} else {
-			// The InsertChain method in blockchain.go will sometimes return an out-of-bounds index,
-			// when it needs to preprocess blocks to import a sidechain.
-			// The importer will put together a new list of blocks to import, which is a superset
-			// of the blocks delivered from the downloader, and the indexing will be off.
-			log.Debug("Downloaded item processing failed on sidechain import", "index", index, "err", err)
+			return chainError(err)
 		}
+		// The InsertChain method in blockchain.go will sometimes return an out-of-bounds index,
+		// when it needs to preprocess blocks to import a sidechain.
+		// The importer will put together a new list of blocks to import, which is a superset
+		// of the blocks delivered from the downloader, and the indexing will be off.
+		log.Debug("Downloaded item processing failed on sidechain import", "index", index, "err", err)
 		return fmt.Errorf("%w: %v", errInvalidChain, err)
 	}
 	return nil
@@ -1908,7 +1942,7 @@ func (d *Downloader) DeliverSnapPacket(peer *snap.Peer, packet snap.Packet) erro
 	case *snap.AccountRangePacket:
 		hashes, accounts, err := packet.Unpack()
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: %v", snap.ErrInvalidResponse, err)
 		}
 		return d.SnapSyncer.OnAccounts(peer, packet.ID, hashes, accounts, packet.Proof)
 
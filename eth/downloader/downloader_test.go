@@ -38,6 +38,7 @@ import (
 	"github.com/shudolab/core-geth/eth/protocols/snap"
 	"github.com/shudolab/core-geth/event"
 	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/params"
 	"github.com/shudolab/core-geth/params/types/genesisT"
 	"github.com/shudolab/core-geth/params/vars"
@@ -51,8 +52,9 @@ type downloadTester struct {
 	chain      *core.BlockChain
 	downloader *Downloader
 
-	peers map[string]*downloadTesterPeer
-	lock  sync.RWMutex
+	peers    map[string]*downloadTesterPeer
+	offenses map[string]p2p.Offense // Offenses of dropped peers
+	lock     sync.RWMutex
 }
 
 // newTester creates a new downloader test mocker.
@@ -80,9 +82,10 @@ func newTesterWithNotification(t *testing.T, success func()) *downloadTester {
 		panic(err)
 	}
 	tester := &downloadTester{
-		freezer: freezer,
-		chain:   chain,
-		peers:   make(map[string]*downloadTesterPeer),
+		freezer:  freezer,
+		chain:    chain,
+		peers:    make(map[string]*downloadTesterPeer),
+		offenses: make(map[string]p2p.Offense),
 	}
 	tester.downloader = New(0, db, new(event.TypeMux), tester.chain, nil, tester.dropPeer, success)
 	return tester
@@ -139,11 +142,12 @@ func (dl *downloadTester) newPeer(id string, version uint, blocks []*types.Block
 }
 
 // dropPeer simulates a hard peer removal from the connection pool.
-func (dl *downloadTester) dropPeer(id string) {
+func (dl *downloadTester) dropPeer(id string, offense p2p.Offense, reason string) {
 	dl.lock.Lock()
 	defer dl.lock.Unlock()
 
 	delete(dl.peers, id)
+	dl.offenses[id] = offense
 	dl.downloader.SnapSyncer.Unregister(id)
 	dl.downloader.UnregisterPeer(id)
 }
@@ -170,7 +174,7 @@ func (dlp *downloadTesterPeer) Head() (common.Hash, *big.Int, *big.Int) {
 
 // SetHead constructs a function to retrieve a peer's current head hash
 // and total difficulty.
-func (dlp *downloadTesterPeer) SetHead(common.Hash, *big.Int, *big.Int) {
+func (dlp *downloadTesterPeer) SetHead(common.Hash, uint64, *big.Int, *big.Int) {
 	// noop
 }
 
@@ -975,6 +979,7 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol uint) {
 		{errPeersUnavailable, true},         // Nobody had the advertised blocks, drop the advertiser
 		{errInvalidAncestor, true},          // Agreed upon ancestor is not acceptable, drop the chain rewriter
 		{errInvalidChain, true},             // Hash chain was detected as invalid, definitely drop
+		{errBadChain, true},                 // Chain failed validation, definitely drop
 		{errInvalidBody, false},             // A bad peer was detected, but not the sync origin
 		{errInvalidReceipt, false},          // A bad peer was detected, but not the sync origin
 		{errCancelContentProcessing, false}, // Synchronisation was canceled, origin may be innocent, don't drop
@@ -1001,6 +1006,51 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol uint) {
 	}
 }
 
+// Tests that synchronisation failures are blamed on the peer only if it's at
+// fault, and that invalid chains are penalized as such.
+func TestOffenseOf(t *testing.T) {
+	tests := []struct {
+		err     error
+		offense p2p.Offense
+	}{
+		{errTimeout, p2p.OffenseStall},
+		{errStallingPeer, p2p.OffenseStall},
+		{errBadPeer, p2p.OffenseInvalidMessage},
+		{errEmptyHeaderSet, p2p.OffenseInvalidData},
+		{errInvalidAncestor, p2p.OffenseInvalidData},
+		{fmt.Errorf("%w: %v", errBadChain, errors.New("invalid difficulty")), p2p.OffenseInvalidData},
+		{fmt.Errorf("%w: %v", errInvalidChain, errors.New("parent TD missing")), p2p.OffenseNone},
+		{chainError(&core.BadBlockError{Err: core.ErrBannedHash}), p2p.OffenseInvalidData},
+		{chainError(errors.New("leveldb: closed")), p2p.OffenseNone},
+		{errPeersUnavailable, p2p.OffenseNone},
+		{errUnsyncedPeer, p2p.OffenseUseless},
+	}
+	for _, tt := range tests {
+		if have := offenseOf(tt.err); have != tt.offense {
+			t.Errorf("offense mismatch for %v: have %v, want %v", tt.err, have, tt.offense)
+		}
+	}
+}
+
+// Tests that a sync failing on a local import error, here an interrupted header
+// insertion, doesn't penalize the sync origin.
+func TestInterruptedImportNotPenalized68(t *testing.T) {
+	tester := newTester(t)
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(MaxHeaderFetch)
+	tester.newPeer("peer", eth.ETH68, chain.blocks[1:])
+	tester.chain.StopInsert()
+
+	err := tester.downloader.LegacySync("peer", chain.blocks[len(chain.blocks)-1].Hash(), big.NewInt(1000000), nil, LightSync)
+	if !errors.Is(err, errInvalidChain) {
+		t.Fatalf("synchronisation error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+	if offense, ok := tester.offenses["peer"]; !ok || offense != p2p.OffenseNone {
+		t.Errorf("offense mismatch: have %v (dropped %v), want %v", offense, ok, p2p.OffenseNone)
+	}
+}
+
 // Tests that synchronisation progress (origin block number, current block number
 // and highest block number) is tracked and updated correctly.
 func TestSyncProgress68Full(t *testing.T)  { testSyncProgress(t, eth.ETH68, FullSync) }
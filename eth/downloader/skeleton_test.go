@@ -30,6 +30,7 @@ import (
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/eth/protocols/eth"
 	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/p2p"
 )
 
 // hookedBackfiller is a tester backfiller with all interface methods mocked and
@@ -192,7 +193,7 @@ func (p *skeletonTestPeer) Head() (common.Hash, *big.Int, *big.Int) {
 	panic("skeleton sync must not request the remote head")
 }
 
-func (p *skeletonTestPeer) SetHead(common.Hash, *big.Int, *big.Int) {
+func (p *skeletonTestPeer) SetHead(common.Hash, uint64, *big.Int, *big.Int) {
 	panic("skeleton sync must not request the remote head")
 }
 
@@ -819,7 +820,7 @@ func TestSkeletonSyncRetrievals(t *testing.T) {
 		}
 		// Create a peer dropper to track malicious peers
 		dropped := make(map[string]int)
-		drop := func(peer string) {
+		drop := func(peer string, offense p2p.Offense, reason string) {
 			if p := peerset.Peer(peer); p != nil {
 				p.peer.(*skeletonTestPeer).dropped.Add(1)
 			}
@@ -29,6 +29,7 @@ import (
 	"github.com/shudolab/core-geth/consensus/beacon"
 	"github.com/shudolab/core-geth/consensus/clique"
 	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/consensus/keccak"
 	"github.com/shudolab/core-geth/consensus/lyra2"
 	"github.com/shudolab/core-geth/core/txpool/blobpool"
 	"github.com/shudolab/core-geth/core/txpool/legacypool"
@@ -85,6 +86,7 @@ var Defaults = Config{
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
+	RPCCacheDepth:      128,
 }
 
 func init() {
@@ -126,6 +128,10 @@ type Config struct {
 	EthDiscoveryURLs  []string
 	SnapDiscoveryURLs []string
 
+	// DiscoveryForkIDFilter skips dialing discovered nodes whose `eth` ENR entry
+	// advertises a fork ID incompatible with the local chain.
+	DiscoveryForkIDFilter bool
+
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
@@ -190,6 +196,9 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Executes the transactions of blocks optimistically in parallel
+	ParallelTxExecution bool
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -209,6 +218,14 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// RPCCacheSize is the memory allowance (in megabytes) of the cache for
+	// immutable RPC responses. Zero disables the cache.
+	RPCCacheSize int
+
+	// RPCCacheDepth is the number of blocks below the head after which
+	// responses are considered immutable, absent an earlier finality signal.
+	RPCCacheDepth uint64
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *ctypes.TrustedCheckpoint `toml:",omitempty"`
 
@@ -249,7 +266,11 @@ func CreateConsensusEngine(stack *node.Node, ethashConfig *ethash.Config, clique
 			engine = ethash.NewFaker()
 		case ethash.ModeTest:
 			log.Warn("Ethash used in test mode")
-			engine = ethash.NewTester(nil, noverify)
+			if ethashConfig.ECIP1049Block != nil {
+				engine = keccak.New(ethash.Config{PowMode: ethash.ModeTest, ECIP1049Block: ethashConfig.ECIP1049Block}, nil, noverify)
+			} else {
+				engine = ethash.NewTester(nil, noverify)
+			}
 		case ethash.ModeShared:
 			log.Warn("Ethash used in shared mode")
 			engine = ethash.NewShared()
@@ -257,7 +278,7 @@ func CreateConsensusEngine(stack *node.Node, ethashConfig *ethash.Config, clique
 			log.Warn("Ethash used in fake Poisson mode")
 			engine = ethash.NewPoissonFaker()
 		default:
-			engine = ethash.New(ethash.Config{
+			config := ethash.Config{
 				PowMode:          ethashConfig.PowMode,
 				CacheDir:         stack.ResolvePath(ethashConfig.CacheDir),
 				CachesInMem:      ethashConfig.CachesInMem,
@@ -269,8 +290,17 @@ func CreateConsensusEngine(stack *node.Node, ethashConfig *ethash.Config, clique
 				DatasetsLockMmap: ethashConfig.DatasetsLockMmap,
 				NotifyFull:       ethashConfig.NotifyFull,
 				ECIP1099Block:    ethashConfig.ECIP1099Block,
-			}, notify, noverify)
-			engine.(*ethash.Ethash).SetThreads(-1) // Disable CPU mining
+				ECIP1049Block:    ethashConfig.ECIP1049Block,
+			}
+			// ECIP-1049 replaces the ethash proof-of-work with Keccak-256
+			var pow *ethash.Ethash
+			if config.ECIP1049Block != nil {
+				pow = keccak.New(config, notify, noverify)
+			} else {
+				pow = ethash.New(config, notify, noverify)
+			}
+			pow.SetThreads(-1) // Disable CPU mining
+			engine = pow
 		}
 	}
 
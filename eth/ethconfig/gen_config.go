@@ -25,6 +25,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SyncMode                   downloader.SyncMode
 		EthDiscoveryURLs           []string
 		SnapDiscoveryURLs          []string
+		DiscoveryForkIDFilter      bool
 		NoPruning                  bool
 		NoPrefetch                 bool
 		TxLookupLimit              uint64                 `toml:",omitempty"`
@@ -59,12 +60,15 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		BlobPool                   blobpool.Config
 		GPO                        gasprice.Config
 		EnablePreimageRecording    bool
+		ParallelTxExecution        bool
 		DocRoot                    string `toml:"-"`
 		EWASMInterpreter           string
 		EVMInterpreter             string
 		RPCGasCap                  uint64
 		RPCEVMTimeout              time.Duration
 		RPCTxFeeCap                float64
+		RPCCacheSize               int
+		RPCCacheDepth              uint64
 		Checkpoint                 *ctypes.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle           *ctypes.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideECBP1100           *uint64                        `toml:",omitempty"`
@@ -81,6 +85,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.EthDiscoveryURLs = c.EthDiscoveryURLs
 	enc.SnapDiscoveryURLs = c.SnapDiscoveryURLs
+	enc.DiscoveryForkIDFilter = c.DiscoveryForkIDFilter
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
@@ -115,12 +120,15 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.BlobPool = c.BlobPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.ParallelTxExecution = c.ParallelTxExecution
 	enc.DocRoot = c.DocRoot
 	enc.EWASMInterpreter = c.EWASMInterpreter
 	enc.EVMInterpreter = c.EVMInterpreter
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.RPCCacheSize = c.RPCCacheSize
+	enc.RPCCacheDepth = c.RPCCacheDepth
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
 	enc.OverrideECBP1100 = c.OverrideECBP1100
@@ -141,6 +149,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SyncMode                   *downloader.SyncMode
 		EthDiscoveryURLs           []string
 		SnapDiscoveryURLs          []string
+		DiscoveryForkIDFilter      *bool
 		NoPruning                  *bool
 		NoPrefetch                 *bool
 		TxLookupLimit              *uint64                `toml:",omitempty"`
@@ -175,12 +184,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		BlobPool                   *blobpool.Config
 		GPO                        *gasprice.Config
 		EnablePreimageRecording    *bool
+		ParallelTxExecution        *bool
 		DocRoot                    *string `toml:"-"`
 		EWASMInterpreter           *string
 		EVMInterpreter             *string
 		RPCGasCap                  *uint64
 		RPCEVMTimeout              *time.Duration
 		RPCTxFeeCap                *float64
+		RPCCacheSize               *int
+		RPCCacheDepth              *uint64
 		Checkpoint                 *ctypes.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle           *ctypes.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideECBP1100           *uint64                        `toml:",omitempty"`
@@ -212,6 +224,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SnapDiscoveryURLs != nil {
 		c.SnapDiscoveryURLs = dec.SnapDiscoveryURLs
 	}
+	if dec.DiscoveryForkIDFilter != nil {
+		c.DiscoveryForkIDFilter = *dec.DiscoveryForkIDFilter
+	}
 	if dec.NoPruning != nil {
 		c.NoPruning = *dec.NoPruning
 	}
@@ -314,6 +329,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.ParallelTxExecution != nil {
+		c.ParallelTxExecution = *dec.ParallelTxExecution
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
@@ -332,6 +350,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.RPCCacheSize != nil {
+		c.RPCCacheSize = *dec.RPCCacheSize
+	}
+	if dec.RPCCacheDepth != nil {
+		c.RPCCacheDepth = *dec.RPCCacheDepth
+	}
 	if dec.Checkpoint != nil {
 		c.Checkpoint = dec.Checkpoint
 	}
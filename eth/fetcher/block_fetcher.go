@@ -29,6 +29,7 @@ import (
 	"github.com/shudolab/core-geth/eth/protocols/eth"
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/metrics"
+	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/trie"
 )
 
@@ -102,8 +103,9 @@ type headersInsertFn func(headers []*types.Header) (int, error)
 // chainInsertFn is a callback type to insert a batch of blocks into the local chain.
 type chainInsertFn func(types.Blocks) (int, error)
 
-// peerDropFn is a callback type for dropping a peer detected as malicious.
-type peerDropFn func(id string)
+// peerDropFn is a callback type for dropping a peer detected as malicious,
+// along with the offense to penalize its reputation for.
+type peerDropFn func(id string, offense p2p.Offense, reason string)
 
 // blockAnnounce is the hash notification of the availability of a new block in the
 // network.
@@ -497,7 +499,7 @@ func (f *BlockFetcher) loop() {
 								// was already rescheduled at this point, we were
 								// waiting for a catchup. With an unresponsive
 								// peer however, it's a protocol violation.
-								f.dropPeer(peer)
+								f.dropPeer(peer, p2p.OffenseStall, "header request timeout")
 							}
 						}(hash)
 					}
@@ -556,7 +558,7 @@ func (f *BlockFetcher) loop() {
 						// was already rescheduled at this point, we were
 						// waiting for a catchup. With an unresponsive
 						// peer however, it's a protocol violation.
-						f.dropPeer(peer)
+						f.dropPeer(peer, p2p.OffenseStall, "body request timeout")
 					}
 				}(peer, hashes)
 			}
@@ -586,7 +588,7 @@ func (f *BlockFetcher) loop() {
 					// If the delivered header does not match the promised number, drop the announcer
 					if header.Number.Uint64() != announce.number {
 						log.Trace("Invalid block number fetched", "peer", announce.origin, "hash", header.Hash(), "announced", announce.number, "provided", header.Number)
-						f.dropPeer(announce.origin)
+						f.dropPeer(announce.origin, p2p.OffenseInvalidData, "invalid announced block number")
 						f.forgetHash(hash)
 						continue
 					}
@@ -825,7 +827,7 @@ func (f *BlockFetcher) importHeaders(peer string, header *types.Header) {
 		// Validate the header and if something went wrong, drop the peer
 		if err := f.verifyHeader(header); err != nil && err != consensus.ErrFutureBlock {
 			log.Debug("Propagated header verification failed", "peer", peer, "number", header.Number, "hash", hash, "err", err)
-			f.dropPeer(peer)
+			f.dropPeer(peer, p2p.OffenseInvalidData, err.Error())
 			return
 		}
 		// Run the actual import and log any issues
@@ -870,7 +872,7 @@ func (f *BlockFetcher) importBlocks(peer string, block *types.Block) {
 		default:
 			// Something went very wrong, drop the peer
 			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
-			f.dropPeer(peer)
+			f.dropPeer(peer, p2p.OffenseInvalidData, err.Error())
 			return
 		}
 		// Run the actual import and log any issues
@@ -31,6 +31,7 @@ import (
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/crypto"
 	"github.com/shudolab/core-geth/eth/protocols/eth"
+	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/params"
 	"github.com/shudolab/core-geth/params/types/genesisT"
 	"github.com/shudolab/core-geth/params/vars"
@@ -190,7 +191,7 @@ func (f *fetcherTester) insertChain(blocks types.Blocks) (int, error) {
 
 // dropPeer is an emulator for the peer removal, simply accumulating the various
 // peers dropped by the fetcher.
-func (f *fetcherTester) dropPeer(peer string) {
+func (f *fetcherTester) dropPeer(peer string, offense p2p.Offense, reason string) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
@@ -129,8 +129,9 @@ type handler struct {
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
-	chainSync *chainSyncer
-	wg        sync.WaitGroup
+	chainSync   *chainSyncer
+	chainSplits *chainSplitMonitor
+	wg          sync.WaitGroup
 
 	handlerStartCh chan struct{}
 	handlerDoneCh  chan struct{}
@@ -156,6 +157,11 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		handlerDoneCh:  make(chan struct{}),
 		handlerStartCh: make(chan struct{}),
 	}
+	h.chainSplits = newChainSplitMonitor(func() forkid.ID {
+		head := h.chain.CurrentHeader()
+		return forkid.NewID(h.chain.Config(), h.chain.Genesis(), head.Number.Uint64(), head.Time)
+	}, h.forkFilter)
+
 	if config.Sync == downloader.FullSync {
 		// The database seems empty as the current block is the genesis. Yet the snap
 		// block is ahead, so snap sync was enabled for this node at a certain point.
@@ -194,7 +200,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return nil, errors.New("snap sync not supported with snapshots disabled")
 	}
 	// Construct the downloader (long sync)
-	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.eventMux, h.chain, nil, h.removePeer, h.enableSyncedFeatures)
+	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.eventMux, h.chain, nil, h.dropPeer, h.enableSyncedFeatures)
 	if ttd := h.chain.Config().GetEthashTerminalTotalDifficulty(); ttd != nil {
 		if h.chain.Config().GetEthashTerminalTotalDifficultyPassed() {
 			log.Info("Chain post-merge, sync via beacon client")
@@ -295,7 +301,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 		return n, err
 	}
-	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.removePeer)
+	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.dropPeer)
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -307,7 +313,10 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	addTxs := func(txs []*types.Transaction) []error {
 		return h.txpool.Add(txs, false, false)
 	}
-	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+	dropTxPeer := func(id string) {
+		h.dropPeer(id, p2p.OffenseInvalidMessage, "transaction announcement violation")
+	}
+	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, dropTxPeer)
 	h.chainSync = newChainSyncer(h)
 	return h, nil
 }
@@ -375,6 +384,10 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	forkID := forkid.NewID(h.chain.Config(), genesis, number, head.Time)
 	if err := peer.Handshake(h.networkID, td, hash, genesis.Hash(), forkID, h.forkFilter); err != nil {
 		peer.Log().Debug("Ethereum handshake failed", "err", err)
+		if errors.Is(err, eth.ErrForkIDRejected) {
+			peerHead, peerTD, _ := peer.Head()
+			h.chainSplits.observe(peer.ID(), peer.ForkID(), peerHead, peerTD, false)
+		}
 		return err
 	}
 	reject := false // reserved peer slots
@@ -395,6 +408,8 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		}
 	}
 	peer.Log().Debug("Ethereum peer connected", "name", peer.Name())
+	peerHead, peerTD, _ := peer.Head()
+	h.chainSplits.observe(peer.ID(), peer.ForkID(), peerHead, peerTD, true)
 
 	// Register the peer locally
 	if err := h.peers.registerPeer(peer, snap); err != nil {
@@ -473,7 +488,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 
 			case <-timeout.C:
 				peer.Log().Warn("Checkpoint challenge timed out, dropping", "addr", peer.RemoteAddr(), "type", peer.Name())
-				h.removePeer(peer.ID())
+				h.dropPeer(peer.ID(), p2p.OffenseStall, "checkpoint challenge timeout")
 
 			case <-dead:
 				// Peer handler terminated, abort all goroutines
@@ -518,7 +533,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 				res.Done <- nil
 			case <-timeout.C:
 				peer.Log().Warn("Required block challenge timed out, dropping", "addr", peer.RemoteAddr(), "type", peer.Name())
-				h.removePeer(peer.ID())
+				h.dropPeer(peer.ID(), p2p.OffenseStall, "required block challenge timeout")
 			}
 		}(number, hash, req)
 	}
@@ -558,6 +573,16 @@ func (h *handler) removePeer(id string) {
 	}
 }
 
+// dropPeer penalizes the reputation of a misbehaving peer for the given offense
+// and requests its disconnection.
+func (h *handler) dropPeer(id string, offense p2p.Offense, reason string) {
+	peer := h.peers.peer(id)
+	if peer != nil {
+		peer.Report(offense, reason)
+		peer.Peer.Disconnect(p2p.DiscUselessPeer)
+	}
+}
+
 // unregisterPeer removes a peer from the downloader, fetchers and main peer set.
 func (h *handler) unregisterPeer(id string) {
 	// Create a custom logger to avoid printing the entire id
@@ -583,6 +608,7 @@ func (h *handler) unregisterPeer(id string) {
 	}
 	h.downloader.UnregisterPeer(id)
 	h.txFetcher.Drop(id)
+	h.chainSplits.disconnected(id)
 
 	if err := h.peers.unregisterPeer(id); err != nil {
 		logger.Error("Ethereum peer removal failed", "err", err)
@@ -614,6 +640,10 @@ func (h *handler) Start(maxPeers int) {
 	// start peer handler tracker
 	h.wg.Add(1)
 	go h.protoTracker()
+
+	// start chain split monitor
+	h.wg.Add(1)
+	go h.chainSplitLoop()
 }
 
 func (h *handler) Stop() {
@@ -772,3 +802,24 @@ func (h *handler) enableSyncedFeatures() {
 		h.chain.TrieDB().SetBufferSize(pathdb.DefaultBufferSize)
 	}
 }
+
+// highestBlock returns the number of the highest block known to be available
+// from the network: the head of the peer with the highest total difficulty, or
+// the target of the chain sync if that is higher. The best peer's head number
+// is looked up locally if the peer did not announce it yet.
+func (h *handler) highestBlock() uint64 {
+	highest := h.downloader.Progress().HighestBlock
+	if peer := h.peers.peerWithHighestTD(); peer != nil {
+		number := peer.HeadNumber()
+		if number == 0 {
+			hash, _, _ := peer.Head()
+			if header := h.chain.GetHeaderByHash(hash); header != nil {
+				number = header.Number.Uint64()
+			}
+		}
+		if number > highest {
+			highest = number
+		}
+	}
+	return highest
+}
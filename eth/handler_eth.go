@@ -127,12 +127,16 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td
 	// Assuming the block is importable by the peer, but possibly not yet done so,
 	// calculate the head hash and TD that the peer truly must have.
 	var (
-		trueHead = block.ParentHash()
-		trueTD   = new(big.Int).Sub(td, block.Difficulty())
+		trueHead   = block.ParentHash()
+		trueNumber uint64
+		trueTD     = new(big.Int).Sub(td, block.Difficulty())
 	)
+	if number := block.NumberU64(); number > 0 {
+		trueNumber = number - 1
+	}
 	// Update the peer's total difficulty if better than the previous
 	if _, td, _ := peer.Head(); trueTD.Cmp(td) > 0 {
-		peer.SetHead(trueHead, trueTD, block.Difficulty())
+		peer.SetHead(trueHead, trueNumber, trueTD, block.Difficulty())
 		h.chainSync.handlePeerEvent()
 	}
 	return nil
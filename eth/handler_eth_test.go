@@ -744,3 +744,48 @@ func testBroadcastMalformedBlock(t *testing.T, protocol uint) {
 		}
 	}
 }
+
+// Tests that the highest block follows the head of the best peer, whether it is
+// known locally or only announced.
+func TestHighestBlock(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandlerWithBlocks(8)
+	defer handler.close()
+
+	p2pSrc, p2pSink := p2p.MsgPipe()
+	defer p2pSrc.Close()
+	defer p2pSink.Close()
+
+	peer := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{1}, "", nil, p2pSrc), p2pSrc, handler.txpool)
+	remote := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{2}, "", nil, p2pSink), p2pSink, handler.txpool)
+	defer peer.Close()
+	defer remote.Close()
+
+	// Let the remote peer advertise a locally known head, without a number
+	var (
+		genesis = handler.chain.Genesis()
+		known   = handler.chain.GetBlockByNumber(5)
+		td      = handler.chain.GetTd(known.Hash(), known.NumberU64())
+		errc    = make(chan error, 1)
+	)
+	go func() {
+		errc <- remote.Handshake(1, td, known.Hash(), genesis.Hash(), forkid.NewIDWithChain(handler.chain), forkid.NewFilter(handler.chain))
+	}()
+	if err := peer.Handshake(1, td, known.Hash(), genesis.Hash(), forkid.NewIDWithChain(handler.chain), forkid.NewFilter(handler.chain)); err != nil {
+		t.Fatalf("failed to run protocol handshake: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to run remote protocol handshake: %v", err)
+	}
+	if err := handler.handler.peers.registerPeer(peer, nil); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if have := handler.handler.highestBlock(); have != 5 {
+		t.Errorf("highest block mismatch for known head: have %d, want %d", have, 5)
+	}
+	peer.SetHead(common.Hash{0x01}, 100, new(big.Int).Add(td, common.Big1), common.Big1)
+	if have := handler.handler.highestBlock(); have != 100 {
+		t.Errorf("highest block mismatch for announced head: have %d, want %d", have, 100)
+	}
+}
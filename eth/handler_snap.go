@@ -17,8 +17,11 @@
 package eth
 
 import (
+	"errors"
+
 	"github.com/shudolab/core-geth/core"
 	"github.com/shudolab/core-geth/eth/protocols/snap"
+	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/p2p/enode"
 )
 
@@ -46,5 +49,14 @@ func (h *snapHandler) PeerInfo(id enode.ID) interface{} {
 // Handle is invoked from a peer's message handler when it receives a new remote
 // message that the handler couldn't consume and serve itself.
 func (h *snapHandler) Handle(peer *snap.Peer, packet snap.Packet) error {
-	return h.downloader.DeliverSnapPacket(peer, packet)
+	if err := h.downloader.DeliverSnapPacket(peer, packet); err != nil {
+		// Only responses failing verification are the peer's fault
+		offense := p2p.OffenseNone
+		if errors.Is(err, snap.ErrInvalidResponse) {
+			offense = p2p.OffenseInvalidData
+		}
+		peer.Report(offense, err.Error())
+		return err
+	}
+	return nil
 }
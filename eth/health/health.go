@@ -0,0 +1,290 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package health implements the /health and /ready HTTP endpoints, reporting
+// whether the node is fit to serve traffic behind a load balancer.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/eth"
+	"github.com/shudolab/core-geth/event"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/node"
+	"github.com/shudolab/core-geth/p2p"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+const chainHeadChanSize = 10
+
+// Config contains the thresholds of the health and readiness checks. Zero
+// values disable the corresponding check.
+type Config struct {
+	Enabled bool
+
+	MaxHeadAge      time.Duration // Maximum age of the head block for the node to be ready
+	MaxBlockLag     uint64        // Maximum number of blocks behind the highest known block for the node to be ready
+	MinPeers        int           // Minimum number of connected peers for the node to be healthy
+	RequireSynced   bool          // Whether the initial chain sync must be complete for the node to be ready
+	RequireECBP1100 bool          // Whether ECBP-1100 must be enabled wherever the chain configuration activates it for the node to be ready
+	MaxReorgs       int           // Maximum number of reorgs within ReorgWindow before the node is considered unhealthy
+	ReorgWindow     time.Duration // Time span over which reorgs are counted
+}
+
+// DefaultConfig contains the default health check thresholds.
+var DefaultConfig = Config{
+	MaxHeadAge:    2 * time.Minute,
+	MaxBlockLag:   16,
+	MinPeers:      1,
+	RequireSynced: true,
+	ReorgWindow:   10 * time.Minute,
+}
+
+// Check is the outcome of a single health or readiness check.
+type Check struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// Head describes the current head block.
+type Head struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Age    uint64      `json:"age"` // seconds since the block timestamp
+}
+
+// ECBP1100 describes the state of the ECBP-1100 (MESS) artificial finality.
+type ECBP1100 struct {
+	Active  bool `json:"active"`  // whether the chain configuration activates ECBP-1100 at the head
+	Enabled bool `json:"enabled"` // whether the node currently enforces it
+}
+
+// Status is the JSON document served by the health and readiness endpoints.
+type Status struct {
+	OK           bool     `json:"ok"`
+	Checks       []Check  `json:"checks"`
+	Head         Head     `json:"head"`
+	HighestBlock uint64   `json:"highestBlock"`
+	Synced       bool     `json:"synced"`
+	Peers        int      `json:"peers"`
+	Reorgs       int      `json:"reorgs"` // number of reorgs within the reorg window
+	ECBP1100     ECBP1100 `json:"ecbp1100"`
+}
+
+// backend is the node state inspected by the checks.
+type backend interface {
+	CurrentHeader() *types.Header
+	GetCanonicalHash(number uint64) common.Hash
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	Config() ctypes.ChainConfigurator
+	IsArtificialFinalityEnabled() bool
+	HighestBlock() uint64
+	Synced() bool
+	PeerCount() int
+}
+
+// ethBackend adapts the Ethereum service and the p2p server to backend.
+type ethBackend struct {
+	*core.BlockChain
+	eth    *eth.Ethereum
+	server *p2p.Server
+}
+
+func (b *ethBackend) HighestBlock() uint64 { return b.eth.HighestBlock() }
+func (b *ethBackend) Synced() bool         { return b.eth.Synced() }
+func (b *ethBackend) PeerCount() int       { return b.server.PeerCount() }
+
+// Service tracks chain reorgs and serves the health and readiness endpoints.
+type Service struct {
+	config  Config
+	backend backend
+
+	lock   sync.Mutex
+	reorgs []time.Time // times of the reorgs within the reorg window
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Register adds the /health and /ready endpoints to the node's HTTP server.
+func Register(stack *node.Node, backend *eth.Ethereum, config Config) *Service {
+	s := newService(&ethBackend{backend.BlockChain(), backend, stack.Server()}, config)
+	stack.RegisterHandler("Health check", "/health", s.Handler(false))
+	stack.RegisterHandler("Readiness check", "/ready", s.Handler(true))
+	stack.RegisterLifecycle(s)
+	return s
+}
+
+func newService(backend backend, config Config) *Service {
+	return &Service{
+		config:  config,
+		backend: backend,
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start implements node.Lifecycle, starting the reorg tracking.
+func (s *Service) Start() error {
+	s.wg.Add(1)
+	go s.loop()
+	log.Info("Started health checks", "maxheadage", s.config.MaxHeadAge, "maxblocklag", s.config.MaxBlockLag,
+		"minpeers", s.config.MinPeers, "synced", s.config.RequireSynced, "ecbp1100", s.config.RequireECBP1100,
+		"maxreorgs", s.config.MaxReorgs, "reorgwindow", s.config.ReorgWindow)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the reorg tracking.
+func (s *Service) Stop() error {
+	close(s.quit)
+	s.wg.Wait()
+	return nil
+}
+
+// loop counts every new head whose predecessor got dropped from the canonical
+// chain as a reorg.
+func (s *Service) loop() {
+	defer s.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := s.backend.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	prev := s.backend.CurrentHeader()
+	for {
+		select {
+		case ev := <-heads:
+			if prev != nil && s.backend.GetCanonicalHash(prev.Number.Uint64()) != prev.Hash() {
+				s.lock.Lock()
+				s.reorgs = append(s.reorgs, time.Now())
+				s.lock.Unlock()
+			}
+			prev = ev.Block.Header()
+		case <-sub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// recentReorgs returns the number of reorgs within the reorg window, dropping
+// the older ones.
+func (s *Service) recentReorgs() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	cutoff := time.Now().Add(-s.config.ReorgWindow)
+	for len(s.reorgs) > 0 && s.reorgs[0].Before(cutoff) {
+		s.reorgs = s.reorgs[1:]
+	}
+	return len(s.reorgs)
+}
+
+// Status evaluates the health checks, and the readiness checks too if ready
+// is set.
+func (s *Service) Status(ready bool) *Status {
+	var (
+		head   = s.backend.CurrentHeader()
+		config = s.backend.Config()
+		status = &Status{
+			OK:     true,
+			Checks: []Check{},
+			Head: Head{
+				Number: head.Number.Uint64(),
+				Hash:   head.Hash(),
+			},
+			HighestBlock: s.backend.HighestBlock(),
+			Synced:       s.backend.Synced(),
+			Peers:        s.backend.PeerCount(),
+			Reorgs:       s.recentReorgs(),
+			ECBP1100: ECBP1100{
+				Active:  config.IsEnabled(config.GetECBP1100Transition, head.Number),
+				Enabled: s.backend.IsArtificialFinalityEnabled(),
+			},
+		}
+	)
+	if now := uint64(time.Now().Unix()); now > head.Time {
+		status.Head.Age = now - head.Time
+	}
+	check := func(name string, ok bool, format string, args ...interface{}) {
+		c := Check{Name: name, OK: ok}
+		if !ok {
+			c.Message = fmt.Sprintf(format, args...)
+			status.OK = false
+		}
+		status.Checks = append(status.Checks, c)
+	}
+	if s.config.MinPeers > 0 {
+		check("peers", status.Peers >= s.config.MinPeers, "%d peers connected, need %d", status.Peers, s.config.MinPeers)
+	}
+	if s.config.MaxReorgs > 0 {
+		check("reorgs", status.Reorgs <= s.config.MaxReorgs, "%d reorgs in the last %v, allowed %d", status.Reorgs, s.config.ReorgWindow, s.config.MaxReorgs)
+	}
+	if !ready {
+		return status
+	}
+	if s.config.RequireSynced {
+		check("synced", status.Synced, "initial chain sync in progress")
+	}
+	if s.config.MaxHeadAge > 0 {
+		age := time.Duration(status.Head.Age) * time.Second
+		check("headAge", age <= s.config.MaxHeadAge, "head block is %v old, allowed %v", age, s.config.MaxHeadAge)
+	}
+	if s.config.MaxBlockLag > 0 {
+		var lag uint64
+		if status.HighestBlock > status.Head.Number {
+			lag = status.HighestBlock - status.Head.Number
+		}
+		check("blockLag", lag <= s.config.MaxBlockLag, "head is %d blocks behind the highest known block, allowed %d", lag, s.config.MaxBlockLag)
+	}
+	if s.config.RequireECBP1100 {
+		check("ecbp1100", !status.ECBP1100.Active || status.ECBP1100.Enabled, "ECBP-1100 is active but not enforced")
+	}
+	return status
+}
+
+// Handler returns the HTTP handler of the health endpoint, or of the readiness
+// endpoint if ready is set. It responds with the JSON encoded status, and with
+// 503 Service Unavailable if any check fails.
+func (s *Service) Handler(ready bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status := s.Status(ready)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !status.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(status)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package health
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/event"
+	"github.com/shudolab/core-geth/params/types/coregeth"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+)
+
+type testBackend struct {
+	lock      sync.Mutex
+	head      *types.Header
+	canonical map[uint64]common.Hash
+	config    *coregeth.CoreGethChainConfig
+	af        bool
+	highest   uint64
+	synced    bool
+	peers     int
+	feed      event.Feed
+}
+
+func newTestBackend() *testBackend {
+	b := &testBackend{
+		canonical: make(map[uint64]common.Hash),
+		config:    &coregeth.CoreGethChainConfig{},
+		synced:    true,
+		peers:     5,
+	}
+	b.setHead(&types.Header{Number: big.NewInt(100), Time: uint64(time.Now().Unix())})
+	return b
+}
+
+func (b *testBackend) setHead(head *types.Header) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.head = head
+	b.canonical[head.Number.Uint64()] = head.Hash()
+	if b.highest < head.Number.Uint64() {
+		b.highest = head.Number.Uint64()
+	}
+}
+
+func (b *testBackend) CurrentHeader() *types.Header {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.head
+}
+
+func (b *testBackend) GetCanonicalHash(number uint64) common.Hash {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.canonical[number]
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+func (b *testBackend) Config() ctypes.ChainConfigurator  { return b.config }
+func (b *testBackend) IsArtificialFinalityEnabled() bool { return b.af }
+func (b *testBackend) HighestBlock() uint64              { return b.highest }
+func (b *testBackend) Synced() bool                      { return b.synced }
+func (b *testBackend) PeerCount() int                    { return b.peers }
+
+// failing returns the names of the failing checks.
+func failing(status *Status) []string {
+	var names []string
+	for _, c := range status.Checks {
+		if !c.OK {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+func TestChecks(t *testing.T) {
+	config := DefaultConfig
+	config.RequireECBP1100 = true
+
+	tests := []struct {
+		name   string
+		modify func(b *testBackend)
+		health []string
+		ready  []string
+	}{
+		{
+			name:   "healthy",
+			modify: func(b *testBackend) {},
+		},
+		{
+			name:   "no peers",
+			modify: func(b *testBackend) { b.peers = 0 },
+			health: []string{"peers"},
+			ready:  []string{"peers"},
+		},
+		{
+			name:   "syncing",
+			modify: func(b *testBackend) { b.synced = false },
+			ready:  []string{"synced"},
+		},
+		{
+			name: "stale head",
+			modify: func(b *testBackend) {
+				b.setHead(&types.Header{Number: big.NewInt(100), Time: uint64(time.Now().Add(-time.Hour).Unix())})
+			},
+			ready: []string{"headAge"},
+		},
+		{
+			name:   "lagging",
+			modify: func(b *testBackend) { b.highest = 117 },
+			ready:  []string{"blockLag"},
+		},
+		{
+			name:   "lag within limit",
+			modify: func(b *testBackend) { b.highest = 116 },
+		},
+		{
+			name: "ecbp1100 not enforced",
+			modify: func(b *testBackend) {
+				activation := uint64(50)
+				b.config.SetECBP1100Transition(&activation)
+			},
+			ready: []string{"ecbp1100"},
+		},
+		{
+			name: "ecbp1100 enforced",
+			modify: func(b *testBackend) {
+				activation := uint64(50)
+				b.config.SetECBP1100Transition(&activation)
+				b.af = true
+			},
+		},
+		{
+			name: "ecbp1100 deactivated",
+			modify: func(b *testBackend) {
+				activation, deactivation := uint64(50), uint64(80)
+				b.config.SetECBP1100Transition(&activation)
+				b.config.SetECBP1100DeactivateTransition(&deactivation)
+			},
+		},
+	}
+	for _, tt := range tests {
+		b := newTestBackend()
+		tt.modify(b)
+		s := newService(b, config)
+
+		for _, ready := range []bool{false, true} {
+			want := tt.health
+			if ready {
+				want = tt.ready
+			}
+			rec := httptest.NewRecorder()
+			s.Handler(ready).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var status Status
+			if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+				t.Fatalf("%s: failed to decode status: %v", tt.name, err)
+			}
+			if have := failing(&status); len(have) != len(want) || (len(want) > 0 && have[0] != want[0]) {
+				t.Errorf("%s (ready %t): failing checks mismatch: have %v, want %v", tt.name, ready, have, want)
+			}
+			wantCode := http.StatusOK
+			if len(want) > 0 {
+				wantCode = http.StatusServiceUnavailable
+			}
+			if rec.Code != wantCode {
+				t.Errorf("%s (ready %t): status code mismatch: have %d, want %d", tt.name, ready, rec.Code, wantCode)
+			}
+			if status.OK != (len(want) == 0) {
+				t.Errorf("%s (ready %t): ok flag mismatch: have %t", tt.name, ready, status.OK)
+			}
+		}
+	}
+}
+
+func TestReorgStorm(t *testing.T) {
+	config := DefaultConfig
+	config.MaxReorgs = 1
+
+	b := newTestBackend()
+	s := newService(b, config)
+	s.Start()
+	defer s.Stop()
+
+	// Wait for the subscription before emitting heads.
+	for b.feed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(b.CurrentHeader())}) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var (
+		parent = b.CurrentHeader()
+		now    = uint64(time.Now().Unix())
+	)
+	extend := func(extra byte) {
+		head := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number, common.Big1), Time: now, Extra: []byte{extra}}
+		b.setHead(head)
+		b.feed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(head)})
+	}
+	waitReorgs := func(want int) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); s.recentReorgs() != want; {
+			if time.Now().After(deadline) {
+				t.Fatalf("reorg count mismatch: have %d, want %d", s.recentReorgs(), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	// Replace the head with a sibling twice, each replacement being a reorg.
+	extend(0)
+	extend(1)
+	waitReorgs(1)
+	if status := s.Status(false); !status.OK {
+		t.Fatalf("node unhealthy after a single reorg: %v", failing(status))
+	}
+	extend(2)
+	waitReorgs(2)
+	if status := s.Status(false); status.OK {
+		t.Fatal("node healthy during a reorg storm")
+	}
+}
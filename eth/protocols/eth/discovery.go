@@ -64,3 +64,17 @@ func currentENREntry(chain *core.BlockChain) *enrEntry {
 		ForkID: forkid.NewID(chain.Config(), chain.Genesis(), head.Number.Uint64(), head.Time),
 	}
 }
+
+// NewNodeFilter creates a discovery filter rejecting nodes whose `eth` ENR entry
+// advertises a fork ID incompatible with the local chain. Nodes without an `eth`
+// entry are accepted, the fork ID is validated during the handshake anyway.
+func NewNodeFilter(chain *core.BlockChain) func(*enode.Node) bool {
+	filter := forkid.NewFilter(chain)
+	return func(n *enode.Node) bool {
+		var entry enrEntry
+		if err := n.Load(&entry); err != nil {
+			return true
+		}
+		return filter(entry.ForkID) == nil
+	}
+}
@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -158,6 +159,9 @@ func Handle(backend Backend, peer *Peer) error {
 	for {
 		if err := handleMessage(backend, peer); err != nil {
 			peer.Log().Debug("Message handling failed in `eth`", "err", err)
+			if errors.Is(err, errDecode) || errors.Is(err, errMsgTooLarge) || errors.Is(err, errInvalidMsgCode) {
+				peer.Report(p2p.OffenseInvalidMessage, err.Error())
+			}
 			return err
 		}
 	}
@@ -201,14 +205,8 @@ func handleMessage(backend Backend, peer *Peer) error {
 
 	// Track the amount of time it takes to serve the request and run the handler
 	if metrics.Enabled {
-		h := fmt.Sprintf("%s/%s/%d/%#02x", p2p.HandleHistName, ProtocolName, peer.Version(), msg.Code)
 		defer func(start time.Time) {
-			sampler := func() metrics.Sample {
-				return metrics.ResettingSample(
-					metrics.NewExpDecaySample(1028, 0.015),
-				)
-			}
-			metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(time.Since(start).Microseconds())
+			p2p.UpdateHandleTime(ProtocolName, peer.Version(), msg.Code, time.Since(start))
 		}(time.Now())
 	}
 	if handler := handlers[msg.Code]; handler != nil {
@@ -62,6 +62,11 @@ func (p *Peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		case err := <-errc:
 			if err != nil {
 				markError(p, err)
+				if errors.Is(err, ErrForkIDRejected) {
+					// Retain the status of peers on other forks for chain split monitoring,
+					// the status was fully read by the failing goroutine.
+					p.td, p.head, p.forkid = status.TD, status.Head, status.ForkID
+				}
 				return err
 			}
 		case <-timeout.C:
@@ -105,7 +110,7 @@ func (p *Peer) readStatus(network uint64, status *StatusPacket, genesis common.H
 		return fmt.Errorf("%w: %x (!= %x)", errGenesisMismatch, status.Genesis, genesis)
 	}
 	if err := forkFilter(status.ForkID); err != nil {
-		return fmt.Errorf("%w: %v", errForkIDRejected, err)
+		return fmt.Errorf("%w: %v", ErrForkIDRejected, err)
 	}
 	return nil
 }
@@ -123,7 +128,7 @@ func markError(p *Peer, err error) {
 		m.protocolVersionMismatch.Mark(1)
 	case errGenesisMismatch:
 		m.genesisMismatch.Mark(1)
-	case errForkIDRejected:
+	case ErrForkIDRejected:
 		m.forkidRejected.Mark(1)
 	case p2p.DiscReadTimeout:
 		m.timeoutError.Mark(1)
@@ -65,7 +65,7 @@ func testHandshake(t *testing.T, protocol uint) {
 		},
 		{
 			code: StatusMsg, data: StatusPacket{uint32(protocol), 1, td, head.Hash(), genesis.Hash(), forkid.ID{Hash: [4]byte{0x00, 0x01, 0x02, 0x03}}},
-			want: errForkIDRejected,
+			want: ErrForkIDRejected,
 		},
 	}
 	for i, test := range tests {
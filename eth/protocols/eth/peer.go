@@ -74,6 +74,7 @@ type Peer struct {
 	version   uint              // Protocol version negotiated
 
 	head            common.Hash // Latest advertised head block hash
+	number          uint64      // Latest advertised head block number, zero if unknown
 	td              *big.Int    // Latest advertised head block total difficulty
 	forkid          forkid.ID   // Advertised forkid at time of handshake
 	blockDifficulty *big.Int    // Latest advertised head block difficulty
@@ -151,12 +152,23 @@ func (p *Peer) Head() (hash common.Hash, td, blockDifficulty *big.Int) {
 	return hash, new(big.Int).Set(p.td), new(big.Int).Set(p.blockDifficulty)
 }
 
-// SetHead updates the head hash and total difficulty of the peer.
-func (p *Peer) SetHead(hash common.Hash, td, blockDifficulty *big.Int) {
+// HeadNumber retrieves the current head block number of the peer. It is zero
+// until the peer announces a block or gets synced with, as the handshake only
+// carries the head hash.
+func (p *Peer) HeadNumber() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.number
+}
+
+// SetHead updates the head hash, number and total difficulty of the peer.
+func (p *Peer) SetHead(hash common.Hash, number uint64, td, blockDifficulty *big.Int) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	copy(p.head[:], hash[:])
+	p.number = number
 	p.td.Set(td)
 	p.blockDifficulty.Set(blockDifficulty)
 }
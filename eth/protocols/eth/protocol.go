@@ -75,7 +75,7 @@ var (
 	errProtocolVersionMismatch = errors.New("protocol version mismatch")
 	errNetworkIDMismatch       = errors.New("network ID mismatch")
 	errGenesisMismatch         = errors.New("genesis mismatch")
-	errForkIDRejected          = errors.New("fork ID rejected")
+	ErrForkIDRejected          = errors.New("fork ID rejected")
 )
 
 // Packet represents a p2p message in the `eth` protocol.
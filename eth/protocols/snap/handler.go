@@ -18,6 +18,7 @@ package snap
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"time"
 
@@ -121,6 +122,9 @@ func Handle(backend Backend, peer *Peer) error {
 	for {
 		if err := HandleMessage(backend, peer); err != nil {
 			peer.Log().Debug("Message handling failed in `snap`", "err", err)
+			if errors.Is(err, errDecode) || errors.Is(err, errMsgTooLarge) || errors.Is(err, errInvalidMsgCode) || errors.Is(err, errBadRequest) {
+				peer.Report(p2p.OffenseInvalidMessage, err.Error())
+			}
 			return err
 		}
 	}
@@ -142,14 +146,8 @@ func HandleMessage(backend Backend, peer *Peer) error {
 	start := time.Now()
 	// Track the amount of time it takes to serve the request and run the handler
 	if metrics.Enabled {
-		h := fmt.Sprintf("%s/%s/%d/%#02x", p2p.HandleHistName, ProtocolName, peer.Version(), msg.Code)
 		defer func(start time.Time) {
-			sampler := func() metrics.Sample {
-				return metrics.ResettingSample(
-					metrics.NewExpDecaySample(1028, 0.015),
-				)
-			}
-			metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(time.Since(start).Microseconds())
+			p2p.UpdateHandleTime(ProtocolName, peer.Version(), msg.Code, time.Since(start))
 		}(start)
 	}
 	// Handle the message depending on its contents
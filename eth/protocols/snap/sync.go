@@ -113,6 +113,10 @@ var (
 // terminated.
 var ErrCancelled = errors.New("sync cancelled")
 
+// ErrInvalidResponse is returned when a delivered response fails verification
+// against the request it answers.
+var ErrInvalidResponse = errors.New("invalid response")
+
 // accountRequest tracks a pending account range request to ensure responses are
 // to actual requests and to validate any security constraints.
 //
@@ -2556,7 +2560,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 		logger.Warn("Account range failed proof", "err", err)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertAccountRequest(req)
-		return err
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
 	}
 	accs := make([]*types.StateAccount, len(accounts))
 	for i, account := range accounts {
@@ -2675,7 +2679,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 		logger.Warn("Unexpected bytecodes", "count", len(bytecodes)-i)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeRequest(req)
-		return errors.New("unexpected bytecode")
+		return fmt.Errorf("%w: unexpected bytecode", ErrInvalidResponse)
 	}
 	// Response validated, send it to the scheduler for filling
 	response := &bytecodeResponse{
@@ -2756,13 +2760,13 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		logger.Warn("Hash and slot set size mismatch", "hashset", len(hashes), "slotset", len(slots))
-		return errors.New("hash and slot set size mismatch")
+		return fmt.Errorf("%w: hash and slot set size mismatch", ErrInvalidResponse)
 	}
 	if len(hashes) > len(req.accounts) {
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		logger.Warn("Hash set larger than requested", "hashset", len(hashes), "requested", len(req.accounts))
-		return errors.New("hash set larger than requested")
+		return fmt.Errorf("%w: hash set larger than requested", ErrInvalidResponse)
 	}
 	// Response is valid, but check if peer is signalling that it does not have
 	// the requested data. For storage range queries that means the state being
@@ -2807,7 +2811,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 			if err != nil {
 				s.scheduleRevertStorageRequest(req) // reschedule request
 				logger.Warn("Storage slots failed proof", "err", err)
-				return err
+				return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
 			}
 		} else {
 			// A proof was attached, the response is only partial, check that the
@@ -2818,7 +2822,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 			if err != nil {
 				s.scheduleRevertStorageRequest(req) // reschedule request
 				logger.Warn("Storage range failed proof", "err", err)
-				return err
+				return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
 			}
 		}
 	}
@@ -2926,7 +2930,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertTrienodeHealRequest(req)
-		return errors.New("unexpected healing trienode")
+		return fmt.Errorf("%w: unexpected healing trienode", ErrInvalidResponse)
 	}
 	// Response validated, send it to the scheduler for filling
 	s.trienodeHealPend.Add(fills)
@@ -3029,7 +3033,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 		logger.Warn("Unexpected healing bytecodes", "count", len(bytecodes)-i)
 		// Signal this request as failed, and ready for rescheduling
 		s.scheduleRevertBytecodeHealRequest(req)
-		return errors.New("unexpected healing bytecode")
+		return fmt.Errorf("%w: unexpected healing bytecode", ErrInvalidResponse)
 	}
 	// Response validated, send it to the scheduler for filling
 	response := &bytecodeHealResponse{
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
 	mrand "math/rand"
@@ -490,6 +491,9 @@ func corruptCodeRequestHandler(t *testPeer, id uint64, hashes []common.Hash, max
 	}
 	if err := t.remote.OnByteCodes(t, id, bytecodes); err != nil {
 		t.logger.Info("remote error on delivery (as expected)", "error", err)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.test.Errorf("delivery error mismatch: have %v, want %v", err, ErrInvalidResponse)
+		}
 		// Mimic the real-life handler, which drops a peer on errors
 		t.remote.Unregister(t.id)
 	}
@@ -529,6 +533,9 @@ func corruptAccountRequestHandler(t *testPeer, requestId uint64, root common.Has
 	}
 	if err := t.remote.OnAccounts(t, requestId, hashes, accounts, proofs); err != nil {
 		t.logger.Info("remote error on delivery (as expected)", "error", err)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.test.Errorf("delivery error mismatch: have %v, want %v", err, ErrInvalidResponse)
+		}
 		// Mimic the real-life handler, which drops a peer on errors
 		t.remote.Unregister(t.id)
 	}
@@ -543,6 +550,9 @@ func corruptStorageRequestHandler(t *testPeer, requestId uint64, root common.Has
 	}
 	if err := t.remote.OnStorage(t, requestId, hashes, slots, proofs); err != nil {
 		t.logger.Info("remote error on delivery (as expected)", "error", err)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.test.Errorf("delivery error mismatch: have %v, want %v", err, ErrInvalidResponse)
+		}
 		// Mimic the real-life handler, which drops a peer on errors
 		t.remote.Unregister(t.id)
 	}
@@ -553,6 +563,9 @@ func noProofStorageRequestHandler(t *testPeer, requestId uint64, root common.Has
 	hashes, slots, _ := createStorageRequestResponse(t, root, accounts, origin, limit, max)
 	if err := t.remote.OnStorage(t, requestId, hashes, slots, nil); err != nil {
 		t.logger.Info("remote error on delivery (as expected)", "error", err)
+		if !errors.Is(err, ErrInvalidResponse) {
+			t.test.Errorf("delivery error mismatch: have %v, want %v", err, ErrInvalidResponse)
+		}
 		// Mimic the real-life handler, which drops a peer on errors
 		t.remote.Unregister(t.id)
 	}
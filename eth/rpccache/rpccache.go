@@ -0,0 +1,320 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package rpccache implements a response cache for RPC methods whose results
+// never change once the data they refer to is final.
+package rpccache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/common/lru"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/metrics"
+	"github.com/shudolab/core-geth/rpc"
+)
+
+var (
+	hitMeter         = metrics.NewRegisteredMeter("rpc/cache/hit", nil)
+	missMeter        = metrics.NewRegisteredMeter("rpc/cache/miss", nil)
+	invalidatedMeter = metrics.NewRegisteredMeter("rpc/cache/invalidated", nil)
+	sizeGauge        = metrics.NewRegisteredGauge("rpc/cache/size", nil)
+)
+
+// Chain is the part of the blockchain the cache needs to decide whether a
+// result is final.
+type Chain interface {
+	CurrentHeader() *types.Header
+	CurrentFinalBlock() *types.Header
+	ArtificialFinalityHeader() *types.Header
+	GetCanonicalHash(number uint64) common.Hash
+	GetHeaderByHash(hash common.Hash) *types.Header
+	GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLookupEntry, *types.Transaction, error)
+}
+
+// anchor identifies the block an immutable result is derived from.
+type anchor struct {
+	number uint64
+	hash   common.Hash
+}
+
+// anchorFunc resolves the block a call result belongs to. It returns false if
+// the result can't be cached, e.g. because it refers to a block tag.
+type anchorFunc func(c *Cache, params, result json.RawMessage) (anchor, bool)
+
+// immutableMethods lists the methods whose results are cached, along with the
+// way to find the block each result is anchored to.
+var immutableMethods = map[string]anchorFunc{
+	"eth_getBlockByNumber":      blockNumberParamAnchor,
+	"eth_getBlockByHash":        resultBlockAnchor,
+	"eth_getTransactionByHash":  resultTxAnchor,
+	"eth_getTransactionReceipt": resultTxAnchor,
+	"eth_getBlockReceipts":      blockParamAnchor,
+	"debug_traceTransaction":    txParamAnchor,
+	"debug_traceBlockByNumber":  blockParamAnchor,
+	"debug_traceBlockByHash":    blockParamAnchor,
+}
+
+var _ rpc.ResponseCache = (*Cache)(nil)
+
+type entry struct {
+	anchor
+	result json.RawMessage
+}
+
+// Cache is a size-bounded, reorg-safe rpc.ResponseCache. Results are only
+// stored once the block they are anchored to is final, i.e. buried at least
+// depth blocks below the head, finalized by the forkchoice or protected by
+// ECBP-1100 artificial finality. Entries are checked against the canonical
+// chain on every lookup, so results of blocks which are reorged out are never
+// served.
+type Cache struct {
+	chain   Chain
+	depth   uint64
+	maxSize int
+
+	mu    sync.Mutex
+	lru   lru.BasicLRU[string, *entry]
+	size  int
+	final struct {
+		head   common.Hash
+		number uint64
+	}
+}
+
+// New creates a cache holding up to maxSize bytes of responses. Blocks at least
+// depth blocks below the current head are considered final.
+func New(chain Chain, maxSize int, depth uint64) *Cache {
+	return &Cache{
+		chain:   chain,
+		depth:   depth,
+		maxSize: maxSize,
+		lru:     lru.NewBasicLRU[string, *entry](math.MaxInt),
+	}
+}
+
+// Get implements rpc.ResponseCache.
+func (c *Cache) Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool) {
+	if _, ok := immutableMethods[method]; !ok {
+		return nil, false
+	}
+	key := cacheKey(method, params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.lru.Get(key)
+	if !ok {
+		missMeter.Mark(1)
+		return nil, false
+	}
+	if c.chain.GetCanonicalHash(e.number) != e.hash {
+		c.remove(key, e)
+		invalidatedMeter.Mark(1)
+		missMeter.Mark(1)
+		return nil, false
+	}
+	hitMeter.Mark(1)
+	return e.result, true
+}
+
+// Put implements rpc.ResponseCache.
+func (c *Cache) Put(ctx context.Context, method string, params, result json.RawMessage) {
+	resolve, ok := immutableMethods[method]
+	if !ok {
+		return
+	}
+	key := cacheKey(method, params)
+	size := len(key) + len(result)
+	if size > c.maxSize {
+		return
+	}
+	a, ok := resolve(c, params, result)
+	if !ok || a.number > c.finalNumber() || c.chain.GetCanonicalHash(a.number) != a.hash {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.lru.Peek(key); ok {
+		c.remove(key, old)
+	}
+	for c.size+size > c.maxSize {
+		k, e, ok := c.lru.GetOldest()
+		if !ok {
+			break
+		}
+		c.remove(k, e)
+	}
+	c.lru.Add(key, &entry{anchor: a, result: bytes.Clone(result)})
+	c.size += size
+	sizeGauge.Update(int64(c.size))
+}
+
+// remove drops an entry from the cache. The lock must be held.
+func (c *Cache) remove(key string, e *entry) {
+	c.lru.Remove(key)
+	c.size -= len(key) + len(e.result)
+	sizeGauge.Update(int64(c.size))
+}
+
+// finalNumber returns the number of the newest block which is considered
+// final. The result is memoized per chain head.
+func (c *Cache) finalNumber() uint64 {
+	head := c.chain.CurrentHeader()
+	if head == nil {
+		return 0
+	}
+	hash := head.Hash()
+
+	c.mu.Lock()
+	if c.final.head == hash {
+		defer c.mu.Unlock()
+		return c.final.number
+	}
+	c.mu.Unlock()
+
+	var final uint64
+	if n := head.Number.Uint64(); n > c.depth {
+		final = n - c.depth
+	}
+	if h := c.chain.CurrentFinalBlock(); h != nil && h.Number.Uint64() > final {
+		final = h.Number.Uint64()
+	}
+	if h := c.chain.ArtificialFinalityHeader(); h != nil && h.Number.Uint64() > final {
+		final = h.Number.Uint64()
+	}
+
+	c.mu.Lock()
+	c.final.head, c.final.number = hash, final
+	c.mu.Unlock()
+	return final
+}
+
+// cacheKey derives the cache key of a call. Parameters are compacted so
+// insignificant whitespace doesn't split entries.
+func cacheKey(method string, params json.RawMessage) string {
+	var buf bytes.Buffer
+	buf.WriteString(method)
+	buf.WriteByte(':')
+	if err := json.Compact(&buf, params); err != nil {
+		buf.Write(params)
+	}
+	return buf.String()
+}
+
+// firstParam returns the first positional parameter of a call.
+func firstParam(params json.RawMessage) (json.RawMessage, bool) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+		return nil, false
+	}
+	return args[0], true
+}
+
+// blockNumberParamAnchor resolves calls taking an explicit block number as
+// first parameter, taking the anchor hash from the returned block.
+func blockNumberParamAnchor(c *Cache, params, result json.RawMessage) (anchor, bool) {
+	arg, ok := firstParam(params)
+	if !ok {
+		return anchor{}, false
+	}
+	var number rpc.BlockNumber
+	if err := json.Unmarshal(arg, &number); err != nil || number < 0 {
+		return anchor{}, false
+	}
+	a, ok := resultBlockAnchor(c, params, result)
+	if !ok || a.number != uint64(number) {
+		return anchor{}, false
+	}
+	return a, true
+}
+
+// resultBlockAnchor resolves calls returning a block.
+func resultBlockAnchor(c *Cache, params, result json.RawMessage) (anchor, bool) {
+	var block struct {
+		Number *hexutil.Uint64 `json:"number"`
+		Hash   *common.Hash    `json:"hash"`
+	}
+	if err := json.Unmarshal(result, &block); err != nil || block.Number == nil || block.Hash == nil {
+		return anchor{}, false
+	}
+	return anchor{uint64(*block.Number), *block.Hash}, true
+}
+
+// resultTxAnchor resolves calls returning a transaction or receipt. Pending
+// transactions and unknown hashes are not cached.
+func resultTxAnchor(c *Cache, params, result json.RawMessage) (anchor, bool) {
+	var tx struct {
+		BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+		BlockHash   *common.Hash    `json:"blockHash"`
+	}
+	if err := json.Unmarshal(result, &tx); err != nil || tx.BlockNumber == nil || tx.BlockHash == nil {
+		return anchor{}, false
+	}
+	return anchor{uint64(*tx.BlockNumber), *tx.BlockHash}, true
+}
+
+// blockParamAnchor resolves calls taking a block number or hash as first
+// parameter. Block tags are not cached.
+func blockParamAnchor(c *Cache, params, result json.RawMessage) (anchor, bool) {
+	arg, ok := firstParam(params)
+	if !ok {
+		return anchor{}, false
+	}
+	var bnh rpc.BlockNumberOrHash
+	if err := json.Unmarshal(arg, &bnh); err != nil {
+		return anchor{}, false
+	}
+	if number, ok := bnh.Number(); ok {
+		if number < 0 {
+			return anchor{}, false
+		}
+		hash := c.chain.GetCanonicalHash(uint64(number))
+		return anchor{uint64(number), hash}, hash != (common.Hash{})
+	}
+	hash, _ := bnh.Hash()
+	header := c.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return anchor{}, false
+	}
+	return anchor{header.Number.Uint64(), hash}, true
+}
+
+// txParamAnchor resolves calls taking a transaction hash as first parameter.
+func txParamAnchor(c *Cache, params, result json.RawMessage) (anchor, bool) {
+	arg, ok := firstParam(params)
+	if !ok {
+		return anchor{}, false
+	}
+	var hash common.Hash
+	if err := json.Unmarshal(arg, &hash); err != nil {
+		return anchor{}, false
+	}
+	lookup, _, err := c.chain.GetTransactionLookup(hash)
+	if err != nil || lookup == nil {
+		return anchor{}, false
+	}
+	return anchor{lookup.BlockIndex, lookup.BlockHash}, true
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpccache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/types"
+)
+
+type testChain struct {
+	headers   []*types.Header
+	finalized *types.Header
+	af        *types.Header
+	txs       map[common.Hash]*rawdb.LegacyTxLookupEntry
+}
+
+func newTestChain(n int) *testChain {
+	c := &testChain{txs: make(map[common.Hash]*rawdb.LegacyTxLookupEntry)}
+	c.extend(0, n, 0)
+	return c
+}
+
+// extend replaces the chain after block from with n new blocks, salted to
+// produce distinct hashes.
+func (c *testChain) extend(from, n int, salt uint64) {
+	c.headers = c.headers[:from]
+	for i := from; i < from+n; i++ {
+		h := &types.Header{Number: big.NewInt(int64(i)), Nonce: types.EncodeNonce(salt), Difficulty: common.Big1}
+		if i > 0 {
+			h.ParentHash = c.headers[i-1].Hash()
+		}
+		c.headers = append(c.headers, h)
+	}
+}
+
+func (c *testChain) CurrentHeader() *types.Header            { return c.headers[len(c.headers)-1] }
+func (c *testChain) CurrentFinalBlock() *types.Header        { return c.finalized }
+func (c *testChain) ArtificialFinalityHeader() *types.Header { return c.af }
+
+func (c *testChain) GetCanonicalHash(number uint64) common.Hash {
+	if number >= uint64(len(c.headers)) {
+		return common.Hash{}
+	}
+	return c.headers[number].Hash()
+}
+
+func (c *testChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, h := range c.headers {
+		if h.Hash() == hash {
+			return h
+		}
+	}
+	return nil
+}
+
+func (c *testChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLookupEntry, *types.Transaction, error) {
+	return c.txs[hash], nil, nil
+}
+
+func blockResult(h *types.Header) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"number":"0x%x","hash":"%s"}`, h.Number, h.Hash().Hex()))
+}
+
+func numberParams(n uint64) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`["0x%x", false]`, n))
+}
+
+func TestCacheDepth(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(100)
+		cache = New(chain, 1<<20, 10)
+	)
+	for _, n := range []uint64{88, 89, 90} {
+		cache.Put(ctx, "eth_getBlockByNumber", numberParams(n), blockResult(chain.headers[n]))
+	}
+	for n, want := range map[uint64]bool{88: true, 89: true, 90: false} {
+		if _, ok := cache.Get(ctx, "eth_getBlockByNumber", numberParams(n)); ok != want {
+			t.Errorf("block %d: cached %v, want %v", n, ok, want)
+		}
+	}
+	// Block tags must never be cached, even if the result is final.
+	cache.Put(ctx, "eth_getBlockByNumber", json.RawMessage(`["latest", false]`), blockResult(chain.headers[50]))
+	if _, ok := cache.Get(ctx, "eth_getBlockByNumber", json.RawMessage(`["latest", false]`)); ok {
+		t.Error("result for block tag was cached")
+	}
+	// Methods not declared immutable are ignored.
+	cache.Put(ctx, "eth_blockNumber", nil, json.RawMessage(`"0x1"`))
+	if _, ok := cache.Get(ctx, "eth_blockNumber", nil); ok {
+		t.Error("result of mutable method was cached")
+	}
+}
+
+func TestCacheFinality(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(100)
+		cache = New(chain, 1<<20, 64)
+	)
+	params := json.RawMessage(`["0x50"]`)
+	cache.Put(ctx, "eth_getBlockReceipts", params, json.RawMessage(`[]`))
+	if _, ok := cache.Get(ctx, "eth_getBlockReceipts", params); ok {
+		t.Fatal("non-final result was cached")
+	}
+	// A finalized block moves the cutoff, but only once the head changes.
+	chain.finalized = chain.headers[80]
+	chain.extend(100, 1, 0)
+	cache.Put(ctx, "eth_getBlockReceipts", params, json.RawMessage(`[]`))
+	if _, ok := cache.Get(ctx, "eth_getBlockReceipts", params); !ok {
+		t.Fatal("forkchoice finalized result was not cached")
+	}
+	// So does the ECBP-1100 final header.
+	chain.af = chain.headers[90]
+	chain.extend(101, 1, 0)
+	params = json.RawMessage(fmt.Sprintf(`[{"blockHash":"%s"}]`, chain.headers[90].Hash().Hex()))
+	cache.Put(ctx, "eth_getBlockReceipts", params, json.RawMessage(`[]`))
+	if _, ok := cache.Get(ctx, "eth_getBlockReceipts", params); !ok {
+		t.Fatal("artificial finality result was not cached")
+	}
+}
+
+func TestCacheReorg(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(100)
+		cache = New(chain, 1<<20, 10)
+		tx    = common.HexToHash("0x01")
+	)
+	chain.txs[tx] = &rawdb.LegacyTxLookupEntry{BlockHash: chain.headers[50].Hash(), BlockIndex: 50}
+	params := json.RawMessage(fmt.Sprintf(`["%s"]`, tx.Hex()))
+	cache.Put(ctx, "debug_traceTransaction", params, json.RawMessage(`{}`))
+	if _, ok := cache.Get(ctx, "debug_traceTransaction", params); !ok {
+		t.Fatal("trace was not cached")
+	}
+	chain.extend(40, 70, 1)
+	if _, ok := cache.Get(ctx, "debug_traceTransaction", params); ok {
+		t.Fatal("trace of reorged block was served")
+	}
+	if cache.size != 0 || cache.lru.Len() != 0 {
+		t.Fatalf("invalidated entry was not dropped: size %d, len %d", cache.size, cache.lru.Len())
+	}
+}
+
+func TestCacheSize(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(100)
+	)
+	entrySize := len(cacheKey("eth_getBlockByNumber", numberParams(10))) + len(blockResult(chain.headers[10]))
+	cache := New(chain, 3*entrySize, 10)
+
+	for n := uint64(10); n < 15; n++ {
+		cache.Put(ctx, "eth_getBlockByNumber", numberParams(n), blockResult(chain.headers[n]))
+	}
+	if cache.size > cache.maxSize {
+		t.Fatalf("cache size %d exceeds limit %d", cache.size, cache.maxSize)
+	}
+	for n := uint64(10); n < 15; n++ {
+		_, ok := cache.Get(ctx, "eth_getBlockByNumber", numberParams(n))
+		if want := n >= 12; ok != want {
+			t.Errorf("block %d: cached %v, want %v", n, ok, want)
+		}
+	}
+	// Re-adding an entry must not account its size twice.
+	cache.Put(ctx, "eth_getBlockByNumber", numberParams(14), blockResult(chain.headers[14]))
+	if cache.size != 3*entrySize {
+		t.Fatalf("cache size %d, want %d", cache.size, 3*entrySize)
+	}
+}
@@ -39,11 +39,13 @@ import (
 	"github.com/shudolab/core-geth/eth/tracers/logger"
 	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/internal/ethapi"
+	"github.com/shudolab/core-geth/internal/tracing"
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/params/confp"
 	"github.com/shudolab/core-geth/params/types/ctypes"
 	"github.com/shudolab/core-geth/rlp"
 	"github.com/shudolab/core-geth/rpc"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -585,7 +587,13 @@ func (api *API) StandardTraceBadBlockToFile(ctx context.Context, hash common.Has
 // traceBlock configures a new tracer according to the provided configuration, and
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requested tracer.
-func (api *API) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
+func (api *API) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) (_ []*txTraceResult, err error) {
+	ctx, span := tracing.Start(ctx, "tracers.traceBlock",
+		attribute.Int64("block.number", block.Number().Int64()),
+		attribute.Int("block.txs", len(block.Transactions())),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
@@ -598,7 +606,9 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if config != nil && config.Reexec != nil {
 		reexec = *config.Reexec
 	}
+	_, sspan := tracing.Start(ctx, "tracers.stateAtBlock", attribute.Int64("block.number", parent.Number().Int64()))
 	statedb, release, err := api.backend.StateAtBlock(ctx, parent, reexec, nil, true, false)
+	tracing.End(sspan, err)
 	if err != nil {
 		return nil, err
 	}
@@ -932,7 +942,7 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 	// Apply the customization rules if required.
 	if config != nil {
-		if err := config.StateOverrides.Apply(statedb); err != nil {
+		if err := config.StateOverrides.Apply(statedb, nil); err != nil {
 			return nil, err
 		}
 		config.BlockOverrides.Apply(&vmctx)
@@ -979,7 +989,7 @@ func (api *API) TraceCallMany(ctx context.Context, txs []ethapi.TransactionArgs,
 
 	// Apply the customized state rules if required.
 	if config != nil {
-		if err := config.StateOverrides.Apply(statedb); err != nil {
+		if err := config.StateOverrides.Apply(statedb, nil); err != nil {
 			return nil, err
 		}
 	}
@@ -1015,10 +1025,15 @@ func (api *API) TraceCallMany(ctx context.Context, txs []ethapi.TransactionArgs,
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
-func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
+func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig) (_ interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "tracers.traceTx",
+		attribute.String("tx.hash", txctx.TxHash.Hex()),
+		attribute.Int("tx.index", txctx.TxIndex),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	var (
 		tracer    Tracer
-		err       error
 		timeout   = defaultTraceTimeout
 		txContext = core.NewEVMTxContext(message)
 	)
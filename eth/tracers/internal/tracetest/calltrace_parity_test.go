@@ -244,7 +244,7 @@ func stateDiffTracerTestRunner(tracerName string, filename string, dirPath strin
 	}
 	state := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false, rawdb.HashScheme)
 
-	if err := test.StateOverrides.Apply(state.StateDB); err != nil {
+	if err := test.StateOverrides.Apply(state.StateDB, nil); err != nil {
 		return fmt.Errorf("failed to apply test stateOverrides: %v", err)
 	}
 
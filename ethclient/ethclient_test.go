@@ -1145,14 +1145,18 @@ trace_traceTransaction
 var allRPCMethods = []string{
 	"admin_addPeer",
 	"admin_addTrustedPeer",
+	"admin_banPeer",
+	"admin_chainSplits",
 	"admin_datadir",
 	"admin_ecbp1100",
 	"admin_exportChain",
 	"admin_importChain",
+	"admin_listBans",
 	"admin_maxPeers",
 	"admin_nodeInfo",
 	"admin_peers",
 	"admin_peerEvents",
+	"admin_reloadRPCPolicy",
 	"admin_removePeer",
 	"admin_removeTrustedPeer",
 	"admin_startHTTP",
@@ -1161,6 +1165,7 @@ var allRPCMethods = []string{
 	"admin_stopHTTP",
 	"admin_stopRPC",
 	"admin_stopWS",
+	"admin_unbanPeer",
 	"debug_accountRange",
 	"debug_blockProfile",
 	"debug_chaindbCompact",
@@ -1274,6 +1279,7 @@ var allRPCMethods = []string{
 	"eth_sendTransaction",
 	"eth_sign",
 	"eth_signTransaction",
+	"eth_simulateV1",
 	"eth_submitHashrate",
 	"eth_submitWork",
 	"eth_subscribe",
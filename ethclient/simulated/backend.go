@@ -17,20 +17,25 @@
 package simulated
 
 import (
+	"errors"
 	"time"
 
 	"github.com/shudolab/core-geth"
 	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core/state"
 	"github.com/shudolab/core-geth/eth"
 	"github.com/shudolab/core-geth/eth/catalyst"
 	"github.com/shudolab/core-geth/eth/downloader"
 	"github.com/shudolab/core-geth/eth/ethconfig"
 	"github.com/shudolab/core-geth/eth/filters"
 	"github.com/shudolab/core-geth/ethclient"
+	"github.com/shudolab/core-geth/ethdb"
 	"github.com/shudolab/core-geth/node"
 	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/params"
 	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/params/vars"
 	"github.com/shudolab/core-geth/rpc"
 )
 
@@ -58,11 +63,21 @@ type simClient struct {
 	*ethclient.Client
 }
 
+// sealer produces the blocks of a simulated chain on demand.
+type sealer interface {
+	Commit() common.Hash
+	CommitAt(timestamp uint64) common.Hash
+	Rollback()
+	Fork(parentHash common.Hash) error
+	AdjustTime(adjustment time.Duration) error
+	Stop() error
+}
+
 // Backend is a simulated blockchain. You can use it to test your contracts or
 // other code that interacts with the Ethereum chain.
 type Backend struct {
 	eth    *eth.Ethereum
-	beacon *catalyst.SimulatedBeacon
+	sealer sealer
 	client simClient
 }
 
@@ -101,23 +116,45 @@ func NewBackend(alloc genesisT.GenesisAlloc, options ...func(nodeConf *node.Conf
 	return sim
 }
 
+// NewGenesisBackend creates a simulated blockchain on an existing node, starting
+// from the genesis of the given configuration, which may use any chain
+// configuration. Chains merged at genesis are driven by the simulated beacon,
+// like the ones of NewBackend. Blocks of all other chains are sealed with fake
+// proof-of-work, so the fork rules and block rewards of the configuration apply
+// to them.
+//
+// The node must not be started, it is started by this function. Closing the
+// backend leaves the node running.
+func NewGenesisBackend(stack *node.Node, conf *ethconfig.Config) (*Backend, error) {
+	if conf.Genesis == nil || conf.Genesis.Config == nil {
+		return nil, errors.New("missing genesis chain config")
+	}
+	difficulty := conf.Genesis.Difficulty
+	if difficulty == nil {
+		difficulty = vars.GenesisDifficulty
+	}
+	if ttd := conf.Genesis.Config.GetEthashTerminalTotalDifficulty(); ttd != nil && difficulty.Cmp(ttd) >= 0 {
+		return newWithNode(stack, conf, 0)
+	}
+	conf.Ethash.PowMode = ethash.ModeFake
+	backend, err := startNode(stack, conf, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{
+		eth:    backend,
+		sealer: newForkSealer(backend, 0, conf.Miner.Etherbase, conf.Miner.GasCeil),
+		client: simClient{ethclient.NewClient(stack.Attach())},
+	}, nil
+}
+
 // newWithNode sets up a simulated backend on an existing node. The provided node
 // must not be started and will be started by this method.
 func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backend, error) {
-	backend, err := eth.New(stack, conf)
+	backend, err := startNode(stack, conf, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	// Register the filter system
-	filterSystem := filters.NewFilterSystem(backend.APIBackend, filters.Config{})
-	stack.RegisterAPIs([]rpc.API{{
-		Namespace: "eth",
-		Service:   filters.NewFilterAPI(filterSystem, false),
-	}})
-	// Start the node
-	if err := stack.Start(); err != nil {
-		return nil, err
-	}
 	// Set up the simulated beacon
 	beacon, err := catalyst.NewSimulatedBeacon(blockPeriod, backend)
 	if err != nil {
@@ -129,11 +166,32 @@ func newWithNode(stack *node.Node, conf *eth.Config, blockPeriod uint64) (*Backe
 	}
 	return &Backend{
 		eth:    backend,
-		beacon: beacon,
+		sealer: beacon,
 		client: simClient{ethclient.NewClient(stack.Attach())},
 	}, nil
 }
 
+// startNode registers the Ethereum service and the filter system on a node that
+// hasn't been started yet, and starts it. The optional chain database and state
+// wrapper are passed through to eth.NewWithDatabase.
+func startNode(stack *node.Node, conf *eth.Config, db ethdb.Database, wrapState func(state.Database) state.Database) (*eth.Ethereum, error) {
+	backend, err := eth.NewWithDatabase(stack, conf, db, wrapState)
+	if err != nil {
+		return nil, err
+	}
+	// Register the filter system
+	filterSystem := filters.NewFilterSystem(backend.APIBackend, filters.Config{})
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   filters.NewFilterAPI(filterSystem, false),
+	}})
+	// Start the node
+	if err := stack.Start(); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
 // Close shuts down the simBackend.
 // The simulated backend can't be used afterwards.
 func (n *Backend) Close() error {
@@ -141,9 +199,9 @@ func (n *Backend) Close() error {
 		n.client.Close()
 		n.client = simClient{}
 	}
-	if n.beacon != nil {
-		err := n.beacon.Stop()
-		n.beacon = nil
+	if n.sealer != nil {
+		err := n.sealer.Stop()
+		n.sealer = nil
 		return err
 	}
 	return nil
@@ -151,12 +209,28 @@ func (n *Backend) Close() error {
 
 // Commit seals a block and moves the chain forward to a new empty block.
 func (n *Backend) Commit() common.Hash {
-	return n.beacon.Commit()
+	return n.sealer.Commit()
+}
+
+// CommitAt seals a block with the given timestamp and moves the chain forward.
+// Timestamps not later than the current head's are bumped past it.
+func (n *Backend) CommitAt(timestamp time.Time) common.Hash {
+	return n.sealer.CommitAt(uint64(timestamp.Unix()))
+}
+
+// CommitBlocks seals count blocks, the first of which includes any pending
+// transactions, and returns the hash of the last one.
+func (n *Backend) CommitBlocks(count int) common.Hash {
+	var hash common.Hash
+	for i := 0; i < count; i++ {
+		hash = n.sealer.Commit()
+	}
+	return hash
 }
 
 // Rollback removes all pending transactions, reverting to the last committed state.
 func (n *Backend) Rollback() {
-	n.beacon.Rollback()
+	n.sealer.Rollback()
 }
 
 // Fork creates a side-chain that can be used to simulate reorgs.
@@ -172,13 +246,13 @@ func (n *Backend) Rollback() {
 // There is a % chance that the side chain becomes canonical at the same length
 // to simulate live network behavior.
 func (n *Backend) Fork(parentHash common.Hash) error {
-	return n.beacon.Fork(parentHash)
+	return n.sealer.Fork(parentHash)
 }
 
 // AdjustTime changes the block timestamp and creates a new block.
 // It can only be called on empty blocks.
 func (n *Backend) AdjustTime(adjustment time.Duration) error {
-	return n.beacon.AdjustTime(adjustment)
+	return n.sealer.AdjustTime(adjustment)
 }
 
 // Client returns a client that accesses the simulated chain.
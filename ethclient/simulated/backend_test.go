@@ -28,6 +28,9 @@ import (
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/core/types"
 	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/eth/ethconfig"
+	"github.com/shudolab/core-geth/node"
+	"github.com/shudolab/core-geth/params"
 	"github.com/shudolab/core-geth/params/types/genesisT"
 	"github.com/shudolab/core-geth/params/vars"
 )
@@ -307,3 +310,60 @@ func TestAdjustTimeAfterFork(t *testing.T) {
 		t.Errorf("failed to build block on fork")
 	}
 }
+
+func TestGenesisBackend(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		config   = params.MordorChainConfig
+		coinbase = common.HexToAddress("0xc014ba5e")
+	)
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stack.Close()
+
+	conf := ethconfig.Defaults
+	conf.Genesis = &genesisT.Genesis{
+		Config:   config,
+		GasLimit: 8_000_000,
+		Alloc:    genesisT.GenesisAlloc{testAddr: {Balance: big.NewInt(vars.Ether)}},
+	}
+	conf.Miner.Etherbase = coinbase
+	sim, err := NewGenesisBackend(stack, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	client := sim.Client()
+	tx, err := types.SignTx(types.NewTransaction(0, coinbase, big.NewInt(1), vars.TxGas, big.NewInt(vars.GWei), nil), types.NewEIP155Signer(config.GetChainID()), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+
+	block, err := client.BlockByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.NumberU64() != 1 || len(block.Transactions()) != 1 {
+		t.Fatalf("wrong head: number %d with %d txs, want 1 with 1", block.NumberU64(), len(block.Transactions()))
+	}
+	if block.Difficulty().Sign() == 0 {
+		t.Error("block sealed without proof-of-work difficulty")
+	}
+	// The Mordor block reward and the fee are credited to the coinbase.
+	balance, err := client.BalanceAt(ctx, coinbase, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(big.Int).Mul(big.NewInt(5), big.NewInt(vars.Ether))
+	want.Add(want, big.NewInt(1+int64(vars.TxGas)*vars.GWei))
+	if balance.Cmp(want) != 0 {
+		t.Errorf("wrong coinbase balance %v, want %v", balance, want)
+	}
+}
@@ -0,0 +1,410 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/eth/downloader"
+	"github.com/shudolab/core-geth/eth/ethconfig"
+	"github.com/shudolab/core-geth/ethclient"
+	"github.com/shudolab/core-geth/ethdb"
+	"github.com/shudolab/core-geth/node"
+	"github.com/shudolab/core-geth/p2p"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/rpc"
+	"github.com/shudolab/core-geth/trie"
+	"github.com/shudolab/core-geth/triedb"
+)
+
+// forkAncestors is the number of headers below the forked block imported from
+// the remote node, so that the BLOCKHASH opcode keeps working after the fork.
+const forkAncestors = 256
+
+// NewForkedBackend creates a simulated blockchain forked off a live chain, which
+// can be used to test contracts against real deployed state.
+//
+// The chain continues from the given block of the remote node, or its current
+// head if number is nil. Accounts, contract code and storage are loaded lazily
+// from the remote node the first time they are accessed (via eth_getProof and
+// eth_getCode), and cached locally afterwards, so the remote must be able to
+// serve state at that block. New blocks are sealed on top with the provided
+// chain configuration and fake proof-of-work, so the fork rules of the real
+// network apply to them. Merged chains are not supported.
+func NewForkedBackend(remote *rpc.Client, number *big.Int, config ctypes.ChainConfigurator, options ...func(nodeConf *node.Config, ethConf *ethconfig.Config)) (*Backend, error) {
+	if config == nil {
+		return nil, errors.New("missing chain config")
+	}
+	if config.GetEthashTerminalTotalDifficulty() != nil {
+		return nil, errors.New("forking merged chains is not supported")
+	}
+	// Retrieve the block to fork off and enough history to serve BLOCKHASH
+	ctx := context.Background()
+	block, err := ethclient.NewClient(remote).BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve fork block: %w", err)
+	}
+	ancestors, err := fetchAncestors(ctx, remote, block.Header())
+	if err != nil {
+		return nil, err
+	}
+	// Create the configurations as NewBackend does, but with the chain loaded
+	// from a prepared database and the state resolved against the remote node
+	nodeConf := node.DefaultConfig
+	nodeConf.DataDir = ""
+	nodeConf.P2P = p2p.Config{NoDiscovery: true}
+
+	fork := &forkRemote{client: remote, block: block.Hash(), root: block.Root()}
+
+	ethConf := ethconfig.Defaults
+	ethConf.Genesis = &genesisT.Genesis{
+		Config:   config,
+		GasLimit: block.GasLimit(),
+	}
+	ethConf.SyncMode = downloader.FullSync
+	ethConf.TxPool.NoLocals = true
+	ethConf.NoPruning = true
+	ethConf.StateScheme = rawdb.HashScheme
+	ethConf.SnapshotCache = 0
+	ethConf.TransactionHistory = 0
+	ethConf.Ethash.PowMode = ethash.ModeFake
+	for _, option := range options {
+		option(&nodeConf, &ethConf)
+	}
+	db := rawdb.NewMemoryDatabase()
+	if err := seedForkChain(db, ethConf.Genesis, block, ancestors); err != nil {
+		return nil, err
+	}
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		return nil, err
+	}
+	wrapState := func(db state.Database) state.Database {
+		return &forkDatabase{Database: db, remote: fork}
+	}
+	backend, err := startNode(stack, &ethConf, db, wrapState)
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	return &Backend{
+		eth:    backend,
+		sealer: newForkSealer(backend, block.NumberU64(), ethConf.Miner.Etherbase, ethConf.Miner.GasCeil),
+		client: simClient{ethclient.NewClient(stack.Attach())},
+	}, nil
+}
+
+// fetchAncestors retrieves up to forkAncestors headers preceding the given one
+// from the remote node, in ascending order.
+func fetchAncestors(ctx context.Context, remote *rpc.Client, header *types.Header) ([]*types.Header, error) {
+	var first uint64 = 1
+	if n := header.Number.Uint64(); n > forkAncestors {
+		first = n - forkAncestors
+	}
+	var (
+		headers = make([]*types.Header, 0, forkAncestors)
+		batch   = make([]rpc.BatchElem, 0, forkAncestors)
+	)
+	for n := first; n < header.Number.Uint64(); n++ {
+		headers = append(headers, new(types.Header))
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(n), false},
+			Result: headers[len(headers)-1],
+		})
+	}
+	if err := remote.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("failed to retrieve fork ancestors: %w", err)
+	}
+	child := header
+	for i := len(batch) - 1; i >= 0; i-- {
+		if batch[i].Error != nil {
+			return nil, fmt.Errorf("failed to retrieve fork ancestor: %w", batch[i].Error)
+		}
+		if headers[i].Hash() != child.ParentHash {
+			return nil, fmt.Errorf("remote chain changed while retrieving fork ancestor #%d", headers[i].Number)
+		}
+		child = headers[i]
+	}
+	return headers, nil
+}
+
+// seedForkChain writes a synthetic genesis block, the ancestor headers and the
+// fork block into an empty database, marking the fork block as the chain head.
+// The state of the fork block is not written, it's resolved from the remote
+// node on demand.
+func seedForkChain(db ethdb.Database, genesis *genesisT.Genesis, block *types.Block, ancestors []*types.Header) error {
+	if _, err := core.CommitGenesis(genesis, db, triedb.NewDatabase(db, triedb.HashDefaults)); err != nil {
+		return err
+	}
+	batch := db.NewBatch()
+	for _, header := range ancestors {
+		rawdb.WriteHeader(batch, header)
+		rawdb.WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64())
+	}
+	hash, number := block.Hash(), block.NumberU64()
+
+	rawdb.WriteBlock(batch, block)
+	rawdb.WriteCanonicalHash(batch, hash, number)
+	rawdb.WriteTd(batch, hash, number, block.Difficulty())
+	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+
+	// The history below the fork block is unavailable, mark the transaction
+	// indices complete so the indexer doesn't try to backfill them.
+	rawdb.WriteTxIndexTail(batch, 0)
+
+	rawdb.WriteHeadHeaderHash(batch, hash)
+	rawdb.WriteHeadFastBlockHash(batch, hash)
+	rawdb.WriteHeadBlockHash(batch, hash)
+	return batch.Write()
+}
+
+// forkRemote loads the state of the forked block from the remote node.
+type forkRemote struct {
+	client *rpc.Client
+	block  common.Hash // Hash of the forked block
+	root   common.Hash // State root of the forked block
+}
+
+// loadProof retrieves the Merkle proof of an account and optionally some of its
+// storage slots, storing the trie nodes along the paths in the database.
+func (r *forkRemote) loadProof(db ethdb.KeyValueWriter, addr common.Address, slots ...common.Hash) error {
+	var proof struct {
+		AccountProof []hexutil.Bytes `json:"accountProof"`
+		StorageProof []struct {
+			Proof []hexutil.Bytes `json:"proof"`
+		} `json:"storageProof"`
+	}
+	if slots == nil {
+		slots = []common.Hash{}
+	}
+	if err := r.client.CallContext(context.Background(), &proof, "eth_getProof", addr, slots, r.block); err != nil {
+		return fmt.Errorf("failed to load state of %x from remote: %w", addr, err)
+	}
+	nodes := proof.AccountProof
+	for _, slot := range proof.StorageProof {
+		nodes = append(nodes, slot.Proof...)
+	}
+	for _, node := range nodes {
+		rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(node), node)
+	}
+	return nil
+}
+
+// loadCode retrieves the code of a contract, storing it in the database if it
+// matches the expected hash.
+func (r *forkRemote) loadCode(db ethdb.KeyValueWriter, addr common.Address, codeHash common.Hash) ([]byte, error) {
+	var code hexutil.Bytes
+	if err := r.client.CallContext(context.Background(), &code, "eth_getCode", addr, r.block); err != nil {
+		return nil, fmt.Errorf("failed to load code of %x from remote: %w", addr, err)
+	}
+	if hash := crypto.Keccak256Hash(code); hash != codeHash {
+		return nil, fmt.Errorf("remote code of %x has hash %x, want %x", addr, hash, codeHash)
+	}
+	rawdb.WriteCode(db, codeHash, code)
+	return code, nil
+}
+
+// forkDatabase is a state database which resolves trie nodes and contract code
+// missing from the local database against the remote node. Everything loaded
+// belongs to the forked block's state, which local blocks build upon, so any
+// node missing locally is necessarily one of the remote state's.
+type forkDatabase struct {
+	state.Database
+	remote *forkRemote
+}
+
+// OpenTrie opens the main account trie, loading its root if it's the forked
+// block's and not yet available.
+func (db *forkDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	if root == db.remote.root && !rawdb.HasLegacyTrieNode(db.DiskDB(), root) {
+		if err := db.remote.loadProof(db.DiskDB(), common.Address{}); err != nil {
+			return nil, err
+		}
+	}
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, db: db}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, loading its root from
+// the remote node if not yet available.
+func (db *forkDatabase) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self state.Trie) (state.Trie, error) {
+	var tr state.Trie
+	err := resolve(db.loader(address, common.Hash{}), func() (err error) {
+		tr, err = db.Database.OpenStorageTrie(stateRoot, address, root, self)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, db: db}, nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *forkDatabase) CopyTrie(t state.Trie) state.Trie {
+	if t, ok := t.(*forkTrie); ok {
+		return &forkTrie{Trie: db.Database.CopyTrie(t.Trie), db: db}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// ContractCode retrieves a particular contract's code, loading it from the
+// remote node if not yet available.
+func (db *forkDatabase) ContractCode(address common.Address, codeHash common.Hash) ([]byte, error) {
+	if code, err := db.Database.ContractCode(address, codeHash); err == nil {
+		return code, nil
+	}
+	return db.remote.loadCode(db.DiskDB(), address, codeHash)
+}
+
+// ContractCodeSize retrieves a particular contracts code's size, loading the
+// code from the remote node if not yet available.
+func (db *forkDatabase) ContractCodeSize(address common.Address, codeHash common.Hash) (int, error) {
+	if size, err := db.Database.ContractCodeSize(address, codeHash); err == nil {
+		return size, nil
+	}
+	code, err := db.ContractCode(address, codeHash)
+	return len(code), err
+}
+
+// ContractCodeWithPrefix retrieves a particular contract's code, loading it from
+// the remote node if not yet available.
+func (db *forkDatabase) ContractCodeWithPrefix(address common.Address, codeHash common.Hash) ([]byte, error) {
+	if reader, ok := db.Database.(interface {
+		ContractCodeWithPrefix(common.Address, common.Hash) ([]byte, error)
+	}); ok {
+		if code, err := reader.ContractCodeWithPrefix(address, codeHash); err == nil {
+			return code, nil
+		}
+	}
+	return db.remote.loadCode(db.DiskDB(), address, codeHash)
+}
+
+// loader returns a function loading the proof of an account and some of its
+// storage slots from the remote node.
+func (db *forkDatabase) loader(address common.Address, slots ...common.Hash) func() error {
+	return func() error {
+		return db.remote.loadProof(db.DiskDB(), address, slots...)
+	}
+}
+
+// forkTrie is a state trie which loads missing nodes from the remote node.
+type forkTrie struct {
+	state.Trie
+	db *forkDatabase
+}
+
+// GetAccount retrieves an account, loading the path to it if needed.
+func (t *forkTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	var account *types.StateAccount
+	err := resolve(t.db.loader(address), func() (err error) {
+		account, err = t.Trie.GetAccount(address)
+		return err
+	})
+	return account, err
+}
+
+// GetStorage retrieves a storage slot, loading the path to it if needed.
+func (t *forkTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	var value []byte
+	err := resolve(t.db.loader(addr, common.BytesToHash(key)), func() (err error) {
+		value, err = t.Trie.GetStorage(addr, key)
+		return err
+	})
+	return value, err
+}
+
+// UpdateAccount writes an account, loading the path to it if needed.
+func (t *forkTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	return resolve(t.db.loader(address), func() error {
+		return t.Trie.UpdateAccount(address, account)
+	})
+}
+
+// UpdateStorage writes a storage slot, loading the path to it if needed.
+func (t *forkTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return resolve(t.db.loader(addr, common.BytesToHash(key)), func() error {
+		return t.Trie.UpdateStorage(addr, key, value)
+	})
+}
+
+// DeleteAccount removes an account, loading the path to it if needed.
+//
+// Deleting may collapse a branch into a sibling which was never loaded, and
+// which cannot be requested from the remote node without knowing any of the
+// keys below it. In that case the account is overwritten with an empty one
+// instead, which is equivalent for execution but yields a different root.
+func (t *forkTrie) DeleteAccount(address common.Address) error {
+	err := resolve(t.db.loader(address), func() error {
+		return t.Trie.DeleteAccount(address)
+	})
+	if isMissingNode(err) {
+		return t.UpdateAccount(address, types.NewEmptyStateAccount())
+	}
+	return err
+}
+
+// DeleteStorage removes a storage slot, loading the path to it if needed. As
+// with accounts, a slot which cannot be deleted due to an unknown sibling is
+// overwritten with zero instead.
+func (t *forkTrie) DeleteStorage(addr common.Address, key []byte) error {
+	err := resolve(t.db.loader(addr, common.BytesToHash(key)), func() error {
+		return t.Trie.DeleteStorage(addr, key)
+	})
+	if isMissingNode(err) {
+		return t.UpdateStorage(addr, key, []byte{0})
+	}
+	return err
+}
+
+// resolve runs a trie operation, loading state from the remote node whenever
+// it fails due to a missing node, until it succeeds or loading doesn't help.
+func resolve(load func() error, op func() error) error {
+	var last common.Hash
+	for {
+		err := op()
+		var missing *trie.MissingNodeError
+		if !errors.As(err, &missing) || missing.NodeHash == last {
+			return err
+		}
+		last = missing.NodeHash
+		if err := load(); err != nil {
+			return err
+		}
+	}
+}
+
+// isMissingNode reports whether err is caused by a trie node missing locally.
+func isMissingNode(err error) bool {
+	var missing *trie.MissingNodeError
+	return errors.As(err, &missing)
+}
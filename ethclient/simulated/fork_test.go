@@ -0,0 +1,207 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/eth"
+	"github.com/shudolab/core-geth/eth/ethconfig"
+	"github.com/shudolab/core-geth/ethclient"
+	"github.com/shudolab/core-geth/node"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/params/vars"
+)
+
+// forkStoreCode is the runtime code of a contract storing the calldata word in
+// slot 0, or returning slot 0 if called without data.
+var forkStoreCode = hexutil.MustDecode("0x3615600c57600035600055005b60005460005260206000f3")
+
+// newRemoteNode starts a proof-of-work node running the given chain, to be
+// forked off by the tests.
+func newRemoteNode(t *testing.T, genesis *genesisT.Genesis, blocks int, gen func(int, *core.BlockGen)) *node.Node {
+	t.Helper()
+
+	_, chain, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), blocks, gen)
+
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create remote node: %v", err)
+	}
+	config := &ethconfig.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	backend, err := eth.New(stack, config)
+	if err != nil {
+		t.Fatalf("can't create remote ethereum service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("can't start remote node: %v", err)
+	}
+	t.Cleanup(func() { stack.Close() })
+
+	if _, err := backend.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("can't import remote chain: %v", err)
+	}
+	return stack
+}
+
+func TestForkedBackend(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		config   = params.MordorChainConfig
+		signer   = types.NewEIP155Signer(config.GetChainID())
+		testAddr = crypto.PubkeyToAddress(testKey.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+	)
+	// Create a remote chain with enough accounts and slots to make the tries
+	// non-trivial, and a transaction updating the contract
+	genesis := &genesisT.Genesis{
+		Config:   config,
+		GasLimit: 8_000_000,
+		Alloc: genesisT.GenesisAlloc{
+			testAddr: {Balance: big.NewInt(vars.Ether)},
+			contract: {Code: forkStoreCode, Storage: map[common.Hash]common.Hash{}},
+		},
+	}
+	for i := 0; i < 256; i++ {
+		genesis.Alloc[common.BigToAddress(big.NewInt(int64(0x1000+i)))] = genesisT.GenesisAccount{Balance: big.NewInt(int64(i + 1))}
+		genesis.Alloc[contract].Storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	store := func(nonce uint64, value int64) *types.Transaction {
+		tx := types.NewTransaction(nonce, contract, new(big.Int), 100_000, big.NewInt(vars.GWei), common.BigToHash(big.NewInt(value)).Bytes())
+		signed, err := types.SignTx(tx, signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	remoteNode := newRemoteNode(t, genesis, 3, func(i int, b *core.BlockGen) {
+		if i == 0 {
+			b.AddTx(store(0, 7))
+		}
+	})
+	remote := ethclient.NewClient(remoteNode.Attach())
+	head, err := remote.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fork the chain and check that the remote state is visible
+	sim, err := NewForkedBackend(remote.Client(), nil, config)
+	if err != nil {
+		t.Fatalf("failed to fork remote chain: %v", err)
+	}
+	defer sim.Close()
+	client := sim.Client()
+
+	if local, err := client.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	} else if local.Hash() != head.Hash() {
+		t.Fatalf("head mismatch: have #%d %x, want #%d %x", local.Number, local.Hash(), head.Number, head.Hash())
+	}
+	want, _ := remote.BalanceAt(ctx, testAddr, nil)
+	if have, err := client.BalanceAt(ctx, testAddr, nil); err != nil || have.Cmp(want) != 0 {
+		t.Errorf("balance mismatch: have %v (%v), want %v", have, err, want)
+	}
+	if have, err := client.BalanceAt(ctx, common.BigToAddress(big.NewInt(0x1000+41)), nil); err != nil || have.Int64() != 42 {
+		t.Errorf("balance mismatch: have %v (%v), want 42", have, err)
+	}
+	if have, err := client.CodeAt(ctx, contract, nil); err != nil || !bytes.Equal(have, forkStoreCode) {
+		t.Errorf("code mismatch: have %x (%v), want %x", have, err, forkStoreCode)
+	}
+	if have, err := client.StorageAt(ctx, contract, common.BigToHash(big.NewInt(10)), nil); err != nil || new(big.Int).SetBytes(have).Int64() != 11 {
+		t.Errorf("storage mismatch: have %x (%v), want 11", have, err)
+	}
+	call := func(c interface {
+		CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error)
+	}) int64 {
+		t.Helper()
+		out, err := c.CallContract(ctx, ethereum.CallMsg{To: &contract}, nil)
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		return new(big.Int).SetBytes(out).Int64()
+	}
+	if have := call(client); have != 7 {
+		t.Errorf("call result mismatch: have %d, want 7", have)
+	}
+	// Execute transactions on top of the fork, both updating and clearing a slot
+	nonce, err := client.PendingNonceAt(ctx, testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, value := range []int64{99, 0} {
+		tx := store(nonce+uint64(i), value)
+		if err := client.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to send transaction: %v", err)
+		}
+		sim.Commit()
+
+		receipt, err := client.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			t.Fatalf("missing receipt: %v", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("transaction failed")
+		}
+		if receipt.BlockNumber.Uint64() != head.Number.Uint64()+uint64(i)+1 {
+			t.Errorf("transaction included in block %d, want %d", receipt.BlockNumber, head.Number.Uint64()+uint64(i)+1)
+		}
+		if have := call(client); have != value {
+			t.Errorf("call result mismatch: have %d, want %d", have, value)
+		}
+	}
+	if have, err := client.StorageAt(ctx, contract, common.BigToHash(big.NewInt(10)), nil); err != nil || new(big.Int).SetBytes(have).Int64() != 11 {
+		t.Errorf("storage mismatch after clearing: have %x (%v), want 11", have, err)
+	}
+	if have := call(remote); have != 7 {
+		t.Errorf("remote call result changed: have %d, want 7", have)
+	}
+	// Check the time and block manipulation helpers
+	parent, _ := client.HeaderByNumber(ctx, nil)
+	sim.CommitAt(time.Unix(int64(parent.Time)+3600, 0))
+	if header, _ := client.HeaderByNumber(ctx, nil); header.Time != parent.Time+3600 {
+		t.Errorf("block time mismatch: have %d, want %d", header.Time, parent.Time+3600)
+	}
+	sim.CommitBlocks(3)
+	if header, _ := client.HeaderByNumber(ctx, nil); header.Number.Uint64() != parent.Number.Uint64()+4 {
+		t.Errorf("block number mismatch: have %d, want %d", header.Number, parent.Number.Uint64()+4)
+	}
+	if err := sim.Fork(parent.Hash()); err != nil {
+		t.Fatalf("failed to fork: %v", err)
+	}
+	if header, _ := client.HeaderByNumber(ctx, nil); header.Hash() != parent.Hash() {
+		t.Errorf("head not rewound to fork point")
+	}
+	if err := sim.AdjustTime(time.Hour); err != nil {
+		t.Fatalf("failed to adjust time: %v", err)
+	}
+	if header, _ := client.HeaderByNumber(ctx, nil); header.Time != parent.Time+3600 {
+		t.Errorf("adjusted block time mismatch: have %d, want %d", header.Time, parent.Time+3600)
+	}
+}
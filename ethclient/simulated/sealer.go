@@ -0,0 +1,211 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/consensus/misc/eip1559"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/txpool"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/eth"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/params/vars"
+)
+
+// forkSealer produces proof-of-work blocks on demand for forked backends and the
+// ones of unmerged chains. It executes the pending transactions on top of the
+// chain head and writes the resulting block directly, without mining or
+// re-executing it.
+type forkSealer struct {
+	eth      *eth.Ethereum
+	base     uint64         // Number of the forked block, below which the chain can't be rewound
+	coinbase common.Address // Recipient of the block rewards and fees
+	gasCeil  uint64         // Gas limit to target for sealed blocks
+
+	lock sync.Mutex // Serializes block production
+}
+
+func newForkSealer(eth *eth.Ethereum, base uint64, coinbase common.Address, gasCeil uint64) *forkSealer {
+	return &forkSealer{
+		eth:      eth,
+		base:     base,
+		coinbase: coinbase,
+		gasCeil:  gasCeil,
+	}
+}
+
+// Commit seals a block on demand.
+func (s *forkSealer) Commit() common.Hash {
+	return s.CommitAt(uint64(time.Now().Unix()))
+}
+
+// CommitAt seals a block on demand with the given timestamp, which is bumped
+// past the parent's if it isn't later.
+func (s *forkSealer) CommitAt(timestamp uint64) common.Hash {
+	if err := s.seal(timestamp); err != nil {
+		log.Warn("Error performing sealing work", "err", err)
+	}
+	return s.eth.BlockChain().CurrentBlock().Hash()
+}
+
+// Rollback un-sends previously added transactions.
+func (s *forkSealer) Rollback() {
+	// Flush all transactions from the transaction pools
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	s.eth.TxPool().SetGasTip(maxUint256)
+	s.eth.TxPool().SetGasTip(big.NewInt(vars.GWei))
+}
+
+// Fork sets the head to the provided hash, which can't precede the forked block.
+func (s *forkSealer) Fork(parentHash common.Hash) error {
+	if len(s.eth.TxPool().Pending(txpool.PendingFilter{})) != 0 {
+		return errors.New("pending block dirty")
+	}
+	parent := s.eth.BlockChain().GetBlockByHash(parentHash)
+	if parent == nil {
+		return errors.New("parent not found")
+	}
+	if parent.NumberU64() < s.base {
+		return errors.New("parent precedes the forked block")
+	}
+	return s.eth.BlockChain().SetHead(parent.NumberU64())
+}
+
+// AdjustTime creates a new block with an adjusted timestamp.
+func (s *forkSealer) AdjustTime(adjustment time.Duration) error {
+	if len(s.eth.TxPool().Pending(txpool.PendingFilter{})) != 0 {
+		return errors.New("could not adjust time on non-empty block")
+	}
+	return s.seal(s.eth.BlockChain().CurrentBlock().Time + uint64(adjustment/time.Second))
+}
+
+// Stop is a no-op, blocks are only produced on demand.
+func (s *forkSealer) Stop() error {
+	return nil
+}
+
+// seal assembles a block out of the pending transactions and imports it as the
+// new chain head.
+func (s *forkSealer) seal(timestamp uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Make sure the pool caught up with the current head before draining it
+	if err := s.eth.TxPool().Sync(); err != nil {
+		return err
+	}
+	var (
+		chain  = s.eth.BlockChain()
+		config = chain.Config()
+		parent = chain.CurrentBlock()
+	)
+	if timestamp <= parent.Time {
+		timestamp = parent.Time + 1
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit, s.gasCeil),
+		Time:       timestamp,
+		Coinbase:   s.coinbase,
+	}
+	if config.IsEnabled(config.GetEIP1559Transition, header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent)
+		if !config.IsEnabled(config.GetEIP1559Transition, parent.Number) {
+			header.GasLimit = core.CalcGasLimit(parent.GasLimit*config.GetElasticityMultiplier(), s.gasCeil)
+		}
+	}
+	if err := s.eth.Engine().Prepare(chain, header); err != nil {
+		return err
+	}
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return err
+	}
+	// Execute the pending transactions sender by sender. The ordering doesn't
+	// follow the fee market like the miner's, but it is deterministic.
+	filter := txpool.PendingFilter{OnlyPlainTxs: true}
+	if header.BaseFee != nil {
+		filter.BaseFee = uint256.MustFromBig(header.BaseFee)
+	}
+	pending := s.eth.TxPool().Pending(filter)
+
+	senders := make([]common.Address, 0, len(pending))
+	for addr := range pending {
+		senders = append(senders, addr)
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i][:], senders[j][:]) < 0
+	})
+	var (
+		gasPool  = new(core.GasPool).AddGas(header.GasLimit)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	for _, addr := range senders {
+		for _, ltx := range pending[addr] {
+			tx := ltx.Resolve()
+			if tx == nil {
+				break
+			}
+			var (
+				snap = statedb.Snapshot()
+				gas  = gasPool.Gas()
+			)
+			statedb.SetTxContext(tx.Hash(), len(txs))
+			receipt, err := core.ApplyTransaction(config, chain, &header.Coinbase, gasPool, statedb, header, tx, &header.GasUsed, *chain.GetVMConfig())
+			if err != nil {
+				// Later transactions of the sender can't be included either
+				log.Debug("Skipping transaction", "hash", tx.Hash(), "err", err)
+				statedb.RevertToSnapshot(snap)
+				gasPool.SetGas(gas)
+				break
+			}
+			txs = append(txs, tx)
+			receipts = append(receipts, receipt)
+		}
+	}
+	block, err := s.eth.Engine().FinalizeAndAssemble(chain, header, statedb, txs, nil, receipts, nil)
+	if err != nil {
+		return err
+	}
+	// Update the block hash in all receipts and logs, now that it is available
+	var (
+		hash = block.Hash()
+		logs []*types.Log
+	)
+	for i, receipt := range receipts {
+		receipt.BlockHash = hash
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(i)
+		for _, log := range receipt.Logs {
+			log.BlockHash = hash
+		}
+		logs = append(logs, receipt.Logs...)
+	}
+	_, err = chain.WriteBlockAndSetHead(block, receipts, logs, statedb, true)
+	return err
+}
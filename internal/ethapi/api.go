@@ -43,6 +43,7 @@ import (
 	"github.com/shudolab/core-geth/crypto"
 	"github.com/shudolab/core-geth/eth/gasestimator"
 	"github.com/shudolab/core-geth/eth/tracers/logger"
+	"github.com/shudolab/core-geth/internal/tracing"
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/p2p"
 	"github.com/shudolab/core-geth/params/types/ctypes"
@@ -52,6 +53,7 @@ import (
 	"github.com/shudolab/core-geth/trie"
 	"github.com/holiman/uint256"
 	"github.com/tyler-smith/go-bip39"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // estimateGasErrorRatio is the amount of overestimation eth_estimateGas is
@@ -350,13 +352,14 @@ func (s *PersonalAccountAPI) OpenWallet(url string, passphrase *string) error {
 }
 
 // DeriveAccount requests an HD wallet to derive a new account, optionally pinning
-// it for later reuse.
-func (s *PersonalAccountAPI) DeriveAccount(url string, path string, pin *bool) (accounts.Account, error) {
+// it for later reuse. Relative paths are resolved against the root path of the
+// optional network (name or SLIP-0044 coin type), defaulting to the wallet's own.
+func (s *PersonalAccountAPI) DeriveAccount(url string, path string, pin *bool, network *string) (accounts.Account, error) {
 	wallet, err := s.am.Wallet(url)
 	if err != nil {
 		return accounts.Account{}, err
 	}
-	derivPath, err := accounts.ParseDerivationPath(path)
+	derivPath, err := accounts.ParseWalletDerivationPath(wallet, path, network)
 	if err != nil {
 		return accounts.Account{}, err
 	}
@@ -951,23 +954,57 @@ func (s *BlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.
 // set, message execution will only use the data in the given state. Otherwise
 // if statDiff is set, all diff will be applied first and then execute the call
 // message.
+// If movePrecompileTo is set, the precompile at the account's address is moved
+// to the given address, and the account can be overridden like any other.
 type OverrideAccount struct {
-	Nonce     *hexutil.Uint64              `json:"nonce"`
-	Code      *hexutil.Bytes               `json:"code"`
-	Balance   **hexutil.Big                `json:"balance"`
-	State     *map[common.Hash]common.Hash `json:"state"`
-	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+	Nonce            *hexutil.Uint64              `json:"nonce"`
+	Code             *hexutil.Bytes               `json:"code"`
+	Balance          **hexutil.Big                `json:"balance"`
+	State            *map[common.Hash]common.Hash `json:"state"`
+	StateDiff        *map[common.Hash]common.Hash `json:"stateDiff"`
+	MovePrecompileTo *common.Address              `json:"movePrecompileToAddress"`
 }
 
 // StateOverride is the collection of overridden accounts.
 type StateOverride map[common.Address]OverrideAccount
 
-// Apply overrides the fields of specified accounts into the given state.
-func (diff *StateOverride) Apply(state *state.StateDB) error {
+// Apply overrides the fields of specified accounts into the given state. The
+// precompiles, if non-nil, are updated in place: precompiles with overridden
+// code are removed and moved ones are re-registered at their new address. If
+// nil, moving precompiles is rejected.
+func (diff *StateOverride) Apply(state *state.StateDB, precompiles vm.PrecompiledContracts) error {
 	if diff == nil {
 		return nil
 	}
+	// Track the destinations of moved precompiles, they can't be overridden
+	moved := make(map[common.Address]struct{})
 	for addr, account := range *diff {
+		if account.MovePrecompileTo == nil {
+			continue
+		}
+		if precompiles == nil {
+			return errors.New("moving precompiles is not supported")
+		}
+		p, ok := precompiles[addr]
+		if !ok {
+			return fmt.Errorf("account %s is not a precompile", addr.Hex())
+		}
+		dest := *account.MovePrecompileTo
+		if _, ok := (*diff)[dest]; ok {
+			return fmt.Errorf("account %s is already overridden", dest.Hex())
+		}
+		if _, ok := moved[dest]; ok {
+			return fmt.Errorf("account %s is the destination of multiple precompiles", dest.Hex())
+		}
+		moved[dest] = struct{}{}
+		precompiles[dest] = p
+	}
+	for addr, account := range *diff {
+		// A precompile that was moved or whose code is replaced is no longer
+		// available at its original address, unless another one moved there.
+		if _, ok := moved[addr]; !ok && (account.MovePrecompileTo != nil || account.Code != nil) {
+			delete(precompiles, addr)
+		}
 		// Override account nonce.
 		if account.Nonce != nil {
 			state.SetNonce(addr, uint64(*account.Nonce))
@@ -1014,6 +1051,37 @@ type BlockOverrides struct {
 	BlobBaseFee *hexutil.Big
 }
 
+// MakeHeader returns a copy of the given header with the overridden fields set.
+// The blob base fee is not part of the header and must be applied separately.
+func (diff *BlockOverrides) MakeHeader(header *types.Header) *types.Header {
+	if diff == nil {
+		return header
+	}
+	h := types.CopyHeader(header)
+	if diff.Number != nil {
+		h.Number = diff.Number.ToInt()
+	}
+	if diff.Difficulty != nil {
+		h.Difficulty = diff.Difficulty.ToInt()
+	}
+	if diff.Time != nil {
+		h.Time = uint64(*diff.Time)
+	}
+	if diff.GasLimit != nil {
+		h.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		h.Coinbase = *diff.Coinbase
+	}
+	if diff.Random != nil {
+		h.MixDigest = *diff.Random
+	}
+	if diff.BaseFee != nil {
+		h.BaseFee = diff.BaseFee.ToInt()
+	}
+	return h
+}
+
 // Apply overrides the given header fields into the given block context.
 func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
 	if diff == nil {
@@ -1077,10 +1145,15 @@ func (context *ChainContext) GetHeader(hash common.Hash, number uint64) *types.H
 	return header
 }
 
-func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.StateDB, header *types.Header, overrides *StateOverride, blockOverrides *BlockOverrides, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
-	if err := overrides.Apply(state); err != nil {
-		return nil, err
-	}
+func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.StateDB, header *types.Header, overrides *StateOverride, blockOverrides *BlockOverrides, timeout time.Duration, globalGasCap uint64) (result *core.ExecutionResult, err error) {
+	ctx, span := tracing.Start(ctx, "ethapi.doCall", attribute.Int64("block.number", header.Number.Int64()))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(attribute.Int64("tx.gasUsed", int64(result.UsedGas)))
+		}
+		tracing.End(span, err)
+	}()
+
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
@@ -1098,11 +1171,16 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	if blockOverrides != nil {
 		blockOverrides.Apply(&blockCtx)
 	}
+	precompiles := vm.PrecompiledContractsForConfig(b.ChainConfig(), blockCtx.BlockNumber, &blockCtx.Time)
+	if err := overrides.Apply(state, precompiles); err != nil {
+		return nil, err
+	}
 	msg, err := args.ToMessage(globalGasCap, blockCtx.BaseFee)
 	if err != nil {
 		return nil, err
 	}
 	evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
+	evm.SetPrecompiles(precompiles)
 
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -1113,7 +1191,7 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 
 	// Execute the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	result, err := core.ApplyMessage(evm, msg, gp)
+	result, err = core.ApplyMessage(evm, msg, gp)
 	if err := state.Error(); err != nil {
 		return nil, err
 	}
@@ -1161,17 +1239,58 @@ func (s *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockNrO
 	return result.Return(), result.Err
 }
 
+// SimulateV1 executes series of transactions on top of a base state.
+// The transactions are packed into blocks. For each block, block header
+// fields can be overridden. The state can also be overridden prior to
+// execution of each block.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to execute and retrieve values.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]*simBlockResult, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &clientLimitExceededError{message: "too many blocks"}
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	state, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gasCap := s.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64
+	}
+	sim := &simulator{
+		b:              s.b,
+		state:          state,
+		base:           base,
+		chainConfig:    s.b.ChainConfig(),
+		gp:             new(core.GasPool).AddGas(gasCap),
+		traceTransfers: opts.TraceTransfers,
+		validate:       opts.Validation,
+		fullTx:         opts.ReturnFullTransactions,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
 // DoEstimateGas returns the lowest possible gas limit that allows the transaction to run
 // successfully at block `blockNrOrHash`. It returns error if the transaction would revert, or if
 // there are unexpected failures. The gas limit is capped by both `args.Gas` (if non-nil &
 // non-zero) and `gasCap` (if non-zero).
-func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, gasCap uint64) (hexutil.Uint64, error) {
+func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, gasCap uint64) (_ hexutil.Uint64, err error) {
+	ctx, span := tracing.Start(ctx, "ethapi.estimateGas")
+	defer func() { tracing.End(span, err) }()
+
 	// Retrieve the base state and mutate it with any overrides
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return 0, err
 	}
-	if err = overrides.Apply(state); err != nil {
+	if err = overrides.Apply(state, nil); err != nil {
 		return 0, err
 	}
 	// Construct the gas estimator option from the user input
@@ -0,0 +1,133 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"math/big"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+)
+
+var (
+	// keccak256("Transfer(address,address,uint256)")
+	transferTopic = common.HexToHash("ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+	// transferAddress is the pseudo contract emitting ether transfer logs, as
+	// specified by ERC-7528.
+	transferAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+)
+
+// transferLog is an ether transfer along with the number of logs emitted by the
+// transaction before it, used to interleave it with the regular logs.
+type transferLog struct {
+	pos int
+	log *types.Log
+}
+
+// transferTracer records ether transfers as ERC-20 like Transfer logs. Transfers
+// include the transaction value, the value of nested calls and creations, and
+// self-destruct beneficiaries. Transfers within reverted call frames are dropped.
+type transferTracer struct {
+	logCount func() int      // Number of logs emitted by the transaction so far
+	frames   [][]transferLog // Transfers of the currently open call frames
+	logs     []transferLog   // Transfers of the successfully finished transaction
+}
+
+func newTransferTracer(logCount func() int) *transferTracer {
+	return &transferTracer{logCount: logCount}
+}
+
+// reset clears the tracer for the next transaction.
+func (t *transferTracer) reset() {
+	t.frames, t.logs = t.frames[:0], nil
+}
+
+// merge interleaves the recorded transfers with the given transaction logs.
+func (t *transferTracer) merge(logs []*types.Log) []*types.Log {
+	if len(t.logs) == 0 {
+		return logs
+	}
+	merged := make([]*types.Log, 0, len(logs)+len(t.logs))
+	next := 0
+	for _, transfer := range t.logs {
+		for next < transfer.pos && next < len(logs) {
+			merged = append(merged, logs[next])
+			next++
+		}
+		merged = append(merged, transfer.log)
+	}
+	return append(merged, logs[next:]...)
+}
+
+func (t *transferTracer) enter(from, to common.Address, value *big.Int) {
+	var frame []transferLog
+	if value != nil && value.Sign() > 0 {
+		frame = append(frame, transferLog{
+			pos: t.logCount(),
+			log: &types.Log{
+				Address: transferAddress,
+				Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+				Data:    common.BigToHash(value).Bytes(),
+			},
+		})
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *transferTracer) exit(err error) {
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil {
+		return
+	}
+	if len(t.frames) == 0 {
+		t.logs = append(t.logs, frame...)
+		return
+	}
+	t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], frame...)
+}
+
+func (t *transferTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *transferTracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *transferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.enter(from, to, value)
+}
+
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+func (t *transferTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if typ == vm.DELEGATECALL || typ == vm.CALLCODE {
+		value = nil // Value stays with the caller, nothing is transferred
+	}
+	t.enter(from, to, value)
+}
+
+func (t *transferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit(err)
+}
+
+func (t *transferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *transferTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
@@ -0,0 +1,520 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/consensus"
+	"github.com/shudolab/core-geth/consensus/misc/eip1559"
+	"github.com/shudolab/core-geth/consensus/misc/eip4844"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+	"github.com/shudolab/core-geth/params/types/ctypes"
+	"github.com/shudolab/core-geth/params/vars"
+	"github.com/shudolab/core-geth/rpc"
+	"github.com/shudolab/core-geth/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request, including the gaps filled in between them.
+	maxSimulateBlocks = 256
+
+	// timestampIncrement is the default increment between block timestamps.
+	timestampIncrement = 12
+)
+
+// simBlock is a batch of calls to be simulated sequentially in a block.
+type simBlock struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides *StateOverride
+	Calls          []TransactionArgs
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+func (r *simCallResult) MarshalJSON() ([]byte, error) {
+	type callResultAlias simCallResult
+	// Marshal logs to be an empty array instead of nil when empty
+	if r.Logs == nil {
+		r.Logs = []*types.Log{}
+	}
+	return json.Marshal((*callResultAlias)(r))
+}
+
+// simOpts are the inputs to eth_simulateV1.
+//
+// If Validation is false, calls are executed like eth_call: the nonce and the
+// fee of calls are not checked, and the base fee of blocks defaults to zero.
+// Calls are never signed, so senders are never authenticated. If TraceTransfers
+// is set, ether transfers are reported as ERC-7528 logs.
+type simOpts struct {
+	BlockStateCalls        []simBlock
+	TraceTransfers         bool
+	Validation             bool
+	ReturnFullTransactions bool
+}
+
+// simBlockResult is the result of a simulated block.
+type simBlockResult struct {
+	fullTx      bool
+	chainConfig ctypes.ChainConfigurator
+	block       *types.Block
+	calls       []simCallResult
+	senders     map[common.Hash]common.Address // Senders of the unsigned transactions
+}
+
+func (r *simBlockResult) MarshalJSON() ([]byte, error) {
+	fields := RPCMarshalBlock(r.block, true, r.fullTx, r.chainConfig)
+	if r.fullTx {
+		for _, tx := range fields.Transactions {
+			if tx, ok := tx.(*RPCTransaction); ok {
+				tx.From = r.senders[tx.Hash]
+			}
+		}
+	}
+	enc, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(enc, &result); err != nil {
+		return nil, err
+	}
+	if result["calls"], err = json.Marshal(r.calls); err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// simulator is a stateful object that simulates a series of blocks.
+// It is not safe for concurrent use.
+type simulator struct {
+	b              Backend
+	state          *state.StateDB
+	base           *types.Header
+	chainConfig    ctypes.ChainConfigurator
+	gp             *core.GasPool
+	traceTransfers bool
+	validate       bool
+	fullTx         bool
+}
+
+// execute runs the simulation of a series of blocks.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]*simBlockResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var (
+		cancel  context.CancelFunc
+		timeout = sim.b.RPCEVMTimeout()
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	blocks, err := sim.sanitizeChain(blocks)
+	if err != nil {
+		return nil, err
+	}
+	// Prepare block headers with preliminary fields for the response.
+	headers, err := sim.makeHeaders(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]*simBlockResult, len(blocks))
+		parent  = sim.base
+	)
+	for bi, block := range blocks {
+		result, err := sim.processBlock(ctx, &block, headers[bi], parent, headers[:bi], timeout)
+		if err != nil {
+			return nil, err
+		}
+		headers[bi] = result.block.Header()
+		results[bi] = result
+		parent = headers[bi]
+	}
+	return results, nil
+}
+
+// processBlock executes the calls of a simulated block on top of the current
+// simulation state.
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header, parent *types.Header, headers []*types.Header, timeout time.Duration) (*simBlockResult, error) {
+	config := sim.chainConfig
+
+	// Set header fields that depend only on the parent block. The parent hash
+	// is needed for BLOCKHASH to resolve the simulated blocks.
+	header.ParentHash = parent.Hash()
+	if config.IsEnabled(config.GetEIP1559Transition, header.Number) && header.BaseFee == nil {
+		// Without validation, the base fee defaults to zero so that calls with
+		// zero gas price don't fail.
+		if sim.validate {
+			header.BaseFee = eip1559.CalcBaseFee(config, parent)
+		} else {
+			header.BaseFee = new(big.Int)
+		}
+	}
+	cancun := config.IsEnabledByTime(config.GetEIP4844TransitionTime, &header.Time) || config.IsEnabled(config.GetEIP4844Transition, header.Number)
+	if cancun {
+		var excessBlobGas uint64
+		if parent.ExcessBlobGas != nil && parent.BlobGasUsed != nil {
+			excessBlobGas = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		} else {
+			excessBlobGas = eip4844.CalcExcessBlobGas(0, 0)
+		}
+		header.ExcessBlobGas = &excessBlobGas
+	}
+	blockCtx := core.NewEVMBlockContext(header, sim.newChainContext(ctx, headers), nil)
+	if block.BlockOverrides != nil && block.BlockOverrides.BlobBaseFee != nil {
+		blockCtx.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
+	} else if cancun && !sim.validate {
+		blockCtx.BlobBaseFee = new(big.Int)
+	}
+	// State overrides are applied prior to the execution of the block, moving
+	// precompiles for this block only.
+	precompiles := vm.PrecompiledContractsForConfig(config, header.Number, &header.Time)
+	if err := block.StateOverrides.Apply(sim.state, precompiles); err != nil {
+		return nil, err
+	}
+	var (
+		eip161d  = config.IsEnabled(config.GetEIP161dTransition, header.Number)
+		byzantum = config.IsEnabled(config.GetEIP658Transition, header.Number)
+
+		gasUsed, blobGasUsed uint64
+		txs                  = make([]*types.Transaction, len(block.Calls))
+		receipts             = make([]*types.Receipt, len(block.Calls))
+		results              = make([]simCallResult, len(block.Calls))
+		senders              = make(map[common.Hash]common.Address)
+
+		txHash   common.Hash
+		txLogs   int // Number of logs of earlier calls with the same hash
+		tracer   = newTransferTracer(func() int { return len(sim.state.GetLogs(txHash, 0, common.Hash{})) - txLogs })
+		vmConfig = vm.Config{NoBaseFee: !sim.validate}
+	)
+	if sim.traceTransfers {
+		vmConfig.Tracer = tracer
+	}
+	evm := vm.NewEVM(blockCtx, vm.TxContext{GasPrice: new(big.Int)}, sim.state, config, vmConfig)
+	evm.SetPrecompiles(precompiles)
+
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	if header.ParentBeaconRoot != nil {
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm, sim.state)
+	}
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := sim.sanitizeCall(&call, header, &gasUsed); err != nil {
+			return nil, err
+		}
+		tx := call.toTransaction()
+		txs[i], txHash = tx, tx.Hash()
+		senders[txHash] = call.from()
+
+		txLogs = len(sim.state.GetLogs(txHash, 0, common.Hash{}))
+		tracer.reset()
+		sim.state.SetTxContext(txHash, i)
+
+		msg, err := call.ToMessage(0, header.BaseFee)
+		if err != nil {
+			return nil, err
+		}
+		msg.Nonce = uint64(*call.Nonce)
+		msg.SkipAccountChecks = !sim.validate
+		msg.SkipFromEOACheck = true
+
+		evm.Reset(core.NewEVMTxContext(msg), sim.state)
+		result, err := core.ApplyMessage(evm, msg, sim.gp)
+		if evm.Cancelled() {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		if err != nil {
+			return nil, txValidationError(err)
+		}
+		if err := sim.state.Error(); err != nil {
+			return nil, err
+		}
+		// Update the state with pending changes.
+		var root []byte
+		if byzantum {
+			sim.state.Finalise(eip161d)
+		} else {
+			root = sim.state.IntermediateRoot(eip161d).Bytes()
+		}
+		gasUsed += result.UsedGas
+
+		logs := sim.state.GetLogs(txHash, header.Number.Uint64(), common.Hash{})[txLogs:]
+		receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: gasUsed, TxHash: txHash, GasUsed: result.UsedGas, Logs: logs}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+		}
+		if tx.Type() == types.BlobTxType {
+			receipt.BlobGasUsed = uint64(len(tx.BlobHashes()) * vars.BlobTxBlobGasPerBlob)
+			blobGasUsed += receipt.BlobGasUsed
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts[i] = receipt
+
+		if sim.traceTransfers {
+			logs = tracer.merge(logs)
+		}
+		res := simCallResult{ReturnValue: result.Return(), Logs: make([]*types.Log, len(logs)), GasUsed: hexutil.Uint64(result.UsedGas), Status: hexutil.Uint64(receipt.Status)}
+		for j, log := range logs {
+			cpy := *log
+			cpy.BlockNumber, cpy.TxHash, cpy.TxIndex = header.Number.Uint64(), txHash, uint(i)
+			res.Logs[j] = &cpy
+		}
+		if result.Failed() {
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				revertErr := newRevertError(result.Revert())
+				res.Error = &callError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.reason}
+			} else {
+				res.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		}
+		results[i] = res
+	}
+	header.Root = sim.state.IntermediateRoot(eip161d)
+	header.GasUsed = gasUsed
+	if cancun {
+		header.BlobGasUsed = &blobGasUsed
+	}
+	var b *types.Block
+	if header.WithdrawalsHash != nil {
+		b = types.NewBlockWithWithdrawals(header, txs, nil, receipts, []*types.Withdrawal{}, trie.NewStackTrie(nil))
+	} else {
+		b = types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	}
+	// Fill in the log fields which depend on the final block
+	var index uint
+	for _, res := range results {
+		for _, log := range res.Logs {
+			log.BlockHash, log.Index = b.Hash(), index
+			index++
+		}
+	}
+	return &simBlockResult{fullTx: sim.fullTx, chainConfig: config, block: b, calls: results, senders: senders}, nil
+}
+
+// sanitizeCall fills in the defaults of a simulated call and checks it against
+// the remaining gas of the block.
+func (sim *simulator) sanitizeCall(call *TransactionArgs, header *types.Header, gasUsed *uint64) error {
+	if call.Data != nil && call.Input != nil && !bytes.Equal(*call.Data, *call.Input) {
+		return &invalidParamsError{message: `both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`}
+	}
+	if call.Nonce == nil {
+		nonce := sim.state.GetNonce(call.from())
+		call.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	// Let the call run wild unless explicitly specified.
+	remaining := header.GasLimit - *gasUsed
+	if call.Gas == nil {
+		gas := min(remaining, sim.gp.Gas())
+		call.Gas = (*hexutil.Uint64)(&gas)
+	}
+	if uint64(*call.Gas) > remaining {
+		return &blockGasLimitReachedError{fmt.Sprintf("block gas limit reached: %d >= %d", *gasUsed+uint64(*call.Gas), header.GasLimit)}
+	}
+	if call.Value == nil {
+		call.Value = new(hexutil.Big)
+	}
+	if call.ChainID == nil {
+		call.ChainID = (*hexutil.Big)(sim.chainConfig.GetChainID())
+	}
+	if call.GasPrice != nil && (call.MaxFeePerGas != nil || call.MaxPriorityFeePerGas != nil) {
+		return &invalidParamsError{message: "both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified"}
+	}
+	if call.GasPrice == nil {
+		if header.BaseFee != nil || call.MaxFeePerGas != nil || call.MaxPriorityFeePerGas != nil {
+			if call.MaxFeePerGas == nil {
+				call.MaxFeePerGas = new(hexutil.Big)
+			}
+			if call.MaxPriorityFeePerGas == nil {
+				call.MaxPriorityFeePerGas = new(hexutil.Big)
+			}
+		} else {
+			call.GasPrice = new(hexutil.Big)
+		}
+	}
+	if call.BlobHashes != nil {
+		if call.To == nil {
+			return &invalidParamsError{message: `missing "to" in blob transaction`}
+		}
+		if call.BlobFeeCap == nil {
+			call.BlobFeeCap = new(hexutil.Big)
+		}
+	}
+	return nil
+}
+
+// sanitizeChain checks the chain integrity. Specifically it checks that
+// block numbers and timestamp are strictly increasing, setting default values
+// when necessary. Gaps in block numbers are filled with empty blocks.
+// Note: It modifies the block's override object.
+func (sim *simulator) sanitizeChain(blocks []simBlock) ([]simBlock, error) {
+	var (
+		res           = make([]simBlock, 0, len(blocks))
+		base          = sim.base
+		prevNumber    = base.Number
+		prevTimestamp = base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(BlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			n := new(big.Int).Add(prevNumber, big.NewInt(1))
+			block.BlockOverrides.Number = (*hexutil.Big)(n)
+		}
+		number := block.BlockOverrides.Number.ToInt()
+		diff := new(big.Int).Sub(number, prevNumber)
+		if diff.Sign() <= 0 {
+			return nil, &invalidBlockNumberError{fmt.Sprintf("block numbers must be in order: %d <= %d", number, prevNumber)}
+		}
+		if total := new(big.Int).Sub(number, base.Number); total.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, &clientLimitExceededError{message: "too many blocks"}
+		}
+		// Fill the gap with empty blocks.
+		for gap := diff.Uint64() - 1; gap > 0; gap-- {
+			n := new(big.Int).Add(prevNumber, common.Big1)
+			t := prevTimestamp + timestampIncrement
+			res = append(res, simBlock{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(n), Time: (*hexutil.Uint64)(&t)}})
+			prevNumber, prevTimestamp = n, t
+		}
+		// Only append block after filling a potential gap.
+		var t uint64
+		if block.BlockOverrides.Time == nil {
+			t = prevTimestamp + timestampIncrement
+			block.BlockOverrides.Time = (*hexutil.Uint64)(&t)
+		} else {
+			t = uint64(*block.BlockOverrides.Time)
+			if t <= prevTimestamp {
+				return nil, &invalidBlockTimestampError{fmt.Sprintf("block timestamps must be in order: %d <= %d", t, prevTimestamp)}
+			}
+		}
+		prevNumber, prevTimestamp = number, t
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeaders makes header object with preliminary fields based on a simulated
+// block. Some fields have to be filled in after execution, e.g. the gas used.
+// The fork rules active at each simulated height decide which fields are set.
+func (sim *simulator) makeHeaders(blocks []simBlock) ([]*types.Header, error) {
+	var (
+		config = sim.chainConfig
+		res    = make([]*types.Header, len(blocks))
+		header = sim.base
+	)
+	for bi, block := range blocks {
+		overrides := block.BlockOverrides
+		if overrides == nil || overrides.Number == nil || overrides.Time == nil {
+			return nil, errors.New("empty block number or time")
+		}
+		var (
+			number = overrides.Number.ToInt()
+			time   = uint64(*overrides.Time)
+		)
+		var withdrawalsHash *common.Hash
+		if config.IsEnabledByTime(config.GetEIP4895TransitionTime, &time) || config.IsEnabled(config.GetEIP4895Transition, number) {
+			withdrawalsHash = &types.EmptyWithdrawalsHash
+		}
+		var parentBeaconRoot *common.Hash
+		if config.IsEnabledByTime(config.GetEIP4788TransitionTime, &time) || config.IsEnabled(config.GetEIP4788Transition, number) {
+			parentBeaconRoot = &common.Hash{}
+		}
+		header = overrides.MakeHeader(&types.Header{
+			UncleHash:        types.EmptyUncleHash,
+			ReceiptHash:      types.EmptyReceiptsHash,
+			TxHash:           types.EmptyTxsHash,
+			Coinbase:         header.Coinbase,
+			Difficulty:       header.Difficulty,
+			GasLimit:         header.GasLimit,
+			WithdrawalsHash:  withdrawalsHash,
+			ParentBeaconRoot: parentBeaconRoot,
+		})
+		res[bi] = header
+	}
+	return res, nil
+}
+
+// newChainContext returns a chain context resolving both the canonical headers
+// preceding the simulation and the already simulated ones.
+func (sim *simulator) newChainContext(ctx context.Context, headers []*types.Header) *ChainContext {
+	return NewChainContext(ctx, &simBackend{base: sim.base, b: sim.b, headers: headers})
+}
+
+// simBackend is a ChainContextBackend aware of the simulated headers.
+type simBackend struct {
+	b       ChainContextBackend
+	base    *types.Header
+	headers []*types.Header
+}
+
+func (b *simBackend) Engine() consensus.Engine {
+	return b.b.Engine()
+}
+
+func (b *simBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if uint64(number) == b.base.Number.Uint64() {
+		return b.base, nil
+	}
+	if uint64(number) < b.base.Number.Uint64() {
+		// Resolve canonical header.
+		return b.b.HeaderByNumber(ctx, number)
+	}
+	// Simulated block.
+	for _, header := range b.headers {
+		if header.Number.Uint64() == uint64(number) {
+			return header, nil
+		}
+	}
+	return nil, errors.New("header not found")
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/consensus/ethash"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/params"
+	"github.com/shudolab/core-geth/params/types/genesisT"
+	"github.com/shudolab/core-geth/params/vars"
+	"github.com/shudolab/core-geth/rpc"
+)
+
+// newSimulateTestAPI creates a chain of 10 blocks with London activating at
+// block 12, past the head of the chain.
+func newSimulateTestAPI(t *testing.T, accounts []account) *BlockChainAPI {
+	config := *params.TestChainConfig
+	config.LondonBlock = big.NewInt(12)
+	config.ArrowGlacierBlock = nil
+	config.GrayGlacierBlock = nil
+
+	genesis := &genesisT.Genesis{
+		Config: &config,
+		Alloc:  genesisT.GenesisAlloc{},
+	}
+	for _, acc := range accounts {
+		genesis.Alloc[acc.addr] = genesisT.GenesisAccount{Balance: big.NewInt(vars.Ether)}
+	}
+	return NewBlockChainAPI(newTestBackend(t, 10, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {}))
+}
+
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		api      = newSimulateTestAPI(t, accounts)
+		contract = common.HexToAddress("0xc0de")
+		moved    = common.HexToAddress("0x1234")
+		identity = common.BytesToAddress([]byte{0x04})
+		latest   = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		number   = hexutil.Big(*big.NewInt(13))
+	)
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		TraceTransfers: true,
+		BlockStateCalls: []simBlock{
+			{
+				// Transfer ether and move the identity precompile.
+				StateOverrides: &StateOverride{identity: {MovePrecompileTo: &moved}},
+				Calls: []TransactionArgs{
+					{From: &accounts[0].addr, To: &accounts[1].addr, Value: (*hexutil.Big)(big.NewInt(1000))},
+					{From: &accounts[0].addr, To: &moved, Input: hex2Bytes("deadbeef")},
+					{From: &accounts[0].addr, To: &identity, Input: hex2Bytes("deadbeef")},
+				},
+			},
+			{
+				// Skip a block, returning the block number.
+				BlockOverrides: &BlockOverrides{Number: &number},
+				StateOverrides: &StateOverride{contract: {Code: hex2Bytes("4360005260206000f3")}},
+				Calls: []TransactionArgs{
+					{From: &accounts[1].addr, To: &contract},
+					{From: &accounts[1].addr, To: &moved, Input: hex2Bytes("deadbeef")},
+				},
+			},
+		},
+	}, &latest)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("have %d blocks, want 3", len(results))
+	}
+	// Check the headers follow the chain and the fork rules.
+	for i, res := range results {
+		header := res.block.Header()
+		if want := uint64(11 + i); header.Number.Uint64() != want {
+			t.Errorf("block %d: have number %d, want %d", i, header.Number, want)
+		}
+		if i > 0 {
+			parent := results[i-1].block.Header()
+			if header.Time != parent.Time+timestampIncrement {
+				t.Errorf("block %d: have time %d, want %d", i, header.Time, parent.Time+timestampIncrement)
+			}
+			if header.ParentHash != parent.Hash() {
+				t.Errorf("block %d: parent hash mismatch", i)
+			}
+		}
+		if london := header.Number.Uint64() >= 12; london != (header.BaseFee != nil) {
+			t.Errorf("block %d: have base fee %v, london %v", i, header.BaseFee, london)
+		}
+	}
+	first, last := results[0].calls, results[2].calls
+	if len(first) != 3 || len(results[1].calls) != 0 || len(last) != 2 {
+		t.Fatalf("unexpected number of calls")
+	}
+	// The ether transfer is reported as an ERC-7528 log.
+	if len(first[0].Logs) != 1 {
+		t.Fatalf("have %d transfer logs, want 1", len(first[0].Logs))
+	}
+	if log := first[0].Logs[0]; log.Address != transferAddress || log.Topics[1] != common.BytesToHash(accounts[0].addr.Bytes()) ||
+		new(big.Int).SetBytes(log.Data).Int64() != 1000 || log.BlockHash != results[0].block.Hash() {
+		t.Errorf("unexpected transfer log %+v", log)
+	}
+	// The precompile only answers at its new address, within its block.
+	if have := first[1].ReturnValue.String(); have != "0xdeadbeef" {
+		t.Errorf("moved precompile: have %s, want 0xdeadbeef", have)
+	}
+	if have := first[2].ReturnValue.String(); have != "0x" {
+		t.Errorf("original precompile address: have %s, want 0x", have)
+	}
+	if have := last[1].ReturnValue.String(); have != "0x" {
+		t.Errorf("precompile moved beyond its block: have %s", have)
+	}
+	if have := new(big.Int).SetBytes(last[0].ReturnValue).Uint64(); have != 13 {
+		t.Errorf("block number: have %d, want 13", have)
+	}
+	if _, err := json.Marshal(results); err != nil {
+		t.Errorf("failed to marshal results: %v", err)
+	}
+}
+
+func TestSimulateV1Errors(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(1)
+		api      = newSimulateTestAPI(t, accounts)
+		latest   = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		nonce    = hexutil.Uint64(100)
+		past     = hexutil.Big(*big.NewInt(5))
+	)
+	tests := []struct {
+		opts simOpts
+		code int
+	}{
+		{
+			opts: simOpts{},
+			code: errCodeInvalidParams,
+		},
+		{
+			opts: simOpts{
+				Validation:      true,
+				BlockStateCalls: []simBlock{{Calls: []TransactionArgs{{From: &accounts[0].addr, To: &accounts[0].addr, Nonce: &nonce}}}},
+			},
+			code: errCodeNonceTooHigh,
+		},
+		{
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{BlockOverrides: &BlockOverrides{Number: &past}}},
+			},
+			code: errCodeBlockNumberInvalid,
+		},
+	}
+	for i, test := range tests {
+		_, err := api.SimulateV1(context.Background(), test.opts, &latest)
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) {
+			t.Errorf("test %d: have error %v, want code %d", i, err, test.code)
+			continue
+		}
+		if rpcErr.ErrorCode() != test.code {
+			t.Errorf("test %d: have code %d, want %d (%v)", i, rpcErr.ErrorCode(), test.code, err)
+		}
+	}
+}
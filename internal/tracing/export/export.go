@@ -0,0 +1,131 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package export sets up the span exporters of package tracing.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/shudolab/core-geth/internal/tracing"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Config contains the settings of the span exporters.
+type Config struct {
+	// Endpoint is the URL of an OTLP/HTTP collector, e.g. http://localhost:4318.
+	Endpoint string
+
+	// File receives the spans as JSON lines, "stdout" writes to the standard output.
+	File string
+
+	// SampleRatio is the fraction of root spans recorded. Child spans follow the
+	// decision of their parent, including remote parents.
+	SampleRatio float64
+
+	// ServiceName and ServiceVersion describe the process in the exported spans.
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup installs the exporters of the config and enables tracing. The returned
+// function flushes pending spans and shuts the exporters down.
+func Setup(config Config) (func(context.Context) error, error) {
+	if config.Endpoint == "" && config.File == "" {
+		return nil, errors.New("no span exporter configured")
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v", config.SampleRatio)
+	}
+	var (
+		opts    []sdktrace.TracerProviderOption
+		closers []io.Closer
+	)
+	if config.Endpoint != "" {
+		exp, err := newOTLPExporter(config.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	if config.File != "" {
+		out := io.Writer(os.Stdout)
+		if config.File != "stdout" {
+			f, err := os.OpenFile(config.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				return nil, err
+			}
+			out = f
+			closers = append(closers, f)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+	)
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	provider := sdktrace.NewTracerProvider(opts...)
+	tracing.Enable(provider)
+
+	shutdown := func(ctx context.Context) error {
+		tracing.Disable()
+		err := provider.Shutdown(ctx)
+		for _, c := range closers {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	return shutdown, nil
+}
+
+// newOTLPExporter creates an exporter sending spans to an OTLP/HTTP collector.
+func newOTLPExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %v", err)
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		opts = append(opts, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Path != "" && u.Path != "/" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	return otlptracehttp.New(context.Background(), opts...)
+}
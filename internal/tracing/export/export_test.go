@@ -0,0 +1,75 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shudolab/core-geth/internal/tracing"
+)
+
+func TestSetupFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := Setup(Config{File: file, SampleRatio: 1, ServiceName: "geth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	_, child := tracing.Start(ctx, "child")
+	child.End()
+	parent.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if tracing.Enabled() {
+		t.Fatal("tracing still enabled after shutdown")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var span struct{ Name string }
+		if err := json.Unmarshal(scanner.Bytes(), &span); err != nil {
+			t.Fatalf("invalid span line: %v", err)
+		}
+		names = append(names, span.Name)
+	}
+	if len(names) != 2 || names[0] != "child" || names[1] != "parent" {
+		t.Fatalf("wrong spans exported: %v", names)
+	}
+}
+
+func TestSetupInvalid(t *testing.T) {
+	for _, config := range []Config{
+		{},
+		{File: "stdout", SampleRatio: 2},
+		{Endpoint: "tcp://localhost:4318"},
+	} {
+		if _, err := Setup(config); err == nil {
+			t.Errorf("no error for config %+v", config)
+		}
+	}
+}
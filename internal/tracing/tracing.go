@@ -0,0 +1,101 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing provides optional OpenTelemetry instrumentation.
+//
+// Spans are only recorded after Enable has installed a tracer provider, see package
+// export for the exporters. Until then Start returns a no-op span without
+// allocating, so instrumented hot paths like block import don't pay for tracing
+// when it is disabled.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer of this module.
+const instrumentationName = "github.com/shudolab/core-geth"
+
+var (
+	enabled atomic.Bool
+
+	// tracer is resolved through the global provider, which delegates to the
+	// provider installed by Enable.
+	tracer = otel.Tracer(instrumentationName)
+
+	// propagator reads and writes W3C trace-context headers.
+	propagator = propagation.TraceContext{}
+)
+
+// Enable installs the tracer provider receiving the spans.
+func Enable(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	enabled.Store(true)
+}
+
+// Disable stops recording spans.
+func Disable() {
+	enabled.Store(false)
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start creates a span as a child of the span in ctx. When tracing is disabled, the
+// returned span is a no-op.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Extract returns a context carrying the remote span given in the W3C trace-context
+// headers of a request, making it the parent of spans started with the context.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if !enabled.Load() {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject adds the W3C trace-context headers of the span in ctx to a request.
+func Inject(ctx context.Context, header http.Header) {
+	if !enabled.Load() {
+		return
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
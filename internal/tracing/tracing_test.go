@@ -0,0 +1,91 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// enableTest records the spans in memory until the test ends.
+func enableTest(t *testing.T) *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	Enable(provider)
+	t.Cleanup(func() {
+		Disable()
+		provider.Shutdown(context.Background())
+	})
+	return exp
+}
+
+func TestDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "test")
+	if span.IsRecording() {
+		t.Fatal("span recorded while tracing is disabled")
+	}
+	if trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		t.Fatal("context carries a span while tracing is disabled")
+	}
+	End(span, errors.New("ignored"))
+}
+
+func TestPropagation(t *testing.T) {
+	exp := enableTest(t)
+
+	const traceparent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	header := make(http.Header)
+	header.Set("traceparent", traceparent)
+	ctx := Extract(context.Background(), header)
+
+	ctx, parent := Start(ctx, "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("failed"))
+	End(parent, nil)
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("wrong number of spans: %d", len(spans))
+	}
+	childStub, parentStub := spans[0], spans[1]
+	if id := parentStub.SpanContext.TraceID().String(); id != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("remote trace not continued, trace ID %s", id)
+	}
+	if parentStub.Parent.SpanID().String() != "b7ad6b7169203331" || !parentStub.Parent.IsRemote() {
+		t.Errorf("wrong parent %v", parentStub.Parent)
+	}
+	if childStub.Parent.SpanID() != parentStub.SpanContext.SpanID() {
+		t.Errorf("child not linked to parent")
+	}
+	if childStub.Status.Code != codes.Error || childStub.Status.Description != "failed" {
+		t.Errorf("wrong child status %v", childStub.Status)
+	}
+
+	// Injecting the context of the parent forwards its span.
+	out := make(http.Header)
+	Inject(trace.ContextWithSpanContext(context.Background(), parentStub.SpanContext), out)
+	if want := "00-0af7651916cd43dd8448eb211c80319c-" + parentStub.SpanContext.SpanID().String() + "-01"; out.Get("traceparent") != want {
+		t.Errorf("wrong injected header %q, want %q", out.Get("traceparent"), want)
+	}
+}
@@ -70,23 +70,48 @@ web3._extend({
 			call: 'clique_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'proposeBatch',
+			call: 'clique_proposeBatch',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'status',
 			call: 'clique_status',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'statusWindow',
+			call: 'clique_status',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getSigner',
 			call: 'clique_getSigner',
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'exportCheckpoint',
+			call: 'clique_exportCheckpoint',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'importCheckpoint',
+			call: 'clique_importCheckpoint',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'proposals',
 			getter: 'clique_proposals'
 		}),
+		new web3._extend.Property({
+			name: 'proposalDetails',
+			getter: 'clique_getProposals'
+		}),
 	]
 });
 `
@@ -143,6 +168,25 @@ web3._extend({
 			call: 'admin_removeTrustedPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listBans',
+			call: 'admin_listBans'
+		}),
+		new web3._extend.Method({
+			name: 'chainSplits',
+			call: 'admin_chainSplits'
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -201,6 +245,10 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'reloadRPCPolicy',
+			call: 'admin_reloadRPCPolicy'
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -628,6 +676,12 @@ web3._extend({
 			params: 4,
 			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null],
 		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter],
+		}),
 		new web3._extend.Method({
 			name: 'getBlockReceipts',
 			call: 'eth_getBlockReceipts',
@@ -748,6 +802,11 @@ web3._extend({
 			call: 'personal_deriveAccount',
 			params: 3
 		}),
+		new web3._extend.Method({
+			name: 'deriveAccountForNetwork',
+			call: 'personal_deriveAccount',
+			params: 4
+		}),
 		new web3._extend.Method({
 			name: 'signTransaction',
 			call: 'personal_signTransaction',
@@ -186,28 +186,34 @@ func (exp *exp) publishResettingTimer(name string, metric metrics.ResettingTimer
 }
 
 func (exp *exp) syncToExpvar() {
-	exp.registry.Each(func(name string, i interface{}) {
-		switch i := i.(type) {
-		case metrics.Counter:
-			exp.publishCounter(name, i.Snapshot())
-		case metrics.CounterFloat64:
-			exp.publishCounterFloat64(name, i.Snapshot())
-		case metrics.Gauge:
-			exp.publishGauge(name, i.Snapshot())
-		case metrics.GaugeFloat64:
-			exp.publishGaugeFloat64(name, i.Snapshot())
-		case metrics.GaugeInfo:
-			exp.publishGaugeInfo(name, i.Snapshot())
-		case metrics.Histogram:
-			exp.publishHistogram(name, i)
-		case metrics.Meter:
-			exp.publishMeter(name, i)
-		case metrics.Timer:
-			exp.publishTimer(name, i)
-		case metrics.ResettingTimer:
-			exp.publishResettingTimer(name, i)
-		default:
-			panic(fmt.Sprintf("unsupported type for '%s': %T", name, i))
-		}
-	})
+	exp.registry.Each(exp.publish)
+}
+
+func (exp *exp) publish(name string, i interface{}) {
+	switch i := i.(type) {
+	case metrics.Counter:
+		exp.publishCounter(name, i.Snapshot())
+	case metrics.CounterFloat64:
+		exp.publishCounterFloat64(name, i.Snapshot())
+	case metrics.Gauge:
+		exp.publishGauge(name, i.Snapshot())
+	case metrics.GaugeFloat64:
+		exp.publishGaugeFloat64(name, i.Snapshot())
+	case metrics.GaugeInfo:
+		exp.publishGaugeInfo(name, i.Snapshot())
+	case metrics.Histogram:
+		exp.publishHistogram(name, i)
+	case metrics.Meter:
+		exp.publishMeter(name, i)
+	case metrics.Timer:
+		exp.publishTimer(name, i)
+	case metrics.ResettingTimer:
+		exp.publishResettingTimer(name, i)
+	case metrics.Vec:
+		i.Each(func(labels []metrics.Label, metric interface{}) {
+			exp.publish(metrics.VecMemberName(name, labels), metric)
+		})
+	default:
+		panic(fmt.Sprintf("unsupported type for '%s': %T", name, i))
+	}
 }
@@ -0,0 +1,65 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package exp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/shudolab/core-geth/metrics"
+)
+
+func TestMain(m *testing.M) {
+	metrics.Enabled = true
+	os.Exit(m.Run())
+}
+
+// Tests that the members of metric vectors are published as expvars of their
+// own, named by the vector name followed by the label values.
+func TestExpHandlerVec(t *testing.T) {
+	r := metrics.NewRegistry()
+	counters := metrics.NewRegisteredCounterVec("test/counter_vec", r, "protocol", "code")
+	counters.WithLabelValues("eth", "0x01").Inc(3)
+	counters.WithLabelValues("snap", "0x02").Inc(5)
+	histograms := metrics.NewRegisteredHistogramVec("test/histogram_vec", r, func() metrics.Sample { return metrics.NewUniformSample(10) }, "method")
+	histograms.WithLabelValues("eth_call").Update(7)
+
+	rec := httptest.NewRecorder()
+	ExpHandler(r).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/metrics", nil))
+
+	var vars map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	for name, want := range map[string]float64{
+		"test/counter_vec/eth/0x01":         3,
+		"test/counter_vec/snap/0x02":        5,
+		"test/histogram_vec/eth_call.count": 1,
+		"test/histogram_vec/eth_call.max":   7,
+	} {
+		have, ok := vars[name]
+		if !ok {
+			t.Errorf("missing expvar %q", name)
+			continue
+		}
+		if have != want {
+			t.Errorf("expvar %q mismatch: have %v, want %v", name, have, want)
+		}
+	}
+}
@@ -6,6 +6,38 @@ import (
 	"github.com/shudolab/core-geth/metrics"
 )
 
+// readMeters calls fn for every measurement produced by the metric i. Plain
+// metrics yield a single measurement, metric vectors one per member along with
+// the member's labels.
+func readMeters(namespace, name string, i interface{}, fn func(measurement string, fields map[string]interface{}, labels []metrics.Label)) {
+	if v, ok := i.(metrics.Vec); ok {
+		v.Each(func(labels []metrics.Label, m interface{}) {
+			if measurement, fields := readMeter(namespace, name, m); fields != nil {
+				fn(measurement, fields, labels)
+			}
+		})
+		return
+	}
+	if measurement, fields := readMeter(namespace, name, i); fields != nil {
+		fn(measurement, fields, nil)
+	}
+}
+
+// withLabels returns the reporter tags extended with the given metric labels.
+func withLabels(tags map[string]string, labels []metrics.Label) map[string]string {
+	if len(labels) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(tags)+len(labels))
+	for k, v := range tags {
+		merged[k] = v
+	}
+	for _, l := range labels {
+		merged[l.Name] = l.Value
+	}
+	return merged
+}
+
 func readMeter(namespace, name string, i interface{}) (string, map[string]interface{}) {
 	switch metric := i.(type) {
 	case metrics.Counter:
@@ -84,12 +84,10 @@ func (r *v2Reporter) send(tstamp int64) {
 		} else {
 			now = time.Unix(tstamp, 0)
 		}
-		measurement, fields := readMeter(r.namespace, name, i)
-		if fields == nil {
-			return
-		}
-		pt := influxdb2.NewPoint(measurement, r.tags, fields, now)
-		r.write.WritePoint(pt)
+		readMeters(r.namespace, name, i, func(measurement string, fields map[string]interface{}, labels []metrics.Label) {
+			pt := influxdb2.NewPoint(measurement, withLabels(r.tags, labels), fields, now)
+			r.write.WritePoint(pt)
+		})
 	})
 	// Force all unwritten data to be sent
 	r.write.Flush()
@@ -26,3 +26,14 @@ func TestRegistryWriteJSONOnce(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRegistryMarshallJSONVec(t *testing.T) {
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	r := NewRegistry()
+	NewRegisteredCounterVec("counter", r, "code").WithLabelValues("0x01").Inc(2)
+	enc.Encode(r)
+	if s := b.String(); s != "{\"counter/0x01\":{\"count\":2}}\n" {
+		t.Fatalf(s)
+	}
+}
@@ -32,6 +32,7 @@ var (
 	typeSummaryTpl         = "# TYPE %s summary\n"
 	keyValueTpl            = "%s %v\n\n"
 	keyQuantileTagValueTpl = "%s {quantile=\"%s\"} %v\n"
+	keyLabelsValueTpl      = "%s {%s} %v\n"
 )
 
 // collector is a collection of byte buffers that aggregate Prometheus reports
@@ -69,6 +70,8 @@ func (c *collector) Add(name string, i any) error {
 		c.addTimer(name, m.Snapshot())
 	case metrics.ResettingTimer:
 		c.addResettingTimer(name, m.Snapshot())
+	case metrics.Vec:
+		c.addVec(name, m)
 	default:
 		return fmt.Errorf("unknown prometheus metric type %T", i)
 	}
@@ -134,6 +137,48 @@ func (c *collector) addResettingTimer(name string, m metrics.ResettingTimerSnaps
 	c.buff.WriteRune('\n')
 }
 
+// addVec writes out every member of a labelled metric vector under a single
+// metric family. Counters and gauges are reported as gauges, histograms as
+// summaries with the quantile label appended to the member's labels.
+func (c *collector) addVec(name string, v metrics.Vec) {
+	var (
+		values    bytes.Buffer
+		counts    bytes.Buffer
+		quantiles bytes.Buffer
+		key       = mutateKey(name)
+		pv        = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+	)
+	v.Each(func(labels []metrics.Label, i interface{}) {
+		switch m := i.(type) {
+		case metrics.Counter:
+			values.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, formatLabels(labels), m.Snapshot().Count()))
+		case metrics.Gauge:
+			values.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, formatLabels(labels), m.Snapshot().Value()))
+		case metrics.Histogram:
+			ms := m.Snapshot()
+			ps := ms.Percentiles(pv)
+			counts.WriteString(fmt.Sprintf(keyLabelsValueTpl, key+"_count", formatLabels(labels), ms.Count()))
+			for i := range pv {
+				quantile := metrics.Label{Name: "quantile", Value: strconv.FormatFloat(pv[i], 'f', -1, 64)}
+				quantiles.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, formatLabels(append(labels, quantile)), ps[i]))
+			}
+		}
+	})
+	if values.Len() > 0 {
+		c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, key))
+		c.buff.Write(values.Bytes())
+		c.buff.WriteRune('\n')
+	}
+	if counts.Len() > 0 {
+		c.buff.WriteString(fmt.Sprintf(typeCounterTpl, key+"_count"))
+		c.buff.Write(counts.Bytes())
+		c.buff.WriteRune('\n')
+		c.buff.WriteString(fmt.Sprintf(typeSummaryTpl, key))
+		c.buff.Write(quantiles.Bytes())
+		c.buff.WriteRune('\n')
+	}
+}
+
 func (c *collector) writeGaugeInfo(name string, value metrics.GaugeInfoValue) {
 	name = mutateKey(name)
 	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
@@ -164,6 +209,16 @@ func (c *collector) writeSummaryPercentile(name, p string, value interface{}) {
 	c.buff.WriteString(fmt.Sprintf(keyQuantileTagValueTpl, name, p, value))
 }
 
+// formatLabels renders the labels in the order given, without the enclosing
+// braces.
+func formatLabels(labels []metrics.Label) string {
+	kvs := make([]string, len(labels))
+	for i, l := range labels {
+		kvs[i] = fmt.Sprintf("%v=%q", l.Name, l.Value)
+	}
+	return strings.Join(kvs, ", ")
+}
+
 func mutateKey(key string) string {
 	return strings.ReplaceAll(key, "/", "_")
 }
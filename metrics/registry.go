@@ -147,65 +147,77 @@ func (r *StandardRegistry) RunHealthchecks() {
 func (r *StandardRegistry) GetAll() map[string]map[string]interface{} {
 	data := make(map[string]map[string]interface{})
 	r.Each(func(name string, i interface{}) {
-		values := make(map[string]interface{})
-		switch metric := i.(type) {
-		case Counter:
-			values["count"] = metric.Snapshot().Count()
-		case CounterFloat64:
-			values["count"] = metric.Snapshot().Count()
-		case Gauge:
-			values["value"] = metric.Snapshot().Value()
-		case GaugeFloat64:
-			values["value"] = metric.Snapshot().Value()
-		case Healthcheck:
-			values["error"] = nil
-			metric.Check()
-			if err := metric.Error(); nil != err {
-				values["error"] = metric.Error().Error()
-			}
-		case Histogram:
-			h := metric.Snapshot()
-			ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			values["count"] = h.Count()
-			values["min"] = h.Min()
-			values["max"] = h.Max()
-			values["mean"] = h.Mean()
-			values["stddev"] = h.StdDev()
-			values["median"] = ps[0]
-			values["75%"] = ps[1]
-			values["95%"] = ps[2]
-			values["99%"] = ps[3]
-			values["99.9%"] = ps[4]
-		case Meter:
-			m := metric.Snapshot()
-			values["count"] = m.Count()
-			values["1m.rate"] = m.Rate1()
-			values["5m.rate"] = m.Rate5()
-			values["15m.rate"] = m.Rate15()
-			values["mean.rate"] = m.RateMean()
-		case Timer:
-			t := metric.Snapshot()
-			ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
-			values["count"] = t.Count()
-			values["min"] = t.Min()
-			values["max"] = t.Max()
-			values["mean"] = t.Mean()
-			values["stddev"] = t.StdDev()
-			values["median"] = ps[0]
-			values["75%"] = ps[1]
-			values["95%"] = ps[2]
-			values["99%"] = ps[3]
-			values["99.9%"] = ps[4]
-			values["1m.rate"] = t.Rate1()
-			values["5m.rate"] = t.Rate5()
-			values["15m.rate"] = t.Rate15()
-			values["mean.rate"] = t.RateMean()
+		if v, ok := i.(Vec); ok {
+			v.Each(func(labels []Label, metric interface{}) {
+				data[VecMemberName(name, labels)] = metricValues(metric)
+			})
+			return
 		}
-		data[name] = values
+		data[name] = metricValues(i)
 	})
 	return data
 }
 
+// metricValues returns the exported fields of a single metric, keyed by field
+// name.
+func metricValues(i interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	switch metric := i.(type) {
+	case Counter:
+		values["count"] = metric.Snapshot().Count()
+	case CounterFloat64:
+		values["count"] = metric.Snapshot().Count()
+	case Gauge:
+		values["value"] = metric.Snapshot().Value()
+	case GaugeFloat64:
+		values["value"] = metric.Snapshot().Value()
+	case Healthcheck:
+		values["error"] = nil
+		metric.Check()
+		if err := metric.Error(); nil != err {
+			values["error"] = metric.Error().Error()
+		}
+	case Histogram:
+		h := metric.Snapshot()
+		ps := h.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+		values["count"] = h.Count()
+		values["min"] = h.Min()
+		values["max"] = h.Max()
+		values["mean"] = h.Mean()
+		values["stddev"] = h.StdDev()
+		values["median"] = ps[0]
+		values["75%"] = ps[1]
+		values["95%"] = ps[2]
+		values["99%"] = ps[3]
+		values["99.9%"] = ps[4]
+	case Meter:
+		m := metric.Snapshot()
+		values["count"] = m.Count()
+		values["1m.rate"] = m.Rate1()
+		values["5m.rate"] = m.Rate5()
+		values["15m.rate"] = m.Rate15()
+		values["mean.rate"] = m.RateMean()
+	case Timer:
+		t := metric.Snapshot()
+		ps := t.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999})
+		values["count"] = t.Count()
+		values["min"] = t.Min()
+		values["max"] = t.Max()
+		values["mean"] = t.Mean()
+		values["stddev"] = t.StdDev()
+		values["median"] = ps[0]
+		values["75%"] = ps[1]
+		values["95%"] = ps[2]
+		values["99%"] = ps[3]
+		values["99.9%"] = ps[4]
+		values["1m.rate"] = t.Rate1()
+		values["5m.rate"] = t.Rate5()
+		values["15m.rate"] = t.Rate15()
+		values["mean.rate"] = t.RateMean()
+	}
+	return values
+}
+
 // Unregister the metric with the given name.
 func (r *StandardRegistry) Unregister(name string) {
 	r.stop(name)
@@ -214,7 +226,7 @@ func (r *StandardRegistry) Unregister(name string) {
 
 func (r *StandardRegistry) loadOrRegister(name string, i interface{}) (interface{}, bool, bool) {
 	switch i.(type) {
-	case Counter, CounterFloat64, Gauge, GaugeFloat64, GaugeInfo, Healthcheck, Histogram, Meter, Timer, ResettingTimer:
+	case Counter, CounterFloat64, Gauge, GaugeFloat64, GaugeInfo, Healthcheck, Histogram, Meter, Timer, ResettingTimer, Vec:
 	default:
 		return nil, false, false
 	}
@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Label is a single name/value pair identifying one member of a metric vector.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Vec is a family of metrics sharing a name, whose members are distinguished
+// by the values of a fixed set of labels. Reporters that support labels (the
+// Prometheus collector and the InfluxDB reporters) emit every member with its
+// labels attached. The expvar handler and the JSON encoding emit every member
+// as a metric of its own, named by VecMemberName. Other reporters skip vectors
+// altogether.
+type Vec interface {
+	// LabelNames returns the names of the labels partitioning the vector.
+	LabelNames() []string
+
+	// Each calls the given function for every member of the vector, ordered
+	// by label values.
+	Each(func(labels []Label, metric interface{}))
+}
+
+// VecMemberName returns the flat name of a vector member for reporters without
+// label support, appending the label values to the vector name as additional
+// path segments, e.g. "rpc/duration/eth_call/success".
+func VecMemberName(name string, labels []Label) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range labels {
+		b.WriteByte('/')
+		b.WriteString(l.Value)
+	}
+	return b.String()
+}
+
+// vec is the label bookkeeping shared by all metric vectors.
+type vec struct {
+	names   []string
+	create  func() interface{}
+	lock    sync.RWMutex
+	members map[string]*vecMember
+}
+
+// vecMember is a single labelled metric within a vector.
+type vecMember struct {
+	values []string
+	metric interface{}
+}
+
+func newVec(create func() interface{}, names []string) vec {
+	return vec{
+		names:   names,
+		create:  create,
+		members: make(map[string]*vecMember),
+	}
+}
+
+// LabelNames returns the names of the labels partitioning the vector.
+func (v *vec) LabelNames() []string {
+	return v.names
+}
+
+// Each calls the given function for every member of the vector, ordered by
+// label values.
+func (v *vec) Each(fn func(labels []Label, metric interface{})) {
+	v.lock.RLock()
+	keys := make([]string, 0, len(v.members))
+	for key := range v.members {
+		keys = append(keys, key)
+	}
+	members := make([]*vecMember, 0, len(keys))
+	sort.Strings(keys)
+	for _, key := range keys {
+		members = append(members, v.members[key])
+	}
+	v.lock.RUnlock()
+
+	for _, m := range members {
+		labels := make([]Label, len(v.names))
+		for i, name := range v.names {
+			labels[i] = Label{Name: name, Value: m.values[i]}
+		}
+		fn(labels, m.metric)
+	}
+}
+
+// Delete removes the member with the given label values, returning whether it
+// existed. Use it to drop members whose labels are no longer live, e.g. those
+// of a disconnected peer.
+func (v *vec) Delete(values ...string) bool {
+	key := v.key(values)
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	_, ok := v.members[key]
+	delete(v.members, key)
+	return ok
+}
+
+// get returns the member with the given label values, creating it on first use.
+func (v *vec) get(values []string) interface{} {
+	key := v.key(values)
+
+	v.lock.RLock()
+	m, ok := v.members[key]
+	v.lock.RUnlock()
+	if ok {
+		return m.metric
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if m, ok := v.members[key]; ok {
+		return m.metric
+	}
+	m = &vecMember{
+		values: append([]string(nil), values...),
+		metric: v.create(),
+	}
+	v.members[key] = m
+	return m.metric
+}
+
+// key joins the label values into the member index, panicking if the number of
+// values does not match the number of labels.
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.names) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(v.names), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec is a set of Counters partitioned by label values.
+type CounterVec struct {
+	vec
+}
+
+// GetOrRegisterCounterVec returns an existing CounterVec or constructs and
+// registers a new one.
+func GetOrRegisterCounterVec(name string, r Registry, labels ...string) *CounterVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() *CounterVec { return NewCounterVec(labels...) }).(*CounterVec)
+}
+
+// NewCounterVec constructs a new CounterVec with the given label names.
+func NewCounterVec(labels ...string) *CounterVec {
+	return &CounterVec{newVec(func() interface{} { return new(StandardCounter) }, labels)}
+}
+
+// NewRegisteredCounterVec constructs and registers a new CounterVec.
+func NewRegisteredCounterVec(name string, r Registry, labels ...string) *CounterVec {
+	c := NewCounterVec(labels...)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// WithLabelValues returns the Counter for the given label values, which must
+// be given in the order of the vector's label names.
+func (v *CounterVec) WithLabelValues(values ...string) Counter {
+	if !Enabled {
+		return NilCounter{}
+	}
+	return v.get(values).(Counter)
+}
+
+// GaugeVec is a set of Gauges partitioned by label values.
+type GaugeVec struct {
+	vec
+}
+
+// GetOrRegisterGaugeVec returns an existing GaugeVec or constructs and
+// registers a new one.
+func GetOrRegisterGaugeVec(name string, r Registry, labels ...string) *GaugeVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() *GaugeVec { return NewGaugeVec(labels...) }).(*GaugeVec)
+}
+
+// NewGaugeVec constructs a new GaugeVec with the given label names.
+func NewGaugeVec(labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(func() interface{} { return new(StandardGauge) }, labels)}
+}
+
+// NewRegisteredGaugeVec constructs and registers a new GaugeVec.
+func NewRegisteredGaugeVec(name string, r Registry, labels ...string) *GaugeVec {
+	g := NewGaugeVec(labels...)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, g)
+	return g
+}
+
+// WithLabelValues returns the Gauge for the given label values, which must be
+// given in the order of the vector's label names.
+func (v *GaugeVec) WithLabelValues(values ...string) Gauge {
+	if !Enabled {
+		return NilGauge{}
+	}
+	return v.get(values).(Gauge)
+}
+
+// HistogramVec is a set of Histograms partitioned by label values. Every
+// member is backed by its own Sample, created by the vector's sample factory.
+type HistogramVec struct {
+	vec
+}
+
+// GetOrRegisterHistogramVec returns an existing HistogramVec or constructs and
+// registers a new one.
+func GetOrRegisterHistogramVec(name string, r Registry, s func() Sample, labels ...string) *HistogramVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() *HistogramVec { return NewHistogramVec(s, labels...) }).(*HistogramVec)
+}
+
+// NewHistogramVec constructs a new HistogramVec with the given sample factory
+// and label names.
+func NewHistogramVec(s func() Sample, labels ...string) *HistogramVec {
+	return &HistogramVec{newVec(func() interface{} { return &StandardHistogram{sample: s()} }, labels)}
+}
+
+// NewRegisteredHistogramVec constructs and registers a new HistogramVec.
+func NewRegisteredHistogramVec(name string, r Registry, s func() Sample, labels ...string) *HistogramVec {
+	h := NewHistogramVec(s, labels...)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, h)
+	return h
+}
+
+// WithLabelValues returns the Histogram for the given label values, which must
+// be given in the order of the vector's label names.
+func (v *HistogramVec) WithLabelValues(values ...string) Histogram {
+	if !Enabled {
+		return NilHistogram{}
+	}
+	return v.get(values).(Histogram)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func BenchmarkCounterVec(b *testing.B) {
+	v := NewCounterVec("protocol", "code")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.WithLabelValues("eth", "0x01").Inc(1)
+	}
+}
+
+func TestCounterVec(t *testing.T) {
+	v := NewCounterVec("protocol", "code")
+	v.WithLabelValues("eth", "0x01").Inc(1)
+	v.WithLabelValues("eth", "0x01").Inc(2)
+	v.WithLabelValues("snap", "0x00").Inc(5)
+
+	if count := v.WithLabelValues("eth", "0x01").Snapshot().Count(); count != 3 {
+		t.Errorf("eth/0x01 count: 3 != %v", count)
+	}
+	type member struct {
+		labels []Label
+		count  int64
+	}
+	var have []member
+	v.Each(func(labels []Label, i interface{}) {
+		have = append(have, member{labels, i.(Counter).Snapshot().Count()})
+	})
+	want := []member{
+		{[]Label{{"protocol", "eth"}, {"code", "0x01"}}, 3},
+		{[]Label{{"protocol", "snap"}, {"code", "0x00"}}, 5},
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("members mismatch: have %v, want %v", have, want)
+	}
+	if !v.Delete("snap", "0x00") {
+		t.Error("existing member not deleted")
+	}
+	if v.Delete("snap", "0x00") {
+		t.Error("missing member reported deleted")
+	}
+}
+
+func TestCounterVecLabelCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mismatching label count did not panic")
+		}
+	}()
+	NewCounterVec("protocol", "code").WithLabelValues("eth")
+}
+
+func TestGaugeVec(t *testing.T) {
+	v := NewGaugeVec("direction")
+	v.WithLabelValues("inbound").Update(3)
+	v.WithLabelValues("outbound").Update(4)
+	if value := v.WithLabelValues("inbound").Snapshot().Value(); value != 3 {
+		t.Errorf("inbound value: 3 != %v", value)
+	}
+	if value := v.WithLabelValues("outbound").Snapshot().Value(); value != 4 {
+		t.Errorf("outbound value: 4 != %v", value)
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	v := NewHistogramVec(func() Sample { return NewUniformSample(100) }, "method")
+	for i := int64(1); i <= 10; i++ {
+		v.WithLabelValues("eth_call").Update(i)
+	}
+	v.WithLabelValues("eth_chainId").Update(100)
+
+	if count := v.WithLabelValues("eth_call").Snapshot().Count(); count != 10 {
+		t.Errorf("eth_call count: 10 != %v", count)
+	}
+	if max := v.WithLabelValues("eth_chainId").Snapshot().Max(); max != 100 {
+		t.Errorf("eth_chainId max: 100 != %v", max)
+	}
+}
+
+func TestVecRegistry(t *testing.T) {
+	r := NewRegistry()
+	v := NewRegisteredCounterVec("p2p/ingress/bytes", r, "protocol")
+	if _, ok := r.Get("p2p/ingress/bytes").(Vec); !ok {
+		t.Fatal("vector not registered")
+	}
+	if have := GetOrRegisterCounterVec("p2p/ingress/bytes", r, "protocol"); have != v {
+		t.Error("registered vector not returned")
+	}
+}
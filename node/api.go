@@ -18,8 +18,10 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/shudolab/core-geth/common/hexutil"
 	"github.com/shudolab/core-geth/crypto"
@@ -120,6 +122,75 @@ func (api *adminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer disconnects a remote node and prevents it, and the IP address it
+// connects from, from reconnecting for the given number of seconds.
+func (api *adminAPI) BanPeer(url string, seconds *uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	duration := p2p.DefaultBanDuration
+	if seconds != nil {
+		duration = time.Duration(*seconds) * time.Second
+	}
+	if err := server.BanPeer(node, duration, "banned by admin"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a remote node, of the IP address banned along with
+// it and of the IP address in its URL.
+func (api *adminAPI) UnbanPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if err := server.UnbanPeer(node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// banInfo is the RPC representation of a node or IP address ban.
+type banInfo struct {
+	ID     string    `json:"id,omitempty"`
+	IP     string    `json:"ip,omitempty"`
+	Expiry time.Time `json:"expiry"`
+	Reason string    `json:"reason"`
+}
+
+// ListBans retrieves all node and IP address bans currently in effect.
+func (api *adminAPI) ListBans() ([]*banInfo, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	bans := server.Bans()
+	infos := make([]*banInfo, 0, len(bans))
+	for _, ban := range bans {
+		info := &banInfo{Expiry: ban.Expiry, Reason: ban.Reason}
+		if ban.IP.IsValid() {
+			info.IP = ban.IP.String()
+		} else {
+			info.ID = ban.ID.String()
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -183,6 +254,8 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			policy:                 api.node.rpcPolicy,
+			cache:                  api.node.rpcCache,
 		},
 	}
 	if cors != nil {
@@ -261,6 +334,8 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			policy:                 api.node.rpcPolicy,
+			cache:                  api.node.rpcCache,
 		},
 	}
 	if apis != nil {
@@ -299,6 +374,18 @@ func (api *adminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// ReloadRPCPolicy reloads the API key policy file of the HTTP and WebSocket
+// endpoints. The rate limits of all clients are reset.
+func (api *adminAPI) ReloadRPCPolicy() (bool, error) {
+	if api.node.rpcPolicy == nil {
+		return false, errors.New("no RPC policy file configured")
+	}
+	if err := api.node.rpcPolicy.reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // Peers retrieves all the information we know about each individual peer at the
 // protocol granularity.
 func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
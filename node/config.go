@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/shudolab/core-geth/accounts"
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/crypto"
 	"github.com/shudolab/core-geth/log"
@@ -98,6 +99,10 @@ type Config struct {
 	// SmartCardDaemonPath is the path to the smartcard daemon's socket.
 	SmartCardDaemonPath string `toml:",omitempty"`
 
+	// HDPath configures the BIP-44 derivation paths of HD and hardware wallets.
+	// If nil, the process wide default coin type is used.
+	HDPath *accounts.HDPathConfig `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -139,6 +144,10 @@ type Config struct {
 	// HTTPPathPrefix specifies a path prefix on which http-rpc is to be served.
 	HTTPPathPrefix string `toml:",omitempty"`
 
+	// HTTPStream enables JSON-RPC streams over HTTP/2 on the HTTP RPC interface.
+	// Plain HTTP listeners then also accept HTTP/2 with prior knowledge (h2c).
+	HTTPStream bool `toml:",omitempty"`
+
 	// AuthAddr is the listening address on which authenticated APIs are provided.
 	AuthAddr string `toml:",omitempty"`
 
@@ -206,6 +215,10 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCPolicyFile is the path of the JSON file restricting the methods and call
+	// rates of API keys on the HTTP and WebSocket endpoints. See RPCPolicyConfig.
+	RPCPolicyFile string `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
@@ -460,6 +473,15 @@ func (c *Config) KeyDirConfig() (string, error) {
 	return keydir, err
 }
 
+// HDPathConfig returns the derivation paths of HD and hardware wallets, falling
+// back to the process wide default if none are configured.
+func (c *Config) HDPathConfig() accounts.HDPathConfig {
+	if c.HDPath != nil {
+		return *c.HDPath
+	}
+	return accounts.DefaultHDPathConfig()
+}
+
 // GetKeyStoreDir retrieves the key directory and will create
 // and ephemeral one if necessary.
 func (c *Config) GetKeyStoreDir() (string, bool, error) {
@@ -57,14 +57,16 @@ type Node struct {
 	state         int           // Tracks state of node lifecycle
 
 	lock          sync.Mutex
-	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	http          *httpServer //
-	ws            *httpServer //
-	httpAuth      *httpServer //
-	wsAuth        *httpServer //
-	ipc           *ipcServer  // Stores information about the ipc http server
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	lifecycles    []Lifecycle       // All registered backends, services, and auxiliary services that have a lifecycle
+	rpcAPIs       []rpc.API         // List of APIs currently provided by the node
+	http          *httpServer       //
+	ws            *httpServer       //
+	httpAuth      *httpServer       //
+	wsAuth        *httpServer       //
+	ipc           *ipcServer        // Stores information about the ipc http server
+	inprocHandler *rpc.Server       // In-process RPC request handler to process the API requests
+	rpcPolicy     *rpcPolicy        // API key policy of the public HTTP and WS endpoints, may be nil
+	rpcCache      rpc.ResponseCache // immutable response cache of the public HTTP and WS endpoints, may be nil
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 
@@ -155,6 +157,11 @@ func New(conf *Config) (*Node, error) {
 	if err := validatePrefix("WebSocket", conf.WSPathPrefix); err != nil {
 		return nil, err
 	}
+	if conf.RPCPolicyFile != "" {
+		if node.rpcPolicy, err = newRPCPolicy(conf.RPCPolicyFile); err != nil {
+			return nil, err
+		}
+	}
 
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
@@ -489,6 +496,8 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		policy:                 n.rpcPolicy,
+		cache:                  n.rpcCache,
 	}
 
 	initHttp := func(server *httpServer, port int) error {
@@ -500,6 +509,7 @@ func (n *Node) startRPC() error {
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
+			stream:             n.config.HTTPStream,
 			rpcEndpointConfig:  rpcConfig,
 		}); err != nil {
 			return err
@@ -675,6 +685,18 @@ func (n *Node) RegisterAPIs(apis []rpc.API) {
 	n.rpcAPIs = append(n.rpcAPIs, apis...)
 }
 
+// SetRPCResponseCache sets the cache serving immutable results on the HTTP and
+// WebSocket endpoints. It must be called before the node is started.
+func (n *Node) SetRPCResponseCache(cache rpc.ResponseCache) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != initializingState {
+		panic("can't set RPC response cache on running/stopped node")
+	}
+	n.rpcCache = cache
+}
+
 // getAPIs return two sets of APIs, both the ones that do not require
 // authentication, and the complete set
 func (n *Node) getAPIs() (unauthenticated, all []rpc.API) {
@@ -0,0 +1,423 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shudolab/core-geth/common/lru"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/metrics"
+	"github.com/shudolab/core-geth/rpc"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// anonymousRuleName is the name of the rule applied to requests without an API
+	// key, used in metrics.
+	anonymousRuleName = "anonymous"
+
+	// maxTrackedIPs is the number of client IPs per rule for which rate limit
+	// buckets are kept.
+	maxTrackedIPs = 4096
+)
+
+var (
+	errMissingAPIKey = errors.New("missing API key")
+	errInvalidAPIKey = errors.New("invalid API key")
+)
+
+// RPCPolicyConfig is the format of the RPC policy file, which controls the access of
+// API keys to the methods served by the public HTTP and WebSocket endpoints.
+//
+// API keys are sent in the X-Api-Key header or as the last URL path element after
+// the endpoint prefix, e.g. http://localhost:8545/<key>.
+type RPCPolicyConfig struct {
+	// Keys are the accepted API keys.
+	Keys []RPCKeyConfig `json:"keys"`
+
+	// Anonymous is the rule applied to requests without an API key. Such requests
+	// are refused if it is not set.
+	Anonymous *RPCAccessRule `json:"anonymous,omitempty"`
+
+	// Costs are the weights of methods against the rate limits, keyed by method
+	// name pattern. When several patterns match, the longest wins. Methods not
+	// matching any pattern cost 1.
+	Costs map[string]int `json:"costs,omitempty"`
+}
+
+// RPCKeyConfig is the rule of an API key.
+type RPCKeyConfig struct {
+	// Name identifies the key in logs and metrics.
+	Name string `json:"name"`
+	Key  string `json:"key"`
+
+	RPCAccessRule
+}
+
+// RPCAccessRule restricts the methods and the rate of calls of a class of clients.
+type RPCAccessRule struct {
+	// Methods are the patterns of the allowed methods, like "eth_*". All methods
+	// are allowed if empty.
+	Methods []string `json:"methods,omitempty"`
+
+	// Rate and Burst define the token bucket shared by all clients of the rule, in
+	// cost units per second. There is no limit if Rate is zero.
+	Rate  float64 `json:"rate,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+
+	// IPRate and IPBurst define the token bucket of every client IP address.
+	IPRate  float64 `json:"ipRate,omitempty"`
+	IPBurst int     `json:"ipBurst,omitempty"`
+}
+
+// methodDeniedError is returned for calls of methods not allowed for the API key.
+type methodDeniedError struct{ method string }
+
+func (e *methodDeniedError) ErrorCode() int { return -32601 }
+
+func (e *methodDeniedError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed", e.method)
+}
+
+// rateLimitedError is returned for calls exceeding the rate limits.
+type rateLimitedError struct{ retry time.Duration }
+
+func (e *rateLimitedError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry in %v", e.retry)
+}
+
+// policyError is returned for calls with a missing or unknown API key.
+type policyError struct{ err error }
+
+func (e *policyError) ErrorCode() int { return -32001 }
+
+func (e *policyError) Error() string { return e.err.Error() }
+
+// rpcPolicy implements rpc.CallPolicy according to a policy file, which can be
+// reloaded while serving requests.
+type rpcPolicy struct {
+	file  string
+	rules atomic.Pointer[policyRules]
+}
+
+// newRPCPolicy loads the policy file.
+func newRPCPolicy(file string) (*rpcPolicy, error) {
+	p := &rpcPolicy{file: file}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// reload reads the policy file and replaces the active rules. The rate limit buckets
+// are reset.
+func (p *rpcPolicy) reload() error {
+	blob, err := os.ReadFile(p.file)
+	if err != nil {
+		return err
+	}
+	var config RPCPolicyConfig
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return fmt.Errorf("invalid RPC policy file %s: %v", p.file, err)
+	}
+	rules, err := compilePolicy(&config)
+	if err != nil {
+		return fmt.Errorf("invalid RPC policy file %s: %v", p.file, err)
+	}
+	p.rules.Store(rules)
+	log.Info("Loaded RPC policy", "file", p.file, "keys", len(config.Keys), "anonymous", config.Anonymous != nil)
+	return nil
+}
+
+// rule returns the rule of an API key.
+func (p *rpcPolicy) rule(key string) (*policyRule, error) {
+	rules := p.rules.Load()
+	if key == "" {
+		if rules.anonymous == nil {
+			return nil, errMissingAPIKey
+		}
+		return rules.anonymous, nil
+	}
+	if r, ok := rules.keys[key]; ok {
+		return r, nil
+	}
+	return nil, errInvalidAPIKey
+}
+
+// Admit implements rpc.CallPolicy.
+func (p *rpcPolicy) Admit(ctx context.Context, method string) error {
+	return p.admit(rpc.PeerInfoFromContext(ctx), method)
+}
+
+func (p *rpcPolicy) admit(info rpc.PeerInfo, method string) error {
+	r, err := p.rule(info.HTTP.APIKey)
+	if err != nil {
+		return &policyError{err}
+	}
+	if !r.allowed(method) {
+		r.deniedMeter.Inc(1)
+		return &methodDeniedError{method}
+	}
+	cost := p.rules.Load().cost(method)
+	if retry := r.take(clientIP(info.RemoteAddr), cost, time.Now()); retry > 0 {
+		r.limitedMeter.Inc(1)
+		return &rateLimitedError{retry.Round(time.Millisecond)}
+	}
+	r.callsMeter.Inc(1)
+	r.costMeter.Inc(int64(cost))
+	return nil
+}
+
+// clientIP strips the port from a remote address.
+func clientIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// policyRules is the compiled form of an RPCPolicyConfig.
+type policyRules struct {
+	keys      map[string]*policyRule
+	anonymous *policyRule
+	costs     map[string]int
+}
+
+// compilePolicy validates the configuration and creates the rate limit buckets.
+func compilePolicy(config *RPCPolicyConfig) (*policyRules, error) {
+	rules := &policyRules{
+		keys:  make(map[string]*policyRule),
+		costs: make(map[string]int),
+	}
+	maxCost := 1
+	for pattern, cost := range config.Costs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("cost pattern %q: %v", pattern, err)
+		}
+		if cost < 1 {
+			return nil, fmt.Errorf("cost of %q must be positive", pattern)
+		}
+		rules.costs[pattern] = cost
+		maxCost = max(maxCost, cost)
+	}
+	names := make(map[string]bool)
+	for i, key := range config.Keys {
+		switch {
+		case key.Key == "":
+			return nil, fmt.Errorf("key #%d has no key", i)
+		case key.Name == "":
+			return nil, fmt.Errorf("key #%d has no name", i)
+		case key.Name == anonymousRuleName:
+			return nil, fmt.Errorf("key name %q is reserved", key.Name)
+		case names[key.Name]:
+			return nil, fmt.Errorf("duplicate key name %q", key.Name)
+		case rules.keys[key.Key] != nil:
+			return nil, fmt.Errorf("key %q: duplicate key", key.Name)
+		}
+		r, err := newPolicyRule(key.Name, &key.RPCAccessRule, maxCost)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", key.Name, err)
+		}
+		names[key.Name] = true
+		rules.keys[key.Key] = r
+	}
+	if config.Anonymous != nil {
+		r, err := newPolicyRule(anonymousRuleName, config.Anonymous, maxCost)
+		if err != nil {
+			return nil, fmt.Errorf("anonymous: %v", err)
+		}
+		rules.anonymous = r
+	}
+	return rules, nil
+}
+
+// cost returns the weight of a method.
+func (rules *policyRules) cost(method string) int {
+	if cost, ok := rules.costs[method]; ok {
+		return cost
+	}
+	cost, longest := 1, -1
+	for pattern, c := range rules.costs {
+		if ok, _ := path.Match(pattern, method); ok && len(pattern) > longest {
+			cost, longest = c, len(pattern)
+		}
+	}
+	return cost
+}
+
+// policyRule is the compiled form of an RPCAccessRule.
+type policyRule struct {
+	methods []string
+	limiter *rate.Limiter // nil if unlimited
+
+	ipRate  rate.Limit
+	ipBurst int
+	ipLock  sync.Mutex
+	ips     lru.BasicLRU[string, *rate.Limiter]
+
+	callsMeter   metrics.Counter
+	costMeter    metrics.Counter
+	deniedMeter  metrics.Counter
+	limitedMeter metrics.Counter
+}
+
+func newPolicyRule(name string, config *RPCAccessRule, maxCost int) (*policyRule, error) {
+	for _, pattern := range config.Methods {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("method pattern %q: %v", pattern, err)
+		}
+	}
+	burst := func(rate float64, burst int) (int, error) {
+		switch {
+		case rate < 0:
+			return 0, errors.New("negative rate")
+		case burst < 0:
+			return 0, errors.New("negative burst")
+		case burst == 0:
+			// Default to one second worth of calls, but always admit the
+			// most expensive method.
+			return max(int(math.Ceil(rate)), maxCost), nil
+		case burst < maxCost:
+			return 0, fmt.Errorf("burst %d lower than the highest method cost %d", burst, maxCost)
+		}
+		return burst, nil
+	}
+	r := &policyRule{
+		methods:      config.Methods,
+		ips:          lru.NewBasicLRU[string, *rate.Limiter](maxTrackedIPs),
+		callsMeter:   metrics.GetOrRegisterCounter("rpc/policy/"+name+"/calls", nil),
+		costMeter:    metrics.GetOrRegisterCounter("rpc/policy/"+name+"/cost", nil),
+		deniedMeter:  metrics.GetOrRegisterCounter("rpc/policy/"+name+"/denied", nil),
+		limitedMeter: metrics.GetOrRegisterCounter("rpc/policy/"+name+"/limited", nil),
+	}
+	if config.Rate != 0 {
+		b, err := burst(config.Rate, config.Burst)
+		if err != nil {
+			return nil, err
+		}
+		r.limiter = rate.NewLimiter(rate.Limit(config.Rate), b)
+	}
+	if config.IPRate != 0 {
+		b, err := burst(config.IPRate, config.IPBurst)
+		if err != nil {
+			return nil, fmt.Errorf("ip limit: %v", err)
+		}
+		r.ipRate, r.ipBurst = rate.Limit(config.IPRate), b
+	}
+	return r, nil
+}
+
+// allowed reports whether the rule allows calling a method.
+func (r *policyRule) allowed(method string) bool {
+	if len(r.methods) == 0 {
+		return true
+	}
+	for _, pattern := range r.methods {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ipLimiter returns the rate limit bucket of a client IP.
+func (r *policyRule) ipLimiter(ip string) *rate.Limiter {
+	r.ipLock.Lock()
+	defer r.ipLock.Unlock()
+
+	if l, ok := r.ips.Get(ip); ok {
+		return l
+	}
+	l := rate.NewLimiter(r.ipRate, r.ipBurst)
+	r.ips.Add(ip, l)
+	return l
+}
+
+// take consumes cost tokens from the buckets of the rule and the client IP. If any
+// of the buckets has too few tokens, nothing is consumed and the time until the call
+// would be admitted is returned.
+func (r *policyRule) take(ip string, cost int, now time.Time) time.Duration {
+	var reservations []*rate.Reservation
+	if r.limiter != nil {
+		reservations = append(reservations, r.limiter.ReserveN(now, cost))
+	}
+	if r.ipBurst > 0 {
+		reservations = append(reservations, r.ipLimiter(ip).ReserveN(now, cost))
+	}
+	var wait time.Duration
+	for _, res := range reservations {
+		wait = max(wait, res.DelayFrom(now))
+	}
+	if wait > 0 {
+		for _, res := range reservations {
+			res.CancelAt(now)
+		}
+	}
+	return wait
+}
+
+// newPolicyHandler returns a handler which refuses requests without a valid API key.
+// Keys given as the last URL path element after the prefix are moved into the
+// rpc.APIKeyHeader.
+func newPolicyHandler(policy *rpcPolicy, prefix string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Let CORS preflight requests through, browsers don't send the key.
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(rpc.APIKeyHeader)
+		if key == "" {
+			if key = pathAPIKey(r.URL.Path, prefix); key != "" {
+				r = r.Clone(r.Context())
+				r.Header.Set(rpc.APIKeyHeader, key)
+			}
+		}
+		if _, err := policy.rule(key); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pathAPIKey returns the API key given as the URL path element after the prefix.
+func pathAPIKey(urlPath, prefix string) string {
+	rest, ok := strings.CutPrefix(urlPath, strings.TrimSuffix(prefix, "/")+"/")
+	if !ok || strings.Contains(rest, "/") {
+		return ""
+	}
+	return rest
+}
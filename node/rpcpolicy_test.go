@@ -0,0 +1,218 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/rpc"
+)
+
+func writePolicy(t *testing.T, file string, config *RPCPolicyConfig) {
+	t.Helper()
+
+	blob, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRPCPolicyRules(t *testing.T) {
+	rules, err := compilePolicy(&RPCPolicyConfig{
+		Keys: []RPCKeyConfig{{
+			Name: "explorer",
+			Key:  "secret",
+			RPCAccessRule: RPCAccessRule{
+				Methods: []string{"eth_*", "debug_traceBlock*"},
+				Rate:    10,
+				Burst:   100,
+			},
+		}},
+		Anonymous: &RPCAccessRule{
+			Methods: []string{"eth_blockNumber"},
+			IPRate:  1,
+		},
+		Costs: map[string]int{"debug_*": 20, "debug_traceBlockByNumber": 50},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := rules.cost("eth_blockNumber"); cost != 1 {
+		t.Errorf("wrong cost %d for eth_blockNumber", cost)
+	}
+	if cost := rules.cost("debug_traceBlockByHash"); cost != 20 {
+		t.Errorf("wrong cost %d for debug_traceBlockByHash", cost)
+	}
+	if cost := rules.cost("debug_traceBlockByNumber"); cost != 50 {
+		t.Errorf("wrong cost %d for debug_traceBlockByNumber", cost)
+	}
+
+	key := rules.keys["secret"]
+	for method, want := range map[string]bool{
+		"eth_blockNumber":          true,
+		"debug_traceBlockByNumber": true,
+		"debug_traceTransaction":   false,
+		"admin_peers":              false,
+	} {
+		if have := key.allowed(method); have != want {
+			t.Errorf("key allows %s: have %v, want %v", method, have, want)
+		}
+	}
+
+	// The burst of the key admits two expensive calls, a third has to wait until
+	// enough tokens have been refilled.
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if wait := key.take("10.0.0.1", 50, now); wait != 0 {
+			t.Fatalf("call %d limited for %v", i, wait)
+		}
+	}
+	if wait := key.take("10.0.0.1", 50, now); wait != 5*time.Second {
+		t.Fatalf("wrong wait %v", wait)
+	}
+	if wait := key.take("10.0.0.1", 50, now.Add(5*time.Second)); wait != 0 {
+		t.Fatalf("refilled call limited for %v", wait)
+	}
+
+	// Anonymous clients are limited per IP, the default burst admits the most
+	// expensive method once.
+	anon := rules.anonymous
+	if wait := anon.take("10.0.0.1", 50, now); wait != 0 {
+		t.Fatalf("first call limited for %v", wait)
+	}
+	if wait := anon.take("10.0.0.1", 1, now); wait == 0 {
+		t.Fatal("second call not limited")
+	}
+	if wait := anon.take("10.0.0.2", 1, now); wait != 0 {
+		t.Fatalf("other client limited for %v", wait)
+	}
+}
+
+func TestRPCPolicyInvalid(t *testing.T) {
+	tests := []RPCPolicyConfig{
+		{Keys: []RPCKeyConfig{{Name: "a"}}},
+		{Keys: []RPCKeyConfig{{Name: "a", Key: "x"}, {Name: "a", Key: "y"}}},
+		{Keys: []RPCKeyConfig{{Name: "a", Key: "x"}, {Name: "b", Key: "x"}}},
+		{Keys: []RPCKeyConfig{{Name: anonymousRuleName, Key: "x"}}},
+		{Anonymous: &RPCAccessRule{Methods: []string{"eth_["}}},
+		{Anonymous: &RPCAccessRule{Rate: 1, Burst: 5}, Costs: map[string]int{"debug_*": 10}},
+		{Anonymous: &RPCAccessRule{IPRate: -1}},
+		{Costs: map[string]int{"eth_call": 0}},
+	}
+	for i, config := range tests {
+		if _, err := compilePolicy(&config); err == nil {
+			t.Errorf("test %d: no error for invalid policy", i)
+		}
+	}
+}
+
+func TestRPCPolicyAdmit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, file, &RPCPolicyConfig{
+		Keys: []RPCKeyConfig{{Name: "full", Key: "secret"}},
+	})
+	policy, err := newRPCPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withKey := func(key string) (info rpc.PeerInfo) {
+		info.HTTP.APIKey = key
+		return info
+	}
+	if err := policy.admit(withKey("secret"), "admin_peers"); err != nil {
+		t.Fatalf("call refused: %v", err)
+	}
+	if err := policy.admit(withKey(""), "eth_blockNumber"); !errors.Is(err.(*policyError).err, errMissingAPIKey) {
+		t.Fatalf("wrong error without key: %v", err)
+	}
+
+	// Reloading the policy revokes the key.
+	writePolicy(t, file, &RPCPolicyConfig{
+		Keys:      []RPCKeyConfig{{Name: "full", Key: "other"}},
+		Anonymous: &RPCAccessRule{Methods: []string{"eth_*"}},
+	})
+	if err := policy.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.admit(withKey("secret"), "admin_peers"); err == nil {
+		t.Fatal("revoked key admitted")
+	}
+	if err := policy.admit(withKey(""), "eth_blockNumber"); err != nil {
+		t.Fatalf("anonymous call refused: %v", err)
+	}
+	var denied *methodDeniedError
+	if err := policy.admit(withKey(""), "admin_peers"); !errors.As(err, &denied) {
+		t.Fatalf("wrong error for denied method: %v", err)
+	}
+}
+
+func TestRPCPolicyHTTP(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, file, &RPCPolicyConfig{
+		Keys: []RPCKeyConfig{{
+			Name:          "greeter",
+			Key:           "secret",
+			RPCAccessRule: RPCAccessRule{Methods: []string{"test_greet"}},
+		}},
+	})
+	policy, err := newRPCPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, prefix := range []string{"", "/rpc"} {
+		conf := &httpConfig{prefix: prefix, rpcEndpointConfig: rpcEndpointConfig{policy: policy}}
+		srv := createAndStartServer(t, conf, false, nil, nil)
+		url := "http://" + srv.listenAddr() + prefix
+
+		if resp := rpcRequest(t, url+"/", "test_greet"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("prefix %q: wrong status without key: %d", prefix, resp.StatusCode)
+		}
+		if resp := rpcRequest(t, url+"/wrong", "test_greet"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("prefix %q: wrong status with invalid key: %d", prefix, resp.StatusCode)
+		}
+		for _, resp := range []*http.Response{
+			rpcRequest(t, url+"/secret", "test_greet"),
+			rpcRequest(t, url+"/", "test_greet", rpc.APIKeyHeader, "secret"),
+		} {
+			var result struct{ Result string }
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Result != "Hello" {
+				t.Errorf("prefix %q: wrong result %q", prefix, result.Result)
+			}
+		}
+		var result struct{ Error struct{ Code int } }
+		resp := rpcRequest(t, url+"/secret", testMethod)
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Error.Code != -32601 {
+			t.Errorf("prefix %q: wrong error code for denied method: %d", prefix, result.Error.Code)
+		}
+		srv.stop()
+	}
+}
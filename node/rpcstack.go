@@ -34,6 +34,8 @@ import (
 	"github.com/shudolab/core-geth/log"
 	"github.com/shudolab/core-geth/rpc"
 	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // httpConfig is the JSON-RPC/HTTP configuration.
@@ -42,6 +44,7 @@ type httpConfig struct {
 	CorsAllowedOrigins []string
 	Vhosts             []string
 	prefix             string // path prefix on which to mount http handler
+	stream             bool   // accept HTTP/2 JSON-RPC streams
 	rpcEndpointConfig
 }
 
@@ -58,6 +61,8 @@ type rpcEndpointConfig struct {
 	batchItemLimit         int
 	batchResponseSizeLimit int
 	httpBodyLimit          int
+	policy                 *rpcPolicy        // optional API key policy
+	cache                  rpc.ResponseCache // optional immutable response cache
 }
 
 type rpcHandler struct {
@@ -140,6 +145,9 @@ func (h *httpServer) start() error {
 
 	// Initialize the server.
 	h.server = &http.Server{Handler: h}
+	if h.httpConfig.stream {
+		h.server.Handler = h2c.NewHandler(h, &http2.Server{})
+	}
 	if h.timeouts != (rpc.HTTPTimeouts{}) {
 		CheckTimeouts(&h.timeouts)
 		h.server.ReadTimeout = h.timeouts.ReadTimeout
@@ -200,7 +208,7 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// check if ws request and serve if ws enabled
 	ws := h.wsHandler.Load().(*rpcHandler)
 	if ws != nil && isWebsocket(r) {
-		if checkPath(r, h.wsConfig.prefix) {
+		if checkPath(r, h.wsConfig.prefix) || h.wsConfig.checkKeyPath(r, h.wsConfig.prefix) {
 			ws.ServeHTTP(w, r)
 		}
 		return
@@ -219,7 +227,7 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if checkPath(r, h.httpConfig.prefix) {
+		if checkPath(r, h.httpConfig.prefix) || h.httpConfig.checkKeyPath(r, h.httpConfig.prefix) {
 			rpc.ServeHTTP(w, r)
 			return
 		}
@@ -237,6 +245,12 @@ func checkPath(r *http.Request, path string) bool {
 	return len(r.URL.Path) >= len(path) && r.URL.Path[:len(path)] == path
 }
 
+// checkKeyPath checks whether a given request URL is the path prefix followed by an
+// API key, which is accepted if the endpoint has a policy.
+func (c *rpcEndpointConfig) checkKeyPath(r *http.Request, prefix string) bool {
+	return c.policy != nil && pathAPIKey(r.URL.Path, prefix) != ""
+}
+
 // validatePrefix checks if 'path' is a valid configuration value for the RPC prefix option.
 func validatePrefix(what, path string) error {
 	if path == "" {
@@ -309,12 +323,20 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
+	if config.cache != nil {
+		srv.SetResponseCache(config.cache)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
+	handler := NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret)
+	if config.policy != nil {
+		srv.SetCallPolicy(config.policy)
+		handler = newPolicyHandler(config.policy, config.prefix, handler)
+	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
@@ -344,12 +366,20 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
+	if config.cache != nil {
+		srv.SetResponseCache(config.cache)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
+	handler := NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret)
+	if config.policy != nil {
+		srv.SetCallPolicy(config.policy)
+		handler = newPolicyHandler(config.policy, config.prefix, handler)
+	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: NewWSHandlerStack(srv.WebsocketHandler(config.Origins), config.jwtSecret),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
@@ -572,7 +602,8 @@ func (w *gzipResponseWriter) close() {
 
 func newGzipHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		// Compressing streams would hold back messages until the buffer fills.
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") || rpc.IsStreamRequest(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -18,6 +18,7 @@ package node
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -596,6 +597,47 @@ func TestHTTPWriteTimeout(t *testing.T) {
 	})
 }
 
+// TestHTTPStream checks that JSON-RPC streams are served when enabled.
+func TestHTTPStream(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{Modules: []string{"test"}, stream: true}, false, &wsConfig{}, nil)
+	defer srv.stop()
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+
+	c, err := rpc.DialOptions(context.Background(), url, rpc.WithHTTPStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var res string
+	if err := c.Call(&res, "test_greet"); err != nil {
+		t.Fatal(err)
+	}
+	if res != "Hello" {
+		t.Fatalf("wrong response %q", res)
+	}
+	// Plain HTTP requests are still served.
+	resp := rpcRequest(t, url, "test_greet")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status code for plain request %d", resp.StatusCode)
+	}
+}
+
+// TestHTTPStreamDisabled checks that streams are refused unless enabled.
+func TestHTTPStreamDisabled(t *testing.T) {
+	srv := createAndStartServer(t, &httpConfig{Modules: []string{"test"}}, false, &wsConfig{}, nil)
+	defer srv.stop()
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if c, err := rpc.DialOptions(ctx, url, rpc.WithHTTPStream()); err == nil {
+		c.Close()
+		t.Fatal("stream opened on server without streams")
+	}
+}
+
 func apis() []rpc.API {
 	return []rpc.API{
 		{
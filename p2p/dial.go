@@ -24,6 +24,7 @@ import (
 	"fmt"
 	mrand "math/rand"
 	"net"
+	"net/netip"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -76,6 +77,8 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
+	errBanned           = errors.New("banned")
+	errDialFiltered     = errors.New("rejected by protocol filter")
 	errNoPort           = errors.New("node does not provide TCP port")
 )
 
@@ -126,10 +129,12 @@ type dialScheduler struct {
 type dialSetupFunc func(net.Conn, connFlag, *enode.Node) error
 
 type dialConfig struct {
-	self           enode.ID         // our own ID
-	maxDialPeers   int              // maximum number of dialed peers
-	maxActiveDials int              // maximum number of active dials
-	netRestrict    *netutil.Netlist // IP netrestrict list, disabled if nil
+	self           enode.ID                        // our own ID
+	maxDialPeers   int                             // maximum number of dialed peers
+	maxActiveDials int                             // maximum number of active dials
+	netRestrict    *netutil.Netlist                // IP netrestrict list, disabled if nil
+	banned         func(enode.ID, netip.Addr) bool // Ban list lookup, disabled if nil
+	filter         func(*enode.Node) bool          // Discovered node filter, disabled if nil
 	resolver       nodeResolver
 	dialer         NodeDialer
 	log            log.Logger
@@ -239,7 +244,11 @@ loop:
 
 		select {
 		case node := <-nodesCh:
-			if err := d.checkDial(node); err != nil {
+			err := d.checkDial(node)
+			if err == nil && d.filter != nil && !d.filter(node) {
+				err = errDialFiltered
+			}
+			if err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IPAddr(), "reason", err)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
@@ -391,6 +400,9 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
+	if d.banned != nil && d.banned(n.ID(), n.IPAddr()) {
+		return errBanned
+	}
 	return nil
 }
 
@@ -152,6 +152,42 @@ func TestDialSchedNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that discovered candidates rejected by the dial filter are not
+// dialed, while static nodes are dialed regardless.
+func TestDialSchedFilter(t *testing.T) {
+	t.Parallel()
+
+	nodes := []*enode.Node{
+		newNode(uintID(0x01), "127.0.0.1:30303"),
+		newNode(uintID(0x02), "127.0.0.2:30303"),
+		newNode(uintID(0x03), "127.0.0.3:30303"),
+		newNode(uintID(0x04), "127.0.0.4:30303"),
+	}
+	config := dialConfig{
+		maxActiveDials: 10,
+		maxDialPeers:   10,
+		filter: func(n *enode.Node) bool {
+			return n.ID() != nodes[1].ID() && n.ID() != nodes[3].ID()
+		},
+	}
+	runDialTest(t, config, []dialTestRound{
+		{
+			update: func(d *dialScheduler) {
+				d.addStatic(nodes[3])
+			},
+			discovered:   nodes,
+			wantNewDials: []*enode.Node{nodes[0], nodes[2], nodes[3]},
+		},
+		{
+			succeeded: []enode.ID{
+				nodes[0].ID(),
+				nodes[2].ID(),
+				nodes[3].ID(),
+			},
+		},
+	})
+}
+
 // This test checks that static dials work and obey the limits.
 func TestDialSchedStaticDial(t *testing.T) {
 	t.Parallel()
@@ -41,6 +41,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbBanPrefix    = "ban:" // Identifier to prefix ban entries with, outside the node expiry range
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -54,6 +55,11 @@ const (
 	// Local information is keyed by ID only, the full key is "local:<ID>:seq".
 	// Use localItemKey to create those keys.
 	dbLocalSeq = "seq"
+
+	// Bans are keyed by node ID or IP, the full key is "ban:id:<ID>" or "ban:ip:<IP>".
+	// Use banKey to create those keys.
+	dbBanID = "id"
+	dbBanIP = "ip"
 )
 
 const (
@@ -194,6 +200,14 @@ func localItemKey(id ID, field string) []byte {
 	return key
 }
 
+// banKey returns the database key of a node ID or IP ban.
+func banKey(kind string, item []byte) []byte {
+	key := append([]byte(dbBanPrefix), kind...)
+	key = append(key, ':')
+	key = append(key, item...)
+	return key
+}
+
 // fetchInt64 retrieves an integer associated with a particular key.
 func (db *DB) fetchInt64(key []byte) int64 {
 	blob, err := db.lvl.Get(key, nil)
@@ -315,6 +329,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireBans()
 		case <-db.quit:
 			return
 		}
@@ -427,6 +442,161 @@ func (db *DB) UpdateFindFailsV5(id ID, ip netip.Addr, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// Ban describes a node or an IP address which is not allowed to connect.
+type Ban struct {
+	ID       ID         // Banned node, zero for address bans
+	IP       netip.Addr // Banned address, invalid for node bans
+	LinkedIP netip.Addr // Address banned along with a node ban, if any
+	Expiry   time.Time  // Time the ban is lifted
+	Reason   string     // Human readable reason of the ban
+}
+
+// banEntry is the RLP encoding of a ban stored in the database.
+type banEntry struct {
+	Expiry   uint64 // Unix time in seconds
+	Reason   string
+	LinkedIP []byte `rlp:"optional"`
+}
+
+// BanNode prevents the given node from connecting until expiry. If valid, the
+// linked address is recorded with the ban, so it can be lifted along with it.
+// The address itself must be banned separately with BanIP.
+func (db *DB) BanNode(id ID, linked netip.Addr, expiry time.Time, reason string) error {
+	entry := &banEntry{Expiry: uint64(expiry.Unix()), Reason: reason}
+	if linked.IsValid() {
+		ip16 := linked.As16()
+		entry.LinkedIP = ip16[:]
+	}
+	return db.storeBan(banKey(dbBanID, id[:]), entry)
+}
+
+// BanIP prevents any node from connecting from the given address until expiry.
+func (db *DB) BanIP(ip netip.Addr, expiry time.Time, reason string) error {
+	if !ip.IsValid() {
+		return errInvalidIP
+	}
+	ip16 := ip.As16()
+	return db.storeBan(banKey(dbBanIP, ip16[:]), &banEntry{Expiry: uint64(expiry.Unix()), Reason: reason})
+}
+
+// UnbanNode lifts the ban of a node, if any.
+func (db *DB) UnbanNode(id ID) error {
+	return db.lvl.Delete(banKey(dbBanID, id[:]), nil)
+}
+
+// UnbanIP lifts the ban of an address, if any.
+func (db *DB) UnbanIP(ip netip.Addr) error {
+	if !ip.IsValid() {
+		return errInvalidIP
+	}
+	ip16 := ip.As16()
+	return db.lvl.Delete(banKey(dbBanIP, ip16[:]), nil)
+}
+
+// NodeBan retrieves the active ban of a node, if there is one.
+func (db *DB) NodeBan(id ID) (Ban, bool) {
+	ban, ok := db.fetchBan(banKey(dbBanID, id[:]))
+	if ok {
+		ban.ID = id
+	}
+	return ban, ok
+}
+
+// IPBan retrieves the active ban of an address, if there is one.
+func (db *DB) IPBan(ip netip.Addr) (Ban, bool) {
+	if !ip.IsValid() {
+		return Ban{}, false
+	}
+	ip16 := ip.As16()
+	ban, ok := db.fetchBan(banKey(dbBanIP, ip16[:]))
+	if ok {
+		ban.IP = ip.Unmap()
+	}
+	return ban, ok
+}
+
+// Bans retrieves all active node and address bans.
+func (db *DB) Bans() []Ban {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	var (
+		now  = time.Now()
+		bans []Ban
+	)
+	for it.Next() {
+		var entry banEntry
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil {
+			continue
+		}
+		ban := entry.ban()
+		if !ban.Expiry.After(now) {
+			continue
+		}
+		item := it.Key()[len(dbBanPrefix):]
+		switch {
+		case bytes.HasPrefix(item, []byte(dbBanID+":")):
+			copy(ban.ID[:], item[len(dbBanID)+1:])
+		case bytes.HasPrefix(item, []byte(dbBanIP+":")):
+			ip, _ := netip.AddrFromSlice(item[len(dbBanIP)+1:])
+			ban.IP = ip.Unmap()
+		default:
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans
+}
+
+// ban converts a database entry into a Ban without node ID and address.
+func (entry *banEntry) ban() Ban {
+	ban := Ban{Expiry: time.Unix(int64(entry.Expiry), 0), Reason: entry.Reason}
+	if ip, ok := netip.AddrFromSlice(entry.LinkedIP); ok {
+		ban.LinkedIP = ip.Unmap()
+	}
+	return ban
+}
+
+// storeBan writes a ban entry into the database.
+func (db *DB) storeBan(key []byte, entry *banEntry) error {
+	blob, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(key, blob, nil)
+}
+
+// fetchBan reads a ban entry from the database, ignoring expired ones.
+func (db *DB) fetchBan(key []byte) (Ban, bool) {
+	blob, err := db.lvl.Get(key, nil)
+	if err != nil {
+		return Ban{}, false
+	}
+	var entry banEntry
+	if err := rlp.DecodeBytes(blob, &entry); err != nil {
+		return Ban{}, false
+	}
+	ban := entry.ban()
+	if !ban.Expiry.After(time.Now()) {
+		return Ban{}, false
+	}
+	return ban, true
+}
+
+// expireBans deletes all bans which have been lifted.
+func (db *DB) expireBans() {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	now := uint64(time.Now().Unix())
+	for it.Next() {
+		var entry banEntry
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil || entry.Expiry <= now {
+			db.lvl.Delete(it.Key(), nil)
+		}
+	}
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
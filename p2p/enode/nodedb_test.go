@@ -470,3 +470,51 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		id      = HexID("51232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+		expired = HexID("a448f24c6d18e575453db13171562b71999873db5b286df957af199ec94617f7")
+		ip      = netip.MustParseAddr("10.3.58.6")
+		future  = time.Now().Add(time.Hour).Truncate(time.Second)
+	)
+	if err := db.BanNode(id, ip, future, "bad block"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.BanNode(expired, netip.Addr{}, time.Now().Add(-time.Second), "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.BanIP(ip, future, "bad block"); err != nil {
+		t.Fatal(err)
+	}
+	if ban, ok := db.NodeBan(id); !ok || ban.ID != id || ban.LinkedIP != ip || !ban.Expiry.Equal(future) || ban.Reason != "bad block" {
+		t.Errorf("node ban mismatch: %v %+v", ok, ban)
+	}
+	if _, ok := db.NodeBan(expired); ok {
+		t.Error("expired node ban still in effect")
+	}
+	if ban, ok := db.IPBan(ip); !ok || ban.IP != ip {
+		t.Errorf("ip ban mismatch: %v %+v", ok, ban)
+	}
+	if bans := db.Bans(); len(bans) != 2 {
+		t.Errorf("wrong number of bans: have %d, want 2", len(bans))
+	}
+	// Bans must survive node expiration, expired bans must not.
+	db.expireNodes()
+	db.expireBans()
+	if bans := db.Bans(); len(bans) != 2 {
+		t.Errorf("wrong number of bans after expiration: have %d, want 2", len(bans))
+	}
+	if err := db.UnbanNode(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UnbanIP(ip); err != nil {
+		t.Fatal(err)
+	}
+	if bans := db.Bans(); len(bans) != 0 {
+		t.Errorf("bans not lifted: %v", bans)
+	}
+}
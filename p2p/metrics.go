@@ -20,7 +20,10 @@ package p2p
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"strconv"
+	"time"
 
 	"github.com/shudolab/core-geth/metrics"
 )
@@ -29,10 +32,10 @@ const (
 	// HandleHistName is the prefix of the per-packet serving time histograms.
 	HandleHistName = "p2p/handle"
 
-	// ingressMeterName is the prefix of the per-packet inbound metrics.
+	// ingressMeterName is the prefix of the inbound traffic metrics.
 	ingressMeterName = "p2p/ingress"
 
-	// egressMeterName is the prefix of the per-packet outbound metrics.
+	// egressMeterName is the prefix of the outbound traffic metrics.
 	egressMeterName = "p2p/egress"
 )
 
@@ -41,8 +44,23 @@ var (
 	activeInboundPeerGauge  metrics.Gauge = metrics.NilGauge{}
 	activeOutboundPeerGauge metrics.Gauge = metrics.NilGauge{}
 
-	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/ingress", nil)
-	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/egress", nil)
+	ingressTrafficMeter = metrics.NewRegisteredMeter(ingressMeterName, nil)
+	egressTrafficMeter  = metrics.NewRegisteredMeter(egressMeterName, nil)
+
+	// per-packet subprotocol traffic, labelled by protocol name, version and
+	// message code
+	ingressMessageBytes   = metrics.NewRegisteredCounterVec(ingressMeterName+"/bytes", nil, "protocol", "version", "code")
+	ingressMessagePackets = metrics.NewRegisteredCounterVec(ingressMeterName+"/packets", nil, "protocol", "version", "code")
+	egressMessageBytes    = metrics.NewRegisteredCounterVec(egressMeterName+"/bytes", nil, "protocol", "version", "code")
+	egressMessagePackets  = metrics.NewRegisteredCounterVec(egressMeterName+"/packets", nil, "protocol", "version", "code")
+
+	// per-packet subprotocol handler serving times, labelled like the traffic
+	// counters
+	handleTimeHist = metrics.NewRegisteredHistogramVec(HandleHistName, nil, func() metrics.Sample {
+		return metrics.ResettingSample(
+			metrics.NewExpDecaySample(1028, 0.015),
+		)
+	}, "protocol", "version", "code")
 
 	// general ingress/egress connection meters
 	serveMeter          metrics.Meter = metrics.NilMeter{}
@@ -103,6 +121,18 @@ func markDialError(err error) {
 	}
 }
 
+// messageLabels returns the label values identifying a subprotocol message in
+// the per-packet traffic metrics.
+func messageLabels(proto string, version uint, code uint64) []string {
+	return []string{proto, strconv.FormatUint(uint64(version), 10), fmt.Sprintf("%#02x", code)}
+}
+
+// UpdateHandleTime tracks the time a subprotocol handler took to serve a
+// message, in microseconds.
+func UpdateHandleTime(proto string, version uint, code uint64, elapsed time.Duration) {
+	handleTimeHist.WithLabelValues(messageLabels(proto, version, code)...).Update(elapsed.Microseconds())
+}
+
 // meteredConn is a wrapper around a net.Conn that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
@@ -30,6 +30,7 @@ import (
 	"github.com/shudolab/core-geth/metrics"
 	"github.com/shudolab/core-geth/p2p/enode"
 	"github.com/shudolab/core-geth/p2p/enr"
+	"github.com/shudolab/core-geth/p2p/netutil"
 	"github.com/shudolab/core-geth/rlp"
 	"golang.org/x/exp/slices"
 )
@@ -118,6 +119,9 @@ type Peer struct {
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
+
+	// reputation records misbehavior reports if set
+	reputation *reputation
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -214,6 +218,18 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report records a protocol violation of the peer, adding to its misbehavior
+// score. Peers exceeding the ban threshold are disconnected and banned by node
+// ID and IP address for some time. Trusted peers are never penalized.
+func (p *Peer) Report(offense Offense, reason string) {
+	if p.reputation == nil || p.rw.is(trustedConn) {
+		return
+	}
+	if p.reputation.report(p.ID(), netutil.AddrAddr(p.RemoteAddr()), offense, reason) {
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	id := p.ID()
@@ -358,9 +374,9 @@ func (p *Peer) handle(msg Msg) error {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
 		if metrics.Enabled {
-			m := fmt.Sprintf("%s/%s/%d/%#02x", ingressMeterName, proto.Name, proto.Version, msg.Code-proto.offset)
-			metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
-			metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
+			labels := messageLabels(proto.Name, proto.Version, msg.Code-proto.offset)
+			ingressMessageBytes.WithLabelValues(labels...).Inc(int64(msg.meterSize))
+			ingressMessagePackets.WithLabelValues(labels...).Inc(1)
 		}
 		select {
 		case proto.in <- msg:
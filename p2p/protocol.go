@@ -60,6 +60,11 @@ type Protocol struct {
 	// attempts to create connections to them.
 	DialCandidates enode.Iterator
 
+	// DialFilter, if non-nil, is consulted for every node found through discovery
+	// before it is dialed. Nodes rejected by the filter of any protocol are skipped.
+	// Static nodes are not subject to filtering.
+	DialFilter func(*enode.Node) bool
+
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
 }
@@ -0,0 +1,306 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/mclock"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/metrics"
+	"github.com/shudolab/core-geth/p2p/enode"
+)
+
+const (
+	// banThreshold is the misbehavior score at which a peer gets banned. It is
+	// above every single penalty, so only repeated offenses lead to a ban.
+	banThreshold = 100
+
+	// scoreHalfLife is the time it takes for a misbehavior score to halve.
+	scoreHalfLife = 30 * time.Minute
+
+	// scorePruneCycle is the interval between sweeps dropping negligible scores.
+	scorePruneCycle = time.Hour
+
+	// DefaultBanDuration is the duration of bans issued for misbehavior.
+	DefaultBanDuration = 6 * time.Hour
+)
+
+var (
+	offenseMeter = metrics.NewRegisteredMeter("p2p/reputation/offenses", nil)
+	banMeter     = metrics.NewRegisteredMeter("p2p/reputation/bans", nil)
+)
+
+// Offense is a category of protocol violation a peer can be penalized for.
+type Offense uint8
+
+const (
+	// OffenseNone is reported for failures which cannot be attributed to the
+	// peer, such as local import errors. It leaves the score untouched.
+	OffenseNone Offense = iota
+
+	// OffenseUseless is reported for peers which cannot serve any useful data,
+	// e.g. because they are unsynced or on a different chain.
+	OffenseUseless
+
+	// OffenseStall is reported for peers failing to answer requests in time.
+	OffenseStall
+
+	// OffenseInvalidMessage is reported for malformed or unsolicited messages.
+	OffenseInvalidMessage
+
+	// OffenseInvalidData is reported for data failing validation, such as bad
+	// blocks, invalid headers or state proofs.
+	OffenseInvalidData
+)
+
+// offensePenalties is the score added to a peer for each kind of offense.
+var offensePenalties = [...]float64{
+	OffenseNone:           0,
+	OffenseUseless:        10,
+	OffenseStall:          25,
+	OffenseInvalidMessage: 50,
+	OffenseInvalidData:    60,
+}
+
+func (o Offense) String() string {
+	switch o {
+	case OffenseNone:
+		return "none"
+	case OffenseUseless:
+		return "useless"
+	case OffenseStall:
+		return "stall"
+	case OffenseInvalidMessage:
+		return "invalid message"
+	case OffenseInvalidData:
+		return "invalid data"
+	default:
+		return fmt.Sprintf("unknown offense %d", o)
+	}
+}
+
+// penalty returns the score added for the offense.
+func (o Offense) penalty() float64 {
+	if int(o) < len(offensePenalties) {
+		return offensePenalties[o]
+	}
+	return offensePenalties[OffenseInvalidMessage]
+}
+
+// peerScore is a misbehavior score decaying exponentially over time.
+type peerScore struct {
+	value   float64
+	updated mclock.AbsTime
+}
+
+// at returns the decayed score at the given time.
+func (s *peerScore) at(now mclock.AbsTime) float64 {
+	elapsed := time.Duration(now - s.updated)
+	return s.value * math.Exp2(-float64(elapsed)/float64(scoreHalfLife))
+}
+
+// reputation tracks the misbehavior of peers, banning those which exceed the
+// threshold. Bans are persisted in the node database, scores are not. Trusted
+// nodes, and the addresses of their records, are exempt from bans.
+//
+// Ban expiry is wall-clock time, which is derived from the clock so that ban
+// lifetimes follow simulated clocks too.
+type reputation struct {
+	db        *enode.DB
+	clock     mclock.Clock
+	log       log.Logger
+	wallStart time.Time      // Wall-clock time at creation
+	clockBase mclock.AbsTime // Clock time at creation
+
+	lock      sync.Mutex
+	scores    map[enode.ID]*peerScore
+	trusted   map[enode.ID]netip.Addr
+	lastPrune mclock.AbsTime
+}
+
+func newReputation(db *enode.DB, clock mclock.Clock, log log.Logger) *reputation {
+	now := clock.Now()
+	return &reputation{
+		db:        db,
+		clock:     clock,
+		log:       log,
+		wallStart: time.Now(),
+		clockBase: now,
+		scores:    make(map[enode.ID]*peerScore),
+		trusted:   make(map[enode.ID]netip.Addr),
+		lastPrune: now,
+	}
+}
+
+// now returns the current wall-clock time according to the clock.
+func (r *reputation) now() time.Time {
+	return r.wallStart.Add(time.Duration(r.clock.Now() - r.clockBase))
+}
+
+// report adds the penalty of an offense to the score of a peer, banning the
+// peer's node ID and address if the score exceeds the threshold. The return
+// value reports whether a ban was issued.
+func (r *reputation) report(id enode.ID, ip netip.Addr, offense Offense, reason string) bool {
+	if offense == OffenseNone {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.trusted[id]; ok {
+		return false
+	}
+	offenseMeter.Mark(1)
+	now := r.clock.Now()
+	r.prune(now)
+
+	score := r.scores[id]
+	if score == nil {
+		score = new(peerScore)
+		r.scores[id] = score
+	}
+	score.value = score.at(now) + offense.penalty()
+	score.updated = now
+
+	r.log.Debug("Peer misbehaved", "id", id, "offense", offense, "reason", reason, "score", int(score.value))
+	if score.value < banThreshold {
+		return false
+	}
+	delete(r.scores, id)
+	if err := r.ban(id, ip, DefaultBanDuration, fmt.Sprintf("%v: %s", offense, reason)); err != nil {
+		r.log.Warn("Failed to ban peer", "id", id, "ip", ip, "err", err)
+	}
+	return true
+}
+
+// ban persists a ban of the given node and, if valid, address. The address is
+// linked to the node ban, so that unban lifts both.
+func (r *reputation) ban(id enode.ID, ip netip.Addr, duration time.Duration, reason string) error {
+	if !ip.IsValid() || ip.IsLoopback() {
+		ip = netip.Addr{}
+	}
+	expiry := r.now().Add(duration)
+	if err := r.db.BanNode(id, ip, expiry, reason); err != nil {
+		return err
+	}
+	if ip.IsValid() {
+		if err := r.db.BanIP(ip, expiry, reason); err != nil {
+			return err
+		}
+	}
+	banMeter.Mark(1)
+	r.log.Info("Banned peer", "id", id, "ip", ip, "duration", common.PrettyDuration(duration), "reason", reason)
+	return nil
+}
+
+// unban lifts the ban of the given node, the address banned along with it and,
+// if valid, the given address.
+func (r *reputation) unban(id enode.ID, ip netip.Addr) error {
+	if ban, ok := r.db.NodeBan(id); ok && ban.LinkedIP.IsValid() {
+		if err := r.db.UnbanIP(ban.LinkedIP); err != nil {
+			return err
+		}
+	}
+	if err := r.db.UnbanNode(id); err != nil {
+		return err
+	}
+	if ip.IsValid() {
+		return r.db.UnbanIP(ip)
+	}
+	return nil
+}
+
+// setTrusted exempts a node and the address of its record from bans, or lifts
+// the exemption.
+func (r *reputation) setTrusted(n *enode.Node, trusted bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if trusted {
+		r.trusted[n.ID()] = n.IPAddr()
+	} else {
+		delete(r.trusted, n.ID())
+	}
+	delete(r.scores, n.ID())
+}
+
+// banned checks whether the node or the address it connects from is banned.
+func (r *reputation) banned(id enode.ID, ip netip.Addr) bool {
+	r.lock.Lock()
+	_, trusted := r.trusted[id]
+	r.lock.Unlock()
+	if trusted {
+		return false
+	}
+	if ban, ok := r.db.NodeBan(id); ok && ban.Expiry.After(r.now()) {
+		return true
+	}
+	return r.ipBanned(ip)
+}
+
+// ipBanned checks whether an address is banned. Addresses of trusted nodes are
+// never considered banned.
+func (r *reputation) ipBanned(ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	r.lock.Lock()
+	for _, addr := range r.trusted {
+		if addr == ip {
+			r.lock.Unlock()
+			return false
+		}
+	}
+	r.lock.Unlock()
+
+	ban, ok := r.db.IPBan(ip)
+	return ok && ban.Expiry.After(r.now())
+}
+
+// bans returns all node and address bans which haven't expired yet.
+func (r *reputation) bans() []enode.Ban {
+	var (
+		now    = r.now()
+		active []enode.Ban
+	)
+	for _, ban := range r.db.Bans() {
+		if ban.Expiry.After(now) {
+			active = append(active, ban)
+		}
+	}
+	return active
+}
+
+// prune drops scores which decayed to insignificance. It must be called with
+// the lock held.
+func (r *reputation) prune(now mclock.AbsTime) {
+	if time.Duration(now-r.lastPrune) < scorePruneCycle {
+		return
+	}
+	r.lastPrune = now
+	for id, score := range r.scores {
+		if score.at(now) < 1 {
+			delete(r.scores, id)
+		}
+	}
+}
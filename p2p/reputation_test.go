@@ -0,0 +1,171 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/shudolab/core-geth/common/mclock"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/p2p/enode"
+	"github.com/shudolab/core-geth/p2p/enr"
+)
+
+func TestReputationBan(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		clock = new(mclock.Simulated)
+		rep   = newReputation(db, clock, log.Root())
+		id    = enode.ID{1}
+		ip    = netip.MustParseAddr("10.0.0.1")
+	)
+	// Offenses below the threshold must not ban
+	for i := 0; i < 3; i++ {
+		if rep.report(id, ip, OffenseStall, "timeout") {
+			t.Fatalf("banned after %d stalls", i+1)
+		}
+	}
+	if rep.banned(id, ip) {
+		t.Fatal("peer banned below threshold")
+	}
+	// Scores decay, so the same offenses spread over time must not ban either
+	clock.Run(4 * scoreHalfLife)
+	if rep.report(id, ip, OffenseStall, "timeout") {
+		t.Fatal("banned despite score decay")
+	}
+	// No single offense bans, repeated ones exceeding the threshold ban both
+	// node and address
+	if rep.report(id, ip, OffenseInvalidData, "bad block") {
+		t.Fatal("banned after single invalid data")
+	}
+	if !rep.report(id, ip, OffenseInvalidData, "bad block") {
+		t.Fatal("not banned after repeated invalid data")
+	}
+	if !rep.banned(id, netip.Addr{}) {
+		t.Error("node not banned")
+	}
+	if !rep.banned(enode.ID{2}, ip) {
+		t.Error("address not banned")
+	}
+	if rep.banned(enode.ID{2}, netip.MustParseAddr("10.0.0.2")) {
+		t.Error("unrelated peer banned")
+	}
+}
+
+func TestReputationLoopbackNotBanned(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	rep := newReputation(db, new(mclock.Simulated), log.Root())
+	rep.report(enode.ID{1}, netip.MustParseAddr("127.0.0.1"), OffenseInvalidData, "bad block")
+	rep.report(enode.ID{1}, netip.MustParseAddr("127.0.0.1"), OffenseInvalidData, "bad block")
+	if rep.ipBanned(netip.MustParseAddr("127.0.0.1")) {
+		t.Error("loopback address banned")
+	}
+	if !rep.banned(enode.ID{1}, netip.Addr{}) {
+		t.Error("node not banned")
+	}
+}
+
+func TestReputationTrusted(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep = newReputation(db, new(mclock.Simulated), log.Root())
+		ip  = netip.MustParseAddr("10.0.0.1")
+		r   enr.Record
+	)
+	r.Set(enr.IPv4Addr(ip))
+	trusted := enode.SignNull(&r, enode.ID{1})
+
+	// Ban another node sharing the address of the trusted node
+	if err := rep.ban(enode.ID{2}, ip, time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if !rep.ipBanned(ip) {
+		t.Fatal("address not banned")
+	}
+	rep.setTrusted(trusted, true)
+	if rep.ipBanned(ip) || rep.banned(trusted.ID(), ip) {
+		t.Error("trusted node banned by address")
+	}
+	if rep.report(trusted.ID(), ip, OffenseInvalidData, "bad block") || rep.report(trusted.ID(), ip, OffenseInvalidData, "bad block") {
+		t.Error("trusted node banned by report")
+	}
+	rep.setTrusted(trusted, false)
+	if !rep.ipBanned(ip) {
+		t.Error("address not banned after lifting trust")
+	}
+}
+
+func TestReputationUnban(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep = newReputation(db, new(mclock.Simulated), log.Root())
+		id  = enode.ID{1}
+		ip  = netip.MustParseAddr("10.0.0.1")
+	)
+	// Ban by the address of the live connection, unban without knowing it
+	if err := rep.ban(id, ip, time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rep.unban(id, netip.Addr{}); err != nil {
+		t.Fatal(err)
+	}
+	if rep.banned(id, netip.Addr{}) || rep.ipBanned(ip) {
+		t.Error("ban not lifted")
+	}
+	if bans := db.Bans(); len(bans) != 0 {
+		t.Errorf("bans left over: %v", bans)
+	}
+}
+
+func TestReputationBanExpiry(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		clock = new(mclock.Simulated)
+		rep   = newReputation(db, clock, log.Root())
+		id    = enode.ID{1}
+		ip    = netip.MustParseAddr("10.0.0.1")
+	)
+	if err := rep.ban(id, ip, time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Run(59 * time.Minute)
+	if !rep.banned(id, netip.Addr{}) || !rep.ipBanned(ip) {
+		t.Fatal("ban lifted before expiry")
+	}
+	if bans := rep.bans(); len(bans) != 2 {
+		t.Fatalf("have %d bans before expiry, want 2", len(bans))
+	}
+	clock.Run(2 * time.Minute)
+	if rep.banned(id, netip.Addr{}) || rep.ipBanned(ip) {
+		t.Error("ban not lifted after expiry")
+	}
+	if bans := rep.bans(); len(bans) != 0 {
+		t.Errorf("bans left over after expiry: %v", bans)
+	}
+}
@@ -189,12 +189,13 @@ type Server struct {
 	peerFeed     event.Feed
 	log          log.Logger
 
-	nodedb    *enode.DB
-	localnode *enode.LocalNode
-	discv4    *discover.UDPv4
-	discv5    *discover.UDPv5
-	discmix   *enode.FairMix
-	dialsched *dialScheduler
+	nodedb     *enode.DB
+	reputation *reputation
+	localnode  *enode.LocalNode
+	discv4     *discover.UDPv4
+	discv5     *discover.UDPv5
+	discmix    *enode.FairMix
+	dialsched  *dialScheduler
 
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
@@ -384,6 +385,43 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// BanPeer bans the given node for the given duration, disconnecting it if it is
+// currently connected. The IP address of the node record, or of the live
+// connection, is banned as well.
+func (srv *Server) BanPeer(node *enode.Node, duration time.Duration, reason string) error {
+	if srv.reputation == nil {
+		return errServerStopped
+	}
+	var ip netip.Addr
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if peer := peers[node.ID()]; peer != nil {
+			ip = netutil.AddrAddr(peer.RemoteAddr())
+			peer.Disconnect(DiscUselessPeer)
+		}
+	})
+	if !ip.IsValid() {
+		ip = node.IPAddr()
+	}
+	return srv.reputation.ban(node.ID(), ip, duration, reason)
+}
+
+// UnbanPeer lifts the ban of the given node, of the IP address banned along with
+// it and of the IP address of its record.
+func (srv *Server) UnbanPeer(node *enode.Node) error {
+	if srv.reputation == nil {
+		return errServerStopped
+	}
+	return srv.reputation.unban(node.ID(), node.IPAddr())
+}
+
+// Bans returns all node and IP address bans currently in effect.
+func (srv *Server) Bans() []enode.Ban {
+	if srv.reputation == nil {
+		return nil
+	}
+	return srv.reputation.bans()
+}
+
 // SubscribeEvents subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -529,6 +567,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db, srv.clock, srv.log)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -610,12 +649,29 @@ func (srv *Server) setupDialScheduler() {
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.Logger,
 		netRestrict:    srv.NetRestrict,
+		banned:         srv.reputation.banned,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
 	}
 	if srv.discv4 != nil {
 		config.resolver = srv.discv4
 	}
+	var filters []func(*enode.Node) bool
+	for _, p := range srv.Protocols {
+		if p.DialFilter != nil {
+			filters = append(filters, p.DialFilter)
+		}
+	}
+	if len(filters) > 0 {
+		config.filter = func(n *enode.Node) bool {
+			for _, filter := range filters {
+				if !filter(n) {
+					return false
+				}
+			}
+			return true
+		}
+	}
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
@@ -727,6 +783,7 @@ func (srv *Server) run() {
 	// Trusted peers are loaded on startup or added via AddTrustedPeer RPC.
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID()] = true
+		srv.reputation.setTrusted(n, true)
 	}
 
 running:
@@ -741,6 +798,7 @@ running:
 			// to the trusted node set.
 			srv.log.Trace("Adding trusted node", "node", n)
 			trusted[n.ID()] = true
+			srv.reputation.setTrusted(n, true)
 			if p, ok := peers[n.ID()]; ok {
 				p.rw.set(trustedConn, true)
 			}
@@ -750,6 +808,7 @@ running:
 			// from the trusted node set.
 			srv.log.Trace("Removing trusted node", "node", n)
 			delete(trusted, n.ID())
+			srv.reputation.setTrusted(n, false)
 			if p, ok := peers[n.ID()]; ok {
 				p.rw.set(trustedConn, false)
 			}
@@ -850,6 +909,10 @@ func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *
 	if len(srv.Protocols) > 0 && countMatchingProtocols(srv.Protocols, c.caps) == 0 {
 		return DiscUselessPeer
 	}
+	// Drop banned nodes, unless explicitly trusted.
+	if !c.is(trustedConn) && srv.reputation.banned(c.node.ID(), netutil.AddrAddr(c.fd.RemoteAddr())) {
+		return DiscUselessPeer
+	}
 	// Repeat the post-handshake checks because the
 	// peer set might have changed since those checks were performed.
 	return srv.postHandshakeChecks(peers, inboundCount, c)
@@ -933,6 +996,10 @@ func (srv *Server) checkInboundConn(remoteIP netip.Addr) error {
 	if srv.NetRestrict != nil && !srv.NetRestrict.ContainsAddr(remoteIP) {
 		return errors.New("not in netrestrict list")
 	}
+	// Reject banned addresses, unless they belong to trusted nodes.
+	if srv.reputation != nil && srv.reputation.ipBanned(remoteIP) {
+		return errors.New("banned")
+	}
 	// Reject Internet peers that try too often.
 	now := srv.clock.Now()
 	srv.inboundHistory.expire(now, nil)
@@ -1049,6 +1116,7 @@ func (srv *Server) launchPeer(c *conn) *Peer {
 		// to the peer.
 		p.events = &srv.peerFeed
 	}
+	p.reputation = srv.reputation
 	go srv.runPeer(p)
 	return p
 }
@@ -99,9 +99,9 @@ func (t *rlpxTransport) WriteMsg(msg Msg) error {
 	// Set metrics.
 	msg.meterSize = size
 	if metrics.Enabled && msg.meterCap.Name != "" { // don't meter non-subprotocol messages
-		m := fmt.Sprintf("%s/%s/%d/%#02x", egressMeterName, msg.meterCap.Name, msg.meterCap.Version, msg.meterCode)
-		metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
-		metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
+		labels := messageLabels(msg.meterCap.Name, msg.meterCap.Version, msg.meterCode)
+		egressMessageBytes.WithLabelValues(labels...).Inc(int64(msg.meterSize))
+		egressMessagePackets.WithLabelValues(labels...).Inc(1)
 	}
 	return nil
 }
@@ -263,6 +263,23 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToBlock: 30,
 			},
 		},
+		// 9
+		// Moving the activation of a custom precompile is a fork incompatibility.
+		{
+			stored: &coregeth.CoreGethChainConfig{Ethash: new(ctypes.EthashConfig), CustomPrecompiles: ctypes.CustomPrecompiles{
+				{Name: "keccak256", Address: common.HexToAddress("0x100"), Block: uint64P(30)},
+			}},
+			new: &coregeth.CoreGethChainConfig{Ethash: new(ctypes.EthashConfig), CustomPrecompiles: ctypes.CustomPrecompiles{
+				{Name: "keccak256", Address: common.HexToAddress("0x100"), Block: uint64P(35)},
+			}},
+			headBlock: 40,
+			wantErr: &confp.ConfigCompatError{
+				What:          "incompatible custom precompile: keccak256@0x0000000000000000000000000000000000000100",
+				StoredBlock:   big.NewInt(30),
+				NewBlock:      big.NewInt(35),
+				RewindToBlock: 29,
+			},
+		},
 	}
 
 	for i, test := range tests {
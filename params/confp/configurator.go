@@ -173,6 +173,9 @@ func IsValid(conf ctypes.ChainConfigurator, head *uint64) *ConfigValidError {
 	if conf.GetNetworkID() == nil {
 		return NewValidErr("NetworkID cannot be nil", "!=nil", conf.GetNetworkID())
 	}
+	if err := conf.GetCustomPrecompiles().Validate(); err != nil {
+		return NewValidErr("invalid custom precompiles", err, nil)
+	}
 	if head == nil {
 		return nil
 	}
@@ -253,6 +256,11 @@ func compatible(headBlock *big.Int, headTime *uint64, a, b ctypes.ChainConfigura
 				return err
 			}
 		}
+		for _, p := range customPrecompilePairs(a, b) {
+			if isBlockForkIncompatible(Uint64Ptr2Big(p.a.Block), Uint64Ptr2Big(p.b.Block), headBlock) {
+				return newBlockCompatError("incompatible custom precompile: "+p.name, Uint64Ptr2Big(p.a.Block), Uint64Ptr2Big(p.b.Block))
+			}
+		}
 		if a.IsEnabled(a.GetEIP155Transition, headBlock) {
 			if a.GetChainID().Cmp(b.GetChainID()) != 0 {
 				ta := a.GetEIP155Transition()
@@ -288,11 +296,44 @@ func compatible(headBlock *big.Int, headTime *uint64, a, b ctypes.ChainConfigura
 				return err
 			}
 		}
+		for _, p := range customPrecompilePairs(a, b) {
+			if isTimeForkIncompatible(p.a.Time, p.b.Time, headTime) {
+				return newTimestampCompatError("incompatible custom precompile: "+p.name, p.a.Time, p.b.Time)
+			}
+		}
 	}
 
 	return nil
 }
 
+// customPrecompilePair holds the activations of a custom precompile in two
+// configurations, which are empty where the precompile is not configured.
+type customPrecompilePair struct {
+	name string
+	a, b ctypes.CustomPrecompile
+}
+
+// customPrecompilePairs matches the custom precompiles of two configurations
+// by name and address.
+func customPrecompilePairs(a, b ctypes.ChainConfigurator) []customPrecompilePair {
+	var (
+		pairs []customPrecompilePair
+		index = make(map[string]int)
+	)
+	for _, p := range a.GetCustomPrecompiles() {
+		index[p.String()] = len(pairs)
+		pairs = append(pairs, customPrecompilePair{name: p.String(), a: p})
+	}
+	for _, p := range b.GetCustomPrecompiles() {
+		if i, ok := index[p.String()]; ok {
+			pairs[i].b = p
+			continue
+		}
+		pairs = append(pairs, customPrecompilePair{name: p.String(), b: p})
+	}
+	return pairs
+}
+
 // isBigNilOrMaxed returns true if the given big.Int is nil or has a value of
 // any math max value (uint64, int64, int, int32, int16, int8).
 func isBigNilOrMaxed(b *big.Int) bool {
@@ -482,6 +523,16 @@ func BlockForks(conf ctypes.ChainConfigurator) []uint64 {
 			forksM[*response] = struct{}{}
 		}
 	}
+	// Custom precompile activations are forks too.
+	for _, p := range conf.GetCustomPrecompiles() {
+		if p.Block == nil || *p.Block == 0 {
+			continue
+		}
+		if _, ok := forksM[*p.Block]; !ok {
+			forks = append(forks, *p.Block)
+			forksM[*p.Block] = struct{}{}
+		}
+	}
 	sort.Slice(forks, func(i, j int) bool {
 		return forks[i] < forks[j]
 	})
@@ -525,6 +576,16 @@ func TimeForks(conf ctypes.ChainConfigurator, genesis uint64) []uint64 {
 			forksM[*response] = struct{}{}
 		}
 	}
+	// Custom precompile activations are forks too.
+	for _, p := range conf.GetCustomPrecompiles() {
+		if p.Time == nil || *p.Time == 0 {
+			continue
+		}
+		if _, ok := forksM[*p.Time]; !ok {
+			forks = append(forks, *p.Time)
+			forksM[*p.Time] = struct{}{}
+		}
+	}
 	sort.Slice(forks, func(i, j int) bool {
 		return forks[i] < forks[j]
 	})
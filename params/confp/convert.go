@@ -168,7 +168,7 @@ methodsLoop:
 		if !crushZeroValues {
 			allNil := true
 			for _, r := range response {
-				if r.Kind() == reflect.Ptr && !r.IsNil() {
+				if (r.Kind() == reflect.Ptr && !r.IsNil()) || (r.Kind() == reflect.Slice && r.Len() > 0) {
 					allNil = false
 					break
 				}
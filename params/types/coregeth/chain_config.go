@@ -185,6 +185,7 @@ type CoreGethChainConfig struct {
 	ECIP1080FBlock     *big.Int `json:"ecip1080FBlock,omitempty"`
 
 	ECIP1099FBlock           *big.Int `json:"ecip1099FBlock,omitempty"`                 // ECIP1099 etchash HF block
+	ECIP1049FBlock           *big.Int `json:"ecip1049FBlock,omitempty"`                 // ECIP1049 Keccak-256 proof-of-work HF block
 	ECBP1100FBlock           *big.Int `json:"ecbp1100FBlock,omitempty"`                 // ECBP1100:MESS artificial finality
 	ECBP1100DeactivateFBlock *big.Int `json:"ecbp1100DeactivateFBlockFBlock,omitempty"` // Deactivate ECBP1100:MESS artificial finality
 
@@ -249,6 +250,18 @@ type CoreGethChainConfig struct {
 	EIP6780FBlock *big.Int `json:"eip6780FBlock,omitempty"` // EIP-6780: SELFDESTRUCT only in same transaction https://eips.ethereum.org/EIPS/eip-6780
 	EIP4788FBlock *big.Int `json:"eip4788FBlock,omitempty"` // EIP-4788: Beacon block root in the EVM https://eips.ethereum.org/EIPS/eip-4788
 
+	// EOF: EVM Object Format with block activations
+	EIP3540FBlock *big.Int `json:"eip3540FBlock,omitempty"` // EIP-3540: EVM Object Format v1 https://eips.ethereum.org/EIPS/eip-3540
+	EIP3670FBlock *big.Int `json:"eip3670FBlock,omitempty"` // EIP-3670: Code Validation https://eips.ethereum.org/EIPS/eip-3670
+	EIP4200FBlock *big.Int `json:"eip4200FBlock,omitempty"` // EIP-4200: Static relative jumps https://eips.ethereum.org/EIPS/eip-4200
+	EIP4750FBlock *big.Int `json:"eip4750FBlock,omitempty"` // EIP-4750: Functions https://eips.ethereum.org/EIPS/eip-4750
+	EIP5450FBlock *big.Int `json:"eip5450FBlock,omitempty"` // EIP-5450: Stack Validation https://eips.ethereum.org/EIPS/eip-5450
+	EIP7480FBlock *big.Int `json:"eip7480FBlock,omitempty"` // EIP-7480: Data section access instructions https://eips.ethereum.org/EIPS/eip-7480
+
+	// CustomPrecompiles activates precompiled contracts registered by name with
+	// the EVM, e.g. for private networks needing additional native contracts.
+	CustomPrecompiles ctypes.CustomPrecompiles `json:"customPrecompiles,omitempty"`
+
 	// Verkle Trie
 	VerkleFTime  *uint64  `json:"verkleFTime,omitempty"`
 	VerkleFBlock *big.Int `json:"verkleFBlock,omitempty"`
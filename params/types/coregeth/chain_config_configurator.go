@@ -157,6 +157,15 @@ func (c *CoreGethChainConfig) SetBaseFeeChangeDenominator(n uint64) error {
 	return internal.GlobalConfigurator().SetBaseFeeChangeDenominator(n)
 }
 
+func (c *CoreGethChainConfig) GetCustomPrecompiles() ctypes.CustomPrecompiles {
+	return c.CustomPrecompiles
+}
+
+func (c *CoreGethChainConfig) SetCustomPrecompiles(p ctypes.CustomPrecompiles) error {
+	c.CustomPrecompiles = p
+	return nil
+}
+
 func (c *CoreGethChainConfig) GetEIP7Transition() *uint64 {
 	return bigNewU64(c.EIP7FBlock)
 }
@@ -748,6 +757,66 @@ func (c *CoreGethChainConfig) SetEIP4788Transition(n *uint64) error {
 	return nil
 }
 
+// GetEIP3540Transition EIP3540: EVM Object Format v1
+func (c *CoreGethChainConfig) GetEIP3540Transition() *uint64 {
+	return bigNewU64(c.EIP3540FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP3540Transition(n *uint64) error {
+	c.EIP3540FBlock = setBig(c.EIP3540FBlock, n)
+	return nil
+}
+
+// GetEIP3670Transition EIP3670: Code Validation
+func (c *CoreGethChainConfig) GetEIP3670Transition() *uint64 {
+	return bigNewU64(c.EIP3670FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP3670Transition(n *uint64) error {
+	c.EIP3670FBlock = setBig(c.EIP3670FBlock, n)
+	return nil
+}
+
+// GetEIP4200Transition EIP4200: Static relative jumps
+func (c *CoreGethChainConfig) GetEIP4200Transition() *uint64 {
+	return bigNewU64(c.EIP4200FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP4200Transition(n *uint64) error {
+	c.EIP4200FBlock = setBig(c.EIP4200FBlock, n)
+	return nil
+}
+
+// GetEIP4750Transition EIP4750: Functions
+func (c *CoreGethChainConfig) GetEIP4750Transition() *uint64 {
+	return bigNewU64(c.EIP4750FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP4750Transition(n *uint64) error {
+	c.EIP4750FBlock = setBig(c.EIP4750FBlock, n)
+	return nil
+}
+
+// GetEIP5450Transition EIP5450: Stack Validation
+func (c *CoreGethChainConfig) GetEIP5450Transition() *uint64 {
+	return bigNewU64(c.EIP5450FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP5450Transition(n *uint64) error {
+	c.EIP5450FBlock = setBig(c.EIP5450FBlock, n)
+	return nil
+}
+
+// GetEIP7480Transition EIP7480: Data section access instructions
+func (c *CoreGethChainConfig) GetEIP7480Transition() *uint64 {
+	return bigNewU64(c.EIP7480FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEIP7480Transition(n *uint64) error {
+	c.EIP7480FBlock = setBig(c.EIP7480FBlock, n)
+	return nil
+}
+
 func (c *CoreGethChainConfig) GetMergeVirtualTransition() *uint64 {
 	return bigNewU64(c.MergeNetsplitVBlock)
 }
@@ -1300,6 +1369,21 @@ func (c *CoreGethChainConfig) SetEthashECIP1099Transition(n *uint64) error {
 	return nil
 }
 
+func (c *CoreGethChainConfig) GetEthashECIP1049Transition() *uint64 {
+	if c.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
+		return nil
+	}
+	return bigNewU64(c.ECIP1049FBlock)
+}
+
+func (c *CoreGethChainConfig) SetEthashECIP1049Transition(n *uint64) error {
+	if c.Ethash == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	c.ECIP1049FBlock = setBig(c.ECIP1049FBlock, n)
+	return nil
+}
+
 func (c *CoreGethChainConfig) GetEthashEIP5133Transition() *uint64 {
 	if c.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
 		return nil
@@ -75,6 +75,10 @@ type ProtocolSpecifier interface {
 	GetBaseFeeChangeDenominator() uint64
 	SetBaseFeeChangeDenominator(n uint64) error
 
+	// GetCustomPrecompiles returns the precompiles activated by name, in addition to the standard ones.
+	GetCustomPrecompiles() CustomPrecompiles
+	SetCustomPrecompiles(p CustomPrecompiles) error
+
 	// Be careful with EIP2.
 	// It is a messy EIP, specifying diverse changes, like difficulty, intrinsic gas costs for contract creation,
 	// txpool management, and contract OoG handling.
@@ -272,6 +276,28 @@ type ProtocolSpecifier interface {
 	GetEIP4788Transition() *uint64
 	SetEIP4788Transition(n *uint64) error
 
+	// EOF: EVM Object Format. EIP-3540 introduces the container, which the
+	// other EIPs extend. They are expressed as block activation numbers only.
+
+	// GetEIP3540Transition implements EIP3540 - EOF - EVM Object Format v1 - https://eips.ethereum.org/EIPS/eip-3540
+	GetEIP3540Transition() *uint64
+	SetEIP3540Transition(n *uint64) error
+	// GetEIP3670Transition implements EIP3670 - EOF - Code Validation - https://eips.ethereum.org/EIPS/eip-3670
+	GetEIP3670Transition() *uint64
+	SetEIP3670Transition(n *uint64) error
+	// GetEIP4200Transition implements EIP4200 - EOF - Static relative jumps - https://eips.ethereum.org/EIPS/eip-4200
+	GetEIP4200Transition() *uint64
+	SetEIP4200Transition(n *uint64) error
+	// GetEIP4750Transition implements EIP4750 - EOF - Functions - https://eips.ethereum.org/EIPS/eip-4750
+	GetEIP4750Transition() *uint64
+	SetEIP4750Transition(n *uint64) error
+	// GetEIP5450Transition implements EIP5450 - EOF - Stack Validation - https://eips.ethereum.org/EIPS/eip-5450
+	GetEIP5450Transition() *uint64
+	SetEIP5450Transition(n *uint64) error
+	// GetEIP7480Transition implements EIP7480 - EOF - Data section access instructions - https://eips.ethereum.org/EIPS/eip-7480
+	GetEIP7480Transition() *uint64
+	SetEIP7480Transition(n *uint64) error
+
 	// Verkle Trie
 
 	GetVerkleTransitionTime() *uint64
@@ -344,6 +370,8 @@ type EthashConfigurator interface {
 	SetEthashECIP1041Transition(n *uint64) error
 	GetEthashECIP1099Transition() *uint64
 	SetEthashECIP1099Transition(n *uint64) error
+	GetEthashECIP1049Transition() *uint64 // Keccak-256 proof-of-work replaces ethash
+	SetEthashECIP1049Transition(n *uint64) error
 	GetEthashEIP5133Transition() *uint64 // Gray Glacier difficulty bomb delay
 	SetEthashEIP5133Transition(n *uint64) error
 
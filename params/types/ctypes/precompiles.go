@@ -0,0 +1,73 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ctypes
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/shudolab/core-geth/common"
+)
+
+// CustomPrecompile activates a precompiled contract implementation, registered
+// by name with the EVM, at an address from a given block or time. Activating a
+// custom precompile is a hard fork.
+type CustomPrecompile struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+	Block   *uint64        `json:"block,omitempty"`
+	Time    *uint64        `json:"time,omitempty"`
+}
+
+// CustomPrecompiles is the list of custom precompiles of a chain configuration.
+type CustomPrecompiles []CustomPrecompile
+
+// IsActive reports whether the precompile is active at the given block and time.
+func (p *CustomPrecompile) IsActive(bn *big.Int, bt *uint64) bool {
+	switch {
+	case p.Block != nil:
+		return bn != nil && new(big.Int).SetUint64(*p.Block).Cmp(bn) <= 0
+	case p.Time != nil:
+		return bt != nil && *p.Time <= *bt
+	}
+	return false
+}
+
+// String returns the name and address identifying the precompile.
+func (p *CustomPrecompile) String() string {
+	return fmt.Sprintf("%s@%s", p.Name, p.Address.Hex())
+}
+
+// Validate checks that every precompile is named and activated either by block
+// or by time, and that no two precompiles share an address.
+func (ps CustomPrecompiles) Validate() error {
+	seen := make(map[common.Address]string, len(ps))
+	for i := range ps {
+		p := &ps[i]
+		if p.Name == "" {
+			return fmt.Errorf("custom precompile at %s has no name", p.Address.Hex())
+		}
+		if (p.Block == nil) == (p.Time == nil) {
+			return fmt.Errorf("custom precompile %v must be activated by either block or time", p)
+		}
+		if other, ok := seen[p.Address]; ok {
+			return fmt.Errorf("custom precompiles %s and %s share address %s", other, p.Name, p.Address.Hex())
+		}
+		seen[p.Address] = p.Name
+	}
+	return nil
+}
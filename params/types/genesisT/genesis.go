@@ -76,6 +76,14 @@ func (g *Genesis) SetBaseFeeChangeDenominator(n uint64) error {
 	return g.Config.SetBaseFeeChangeDenominator(n)
 }
 
+func (g *Genesis) GetCustomPrecompiles() ctypes.CustomPrecompiles {
+	return g.Config.GetCustomPrecompiles()
+}
+
+func (g *Genesis) SetCustomPrecompiles(p ctypes.CustomPrecompiles) error {
+	return g.Config.SetCustomPrecompiles(p)
+}
+
 func (g *Genesis) GetEIP3651TransitionTime() *uint64 {
 	return g.Config.GetEIP3651TransitionTime()
 }
@@ -253,6 +261,54 @@ func (g *Genesis) SetEIP4788Transition(n *uint64) error {
 	return g.Config.SetEIP4788Transition(n)
 }
 
+func (g *Genesis) GetEIP3540Transition() *uint64 {
+	return g.Config.GetEIP3540Transition()
+}
+
+func (g *Genesis) SetEIP3540Transition(n *uint64) error {
+	return g.Config.SetEIP3540Transition(n)
+}
+
+func (g *Genesis) GetEIP3670Transition() *uint64 {
+	return g.Config.GetEIP3670Transition()
+}
+
+func (g *Genesis) SetEIP3670Transition(n *uint64) error {
+	return g.Config.SetEIP3670Transition(n)
+}
+
+func (g *Genesis) GetEIP4200Transition() *uint64 {
+	return g.Config.GetEIP4200Transition()
+}
+
+func (g *Genesis) SetEIP4200Transition(n *uint64) error {
+	return g.Config.SetEIP4200Transition(n)
+}
+
+func (g *Genesis) GetEIP4750Transition() *uint64 {
+	return g.Config.GetEIP4750Transition()
+}
+
+func (g *Genesis) SetEIP4750Transition(n *uint64) error {
+	return g.Config.SetEIP4750Transition(n)
+}
+
+func (g *Genesis) GetEIP5450Transition() *uint64 {
+	return g.Config.GetEIP5450Transition()
+}
+
+func (g *Genesis) SetEIP5450Transition(n *uint64) error {
+	return g.Config.SetEIP5450Transition(n)
+}
+
+func (g *Genesis) GetEIP7480Transition() *uint64 {
+	return g.Config.GetEIP7480Transition()
+}
+
+func (g *Genesis) SetEIP7480Transition(n *uint64) error {
+	return g.Config.SetEIP7480Transition(n)
+}
+
 // Verkle Trie
 func (g *Genesis) GetVerkleTransitionTime() *uint64 {
 	return g.Config.GetVerkleTransitionTime()
@@ -1113,6 +1169,14 @@ func (g *Genesis) SetEthashECIP1099Transition(n *uint64) error {
 	return g.Config.SetEthashECIP1099Transition(n)
 }
 
+func (g *Genesis) GetEthashECIP1049Transition() *uint64 {
+	return g.Config.GetEthashECIP1049Transition()
+}
+
+func (g *Genesis) SetEthashECIP1049Transition(n *uint64) error {
+	return g.Config.SetEthashECIP1049Transition(n)
+}
+
 func (g *Genesis) GetEthashDifficultyBombDelaySchedule() ctypes.Uint64Uint256MapEncodesHex {
 	return g.Config.GetEthashDifficultyBombDelaySchedule()
 }
@@ -98,6 +98,14 @@ type ChainConfig struct {
 	EIP1706Transition  *big.Int `json:"-"`
 	ECIP1080Transition *big.Int `json:"-"`
 
+	// EOF is not scheduled upstream, these are only set by conversion from other configuration types.
+	EIP3540Transition *big.Int `json:"-"`
+	EIP3670Transition *big.Int `json:"-"`
+	EIP4200Transition *big.Int `json:"-"`
+	EIP4750Transition *big.Int `json:"-"`
+	EIP5450Transition *big.Int `json:"-"`
+	EIP7480Transition *big.Int `json:"-"`
+
 	// Cache types for use with testing, but will not show up in config API.
 	ecbp1100Transition           *big.Int
 	ecbp1100DeactivateTransition *big.Int
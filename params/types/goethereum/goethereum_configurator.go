@@ -120,6 +120,17 @@ func (c *ChainConfig) SetBaseFeeChangeDenominator(n uint64) error {
 	return internal.GlobalConfigurator().SetBaseFeeChangeDenominator(n)
 }
 
+func (c *ChainConfig) GetCustomPrecompiles() ctypes.CustomPrecompiles {
+	return nil
+}
+
+func (c *ChainConfig) SetCustomPrecompiles(p ctypes.CustomPrecompiles) error {
+	if len(p) > 0 {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	return nil
+}
+
 // GetNetworkID and the following Set/Getters for ChainID too
 // are... opinionated... because of where and how currently the NetworkID
 // value is designed.
@@ -758,6 +769,66 @@ func (c *ChainConfig) SetEIP4788Transition(n *uint64) error {
 	return ctypes.ErrUnsupportedConfigNoop
 }
 
+// GetEIP3540Transition EIP3540: EVM Object Format v1
+func (c *ChainConfig) GetEIP3540Transition() *uint64 {
+	return bigNewU64(c.EIP3540Transition)
+}
+
+func (c *ChainConfig) SetEIP3540Transition(n *uint64) error {
+	c.EIP3540Transition = setBig(c.EIP3540Transition, n)
+	return nil
+}
+
+// GetEIP3670Transition EIP3670: Code Validation
+func (c *ChainConfig) GetEIP3670Transition() *uint64 {
+	return bigNewU64(c.EIP3670Transition)
+}
+
+func (c *ChainConfig) SetEIP3670Transition(n *uint64) error {
+	c.EIP3670Transition = setBig(c.EIP3670Transition, n)
+	return nil
+}
+
+// GetEIP4200Transition EIP4200: Static relative jumps
+func (c *ChainConfig) GetEIP4200Transition() *uint64 {
+	return bigNewU64(c.EIP4200Transition)
+}
+
+func (c *ChainConfig) SetEIP4200Transition(n *uint64) error {
+	c.EIP4200Transition = setBig(c.EIP4200Transition, n)
+	return nil
+}
+
+// GetEIP4750Transition EIP4750: Functions
+func (c *ChainConfig) GetEIP4750Transition() *uint64 {
+	return bigNewU64(c.EIP4750Transition)
+}
+
+func (c *ChainConfig) SetEIP4750Transition(n *uint64) error {
+	c.EIP4750Transition = setBig(c.EIP4750Transition, n)
+	return nil
+}
+
+// GetEIP5450Transition EIP5450: Stack Validation
+func (c *ChainConfig) GetEIP5450Transition() *uint64 {
+	return bigNewU64(c.EIP5450Transition)
+}
+
+func (c *ChainConfig) SetEIP5450Transition(n *uint64) error {
+	c.EIP5450Transition = setBig(c.EIP5450Transition, n)
+	return nil
+}
+
+// GetEIP7480Transition EIP7480: Data section access instructions
+func (c *ChainConfig) GetEIP7480Transition() *uint64 {
+	return bigNewU64(c.EIP7480Transition)
+}
+
+func (c *ChainConfig) SetEIP7480Transition(n *uint64) error {
+	c.EIP7480Transition = setBig(c.EIP7480Transition, n)
+	return nil
+}
+
 func (c *ChainConfig) GetMergeVirtualTransition() *uint64 {
 	return bigNewU64(c.MergeNetsplitBlock)
 }
@@ -1163,6 +1234,20 @@ func (c *ChainConfig) SetEthashECIP1099Transition(n *uint64) error {
 	return ctypes.ErrUnsupportedConfigFatal
 }
 
+func (c *ChainConfig) GetEthashECIP1049Transition() *uint64 {
+	return nil
+}
+
+func (c *ChainConfig) SetEthashECIP1049Transition(n *uint64) error {
+	if c.Ethash == nil {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
 func (c *ChainConfig) GetEthashEIP5133Transition() *uint64 {
 	return bigNewU64(c.GrayGlacierBlock)
 }
@@ -88,6 +88,8 @@ type Client struct {
 	// config fields
 	batchItemLimit       int
 	batchResponseMaxSize int
+	callPolicy           CallPolicy
+	responseCache        ResponseCache
 
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
@@ -120,6 +122,8 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize)
+	handler.callPolicy = c.callPolicy
+	handler.responseCache = c.responseCache
 	return &clientConn{conn, handler}
 }
 
@@ -204,7 +208,11 @@ func DialOptions(ctx context.Context, rawurl string, options ...ClientOption) (*
 	var reconnect reconnectFunc
 	switch u.Scheme {
 	case "http", "https":
-		reconnect = newClientTransportHTTP(rawurl, cfg)
+		if cfg.httpStream {
+			reconnect = newClientTransportStream(rawurl, cfg)
+		} else {
+			reconnect = newClientTransportHTTP(rawurl, cfg)
+		}
 	case "ws", "wss":
 		rc, err := newClientTransportWS(rawurl, cfg)
 		if err != nil {
@@ -247,6 +255,8 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		callPolicy:           cfg.callPolicy,
+		responseCache:        cfg.responseCache,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
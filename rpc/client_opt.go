@@ -32,6 +32,7 @@ type clientConfig struct {
 	httpClient  *http.Client
 	httpHeaders http.Header
 	httpAuth    HTTPAuth
+	httpStream  bool
 
 	// WebSocket options
 	wsDialer           *websocket.Dialer
@@ -41,6 +42,8 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	callPolicy         CallPolicy
+	responseCache      ResponseCache
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -102,6 +105,18 @@ func WithHTTPClient(c *http.Client) ClientOption {
 	})
 }
 
+// WithHTTPStream makes the client connect to http:// and https:// endpoints using a
+// single long-lived HTTP/2 stream instead of one request per call. Streams support
+// subscriptions. Plain http:// endpoints are dialed using HTTP/2 with prior knowledge.
+//
+// If a custom http.Client is configured with WithHTTPClient, it must speak HTTP/2
+// and must not set a timeout.
+func WithHTTPStream() ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.httpStream = true
+	})
+}
+
 // WithHTTPAuth configures HTTP request authentication. The given provider will be called
 // whenever a request is made. Note that only one authentication provider can be active at
 // any time.
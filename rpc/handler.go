@@ -25,7 +25,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/shudolab/core-geth/internal/tracing"
 	"github.com/shudolab/core-geth/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
@@ -62,6 +64,8 @@ type handler struct {
 	allowSubscribe       bool
 	batchRequestLimit    int
 	batchResponseMaxSize int
+	callPolicy           CallPolicy    // consulted before running calls, may be nil
+	responseCache        ResponseCache // serves immutable results, may be nil
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -503,13 +507,28 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if callb == nil {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	if callb != h.unsubscribeCb && h.callPolicy != nil {
+		if err := h.callPolicy.Admit(cp.ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	ctx, span := tracing.Start(cp.ctx, msg.Method,
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", msg.Method),
+	)
+	answer := h.runCachedMethod(ctx, msg, callb, args)
+	if answer.Error != nil {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", answer.Error.Code))
+		tracing.End(span, answer.Error)
+	} else {
+		span.End()
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -543,6 +562,11 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	if callb == nil {
 		return msg.errorResponse(&subscriptionNotFoundError{namespace, name})
 	}
+	if h.callPolicy != nil {
+		if err := h.callPolicy.Admit(cp.ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 
 	// Parse subscription name arg too, but remove it before calling the callback.
 	argTypes := append([]reflect.Type{stringType}, callb.argTypes...)
@@ -560,6 +584,22 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	return h.runMethod(ctx, msg, callb, args)
 }
 
+// runCachedMethod serves a call from the response cache if possible. Otherwise it runs
+// the method and offers the result to the cache.
+func (h *handler) runCachedMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	if h.responseCache == nil || callb == h.unsubscribeCb {
+		return h.runMethod(ctx, msg, callb, args)
+	}
+	if result, ok := h.responseCache.Get(ctx, msg.Method, msg.Params); ok {
+		return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+	}
+	answer := h.runMethod(ctx, msg, callb, args)
+	if answer.Error == nil {
+		h.responseCache.Put(ctx, msg.Method, msg.Params, answer.Result)
+	}
+	return answer
+}
+
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
 	result, err := callb.call(ctx, msg.Method, args)
@@ -30,6 +30,8 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/shudolab/core-geth/internal/tracing"
 )
 
 const (
@@ -218,6 +220,7 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Header = hc.headers.Clone()
 	hc.mu.Unlock()
 	setHeaders(req.Header, headersFromContext(ctx))
+	tracing.Inject(ctx, req.Header)
 
 	if hc.auth != nil {
 		if err := hc.auth(req.Header); err != nil {
@@ -312,6 +315,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
+	if IsStreamRequest(r) {
+		s.serveStream(w, r)
+		return
+	}
 	if code, err := s.validateRequest(r); err != nil {
 		http.Error(w, err.Error(), code)
 		return
@@ -323,8 +330,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	connInfo.HTTP.Host = r.Host
 	connInfo.HTTP.Origin = r.Header.Get("Origin")
 	connInfo.HTTP.UserAgent = r.Header.Get("User-Agent")
+	connInfo.HTTP.APIKey = r.Header.Get(APIKeyHeader)
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, connInfo)
+	ctx = tracing.Extract(ctx, r.Header)
 
 	// All checks passed, create a codec that reads directly from the request body
 	// until EOF, writes the response to w, and orders the server to process a
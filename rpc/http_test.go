@@ -23,6 +23,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/shudolab/core-geth/internal/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func confirmStatusCode(t *testing.T, got, want int) {
@@ -180,6 +184,7 @@ func TestHTTPPeerInfo(t *testing.T) {
 	}
 	c.SetHeader("user-agent", "ua-testing")
 	c.SetHeader("origin", "origin.example.com")
+	c.SetHeader(APIKeyHeader, "key-testing")
 
 	// Request peer information.
 	var info PeerInfo
@@ -202,6 +207,9 @@ func TestHTTPPeerInfo(t *testing.T) {
 	if info.HTTP.Origin != "origin.example.com" {
 		t.Errorf("wrong HTTP.Origin %q", info.HTTP.UserAgent)
 	}
+	if info.HTTP.APIKey != "key-testing" {
+		t.Errorf("wrong HTTP.APIKey %q", info.HTTP.APIKey)
+	}
 }
 
 func TestNewContextWithHeaders(t *testing.T) {
@@ -243,3 +251,37 @@ func TestNewContextWithHeaders(t *testing.T) {
 		t.Error("call failed:", err)
 	}
 }
+
+func TestHTTPTraceContext(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracing.Enable(provider)
+	defer func() {
+		tracing.Disable()
+		provider.Shutdown(context.Background())
+	}()
+
+	s := newTestServer()
+	defer s.Stop()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetHeader("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if err := c.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatal(err)
+	}
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("wrong number of spans: %d", len(spans))
+	}
+	if spans[0].Name != "test_noArgsRets" {
+		t.Errorf("wrong span name %q", spans[0].Name)
+	}
+	if spans[0].Parent.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("trace context not propagated, parent %v", spans[0].Parent)
+	}
+}
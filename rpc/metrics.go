@@ -17,7 +17,6 @@
 package rpc
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/shudolab/core-geth/metrics"
@@ -28,8 +27,13 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedRequestGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 
-	// serveTimeHistName is the prefix of the per-request serving time histograms.
-	serveTimeHistName = "rpc/duration"
+	// serveTimeHist tracks the per-request serving times, labelled by method
+	// name and outcome.
+	serveTimeHist = metrics.NewRegisteredHistogramVec("rpc/duration", nil, func() metrics.Sample {
+		return metrics.ResettingSample(
+			metrics.NewExpDecaySample(1028, 0.015),
+		)
+	}, "method", "result")
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
 )
@@ -40,11 +44,5 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	if !success {
 		note = "failure"
 	}
-	h := fmt.Sprintf("%s/%s/%s", serveTimeHistName, method, note)
-	sampler := func() metrics.Sample {
-		return metrics.ResettingSample(
-			metrics.NewExpDecaySample(1028, 0.015),
-		)
-	}
-	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Nanoseconds())
+	serveTimeHist.WithLabelValues(method, note).Update(elapsed.Nanoseconds())
 }
@@ -0,0 +1,64 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key of a client. It is read for
+// HTTP requests and during the WebSocket handshake, and made available to call
+// policies through PeerInfo.
+const APIKeyHeader = "X-Api-Key"
+
+// CallPolicy decides whether method calls may be served.
+type CallPolicy interface {
+	// Admit is called before a method call or subscription is executed. The context
+	// carries the PeerInfo of the connection. A non-nil error is sent to the caller
+	// instead of running the method.
+	Admit(ctx context.Context, method string) error
+}
+
+// SetCallPolicy sets the policy consulted before executing method calls. Passing nil
+// removes the policy.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetCallPolicy(p CallPolicy) {
+	s.callPolicy = p
+}
+
+// ResponseCache stores the results of method calls which don't change anymore,
+// allowing them to be served without running the method.
+type ResponseCache interface {
+	// Get returns the cached result of a call.
+	Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool)
+
+	// Put offers the result of a successful call to the cache, which decides
+	// whether the result is immutable.
+	Put(ctx context.Context, method string, params, result json.RawMessage)
+}
+
+// SetResponseCache sets the cache consulted before executing method calls. Passing nil
+// removes the cache.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetResponseCache(c ResponseCache) {
+	s.responseCache = c
+}
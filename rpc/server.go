@@ -52,6 +52,8 @@ type Server struct {
 	batchItemLimit     int
 	batchResponseLimit int
 	httpBodyLimit      int
+	callPolicy         CallPolicy
+	responseCache      ResponseCache
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -112,6 +114,8 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		callPolicy:         s.callPolicy,
+		responseCache:      s.responseCache,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -147,6 +151,8 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
 	h.allowSubscribe = false
+	h.callPolicy = s.callPolicy
+	h.responseCache = s.responseCache
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -223,7 +229,7 @@ func (s *RPCService) methods() map[string][]string {
 // the current method call.
 type PeerInfo struct {
 	// Transport is name of the protocol used by the client.
-	// This can be "http", "ws" or "ipc".
+	// This can be "http", "ws", "stream" or "ipc".
 	Transport string
 
 	// Address of client. This will usually contain the IP address and port.
@@ -237,6 +243,8 @@ type PeerInfo struct {
 		UserAgent string
 		Origin    string
 		Host      string
+		// API key sent in the APIKeyHeader.
+		APIKey string
 	}
 }
 
@@ -19,8 +19,11 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"net"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -192,3 +195,105 @@ func TestServerBatchResponseSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+type denyPolicy struct{ denied []string }
+
+func (p *denyPolicy) Admit(ctx context.Context, method string) error {
+	if PeerInfoFromContext(ctx).HTTP.APIKey == "admin" {
+		return nil
+	}
+	for _, m := range p.denied {
+		if m == method {
+			return testError{}
+		}
+	}
+	return nil
+}
+
+// This test checks that the call policy is consulted for calls and subscriptions.
+func TestServerCallPolicy(t *testing.T) {
+	server := newTestServer()
+	server.SetCallPolicy(&denyPolicy{denied: []string{"test_echo", "nftest_subscribe"}})
+	defer server.Stop()
+
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer ts.Close()
+	url := "ws:" + strings.TrimPrefix(ts.URL, "http:")
+
+	client, err := DialOptions(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var res echoResult
+	if err := client.Call(&res, "test_echo", "x", 1); err == nil {
+		t.Fatal("denied call succeeded")
+	} else if re, ok := err.(Error); !ok || re.ErrorCode() != (testError{}).ErrorCode() {
+		t.Fatalf("wrong error %v", err)
+	}
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("admitted call failed: %v", err)
+	}
+	if _, err := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 1, 1); err == nil {
+		t.Fatal("denied subscription succeeded")
+	}
+
+	admin, err := DialOptions(context.Background(), url, WithHeader(APIKeyHeader, "admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if err := admin.Call(&res, "test_echo", "x", 1); err != nil {
+		t.Fatalf("admitted call failed: %v", err)
+	}
+}
+
+type mapCache struct {
+	results map[string]json.RawMessage
+	puts    int
+}
+
+func (c *mapCache) Get(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, bool) {
+	res, ok := c.results[method+string(params)]
+	return res, ok
+}
+
+func (c *mapCache) Put(ctx context.Context, method string, params, result json.RawMessage) {
+	c.puts++
+	c.results[method+string(params)] = result
+}
+
+// This test checks that cached responses are served without running the method,
+// and that only successful results are offered to the cache.
+func TestServerResponseCache(t *testing.T) {
+	server := newTestServer()
+	cache := &mapCache{results: map[string]json.RawMessage{
+		`test_echo["x",1]`: json.RawMessage(`{"String":"cached","Int":0,"Args":null}`),
+	}}
+	server.SetResponseCache(cache)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	var res echoResult
+	if err := client.Call(&res, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if res.String != "cached" {
+		t.Fatalf("wrong result %+v, want cached response", res)
+	}
+	if err := client.Call(&res, "test_echo", "y", 2); err != nil {
+		t.Fatal(err)
+	}
+	if res.String != "y" || cache.puts != 1 {
+		t.Fatalf("wrong result %+v or put count %d", res, cache.puts)
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	if cache.puts != 1 {
+		t.Fatalf("failed call was offered to the cache")
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shudolab/core-geth/log"
+	"golang.org/x/net/http2"
+)
+
+// StreamContentType is the content type of JSON-RPC streams. A stream is a single
+// HTTP/2 POST request whose body carries newline-delimited JSON-RPC messages from
+// the client, while the response body carries responses and subscription
+// notifications back. Unlike plain HTTP, streams are long-lived and support
+// subscriptions.
+const StreamContentType = "application/x-ndjson"
+
+var (
+	errStreamLimit   = errors.New("stream message too large")
+	errStreamClosed  = errors.New("stream closed")
+	streamReadLimit  = int64(wsDefaultReadLimit)
+	streamHTTPClient = &http.Client{Transport: &http2.Transport{
+		AllowHTTP:          true,
+		DisableCompression: true,
+		// Plain HTTP streams use HTTP/2 with prior knowledge (h2c), so dial
+		// without TLS. The transport always dials through DialTLSContext.
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	streamHTTPSClient = &http.Client{Transport: &http2.Transport{DisableCompression: true}}
+)
+
+// IsStreamRequest checks whether r opens a JSON-RPC stream.
+func IsStreamRequest(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("content-type"))
+	return err == nil && r.Method == http.MethodPost && mt == StreamContentType
+}
+
+// serveStream serves a JSON-RPC stream until the client closes the request body
+// or the server is stopped.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor < 2 {
+		http.Error(w, "JSON-RPC streams require HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	// Streams outlive any request timeouts of the HTTP server. Lifting them is not
+	// supported by all response writers, so errors are ignored.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("content-type", StreamContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Debug("Can't flush JSON-RPC stream", "addr", r.RemoteAddr, "err", err)
+		return
+	}
+	conn := &streamServerConn{body: r.Body, w: w, rc: rc, remote: r.RemoteAddr}
+	info := PeerInfo{Transport: "stream", RemoteAddr: r.RemoteAddr}
+	info.HTTP.Version = r.Proto
+	info.HTTP.Host = r.Host
+	info.HTTP.Origin = r.Header.Get("Origin")
+	info.HTTP.UserAgent = r.Header.Get("User-Agent")
+	info.HTTP.APIKey = r.Header.Get(APIKeyHeader)
+	s.ServeCodec(newStreamCodec(conn, r.Body, info, streamReadLimit), 0)
+}
+
+// streamCodec reads and writes newline-delimited JSON-RPC messages on a stream.
+type streamCodec struct {
+	*jsonCodec
+	info PeerInfo
+}
+
+func newStreamCodec(conn deadlineCloser, r io.Reader, info PeerInfo, readLimit int64) ServerCodec {
+	var (
+		br  = bufio.NewReader(r)
+		enc = json.NewEncoder(conn.(io.Writer))
+	)
+	encode := func(v interface{}, isErrorResponse bool) error {
+		return enc.Encode(v)
+	}
+	decode := func(v interface{}) error {
+		for {
+			line, err := readStreamLine(br, readLimit)
+			if err != nil {
+				return err
+			}
+			if len(bytes.TrimSpace(line)) > 0 {
+				return json.Unmarshal(line, v)
+			}
+		}
+	}
+	return &streamCodec{
+		jsonCodec: NewFuncCodec(conn, encode, decode).(*jsonCodec),
+		info:      info,
+	}
+}
+
+func (c *streamCodec) peerInfo() PeerInfo {
+	return c.info
+}
+
+// readStreamLine reads the next newline-terminated message, failing if it exceeds
+// limit bytes. A limit of zero means no limit. The final message may omit the newline.
+func readStreamLine(br *bufio.Reader, limit int64) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if limit > 0 && int64(len(line)+len(chunk)) > limit {
+			return nil, errStreamLimit
+		}
+		line = append(line, chunk...)
+		switch {
+		case err == nil:
+			return line, nil
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(line) > 0:
+			return line, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+// streamServerConn is the server side of a stream. Every write is flushed so that
+// messages are delivered immediately.
+type streamServerConn struct {
+	body   io.ReadCloser
+	w      io.Writer
+	rc     *http.ResponseController
+	remote string
+
+	mu       sync.Mutex
+	closed   bool
+	deadline time.Time
+}
+
+func (c *streamServerConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The response writer must not be used after the handler has returned.
+	if c.closed {
+		return 0, errStreamClosed
+	}
+	// HTTP/2 resets the stream when a write deadline passes, even if the stream is
+	// idle at the time. Apply the deadline only while writing.
+	c.rc.SetWriteDeadline(c.deadline)
+	defer c.rc.SetWriteDeadline(time.Time{})
+
+	n, err := c.w.Write(b)
+	if err == nil {
+		err = c.rc.Flush()
+	}
+	return n, err
+}
+
+func (c *streamServerConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deadline = t
+	return nil
+}
+
+func (c *streamServerConn) Close() error {
+	err := c.body.Close()
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return err
+}
+
+func (c *streamServerConn) RemoteAddr() string {
+	return c.remote
+}
+
+// streamClientConn is the client side of a stream.
+type streamClientConn struct {
+	pw     *io.PipeWriter
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	remote string
+}
+
+func (c *streamClientConn) Write(b []byte) (int, error) {
+	return c.pw.Write(b)
+}
+
+// SetWriteDeadline is a no-op. Writes are bounded by HTTP/2 flow control, and
+// they fail once the stream is reset.
+func (c *streamClientConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (c *streamClientConn) Close() error {
+	c.pw.Close()
+	c.cancel()
+	return c.body.Close()
+}
+
+func (c *streamClientConn) RemoteAddr() string {
+	return c.remote
+}
+
+func newClientTransportStream(endpoint string, cfg *clientConfig) reconnectFunc {
+	headers := make(http.Header, 2+len(cfg.httpHeaders))
+	headers.Set("accept", StreamContentType)
+	headers.Set("content-type", StreamContentType)
+	for key, values := range cfg.httpHeaders {
+		headers[key] = values
+	}
+
+	client := cfg.httpClient
+	if client == nil {
+		client = streamHTTPClient
+		if strings.HasPrefix(endpoint, "https:") {
+			client = streamHTTPSClient
+		}
+	}
+	return func(ctx context.Context) (ServerCodec, error) {
+		return dialStream(ctx, client, endpoint, headers.Clone(), cfg.httpAuth)
+	}
+}
+
+// dialStream opens a stream to endpoint. The stream outlives ctx, which only
+// bounds the time until the server has responded.
+func dialStream(ctx context.Context, client *http.Client, endpoint string, headers http.Header, auth HTTPAuth) (ServerCodec, error) {
+	pr, pw := io.Pipe()
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header = headers
+	setHeaders(req.Header, headersFromContext(ctx))
+	if auth != nil {
+		if err := auth(req.Header); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	stop := context.AfterFunc(ctx, cancel)
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		pw.Close()
+		return nil, err
+	}
+	if !stop() {
+		resp.Body.Close()
+		pw.Close()
+		return nil, ctx.Err()
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		cancel()
+		pw.Close()
+		return nil, HTTPError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Body:       body,
+		}
+	}
+	if resp.ProtoMajor < 2 {
+		resp.Body.Close()
+		cancel()
+		pw.Close()
+		return nil, fmt.Errorf("JSON-RPC stream requires HTTP/2, server responded with %s", resp.Proto)
+	}
+	conn := &streamClientConn{pw: pw, body: resp.Body, cancel: cancel, remote: endpoint}
+	return newStreamCodec(conn, resp.Body, PeerInfo{Transport: "stream", RemoteAddr: endpoint}, 0), nil
+}
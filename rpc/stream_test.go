@@ -0,0 +1,209 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// newTestStreamServer serves s over HTTP/2 with prior knowledge.
+func newTestStreamServer(s *Server) *httptest.Server {
+	return httptest.NewServer(h2c.NewHandler(s, &http2.Server{}))
+}
+
+func TestIsStreamRequest(t *testing.T) {
+	tests := []struct {
+		method, contentType string
+		want                bool
+	}{
+		{http.MethodPost, "application/x-ndjson", true},
+		{http.MethodPost, "Application/X-NDJSON", true},
+		{http.MethodPost, " application/x-ndjson; charset=utf-8", true},
+		{http.MethodPost, "application/x-ndjsonx", false},
+		{http.MethodPost, "application/json", false},
+		{http.MethodGet, "application/x-ndjson", false},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, "/", nil)
+		r.Header.Set("content-type", test.contentType)
+		if have := IsStreamRequest(r); have != test.want {
+			t.Errorf("%s %q: have %v, want %v", test.method, test.contentType, have, test.want)
+		}
+	}
+}
+
+func TestStreamPeerInfo(t *testing.T) {
+	var (
+		s  = newTestServer()
+		ts = newTestStreamServer(s)
+	)
+	defer s.Stop()
+	defer ts.Close()
+
+	c, err := DialOptions(context.Background(), ts.URL, WithHTTPStream(), WithHeader("user-agent", "ua-testing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var connInfo PeerInfo
+	if err := c.Call(&connInfo, "test_peerInfo"); err != nil {
+		t.Fatal(err)
+	}
+	if connInfo.RemoteAddr == "" {
+		t.Error("RemoteAddr not set")
+	}
+	if connInfo.Transport != "stream" {
+		t.Errorf("wrong Transport %q", connInfo.Transport)
+	}
+	if connInfo.HTTP.Version != "HTTP/2.0" {
+		t.Errorf("wrong HTTP.Version %q", connInfo.HTTP.Version)
+	}
+	if connInfo.HTTP.UserAgent != "ua-testing" {
+		t.Errorf("wrong HTTP.UserAgent %q", connInfo.HTTP.UserAgent)
+	}
+}
+
+func TestStreamSubscribe(t *testing.T) {
+	var (
+		s  = newTestServer()
+		ts = newTestStreamServer(s)
+	)
+	defer s.Stop()
+	defer ts.Close()
+
+	c, err := DialOptions(context.Background(), ts.URL, WithHTTPStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const count = 10
+	nc := make(chan int)
+	sub, err := c.Subscribe(ctx, "nftest", nc, "someSubscription", count, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; i < count; i++ {
+		select {
+		case val := <-nc:
+			if val != i {
+				t.Fatalf("(%d/%d) unexpected value %d", i, count, val)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("(%d/%d) subscription error: %v", i, count, err)
+		case <-ctx.Done():
+			t.Fatalf("(%d/%d) timed out waiting for notification", i, count)
+		}
+		// Calls are served on the same stream between notifications.
+		var r int
+		if err := c.CallContext(ctx, &r, "nftest_echo", i); err != nil {
+			t.Fatal("call failed:", err)
+		}
+		if r != i {
+			t.Fatalf("wrong echo result %d, want %d", r, i)
+		}
+	}
+}
+
+// This test checks that streams are not reset by the timeouts of the HTTP server.
+func TestStreamServerTimeouts(t *testing.T) {
+	var (
+		s  = newTestServer()
+		ts = httptest.NewUnstartedServer(h2c.NewHandler(s, &http2.Server{}))
+	)
+	ts.Config.ReadTimeout = 200 * time.Millisecond
+	ts.Config.WriteTimeout = 200 * time.Millisecond
+	ts.Start()
+	defer s.Stop()
+	defer ts.Close()
+
+	c, err := DialOptions(context.Background(), ts.URL, WithHTTPStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	nc := make(chan int, 1)
+	sub, err := c.Subscribe(context.Background(), "nftest", nc, "someSubscription", 1, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+	<-nc
+
+	// A reset of the stream would end the subscription.
+	select {
+	case err := <-sub.Err():
+		t.Fatal("subscription ended:", err)
+	case <-time.After(time.Second):
+	}
+	var r int
+	if err := c.Call(&r, "nftest_echo", 1); err != nil {
+		t.Fatal("call failed:", err)
+	}
+}
+
+func TestStreamRequiresHTTP2(t *testing.T) {
+	var (
+		s  = newTestServer()
+		ts = httptest.NewServer(s)
+	)
+	defer s.Stop()
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, StreamContentType, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusHTTPVersionNotSupported {
+		t.Fatalf("wrong status code %d, want %d", resp.StatusCode, http.StatusHTTPVersionNotSupported)
+	}
+}
+
+func TestReadStreamLine(t *testing.T) {
+	br := bufio.NewReaderSize(strings.NewReader("{\"a\":1}\n\n{\"b\":2}"), 16)
+	for _, want := range []string{"{\"a\":1}\n", "\n", "{\"b\":2}"} {
+		line, err := readStreamLine(br, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(line) != want {
+			t.Fatalf("wrong line %q, want %q", line, want)
+		}
+	}
+	// Messages exceeding the limit are rejected, even across buffer refills.
+	br = bufio.NewReaderSize(strings.NewReader(strings.Repeat("x", 64)+"\n"), 16)
+	if _, err := readStreamLine(br, 32); err != errStreamLimit {
+		t.Fatalf("wrong error %v, want %v", err, errStreamLimit)
+	}
+}
@@ -24,9 +24,11 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 
 	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/hdwallet"
 	"github.com/shudolab/core-geth/accounts/keystore"
 	"github.com/shudolab/core-geth/accounts/scwallet"
 	"github.com/shudolab/core-geth/accounts/usbwallet"
@@ -44,9 +46,9 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.1.0"
+	ExternalAPIVersion = "6.2.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.1"
+	InternalAPIVersion = "7.3.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -81,6 +83,9 @@ type UIClientAPI interface {
 	ApproveListing(request *ListRequest) (ListResponse, error)
 	// ApproveNewAccount prompt the user for confirmation to create new Account, and reveal to caller
 	ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error)
+	// ApproveMultisig prompt the user for confirmation to create, import or discard
+	// a pending Gnosis Safe transaction
+	ApproveMultisig(request *MultisigRequest) (MultisigResponse, error)
 	// ShowError displays error message to user
 	ShowError(message string)
 	// ShowInfo displays info message to user
@@ -130,7 +135,9 @@ type Metadata struct {
 	Origin    string `json:"Origin"`
 }
 
-func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath string) *accounts.Manager {
+// StartClefAccountManager assembles the wallet backends of clef, deriving HD and
+// hardware wallet accounts with the given paths.
+func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath string, paths accounts.HDPathConfig) *accounts.Manager {
 	var (
 		backends []accounts.Backend
 		n, p     = keystore.StandardScryptN, keystore.StandardScryptP
@@ -141,24 +148,26 @@ func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath str
 	// support password based accounts
 	if len(ksLocation) > 0 {
 		backends = append(backends, keystore.NewKeyStore(ksLocation, n, p))
+		// support mnemonic based HD wallets, kept next to the keys
+		backends = append(backends, hdwallet.NewBackend(filepath.Join(ksLocation, hdwallet.Scheme), n, p, paths))
 	}
 	if !nousb {
 		// Start a USB hub for Ledger hardware wallets
-		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
+		if ledgerhub, err := usbwallet.NewLedgerHubWithPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
 		} else {
 			backends = append(backends, ledgerhub)
 			log.Debug("Ledger support enabled")
 		}
 		// Start a USB hub for Trezor hardware wallets (HID version)
-		if trezorhub, err := usbwallet.NewTrezorHubWithHID(); err != nil {
+		if trezorhub, err := usbwallet.NewTrezorHubWithHIDAndPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start HID Trezor hub, disabling: %v", err))
 		} else {
 			backends = append(backends, trezorhub)
 			log.Debug("Trezor support enabled via HID")
 		}
 		// Start a USB hub for Trezor hardware wallets (WebUSB version)
-		if trezorhub, err := usbwallet.NewTrezorHubWithWebUSB(); err != nil {
+		if trezorhub, err := usbwallet.NewTrezorHubWithWebUSBAndPaths(paths); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start WebUSB Trezor hub, disabling: %v", err))
 		} else {
 			backends = append(backends, trezorhub)
@@ -176,7 +185,7 @@ func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath str
 			if fi.Mode()&os.ModeType != os.ModeSocket {
 				log.Error("Invalid smartcard socket file type", "path", scpath, "type", fi.Mode().String())
 			} else {
-				if schub, err := scwallet.NewHub(scpath, scwallet.Scheme, ksLocation); err != nil {
+				if schub, err := scwallet.NewHubWithPaths(scpath, scwallet.Scheme, ksLocation, paths); err != nil {
 					log.Warn(fmt.Sprintf("Failed to start smart card hub, disabling: %v", err))
 				} else {
 					backends = append(backends, schub)
@@ -253,6 +262,14 @@ type (
 	NewAccountResponse struct {
 		Approved bool `json:"approved"`
 	}
+	MultisigRequest struct {
+		Action  string         `json:"action"` // One of "new", "import" or "discard"
+		Pending *PendingSafeTx `json:"pending"`
+		Meta    Metadata       `json:"meta"`
+	}
+	MultisigResponse struct {
+		Approved bool `json:"approved"`
+	}
 	ListRequest struct {
 		Accounts []accounts.Account `json:"accounts"`
 		Meta     Metadata           `json:"meta"`
@@ -330,6 +347,9 @@ func (api *SignerAPI) startUSBListener() {
 	am.Subscribe(eventCh)
 	// Open any wallets already attached
 	for _, wallet := range am.Wallets() {
+		if wallet.URL().Scheme == hdwallet.Scheme {
+			continue // Mnemonic wallets need a password, opened via clef_openWallet
+		}
 		if err := wallet.Open(""); err != nil {
 			log.Warn("Failed to open wallet", "url", wallet.URL(), "err", err)
 			if err == usbwallet.ErrTrezorPINNeeded {
@@ -346,6 +366,9 @@ func (api *SignerAPI) derivationLoop(events chan accounts.WalletEvent) {
 	for event := range events {
 		switch event.Kind {
 		case accounts.WalletArrived:
+			if event.Wallet.URL().Scheme == hdwallet.Scheme {
+				continue
+			}
 			if err := event.Wallet.Open(""); err != nil {
 				log.Warn("New wallet appeared, failed to open", "url", event.Wallet.URL(), "err", err)
 				if err == usbwallet.ErrTrezorPINNeeded {
@@ -366,16 +389,17 @@ func (api *SignerAPI) derivationLoop(events chan accounts.WalletEvent) {
 					}
 				}
 			}
-			log.Info("Deriving default paths")
-			derive(numberOfAccountsToDerive, accounts.DefaultIterator(accounts.DefaultBaseDerivationPath))
+			paths := accounts.WalletHDPathConfig(event.Wallet)
+			log.Info("Deriving default paths", "cointype", paths.CoinType)
+			derive(numberOfAccountsToDerive, accounts.DefaultIterator(paths.BasePath()))
 			if event.Wallet.URL().Scheme == "ledger" {
 				log.Info("Deriving ledger legacy paths")
-				derive(numberOfAccountsToDerive, accounts.DefaultIterator(accounts.LegacyLedgerBaseDerivationPath))
+				derive(numberOfAccountsToDerive, accounts.DefaultIterator(paths.LegacyLedgerBasePath()))
 				log.Info("Deriving ledger live paths")
-				// For ledger live, since it's based off the same (DefaultBaseDerivationPath)
+				// For ledger live, since it's based off the same (BasePath)
 				// as one we've already used, we need to step it forward one step to avoid
 				// hitting the same path again
-				nextFn := accounts.LedgerLiveIterator(accounts.DefaultBaseDerivationPath)
+				nextFn := accounts.LedgerLiveIterator(paths.BasePath())
 				nextFn()
 				derive(numberOfAccountsToDerive, nextFn)
 			}
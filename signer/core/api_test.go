@@ -96,6 +96,11 @@ func (ui *headlessUi) ApproveNewAccount(request *core.NewAccountRequest) (core.N
 	return core.NewAccountResponse{false}, nil
 }
 
+func (ui *headlessUi) ApproveMultisig(request *core.MultisigRequest) (core.MultisigResponse, error) {
+	approved := (<-ui.approveCh == "Y")
+	return core.MultisigResponse{approved}, nil
+}
+
 func (ui *headlessUi) ShowError(message string) {
 	//stdout is used by communication
 	fmt.Fprintln(os.Stderr, message)
@@ -121,7 +126,7 @@ func setup(t *testing.T) (*core.SignerAPI, *headlessUi) {
 		t.Fatal(err.Error())
 	}
 	ui := &headlessUi{make(chan string, 20), make(chan string, 20)}
-	am := core.StartClefAccountManager(tmpDirName(t), true, true, "")
+	am := core.StartClefAccountManager(tmpDirName(t), true, true, "", accounts.DefaultHDPathConfig())
 	api := core.NewSignerAPI(am, 1337, true, ui, db, true, &storage.NoStorage{})
 	return api, ui
 }
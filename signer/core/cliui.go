@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
 	"sync"
@@ -223,6 +224,29 @@ func (ui *CommandlineUI) ApproveNewAccount(request *NewAccountRequest) (NewAccou
 	return NewAccountResponse{true}, nil
 }
 
+// ApproveMultisig prompt the user for confirmation to change the pending Gnosis Safe transactions
+func (ui *CommandlineUI) ApproveMultisig(request *MultisigRequest) (MultisigResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- Multisig request--------------\n\n")
+	fmt.Printf("A request has been made to %s a pending safe transaction.\n", request.Action)
+	if pending := request.Pending; pending != nil {
+		fmt.Printf("safe:       %v\n", pending.Tx.Safe.Address().Hex())
+		fmt.Printf("to:         %v\n", pending.Tx.To.Address().Hex())
+		fmt.Printf("value:      %v wei\n", (*big.Int)(&pending.Tx.Value))
+		fmt.Printf("safeTxHash: %v\n", pending.Hash().Hex())
+		fmt.Printf("threshold:  %d\n", pending.Threshold)
+		fmt.Printf("signatures: %d\n", len(pending.Signatures))
+	}
+	fmt.Printf("-------------------------------------------\n")
+	showMetadata(request.Meta)
+	if !ui.confirm() {
+		return MultisigResponse{false}, nil
+	}
+	return MultisigResponse{true}, nil
+}
+
 // ShowError displays error message to user
 func (ui *CommandlineUI) ShowError(message string) {
 	fmt.Printf("## Error \n%s\n", message)
@@ -0,0 +1,503 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shudolab/core-geth/accounts/abi"
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/common/math"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/internal/ethapi"
+	"github.com/shudolab/core-geth/log"
+	"github.com/shudolab/core-geth/signer/core/apitypes"
+	"github.com/shudolab/core-geth/signer/storage"
+)
+
+// multisigIndexKey is the storage key under which the list of pending safe
+// transaction hashes is kept, since the storage backend cannot enumerate keys.
+const multisigIndexKey = "pending"
+
+// gnosisSafeExecABI is the ABI of the Gnosis Safe execTransaction method, used to
+// assemble the final transaction once enough owners have confirmed.
+const gnosisSafeExecABI = `[{"name":"execTransaction","type":"function","stateMutability":"payable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]}]`
+
+var (
+	// ErrUnknownMultisigTx is returned if a pending safe transaction is not tracked.
+	ErrUnknownMultisigTx = errors.New("unknown multisig transaction")
+
+	// ErrThresholdNotReached is returned when attempting to execute a safe
+	// transaction which has fewer confirmations than its threshold.
+	ErrThresholdNotReached = errors.New("confirmation threshold not reached")
+)
+
+// SafeSignature is a single owner confirmation of a Gnosis Safe transaction.
+type SafeSignature struct {
+	Owner     common.Address `json:"owner"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// PendingSafeTx is a Gnosis Safe transaction collecting owner confirmations
+// until the threshold of the safe is reached. It is also the format used to
+// exchange signatures between co-signers.
+type PendingSafeTx struct {
+	Tx         GnosisSafeTx     `json:"tx"`
+	Threshold  uint64           `json:"threshold"`
+	Owners     []common.Address `json:"owners,omitempty"`
+	Signatures []SafeSignature  `json:"signatures"`
+
+	// Executed is the hash of the last execTransaction transaction signed by
+	// ExecuteMultisigTx. The entry is kept until it's discarded, so that the
+	// confirmations aren't lost if that transaction is never mined.
+	Executed *common.Hash `json:"executed,omitempty"`
+}
+
+// Hash returns the EIP-712 safeTxHash identifying the pending transaction.
+func (p *PendingSafeTx) Hash() common.Hash {
+	return p.Tx.InputExpHash
+}
+
+// Confirmed reports whether enough owners have signed the transaction.
+func (p *PendingSafeTx) Confirmed() bool {
+	return uint64(len(p.Signatures)) >= p.Threshold
+}
+
+// isOwner reports whether addr may confirm the transaction. If no owner set was
+// given on creation, any signer is accepted and the safe itself will enforce
+// ownership on execution.
+func (p *PendingSafeTx) isOwner(addr common.Address) bool {
+	if len(p.Owners) == 0 {
+		return true
+	}
+	for _, owner := range p.Owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// addSignature verifies an EOA signature over the safeTxHash and records it,
+// replacing any previous signature of the same owner.
+func (p *PendingSafeTx) addSignature(sig SafeSignature) error {
+	if len(sig.Signature) != crypto.SignatureLength {
+		return fmt.Errorf("invalid signature length %d", len(sig.Signature))
+	}
+	if v := sig.Signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		return fmt.Errorf("unsupported signature type (v=%d), only EOA signatures are accepted", v)
+	}
+	rsv := common.CopyBytes(sig.Signature)
+	rsv[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(p.Hash().Bytes(), rsv)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != sig.Owner {
+		return fmt.Errorf("signature of %v recovers to %v", sig.Owner, signer)
+	}
+	if !p.isOwner(sig.Owner) {
+		return fmt.Errorf("%v is not an owner of the safe", sig.Owner)
+	}
+	for i, have := range p.Signatures {
+		if have.Owner == sig.Owner {
+			p.Signatures[i] = sig
+			return nil
+		}
+	}
+	p.Signatures = append(p.Signatures, sig)
+	return nil
+}
+
+// PackedSignatures returns the confirmations in the layout expected by the
+// safe contract: 65-byte signatures concatenated in ascending owner order.
+func (p *PendingSafeTx) PackedSignatures() []byte {
+	sigs := make([]SafeSignature, len(p.Signatures))
+	copy(sigs, p.Signatures)
+	sort.Slice(sigs, func(i, j int) bool {
+		return bytes.Compare(sigs[i].Owner[:], sigs[j].Owner[:]) < 0
+	})
+	packed := make([]byte, 0, len(sigs)*crypto.SignatureLength)
+	for _, sig := range sigs {
+		packed = append(packed, sig.Signature...)
+	}
+	return packed
+}
+
+// ExecTransactionData returns the calldata of the execTransaction call on the
+// safe, carrying all collected confirmations.
+func (p *PendingSafeTx) ExecTransactionData() ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(gnosisSafeExecABI))
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if p.Tx.Data != nil {
+		data = *p.Tx.Data
+	}
+	value, gasPrice := big.Int(p.Tx.Value), big.Int(p.Tx.GasPrice)
+	return parsed.Pack("execTransaction",
+		p.Tx.To.Address(), &value, data, p.Tx.Operation,
+		&p.Tx.SafeTxGas, &p.Tx.BaseGas, &gasPrice,
+		p.Tx.GasToken, p.Tx.RefundReceiver, p.PackedSignatures())
+}
+
+// MultisigAPI collects confirmations from several owners of a Gnosis Safe and
+// produces the execution transaction once the threshold is reached. Pending
+// transactions are kept in the (encrypted) storage given on creation, so they
+// survive restarts of the signer.
+type MultisigAPI struct {
+	api     ExternalAPI
+	ui      UIClientAPI
+	chainID *big.Int
+	db      storage.Storage
+	lock    sync.Mutex
+}
+
+// NewMultisigAPI creates a new multisig coordinator, signing through the given
+// external API (which handles user approval) and persisting into db. Changes to
+// the set of pending transactions are approved through ui.
+func NewMultisigAPI(api ExternalAPI, ui UIClientAPI, chainID int64, db storage.Storage) *MultisigAPI {
+	return &MultisigAPI{api: api, ui: ui, chainID: big.NewInt(chainID), db: db}
+}
+
+// NewMultisigTx starts tracking a safe transaction which needs confirmations of
+// threshold owners. The optional owners list restricts who may confirm.
+func (m *MultisigAPI) NewMultisigTx(ctx context.Context, gnosisTx GnosisSafeTx, threshold uint64, owners []common.MixedcaseAddress) (common.Hash, error) {
+	if threshold == 0 {
+		return common.Hash{}, errors.New("threshold must be positive")
+	}
+	if err := m.verifySafeTxHash(&gnosisTx); err != nil {
+		return common.Hash{}, err
+	}
+	pending := &PendingSafeTx{Tx: gnosisTx, Threshold: threshold}
+	for _, owner := range owners {
+		pending.Owners = append(pending.Owners, owner.Address())
+	}
+	if len(pending.Owners) > 0 && uint64(len(pending.Owners)) < threshold {
+		return common.Hash{}, fmt.Errorf("threshold %d exceeds number of owners %d", threshold, len(pending.Owners))
+	}
+	if err := m.approve(ctx, "new", pending); err != nil {
+		return common.Hash{}, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, err := m.load(pending.Hash()); err == nil {
+		return common.Hash{}, fmt.Errorf("multisig transaction %v already exists", pending.Hash())
+	} else if !errors.Is(err, ErrUnknownMultisigTx) {
+		return common.Hash{}, err
+	}
+	if err := m.store(pending); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Created multisig transaction", "safe", gnosisTx.Safe.Address(), "hash", pending.Hash(), "threshold", threshold)
+	return pending.Hash(), nil
+}
+
+// ListMultisigTxs returns all pending safe transactions.
+func (m *MultisigAPI) ListMultisigTxs(ctx context.Context) ([]*PendingSafeTx, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	hashes, err := m.index()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*PendingSafeTx, 0, len(hashes))
+	for _, hash := range hashes {
+		pending, err := m.load(hash)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pending)
+	}
+	return list, nil
+}
+
+// ExportMultisigTx returns a pending safe transaction along with all collected
+// signatures, to be handed to the other co-signers.
+func (m *MultisigAPI) ExportMultisigTx(ctx context.Context, hash common.Hash) (*PendingSafeTx, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.load(hash)
+}
+
+// ImportMultisigTx merges the signatures of an exported safe transaction into
+// the local state. Unknown transactions are tracked as new. Every signature is
+// verified against the safeTxHash before being accepted.
+func (m *MultisigAPI) ImportMultisigTx(ctx context.Context, exported PendingSafeTx) (*PendingSafeTx, error) {
+	if err := m.verifySafeTxHash(&exported.Tx); err != nil {
+		return nil, err
+	}
+	if err := m.approve(ctx, "import", &exported); err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	pending, err := m.load(exported.Hash())
+	if errors.Is(err, ErrUnknownMultisigTx) {
+		if exported.Threshold == 0 {
+			return nil, errors.New("threshold must be positive")
+		}
+		pending = &PendingSafeTx{Tx: exported.Tx, Threshold: exported.Threshold, Owners: exported.Owners}
+	} else if err != nil {
+		return nil, err
+	}
+	for _, sig := range exported.Signatures {
+		if err := pending.addSignature(sig); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.store(pending); err != nil {
+		return nil, err
+	}
+	log.Info("Imported multisig signatures", "hash", pending.Hash(), "signatures", len(pending.Signatures), "threshold", pending.Threshold)
+	return pending, nil
+}
+
+// ConfirmMultisigTx signs a pending safe transaction with a local account. The
+// signing goes through the regular approval flow of account_signGnosisSafeTx.
+func (m *MultisigAPI) ConfirmMultisigTx(ctx context.Context, hash common.Hash, signerAddress common.MixedcaseAddress, methodSelector *string) (*PendingSafeTx, error) {
+	m.lock.Lock()
+	pending, err := m.load(hash)
+	m.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !pending.isOwner(signerAddress.Address()) {
+		return nil, fmt.Errorf("%v is not an owner of the safe", signerAddress.Address())
+	}
+	signed, err := m.api.SignGnosisSafeTx(ctx, signerAddress, pending.Tx, methodSelector)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Reload, other confirmations might have been imported while waiting for the user
+	if pending, err = m.load(hash); err != nil {
+		return nil, err
+	}
+	if err := pending.addSignature(SafeSignature{Owner: signerAddress.Address(), Signature: signed.Signature}); err != nil {
+		return nil, err
+	}
+	if err := m.store(pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// ExecuteMultisigTx signs the execTransaction call of a fully confirmed safe
+// transaction. The args describe the outer transaction sent by the executor
+// (from, gas, fees, nonce); recipient, value and data are filled in. The
+// returned transaction is ready to be submitted to the network by the caller,
+// as the signer has no connection to it. The pending entry is marked executed
+// with the hash of the transaction, but kept until it's discarded, so that it
+// can be executed again if submitting fails.
+func (m *MultisigAPI) ExecuteMultisigTx(ctx context.Context, hash common.Hash, args apitypes.SendTxArgs) (*ethapi.SignTransactionResult, error) {
+	m.lock.Lock()
+	pending, err := m.load(hash)
+	m.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !pending.Confirmed() {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrThresholdNotReached, len(pending.Signatures), pending.Threshold)
+	}
+	data, err := pending.ExecTransactionData()
+	if err != nil {
+		return nil, err
+	}
+	input := hexutil.Bytes(data)
+	args.To = &pending.Tx.Safe
+	args.Value = hexutil.Big{}
+	args.Data = &input
+	args.Input = nil
+
+	res, err := m.api.SignTransaction(ctx, args, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Reload, the transaction might have been discarded while waiting for the user
+	if pending, err = m.load(hash); err != nil {
+		return nil, err
+	}
+	executed := res.Tx.Hash()
+	pending.Executed = &executed
+	if err := m.store(pending); err != nil {
+		return nil, err
+	}
+	log.Info("Executed multisig transaction", "hash", hash, "tx", executed)
+	return res, nil
+}
+
+// DiscardMultisigTx stops tracking a pending safe transaction.
+func (m *MultisigAPI) DiscardMultisigTx(ctx context.Context, hash common.Hash) error {
+	m.lock.Lock()
+	pending, err := m.load(hash)
+	m.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := m.approve(ctx, "discard", pending); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.remove(hash)
+}
+
+// approve asks the user to confirm a change to the set of pending transactions.
+func (m *MultisigAPI) approve(ctx context.Context, action string, pending *PendingSafeTx) error {
+	resp, err := m.ui.ApproveMultisig(&MultisigRequest{Action: action, Pending: pending, Meta: MetadataFromContext(ctx)})
+	if err != nil {
+		return err
+	}
+	if !resp.Approved {
+		return ErrRequestDenied
+	}
+	return nil
+}
+
+// verifySafeTxHash checks that the safeTxHash of the transaction matches its
+// contents, filling in the chain id if the input omitted it. If no hash was
+// given, it is calculated. The output fields describing a single confirmation
+// are reset, the confirmations are tracked separately.
+func (m *MultisigAPI) verifySafeTxHash(tx *GnosisSafeTx) error {
+	tx.Signature = nil
+	tx.SafeTxHash = common.Hash{}
+	tx.Sender = common.NewMixedcaseAddress(common.Address{})
+
+	sighash, _, err := apitypes.TypedDataAndHash(tx.ToTypedData())
+	if err != nil {
+		return err
+	}
+	if tx.InputExpHash == (common.Hash{}) {
+		tx.InputExpHash = common.BytesToHash(sighash)
+		return nil
+	}
+	if !bytes.Equal(sighash, tx.InputExpHash.Bytes()) {
+		if tx.ChainId != nil {
+			return fmt.Errorf("mismatched safeTxHash; have %#x want %#x", sighash, tx.InputExpHash[:])
+		}
+		// It might be the case that the json is missing chain id.
+		tx.ChainId = (*math.HexOrDecimal256)(m.chainID)
+		sighash, _, _ = apitypes.TypedDataAndHash(tx.ToTypedData())
+		if !bytes.Equal(sighash, tx.InputExpHash.Bytes()) {
+			return fmt.Errorf("mismatched safeTxHash; have %#x want %#x", sighash, tx.InputExpHash[:])
+		}
+	}
+	return nil
+}
+
+// index returns the hashes of all tracked transactions.
+func (m *MultisigAPI) index() ([]common.Hash, error) {
+	var hashes []common.Hash
+	blob, err := m.db.Get(multisigIndexKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(blob), &hashes); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (m *MultisigAPI) load(hash common.Hash) (*PendingSafeTx, error) {
+	blob, err := m.db.Get(hash.Hex())
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrUnknownMultisigTx
+	}
+	if err != nil {
+		return nil, err
+	}
+	pending := new(PendingSafeTx)
+	if err := json.Unmarshal([]byte(blob), pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func (m *MultisigAPI) store(pending *PendingSafeTx) error {
+	blob, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	hashes, err := m.index()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, hash := range hashes {
+		if hash == pending.Hash() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		hashes = append(hashes, pending.Hash())
+		if err := m.putIndex(hashes); err != nil {
+			return err
+		}
+	}
+	return m.db.Put(pending.Hash().Hex(), string(blob))
+}
+
+func (m *MultisigAPI) remove(hash common.Hash) error {
+	hashes, err := m.index()
+	if err != nil {
+		return err
+	}
+	for i, have := range hashes {
+		if have == hash {
+			hashes = append(hashes[:i], hashes[i+1:]...)
+			break
+		}
+	}
+	if err := m.putIndex(hashes); err != nil {
+		return err
+	}
+	m.db.Del(hash.Hex())
+	return nil
+}
+
+func (m *MultisigAPI) putIndex(hashes []common.Hash) error {
+	blob, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	return m.db.Put(multisigIndexKey, string(blob))
+}
@@ -0,0 +1,295 @@
+// Copyright 2024 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/common/hexutil"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/crypto"
+	"github.com/shudolab/core-geth/signer/core"
+	"github.com/shudolab/core-geth/signer/storage"
+)
+
+func signSafeTx(t *testing.T, hash common.Hash) core.SafeSignature {
+	key, _ := crypto.GenerateKey()
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return core.SafeSignature{Owner: crypto.PubkeyToAddress(key.PublicKey), Signature: sig}
+}
+
+func TestMultisigCollectSignatures(t *testing.T) {
+	t.Parallel()
+	var tx core.GnosisSafeTx
+	if err := json.Unmarshal([]byte(gnosisTxWithChainId), &tx); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx = context.Background()
+		db  = storage.NewEphemeralStorage()
+		ui  = &headlessUi{make(chan string, 20), make(chan string, 20)}
+		api = core.NewMultisigAPI(nil, ui, 4, db)
+	)
+	ui.approveCh <- "Y"
+	hash, err := api.NewMultisigTx(ctx, tx, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToHash("0x6619dab5401503f2735256e12b898e69eb701d6a7e0d07abf1be4bb8aebfba29"); hash != want {
+		t.Fatalf("wrong safeTxHash: have %x, want %x", hash, want)
+	}
+	ui.approveCh <- "Y"
+	if _, err := api.NewMultisigTx(ctx, tx, 2, nil); err == nil {
+		t.Fatal("expected error on duplicate transaction")
+	}
+	// Import the confirmation recorded by the relay
+	exported, err := api.ExportMultisigTx(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported.Signatures = []core.SafeSignature{{
+		Owner:     common.HexToAddress("0xbc2BB26a6d821e69A38016f3858561a1D80d4182"),
+		Signature: hexutil.MustDecode("0x5ca34641bcdee06e7b99143bfe34778195ca41022bd35837b96c204c7786be9d6dfa6dba43b53cd92da45ac728899e1561b232d28f38ba82df45f164caba38be1b"),
+	}}
+	ui.approveCh <- "Y"
+	pending, err := api.ImportMultisigTx(ctx, *exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Confirmed() {
+		t.Fatal("transaction confirmed with one of two signatures")
+	}
+	if _, err := api.ExecuteMultisigTx(ctx, hash, mkTestTx(common.MixedcaseAddress{})); !errors.Is(err, core.ErrThresholdNotReached) {
+		t.Fatalf("expected %v, got %v", core.ErrThresholdNotReached, err)
+	}
+	// A signature not matching the claimed owner must be rejected
+	forged := signSafeTx(t, hash)
+	forged.Owner = common.HexToAddress("0x1337")
+	exported.Signatures = []core.SafeSignature{forged}
+	ui.approveCh <- "Y"
+	if _, err := api.ImportMultisigTx(ctx, *exported); err == nil {
+		t.Fatal("expected error on forged signature")
+	}
+	exported.Signatures = []core.SafeSignature{signSafeTx(t, hash)}
+	ui.approveCh <- "Y"
+	if pending, err = api.ImportMultisigTx(ctx, *exported); err != nil {
+		t.Fatal(err)
+	}
+	if !pending.Confirmed() {
+		t.Fatal("transaction not confirmed with two of two signatures")
+	}
+	// Signatures are packed in ascending owner order
+	packed := pending.PackedSignatures()
+	if len(packed) != 2*crypto.SignatureLength {
+		t.Fatalf("wrong packed signature length %d", len(packed))
+	}
+	first, second := pending.Signatures[0], pending.Signatures[1]
+	if bytes.Compare(first.Owner[:], second.Owner[:]) > 0 {
+		first, second = second, first
+	}
+	if !bytes.Equal(packed[:crypto.SignatureLength], first.Signature) || !bytes.Equal(packed[crypto.SignatureLength:], second.Signature) {
+		t.Fatal("signatures not sorted by owner")
+	}
+	// The pending state must be restored from storage
+	list, err := core.NewMultisigAPI(nil, ui, 4, db).ListMultisigTxs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || len(list[0].Signatures) != 2 {
+		t.Fatalf("unexpected pending transactions: %v", list)
+	}
+	ui.approveCh <- "Y"
+	if err := api.DiscardMultisigTx(ctx, hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.ExportMultisigTx(ctx, hash); !errors.Is(err, core.ErrUnknownMultisigTx) {
+		t.Fatalf("expected %v, got %v", core.ErrUnknownMultisigTx, err)
+	}
+}
+
+func TestMultisigExecute(t *testing.T) {
+	t.Parallel()
+	var tx core.GnosisSafeTx
+	if err := json.Unmarshal([]byte(gnosisTxWithChainId), &tx); err != nil {
+		t.Fatal(err)
+	}
+	api, control := setup(t)
+	createAccount(control, api, t)
+	accs, err := list(control, api, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx      = context.Background()
+		executor = common.NewMixedcaseAddress(accs[0])
+		ms       = core.NewMultisigAPI(api, control, 4, storage.NewEphemeralStorage())
+	)
+	control.approveCh <- "Y"
+	hash, err := ms.NewMultisigTx(ctx, tx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := ms.ExportMultisigTx(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending.Signatures = []core.SafeSignature{signSafeTx(t, hash)}
+	control.approveCh <- "Y"
+	if pending, err = ms.ImportMultisigTx(ctx, *pending); err != nil {
+		t.Fatal(err)
+	}
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	res, err := ms.ExecuteMultisigTx(ctx, hash, mkTestTx(executor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		t.Fatal(err)
+	}
+	if *signed.To() != tx.Safe.Address() {
+		t.Errorf("wrong recipient: have %v, want %v", signed.To(), tx.Safe.Address())
+	}
+	if signed.Value().Cmp(new(big.Int)) != 0 {
+		t.Errorf("unexpected value %v", signed.Value())
+	}
+	want, _ := pending.ExecTransactionData()
+	if !bytes.Equal(signed.Data(), want) {
+		t.Errorf("wrong calldata: have %x, want %x", signed.Data(), want)
+	}
+	// The entry is kept until discarded, in case the transaction is never mined
+	list, err := ms.ListMultisigTxs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Executed == nil || *list[0].Executed != signed.Hash() {
+		t.Fatalf("executed transaction not marked: %v", list)
+	}
+	if len(list[0].Signatures) != 1 {
+		t.Fatalf("executed transaction lost confirmations: %v", list[0].Signatures)
+	}
+}
+
+// failingStorage fails to read one key, like an entry the encrypted storage
+// can't decrypt.
+type failingStorage struct {
+	storage.Storage
+	key string
+}
+
+var errStorageBroken = errors.New("broken storage")
+
+func (s *failingStorage) Get(key string) (string, error) {
+	if key == s.key {
+		return "", errStorageBroken
+	}
+	return s.Storage.Get(key)
+}
+
+func TestMultisigStorageError(t *testing.T) {
+	t.Parallel()
+	var tx core.GnosisSafeTx
+	if err := json.Unmarshal([]byte(gnosisTxWithChainId), &tx); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx = context.Background()
+		db  = storage.NewEphemeralStorage()
+		ui  = &headlessUi{make(chan string, 20), make(chan string, 20)}
+		api = core.NewMultisigAPI(nil, ui, 4, db)
+	)
+	ui.approveCh <- "Y"
+	hash, err := api.NewMultisigTx(ctx, tx, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := api.ExportMultisigTx(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported.Signatures = []core.SafeSignature{signSafeTx(t, hash)}
+	ui.approveCh <- "Y"
+	if _, err := api.ImportMultisigTx(ctx, *exported); err != nil {
+		t.Fatal(err)
+	}
+	// Read errors must not be mistaken for unknown transactions, which would
+	// overwrite the stored confirmations
+	broken := core.NewMultisigAPI(nil, ui, 4, &failingStorage{db, hash.Hex()})
+	exported.Signatures = []core.SafeSignature{signSafeTx(t, hash)}
+	ui.approveCh <- "Y"
+	if _, err := broken.ImportMultisigTx(ctx, *exported); !errors.Is(err, errStorageBroken) {
+		t.Fatalf("expected %v, got %v", errStorageBroken, err)
+	}
+	ui.approveCh <- "Y"
+	if _, err := broken.NewMultisigTx(ctx, tx, 2, nil); !errors.Is(err, errStorageBroken) {
+		t.Fatalf("expected %v, got %v", errStorageBroken, err)
+	}
+	pending, err := api.ExportMultisigTx(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending.Signatures) != 1 {
+		t.Fatalf("stored confirmations changed: %v", pending.Signatures)
+	}
+}
+
+func TestMultisigDenied(t *testing.T) {
+	t.Parallel()
+	var tx core.GnosisSafeTx
+	if err := json.Unmarshal([]byte(gnosisTxWithChainId), &tx); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx = context.Background()
+		ui  = &headlessUi{make(chan string, 20), make(chan string, 20)}
+		api = core.NewMultisigAPI(nil, ui, 4, storage.NewEphemeralStorage())
+	)
+	ui.approveCh <- "N"
+	if _, err := api.NewMultisigTx(ctx, tx, 1, nil); !errors.Is(err, core.ErrRequestDenied) {
+		t.Fatalf("expected %v, got %v", core.ErrRequestDenied, err)
+	}
+	ui.approveCh <- "N"
+	if _, err := api.ImportMultisigTx(ctx, core.PendingSafeTx{Tx: tx, Threshold: 1}); !errors.Is(err, core.ErrRequestDenied) {
+		t.Fatalf("expected %v, got %v", core.ErrRequestDenied, err)
+	}
+	if list, _ := api.ListMultisigTxs(ctx); len(list) != 0 {
+		t.Fatalf("denied transaction tracked: %v", list)
+	}
+	ui.approveCh <- "Y"
+	hash, err := api.NewMultisigTx(ctx, tx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui.approveCh <- "N"
+	if err := api.DiscardMultisigTx(ctx, hash); !errors.Is(err, core.ErrRequestDenied) {
+		t.Fatalf("expected %v, got %v", core.ErrRequestDenied, err)
+	}
+	if _, err := api.ExportMultisigTx(ctx, hash); err != nil {
+		t.Fatalf("denied discard removed transaction: %v", err)
+	}
+}
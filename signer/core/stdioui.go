@@ -84,6 +84,12 @@ func (ui *StdIOUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResp
 	return result, err
 }
 
+func (ui *StdIOUI) ApproveMultisig(request *MultisigRequest) (MultisigResponse, error) {
+	var result MultisigResponse
+	err := ui.dispatch("ui_approveMultisig", request, &result)
+	return result, err
+}
+
 func (ui *StdIOUI) ShowError(message string) {
 	err := ui.notify("ui_showError", &Message{message})
 	if err != nil {
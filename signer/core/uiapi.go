@@ -25,6 +25,7 @@ import (
 	"os"
 
 	"github.com/shudolab/core-geth/accounts"
+	"github.com/shudolab/core-geth/accounts/hdwallet"
 	"github.com/shudolab/core-geth/accounts/keystore"
 	"github.com/shudolab/core-geth/common"
 	"github.com/shudolab/core-geth/common/math"
@@ -91,15 +92,17 @@ func (s *UIServerAPI) ListWallets() []rawWallet {
 }
 
 // DeriveAccount requests a HD wallet to derive a new account, optionally pinning
-// it for later reuse.
+// it for later reuse. Relative paths are resolved against the root path of the
+// optional network (name or SLIP-0044 coin type), defaulting to the wallet's own.
 // Example call
 // {"jsonrpc":"2.0","method":"clef_deriveAccount","params":["ledger://","m/44'/60'/0'", false], "id":6}
-func (s *UIServerAPI) DeriveAccount(url string, path string, pin *bool) (accounts.Account, error) {
+// {"jsonrpc":"2.0","method":"clef_deriveAccount","params":["ledger://","0", false, "classic"], "id":7}
+func (s *UIServerAPI) DeriveAccount(url string, path string, pin *bool, network *string) (accounts.Account, error) {
 	wallet, err := s.am.Wallet(url)
 	if err != nil {
 		return accounts.Account{}, err
 	}
-	derivPath, err := accounts.ParseDerivationPath(path)
+	derivPath, err := accounts.ParseWalletDerivationPath(wallet, path, network)
 	if err != nil {
 		return accounts.Account{}, err
 	}
@@ -134,6 +137,73 @@ func (s *UIServerAPI) ImportRawKey(privkey string, password string) (accounts.Ac
 	return fetchKeystore(s.am).ImportECDSA(key, password)
 }
 
+// fetchHDWallets retrieves the mnemonic HD wallet backend from the account manager.
+func fetchHDWallets(am *accounts.Manager) (*hdwallet.Backend, error) {
+	be := am.Backends(hdwallet.BackendType)
+	if len(be) == 0 {
+		return nil, errors.New("mnemonic based wallets not supported")
+	}
+	return be[0].(*hdwallet.Backend), nil
+}
+
+// NewMnemonicWallet generates a new BIP-39 mnemonic and stores it as an HD wallet
+// encrypted with the given password. The mnemonic is returned once, users are
+// responsible for backing it up.
+// Example
+// {"jsonrpc":"2.0","method":"clef_newMnemonicWallet","params":["a_long_password"], "id":6}
+func (s *UIServerAPI) NewMnemonicWallet(password string) (map[string]string, error) {
+	be, err := fetchHDWallets(s.am)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePasswordFormat(password); err != nil {
+		return nil, fmt.Errorf("password requirements not met: %v", err)
+	}
+	wallet, mnemonic, err := be.NewWallet(password)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"url": wallet.URL().String(), "mnemonic": mnemonic}, nil
+}
+
+// ImportMnemonic stores an existing BIP-39 mnemonic, along with an optional seed
+// passphrase, as an HD wallet encrypted with the given password.
+// Example
+// {"jsonrpc":"2.0","method":"clef_importMnemonic","params":["test test test test test test test test test test test junk","","a_long_password"], "id":6}
+func (s *UIServerAPI) ImportMnemonic(mnemonic string, seedPassphrase string, password string) (string, error) {
+	be, err := fetchHDWallets(s.am)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidatePasswordFormat(password); err != nil {
+		return "", fmt.Errorf("password requirements not met: %v", err)
+	}
+	wallet, err := be.ImportMnemonic(mnemonic, seedPassphrase, password)
+	if err != nil {
+		return "", err
+	}
+	return wallet.URL().String(), nil
+}
+
+// ExportMnemonic decrypts and returns the mnemonic and seed passphrase of an HD wallet.
+// Example
+// {"jsonrpc":"2.0","method":"clef_exportMnemonic","params":["hd:///path/to/wallet.json","a_long_password"], "id":6}
+func (s *UIServerAPI) ExportMnemonic(url string, password string) (map[string]string, error) {
+	wallet, err := s.am.Wallet(url)
+	if err != nil {
+		return nil, err
+	}
+	hd, ok := wallet.(*hdwallet.Wallet)
+	if !ok {
+		return nil, errors.New("wallet is not a mnemonic wallet")
+	}
+	mnemonic, seedPassphrase, err := hd.Mnemonic(password)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"mnemonic": mnemonic, "passphrase": seedPassphrase}, nil
+}
+
 // OpenWallet initiates a hardware wallet opening procedure, establishing a USB
 // connection and attempting to authenticate via the provided passphrase. Note,
 // the method may return an extra challenge requiring a second open (e.g. the
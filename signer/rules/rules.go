@@ -83,8 +83,8 @@ func (r *rulesetUI) execute(jsfunc string, jsarg interface{}) (goja.Value, error
 		key, val := call.Argument(0).String(), call.Argument(1).String()
 		if val == "" {
 			r.storage.Del(key)
-		} else {
-			r.storage.Put(key, val)
+		} else if err := r.storage.Put(key, val); err != nil {
+			log.Warn("Failed to store rule data", "key", key, "err", err)
 		}
 		return goja.Null()
 	})
@@ -204,6 +204,19 @@ func (r *rulesetUI) ApproveNewAccount(request *core.NewAccountRequest) (core.New
 	return r.next.ApproveNewAccount(request)
 }
 
+func (r *rulesetUI) ApproveMultisig(request *core.MultisigRequest) (core.MultisigResponse, error) {
+	jsonreq, err := json.Marshal(request)
+	approved, err := r.checkApproval("ApproveMultisig", jsonreq, err)
+	if err != nil {
+		log.Info("Rule-based approval error, going to manual", "error", err)
+		return r.next.ApproveMultisig(request)
+	}
+	if approved {
+		return core.MultisigResponse{Approved: true}, nil
+	}
+	return core.MultisigResponse{Approved: false}, err
+}
+
 func (r *rulesetUI) ShowError(message string) {
 	log.Error(message)
 	r.next.ShowError(message)
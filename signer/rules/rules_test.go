@@ -100,6 +100,10 @@ func (alwaysDenyUI) ApproveNewAccount(request *core.NewAccountRequest) (core.New
 	return core.NewAccountResponse{Approved: false}, nil
 }
 
+func (alwaysDenyUI) ApproveMultisig(request *core.MultisigRequest) (core.MultisigResponse, error) {
+	return core.MultisigResponse{Approved: false}, nil
+}
+
 func (alwaysDenyUI) ShowError(message string) {
 	panic("implement me")
 }
@@ -229,6 +233,11 @@ func (d *dummyUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAc
 	return core.NewAccountResponse{}, core.ErrRequestDenied
 }
 
+func (d *dummyUI) ApproveMultisig(request *core.MultisigRequest) (core.MultisigResponse, error) {
+	d.calls = append(d.calls, "ApproveMultisig")
+	return core.MultisigResponse{}, core.ErrRequestDenied
+}
+
 func (d *dummyUI) ShowError(message string) {
 	d.calls = append(d.calls, "ShowError")
 }
@@ -261,13 +270,14 @@ func TestForwarding(t *testing.T) {
 	r.ApproveTx(nil)
 	r.ApproveNewAccount(nil)
 	r.ApproveListing(nil)
+	r.ApproveMultisig(nil)
 	r.ShowError("test")
 	r.ShowInfo("test")
 
 	//This one is not forwarded
 	r.OnApprovedTx(ethapi.SignTransactionResult{})
 
-	expCalls := 6
+	expCalls := 7
 	if len(ui.calls) != expCalls {
 		t.Errorf("Expected %d forwarded calls, got %d: %s", expCalls, len(ui.calls), strings.Join(ui.calls, ","))
 	}
@@ -530,6 +540,11 @@ func (d *dontCallMe) ApproveNewAccount(request *core.NewAccountRequest) (core.Ne
 	return core.NewAccountResponse{}, core.ErrRequestDenied
 }
 
+func (d *dontCallMe) ApproveMultisig(request *core.MultisigRequest) (core.MultisigResponse, error) {
+	d.t.Fatalf("Did not expect next-handler to be called")
+	return core.MultisigResponse{}, core.ErrRequestDenied
+}
+
 func (d *dontCallMe) ShowError(message string) {
 	d.t.Fatalf("Did not expect next-handler to be called")
 }
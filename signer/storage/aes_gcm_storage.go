@@ -52,25 +52,27 @@ func NewAESEncryptedStorage(filename string, key []byte) *AESEncryptedStorage {
 }
 
 // Put stores a value by key. 0-length keys results in noop.
-func (s *AESEncryptedStorage) Put(key, value string) {
+func (s *AESEncryptedStorage) Put(key, value string) error {
 	if len(key) == 0 {
-		return
+		return nil
 	}
 	data, err := s.readEncryptedStorage()
 	if err != nil {
 		log.Warn("Failed to read encrypted storage", "err", err, "file", s.filename)
-		return
+		return err
 	}
 	ciphertext, iv, err := encrypt(s.key, []byte(value), []byte(key))
 	if err != nil {
 		log.Warn("Failed to encrypt entry", "err", err)
-		return
+		return err
 	}
 	encrypted := storedCredential{Iv: iv, CipherText: ciphertext}
 	data[key] = encrypted
 	if err = s.writeEncryptedStorage(data); err != nil {
 		log.Warn("Failed to write entry", "err", err)
+		return err
 	}
+	return nil
 }
 
 // Get returns the previously stored value, or an error if it does not exist or
@@ -28,7 +28,7 @@ var (
 
 type Storage interface {
 	// Put stores a value by key. 0-length keys results in noop.
-	Put(key, value string)
+	Put(key, value string) error
 
 	// Get returns the previously stored value, or an error if the key is 0-length
 	// or unknown.
@@ -45,11 +45,12 @@ type EphemeralStorage struct {
 }
 
 // Put stores a value by key. 0-length keys results in noop.
-func (s *EphemeralStorage) Put(key, value string) {
+func (s *EphemeralStorage) Put(key, value string) error {
 	if len(key) == 0 {
-		return
+		return nil
 	}
 	s.data[key] = value
+	return nil
 }
 
 // Get returns the previously stored value, or an error if the key is 0-length
@@ -79,8 +80,8 @@ func NewEphemeralStorage() Storage {
 // NoStorage is a dummy construct which doesn't remember anything you tell it
 type NoStorage struct{}
 
-func (s *NoStorage) Put(key, value string) {}
-func (s *NoStorage) Del(key string)        {}
+func (s *NoStorage) Put(key, value string) error { return nil }
+func (s *NoStorage) Del(key string)              {}
 func (s *NoStorage) Get(key string) (string, error) {
 	return "", errors.New("missing key, I probably forgot")
 }
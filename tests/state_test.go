@@ -75,19 +75,6 @@ func initMatcher(st *testMatcher) {
 		st.skipLoad(`^stQuadraticComplexityTest/`)
 		st.skipLoad(`^stStaticCall/static_Call50000`)
 	}
-	if *testEVM != "" || *testEWASM != "" {
-		// Berlin tests are not expected to pass for external EVMs, yet.
-		//
-		st.skipFork("Berlin")   // ETH
-		st.skipFork("Magneto")  // ETC
-		st.skipFork("London")   // ETH
-		st.skipFork("Mystique") // ETC
-		st.skipFork("Merge")    // ETH
-		st.skipFork("Shanghai") // ETH
-		st.skipFork("Spiral")   // ETC
-		st.skipFork("Cancun")   // ETH
-	}
-
 	// Un-skip this when https://github.com/ethereum/tests/issues/908 is closed
 	st.skipLoad(`^stQuadraticComplexityTest/QuadraticComplexitySolidity_CallDataCopy`)
 
@@ -96,6 +83,27 @@ func initMatcher(st *testMatcher) {
 	st.skipLoad(`^stEOF/`)
 }
 
+// evmcSkipReason returns why the subtest can't be executed by an EVMC VM, or
+// an empty string if it can.
+func evmcSkipReason(test *StateTest, subtest StateSubtest) string {
+	config, eips, err := GetChainConfig(subtest.Fork)
+	if err != nil {
+		return ""
+	}
+	if len(eips) > 0 {
+		return "extra EIPs are not supported by EVMC VMs"
+	}
+	var (
+		env         = test.json.Env
+		number      = new(big.Int).SetUint64(env.Number)
+		isPostMerge = env.Random != nil && config.IsEnabled(config.GetEIP1559Transition, new(big.Int))
+	)
+	if _, err := vm.EVMCRevision(config, isPostMerge, number, &env.Timestamp); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
 func TestState(t *testing.T) {
 	t.Parallel()
 
@@ -146,6 +154,13 @@ func execStateTest(t *testing.T, st *testMatcher, test *StateTest) {
 		subtest := subtest
 		key := fmt.Sprintf("%s/%d", subtest.Fork, subtest.Index)
 
+		// Forks which EVMC VMs can't execute are rejected at runtime, skip them.
+		if *testEVM != "" {
+			if reason := evmcSkipReason(test, subtest); reason != "" {
+				t.Run(key, func(t *testing.T) { t.Skip(reason) })
+				continue
+			}
+		}
 		t.Run(key+"/hash/trie", func(t *testing.T) {
 			withTrace(t, test.gasLimit(subtest), func(vmconfig vm.Config) error {
 				var result error
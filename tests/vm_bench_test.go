@@ -2,12 +2,22 @@ package tests
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/shudolab/core-geth/common"
+	"github.com/shudolab/core-geth/core"
+	"github.com/shudolab/core-geth/core/rawdb"
+	"github.com/shudolab/core-geth/core/state"
+	"github.com/shudolab/core-geth/core/types"
+	"github.com/shudolab/core-geth/core/vm"
+	"github.com/shudolab/core-geth/params"
 )
 
 // meowsbits:
@@ -51,6 +61,78 @@ import (
 // 	})
 // }
 
+// classicBenchContract calls the identity precompile eight times, the kind of
+// precompile heavy call pattern common to token and multisig contracts.
+var classicBenchContract = common.FromHex(
+	"6020600060206000600060045af150" + "6020600060206000600060045af150" +
+		"6020600060206000600060045af150" + "6020600060206000600060045af150" +
+		"6020600060206000600060045af150" + "6020600060206000600060045af150" +
+		"6020600060206000600060045af150" + "6020600060206000600060045af150" + "00")
+
+// benchmarkClassicBlock executes blocks of calls to classicBenchContract on top
+// of the ETC mainnet rules after the Spiral fork. If evmPerTx is set, a new EVM
+// is created for every transaction, as done by RPC calls and tracers, otherwise
+// a single EVM executes the whole block, as done during block import.
+func benchmarkClassicBlock(b *testing.B, evmPerTx bool) {
+	const txsPerBlock = 200
+
+	var (
+		config   = params.ClassicChainConfig
+		contract = common.BytesToAddress([]byte("contract"))
+		sender   = common.BytesToAddress([]byte("sender"))
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, classicBenchContract)
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		BlockNumber: big.NewInt(20_000_000),
+		Time:        1_700_000_000,
+		Difficulty:  big.NewInt(1),
+		GasLimit:    math.MaxUint64,
+	}
+	msg := &core.Message{
+		To:                &contract,
+		From:              sender,
+		Value:             new(big.Int),
+		GasLimit:          1_000_000,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		SkipAccountChecks: true,
+	}
+	txCtx := core.NewEVMTxContext(msg)
+	vmConfig := vm.Config{NoBaseFee: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gp := new(core.GasPool).AddGas(math.MaxUint64)
+		evm := vm.NewEVM(blockCtx, txCtx, statedb, config, vmConfig)
+		for j := 0; j < txsPerBlock; j++ {
+			if evmPerTx {
+				evm = vm.NewEVM(blockCtx, txCtx, statedb, config, vmConfig)
+			} else {
+				evm.Reset(txCtx, statedb)
+			}
+			if _, err := core.ApplyMessage(evm, msg, gp); err != nil {
+				b.Fatal(err)
+			}
+			statedb.Finalise(true)
+		}
+	}
+}
+
+// BenchmarkClassicBlockImport measures the execution of ETC mainnet-like blocks
+// with a single EVM per block.
+func BenchmarkClassicBlockImport(b *testing.B) { benchmarkClassicBlock(b, false) }
+
+// BenchmarkClassicBlockEVMPerTx measures the execution of ETC mainnet-like
+// blocks with a fresh EVM per transaction.
+func BenchmarkClassicBlockEVMPerTx(b *testing.B) { benchmarkClassicBlock(b, true) }
+
 // walkB invokes its runTest argument for all subtests in the given directory.
 //
 // runTest should be a function of type func(t *testing.T, name string, x <TestType>),